@@ -1,24 +1,210 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
+	"github.com/mattn/go-isatty"
 	"github.com/mitchellh/cli"
 
+	"command-line-app/internal/command"
 	"command-line-app/internal/command/greet"
+	"command-line-app/internal/command/user"
+	"command-line-app/internal/debug"
+	"command-line-app/internal/github"
+	"command-line-app/internal/history"
 	"command-line-app/metadata"
 )
 
+const globalHelp = `
+Global flags:
+    -timeout          the maximum duration of a command (default: 1m)
+    -request-timeout  the maximum duration of each request to GitHub (default: 10s)
+    -github-url       the base URL of the GitHub API (default: $GITHUB_URL or https://api.github.com)
+    -no-input         never prompt for missing inputs, even if the input is a terminal
+    -debug            log the requests to GitHub and their responses to stderr
+    -debug-bodies     log the bodies of requests and responses too (implies -debug)
+    -har              a file for recording the requests to GitHub in HTTP Archive (HAR) format
+`
+
 func main() {
 	ui := createUI()
-	app := createCLI(ui)
+	code := run(ui, os.Args[1:])
+
+	os.Exit(code)
+}
+
+// run parses the global flags and runs the command-line app with a root context cancelled on SIGINT and SIGTERM.
+func run(ui cli.Ui, args []string) int {
+	config, args, code := parseGlobalFlags(ui, args)
+	if code != command.Success {
+		return code
+	}
+
+	// Prompting for missing inputs is only possible if the input is a terminal
+	config.Interactive = config.Interactive && isatty.IsTerminal(os.Stdin.Fd())
+
+	var archive *debug.Archive
+	config.Transport, archive = newTransport(config, os.Stderr)
+
+	ctx, stop := newSignalContext()
+	defer stop()
+
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	app := createCLI(ctx, ui, config)
+	app.Args = args
+
+	code = runCLI(ctx, ui, app)
+
+	if archive != nil {
+		if err := archive.WriteFile(config.HARFile); err != nil {
+			ui.Error(fmt.Sprintf("Failed to write HAR file: %s", err))
+			if code == command.Success {
+				code = command.GenericError
+			}
+		}
+	}
+
+	return code
+}
+
+// newTransport creates the transport for sending the requests to the GitHub API.
+// In debug mode, requests and responses are logged to the given writer.
+// If a HAR file is requested, an archive is returned for recording requests and responses.
+func newTransport(config command.Config, stderr io.Writer) (http.RoundTripper, *debug.Archive) {
+	transport := &http.Transport{}
+
+	if !config.Debug && !config.DebugBodies && config.HARFile == "" {
+		return transport, nil
+	}
+
+	opts := debug.Options{
+		Bodies: config.DebugBodies,
+	}
+
+	if config.Debug || config.DebugBodies {
+		opts.Output = stderr
+	}
+
+	if config.HARFile != "" {
+		opts.Archive = debug.NewArchive("command-line-app", metadata.Version)
+	}
+
+	return debug.NewTransport(transport, opts), opts.Archive
+}
+
+// newSignalContext returns a context that is cancelled when the process receives SIGINT or SIGTERM.
+// Once the context is cancelled, the default behavior of the signals is restored, so a second signal terminates the process.
+func newSignalContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
+	return ctx, stop
+}
+
+// parseGlobalFlags parses the flags preceding the command name and returns the remaining arguments.
+func parseGlobalFlags(ui cli.Ui, args []string) (command.Config, []string, int) {
+	config := command.Config{
+		Timeout:        time.Minute,
+		RequestTimeout: 10 * time.Second,
+		GithubURL:      github.DefaultBaseURL,
+		Interactive:    true,
+		HistoryFile:    history.DefaultPath(),
+	}
+
+	if url := os.Getenv("GITHUB_URL"); url != "" {
+		config.GithubURL = url
+	}
+
+	fs := flag.NewFlagSet("command-line-app", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.DurationVar(&config.Timeout, "timeout", config.Timeout, "")
+	fs.DurationVar(&config.RequestTimeout, "request-timeout", config.RequestTimeout, "")
+	fs.StringVar(&config.GithubURL, "github-url", config.GithubURL, "")
+	noInput := fs.Bool("no-input", false, "")
+	fs.BoolVar(&config.Debug, "debug", false, "")
+	fs.BoolVar(&config.DebugBodies, "debug-bodies", false, "")
+	fs.StringVar(&config.HARFile, "har", "", "")
+
+	fs.Usage = func() {
+		ui.Output(globalHelp)
+	}
+
+	global, rest := splitGlobalArgs(fs, args)
+	if err := fs.Parse(global); err != nil {
+		// The help is printed by the Parse method and the error is printed through the ui
+		if !errors.Is(err, flag.ErrHelp) {
+			ui.Error(err.Error())
+		}
+		return command.Config{}, nil, command.FlagError
+	}
+
+	if config.Timeout <= 0 {
+		ui.Error("The timeout must be a positive duration.")
+		return command.Config{}, nil, command.FlagError
+	}
+
+	if config.RequestTimeout <= 0 {
+		ui.Error("The request timeout must be a positive duration.")
+		return command.Config{}, nil, command.FlagError
+	}
+
+	config.Interactive = !*noInput
+
+	return config, rest, command.Success
+}
+
+// splitGlobalArgs splits the leading arguments that are defined in the flag set from the rest of arguments.
+// Other flags such as -help and -version are left for the cli package to handle.
+func splitGlobalArgs(fs *flag.FlagSet, args []string) ([]string, []string) {
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			return args[:i], args[i:]
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			return args[:i], args[i:]
+		}
+
+		// Boolean flags do not consume the next argument as their value
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) {
+			i++
+		}
+	}
+
+	return args, nil
+}
+
+// runCLI runs the command-line app and maps the cancellation of the root context to the interrupted exit code.
+func runCLI(ctx context.Context, ui cli.Ui, app *cli.CLI) int {
 	code, err := app.Run()
 	if err != nil {
 		ui.Error(err.Error())
 	}
 
-	os.Exit(code)
+	if code != command.Success && errors.Is(ctx.Err(), context.Canceled) {
+		ui.Error("Interrupted.")
+		return command.Interrupted
+	}
+
+	return code
 }
 
 func createUI() cli.Ui {
@@ -37,11 +223,20 @@ func createUI() cli.Ui {
 	}
 }
 
-func createCLI(ui cli.Ui) *cli.CLI {
+func createCLI(ctx context.Context, ui cli.Ui, config command.Config) *cli.CLI {
 	c := cli.NewCLI("command-line-app", metadata.String())
-	c.Args = os.Args[1:]
 	c.Commands = map[string]cli.CommandFactory{
-		"greet": greet.NewFactory(ui),
+		"greet":          greet.NewFactory(ctx, ui, config),
+		"user":           user.NewFactory(),
+		"user show":      user.NewShowFactory(ctx, ui, config),
+		"user repos":     user.NewReposFactory(ctx, ui, config),
+		"user followers": user.NewFollowersFactory(ctx, ui, config),
+		"user orgs":      user.NewOrgsFactory(ctx, ui, config),
+	}
+
+	basicHelp := cli.BasicHelpFunc("command-line-app")
+	c.HelpFunc = func(commands map[string]cli.CommandFactory) string {
+		return basicHelp(commands) + globalHelp
 	}
 
 	return c
@@ -1,12 +1,255 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
+
+	"command-line-app/internal/command"
+	"command-line-app/internal/debug"
+	"command-line-app/internal/github"
+	"command-line-app/internal/history"
 )
 
+type blockingCommand struct {
+	ctx context.Context
+}
+
+func (c *blockingCommand) Help() string     { return "" }
+func (c *blockingCommand) Synopsis() string { return "" }
+
+func (c *blockingCommand) Run([]string) int {
+	<-c.ctx.Done()
+	return command.ExitCode(c.ctx.Err())
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectedExitCode int
+	}{
+		{
+			name:             "InvalidGlobalFlag",
+			args:             []string{"-timeout", "invalid", "greet"},
+			expectedExitCode: command.FlagError,
+		},
+		{
+			name:             "Help",
+			args:             []string{"-timeout", "1s", "-help"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "Version",
+			args:             []string{"-version"},
+			expectedExitCode: command.Success,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			exitCode := run(ui, tc.args)
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+		})
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectedConfig   command.Config
+		expectedArgs     []string
+		expectedExitCode int
+	}{
+		{
+			name:             "InvalidFlag",
+			args:             []string{"-timeout", "invalid", "greet"},
+			expectedConfig:   command.Config{},
+			expectedArgs:     nil,
+			expectedExitCode: command.FlagError,
+		},
+		{
+			name:             "ZeroTimeout",
+			args:             []string{"-timeout", "0", "greet"},
+			expectedConfig:   command.Config{},
+			expectedArgs:     nil,
+			expectedExitCode: command.FlagError,
+		},
+		{
+			name:             "NegativeRequestTimeout",
+			args:             []string{"-request-timeout=-1s", "greet"},
+			expectedConfig:   command.Config{},
+			expectedArgs:     nil,
+			expectedExitCode: command.FlagError,
+		},
+		{
+			name:             "Defaults",
+			args:             []string{"greet", "-username", "octocat"},
+			expectedConfig:   command.Config{Timeout: time.Minute, RequestTimeout: 10 * time.Second, GithubURL: github.DefaultBaseURL, Interactive: true, HistoryFile: history.DefaultPath()},
+			expectedArgs:     []string{"greet", "-username", "octocat"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "GlobalFlags",
+			args:             []string{"-timeout", "30s", "-request-timeout=5s", "greet", "-username", "octocat"},
+			expectedConfig:   command.Config{Timeout: 30 * time.Second, RequestTimeout: 5 * time.Second, GithubURL: github.DefaultBaseURL, Interactive: true, HistoryFile: history.DefaultPath()},
+			expectedArgs:     []string{"greet", "-username", "octocat"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "GithubURL",
+			args:             []string{"-github-url", "http://localhost:8081", "user", "show"},
+			expectedConfig:   command.Config{Timeout: time.Minute, RequestTimeout: 10 * time.Second, GithubURL: "http://localhost:8081", Interactive: true, HistoryFile: history.DefaultPath()},
+			expectedArgs:     []string{"user", "show"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "NoInput",
+			args:             []string{"-no-input", "greet"},
+			expectedConfig:   command.Config{Timeout: time.Minute, RequestTimeout: 10 * time.Second, GithubURL: github.DefaultBaseURL, Interactive: false, HistoryFile: history.DefaultPath()},
+			expectedArgs:     []string{"greet"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "Debug",
+			args:             []string{"-debug-bodies", "-har=debug.har", "greet"},
+			expectedConfig:   command.Config{Timeout: time.Minute, RequestTimeout: 10 * time.Second, GithubURL: github.DefaultBaseURL, Interactive: true, HistoryFile: history.DefaultPath(), DebugBodies: true, HARFile: "debug.har"},
+			expectedArgs:     []string{"greet"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "CLIFlags",
+			args:             []string{"--timeout=30s", "-version"},
+			expectedConfig:   command.Config{Timeout: 30 * time.Second, RequestTimeout: 10 * time.Second, GithubURL: github.DefaultBaseURL, Interactive: true, HistoryFile: history.DefaultPath()},
+			expectedArgs:     []string{"-version"},
+			expectedExitCode: command.Success,
+		},
+	}
+
+	t.Setenv("GITHUB_URL", "")
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			config, args, exitCode := parseGlobalFlags(ui, tc.args)
+
+			assert.Equal(t, tc.expectedConfig, config)
+			assert.Equal(t, tc.expectedArgs, args)
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+		})
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          command.Config
+		expectedDebug   bool
+		expectedArchive bool
+	}{
+		{
+			name:            "Default",
+			config:          command.Config{},
+			expectedDebug:   false,
+			expectedArchive: false,
+		},
+		{
+			name:            "Debug",
+			config:          command.Config{Debug: true},
+			expectedDebug:   true,
+			expectedArchive: false,
+		},
+		{
+			name:            "HAR",
+			config:          command.Config{HARFile: "debug.har"},
+			expectedDebug:   true,
+			expectedArchive: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			transport, archive := newTransport(tc.config, new(bytes.Buffer))
+
+			_, isDebug := transport.(*debug.Transport)
+			assert.Equal(t, tc.expectedDebug, isDebug)
+			assert.Equal(t, tc.expectedArchive, archive != nil)
+		})
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	tests := []struct {
+		name             string
+		signal           syscall.Signal
+		expectedExitCode int
+	}{
+		{
+			name:             "SIGINT",
+			signal:           syscall.SIGINT,
+			expectedExitCode: command.Interrupted,
+		},
+		{
+			name:             "SIGTERM",
+			signal:           syscall.SIGTERM,
+			expectedExitCode: command.Interrupted,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, stop := newSignalContext()
+			defer stop()
+
+			ui := cli.NewMockUi()
+			app := &cli.CLI{
+				Args: []string{"block"},
+				Commands: map[string]cli.CommandFactory{
+					"block": func() (cli.Command, error) {
+						return &blockingCommand{ctx: ctx}, nil
+					},
+				},
+			}
+
+			time.AfterFunc(50*time.Millisecond, func() {
+				_ = syscall.Kill(syscall.Getpid(), tc.signal)
+			})
+
+			exitCode := runCLI(ctx, ui, app)
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+			assert.Contains(t, ui.ErrorWriter.String(), "Interrupted.")
+		})
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		ui := cli.NewMockUi()
+		app := &cli.CLI{
+			Args: []string{"block"},
+			Commands: map[string]cli.CommandFactory{
+				"block": func() (cli.Command, error) {
+					return &blockingCommand{ctx: ctx}, nil
+				},
+			},
+		}
+
+		exitCode := runCLI(ctx, ui, app)
+
+		assert.Equal(t, command.TimeoutError, exitCode)
+	})
+}
+
 func TestCreateUI(t *testing.T) {
 	ui := createUI()
 	assert.NotNil(t, ui)
@@ -14,6 +257,6 @@ func TestCreateUI(t *testing.T) {
 
 func TestCreateCLI(t *testing.T) {
 	ui := cli.NewMockUi()
-	cli := createCLI(ui)
+	cli := createCLI(context.Background(), ui, command.Config{})
 	assert.NotNil(t, cli)
 }
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os/exec"
+	"strconv"
+	"testing"
+
+	"github.com/rogpeppe/go-internal/testscript"
+
+	"command-line-app/internal/fakegithub"
+)
+
+// TestMain registers the main function as the command-line-app command for test scripts.
+// The test binary runs main when it is executed as command-line-app by a script.
+func TestMain(m *testing.M) {
+	testscript.Main(m, map[string]func(){
+		"command-line-app": main,
+	})
+}
+
+// TestScripts runs the end-to-end test scripts in testdata/script against a fake GitHub API.
+// See https://pkg.go.dev/github.com/rogpeppe/go-internal/testscript for the script syntax.
+//
+// The following environment variables are available to scripts:
+//
+//	GITHUB_URL       the base URL of a fake GitHub API (used by command-line-app by default)
+//	UNREACHABLE_URL  the base URL of a closed server for simulating network failures
+//
+// In addition to the built-in commands, scripts can use the exitcode command:
+//
+//	exitcode <code> <command> [args...]
+//
+// which runs a command like exec and asserts its exit code.
+func TestScripts(t *testing.T) {
+	testscript.Run(t, testscript.Params{
+		Dir: "testdata/script",
+		Setup: func(env *testscript.Env) error {
+			fake, err := fakegithub.NewServer()
+			if err != nil {
+				return err
+			}
+
+			closed := httptest.NewServer(nil)
+			closed.Close()
+
+			env.Defer(fake.Close)
+			env.Setenv("GITHUB_URL", fake.URL)
+			env.Setenv("UNREACHABLE_URL", closed.URL)
+			env.Setenv("LANG", "en_US.UTF-8")
+
+			return nil
+		},
+		Cmds: map[string]func(*testscript.TestScript, bool, []string){
+			"exitcode": exitcode,
+		},
+	})
+}
+
+func exitcode(ts *testscript.TestScript, neg bool, args []string) {
+	if neg {
+		ts.Fatalf("unsupported: ! exitcode")
+	}
+
+	if len(args) < 2 {
+		ts.Fatalf("usage: exitcode <code> <command> [args...]")
+	}
+
+	expected, err := strconv.Atoi(args[0])
+	if err != nil {
+		ts.Fatalf("invalid exit code %q: %s", args[0], err)
+	}
+
+	actual := 0
+	if err := ts.Exec(args[1], args[2:]...); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			ts.Fatalf("%s", err)
+		}
+		actual = exitErr.ExitCode()
+	}
+
+	if actual != expected {
+		ts.Fatalf("unexpected exit code: got %d, want %d", actual, expected)
+	}
+}
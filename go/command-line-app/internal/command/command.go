@@ -1,5 +1,17 @@
 package command
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"command-line-app/internal/github"
+)
+
 const (
 	// Success is the exit code when a command execution is successful.
 	Success int = iota
@@ -9,4 +21,74 @@ const (
 	ArgError
 	// GenericError is the generic exit code when something fails.
 	GenericError
+	// NotFoundError is the exit code when a requested GitHub resource does not exist.
+	NotFoundError
+	// UnauthorizedError is the exit code when a GitHub request is not authenticated or not permitted.
+	UnauthorizedError
+	// RateLimitError is the exit code when the GitHub API rate limit is exceeded.
+	RateLimitError
+	// NetworkError is the exit code when the GitHub API cannot be reached.
+	NetworkError
+	// TimeoutError is the exit code when a command or a GitHub request times out.
+	TimeoutError
+	// Interrupted is the exit code when a command is cancelled by an interrupt or termination signal.
+	Interrupted
 )
+
+// Config contains the global configurations shared by all commands.
+type Config struct {
+	// Timeout is the maximum duration of a command execution.
+	Timeout time.Duration
+	// RequestTimeout is the maximum duration of each request to the GitHub API.
+	RequestTimeout time.Duration
+	// GithubURL is the base URL of the GitHub REST API.
+	GithubURL string
+	// Interactive determines whether commands can prompt for missing inputs.
+	Interactive bool
+	// HistoryFile is the path of the file for persisting the recently used usernames.
+	HistoryFile string
+	// Debug determines whether the requests to the GitHub API and their responses are logged.
+	Debug bool
+	// DebugBodies determines whether the bodies of requests and responses are logged too.
+	DebugBodies bool
+	// HARFile is the path of the HTTP Archive (HAR) file for recording the requests to the GitHub API.
+	HARFile string
+	// Transport is used for sending the requests to the GitHub API.
+	Transport http.RoundTripper
+}
+
+// ExitCode returns the exit code corresponding to an error.
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return Success
+	case errors.Is(err, context.Canceled):
+		return Interrupted
+	case errors.Is(err, ErrInvalidInput):
+		return ArgError
+	case errors.Is(err, github.ErrNotFound):
+		return NotFoundError
+	case errors.Is(err, github.ErrUnauthorized):
+		return UnauthorizedError
+	case errors.Is(err, github.ErrRateLimited):
+		return RateLimitError
+	case errors.Is(err, github.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return TimeoutError
+	case errors.Is(err, github.ErrNetwork):
+		return NetworkError
+	default:
+		return GenericError
+	}
+}
+
+// PrintError prints an error using the given ui.
+// If verbose is true, the chain of underlying causes is printed too.
+func PrintError(ui cli.Ui, err error, verbose bool) {
+	ui.Error(err.Error())
+
+	if verbose {
+		for e := errors.Unwrap(err); e != nil; e = errors.Unwrap(e) {
+			ui.Error(fmt.Sprintf("  caused by (%T): %s", e, e))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+
+	"command-line-app/internal/github"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name             string
+		err              error
+		expectedExitCode int
+	}{
+		{
+			name:             "NoError",
+			err:              nil,
+			expectedExitCode: Success,
+		},
+		{
+			name:             "GenericError",
+			err:              errors.New("error"),
+			expectedExitCode: GenericError,
+		},
+		{
+			name:             "InvalidInput",
+			err:              ErrInvalidInput,
+			expectedExitCode: ArgError,
+		},
+		{
+			name:             "NotFound",
+			err:              &github.Error{Kind: github.ErrNotFound, Err: errors.New("error")},
+			expectedExitCode: NotFoundError,
+		},
+		{
+			name:             "Unauthorized",
+			err:              &github.Error{Kind: github.ErrUnauthorized, Err: errors.New("error")},
+			expectedExitCode: UnauthorizedError,
+		},
+		{
+			name:             "RateLimited",
+			err:              &github.Error{Kind: github.ErrRateLimited, Err: errors.New("error")},
+			expectedExitCode: RateLimitError,
+		},
+		{
+			name:             "Network",
+			err:              &github.Error{Kind: github.ErrNetwork, Err: errors.New("error")},
+			expectedExitCode: NetworkError,
+		},
+		{
+			name:             "Timeout",
+			err:              &github.Error{Kind: github.ErrTimeout, Err: errors.New("error")},
+			expectedExitCode: TimeoutError,
+		},
+		{
+			name:             "Canceled",
+			err:              fmt.Errorf("request failed: %w", context.Canceled),
+			expectedExitCode: Interrupted,
+		},
+		{
+			name:             "DeadlineExceeded",
+			err:              fmt.Errorf("request failed: %w", context.DeadlineExceeded),
+			expectedExitCode: TimeoutError,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedExitCode, ExitCode(tc.err))
+		})
+	}
+}
+
+func TestPrintError(t *testing.T) {
+	err := &github.Error{
+		Kind: github.ErrNetwork,
+		Err:  fmt.Errorf("dial tcp: %w", errors.New("connection refused")),
+	}
+
+	tests := []struct {
+		name           string
+		verbose        bool
+		expectedOutput string
+	}{
+		{
+			name:           "NotVerbose",
+			verbose:        false,
+			expectedOutput: "network error: dial tcp: connection refused\n",
+		},
+		{
+			name:    "Verbose",
+			verbose: true,
+			expectedOutput: "network error: dial tcp: connection refused\n" +
+				"  caused by (*fmt.wrapError): dial tcp: connection refused\n" +
+				"  caused by (*errors.errorString): connection refused\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			PrintError(ui, err, tc.verbose)
+
+			assert.Equal(t, tc.expectedOutput, ui.ErrorWriter.String())
+		})
+	}
+}
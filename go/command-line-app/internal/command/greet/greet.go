@@ -3,17 +3,17 @@ package greet
 import (
 	"context"
 	"flag"
-	"fmt"
-	"time"
+	"os"
 
 	"github.com/mitchellh/cli"
 
 	"command-line-app/internal/command"
 	"command-line-app/internal/github"
+	"command-line-app/internal/history"
+	"command-line-app/internal/locale"
 )
 
 const (
-	timeout  = time.Minute
 	synopsis = `Greet a GitHub user!`
 	help     = `
   Use this command for greeting a GitHub user!
@@ -21,11 +21,28 @@ const (
   Usage:  command-line-app greet [flags]
 
   Flags:
-    -username  a GitHub username
+    -username   a GitHub username (prompted for if missing and the input is a terminal)
+    -lang       the language of the greeting (default: $LANG or en)
+    -templates  a directory with additional greeting templates named <language>.tmpl
+    -verbose    print the underlying causes of errors
 
   Examples:
     command-line-app greet -username octocat
     command-line-app greet -username=moorara
+    command-line-app greet -verbose -username octocat
+    command-line-app greet -lang fr -username octocat
+
+  Exit Codes:
+    0  success
+    1  invalid flag
+    2  invalid input
+    3  generic error
+    4  user not found
+    5  unauthorized
+    6  rate limited
+    7  network error
+    8  timeout
+    9  interrupted
   `
 )
 
@@ -33,33 +50,48 @@ type (
 	githubService interface {
 		GetUser(context.Context, string) (*github.User, error)
 	}
+
+	historyService interface {
+		Recent() []string
+		Add(string) error
+	}
 )
 
 // Command implements the cli.Command implementation.
 type Command struct {
-	ui    cli.Ui
-	flags struct {
-		username string
+	ctx    context.Context
+	ui     cli.Ui
+	config command.Config
+	flags  struct {
+		username  string
+		lang      string
+		templates string
+		verbose   bool
 	}
 	services struct {
-		github githubService
+		github  githubService
+		history historyService
 	}
+	catalog *locale.Catalog
 	outputs struct {
 		greeting string
 	}
 }
 
 // New creates a new command.
-func New(ui cli.Ui) *Command {
+// The given context is the root context for the command execution.
+func New(ctx context.Context, ui cli.Ui, config command.Config) *Command {
 	return &Command{
-		ui: ui,
+		ctx:    ctx,
+		ui:     ui,
+		config: config,
 	}
 }
 
 // NewFactory returns a cli.CommandFactory for creating a new command.
-func NewFactory(ui cli.Ui) cli.CommandFactory {
+func NewFactory(ctx context.Context, ui cli.Ui, config command.Config) cli.CommandFactory {
 	return func() (cli.Command, error) {
-		return New(ui), nil
+		return New(ctx, ui, config), nil
 	}
 }
 
@@ -80,13 +112,22 @@ func (c *Command) Run(args []string) int {
 		return code
 	}
 
-	github, err := github.NewService()
+	github, err := github.NewService(c.config.GithubURL, c.config.RequestTimeout, c.config.Transport)
 	if err != nil {
 		c.ui.Error(err.Error())
 		return command.GenericError
 	}
 
 	c.services.github = github
+	c.services.history = history.New(c.config.HistoryFile)
+
+	catalog, err := locale.NewCatalog(c.flags.templates)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return command.GenericError
+	}
+
+	c.catalog = catalog
 
 	return c.exec()
 }
@@ -94,6 +135,9 @@ func (c *Command) Run(args []string) int {
 func (c *Command) parseFlags(args []string) int {
 	fs := flag.NewFlagSet("greet", flag.ContinueOnError)
 	fs.StringVar(&c.flags.username, "username", "", "")
+	fs.StringVar(&c.flags.lang, "lang", os.Getenv("LANG"), "")
+	fs.StringVar(&c.flags.templates, "templates", "", "")
+	fs.BoolVar(&c.flags.verbose, "verbose", false, "")
 
 	fs.Usage = func() {
 		c.ui.Output(c.Help())
@@ -109,24 +153,47 @@ func (c *Command) parseFlags(args []string) int {
 
 // exec in an auxiliary method, so we can test the business logic with mock dependencies.
 func (c *Command) exec() int {
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
-	defer cancel()
-
 	if c.flags.username == "" {
-		c.ui.Error("No GitHub username is provided.")
-		return command.GenericError
+		if !c.config.Interactive {
+			c.ui.Error("No GitHub username is provided.")
+			return command.ArgError
+		}
+
+		username, err := command.AskUsername(c.ui, c.services.history.Recent())
+		if err != nil {
+			command.PrintError(c.ui, err, c.flags.verbose)
+			return command.ExitCode(err)
+		}
+
+		c.flags.username = username
+	} else if err := command.ValidateUsername(c.flags.username); err != nil {
+		c.ui.Error(err.Error())
+		return command.ArgError
+	}
+
+	user, err := c.services.github.GetUser(c.ctx, c.flags.username)
+	if err != nil {
+		command.PrintError(c.ui, err, c.flags.verbose)
+		return command.ExitCode(err)
 	}
 
-	user, err := c.services.github.GetUser(ctx, c.flags.username)
+	c.outputs.greeting, err = c.catalog.Greet(c.flags.lang, locale.User{
+		Login:    user.Login,
+		Name:     user.Name,
+		Company:  user.Company,
+		Location: user.Location,
+	})
+
 	if err != nil {
 		c.ui.Error(err.Error())
 		return command.GenericError
 	}
 
-	c.outputs.greeting = fmt.Sprintf("Hello, %s!", user.Name)
-
 	c.ui.Info(c.outputs.greeting)
 
+	// Failing to remember the username should not fail the command
+	_ = c.services.history.Add(c.flags.username)
+
 	return command.Success
 }
 
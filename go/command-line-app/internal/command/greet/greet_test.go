@@ -1,26 +1,33 @@
 package greet
 
 import (
+	"context"
 	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
 
 	"command-line-app/internal/command"
 	"command-line-app/internal/github"
+	"command-line-app/internal/locale"
 )
 
 func TestNew(t *testing.T) {
 	ui := cli.NewMockUi()
-	c := New(ui)
+	c := New(context.Background(), ui, command.Config{})
 
 	assert.NotNil(t, c)
 }
 
 func TestNewFactory(t *testing.T) {
 	ui := cli.NewMockUi()
-	c, err := NewFactory(ui)()
+	c, err := NewFactory(context.Background(), ui, command.Config{})()
 
 	assert.NoError(t, err)
 	assert.NotNil(t, c)
@@ -48,11 +55,30 @@ func TestCommand_Run(t *testing.T) {
 		assert.Equal(t, command.FlagError, exitCode)
 	})
 
+	t.Run("InvalidTemplates", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, "en.tmpl"), []byte(`Hi, {{.Name}!`), 0644))
+
+		c := &Command{
+			ctx:    context.Background(),
+			ui:     cli.NewMockUi(),
+			config: command.Config{RequestTimeout: time.Second},
+		}
+		exitCode := c.Run([]string{"-username", "octocat", "-templates", dir})
+
+		assert.Equal(t, command.GenericError, exitCode)
+	})
+
 	t.Run("OK", func(t *testing.T) {
-		c := &Command{ui: cli.NewMockUi()}
+		c := &Command{
+			ctx:    context.Background(),
+			ui:     cli.NewMockUi(),
+			config: command.Config{RequestTimeout: time.Second},
+		}
 		c.Run([]string{})
 
 		assert.NotNil(t, c.services.github)
+		assert.NotNil(t, c.catalog)
 	})
 }
 
@@ -69,7 +95,7 @@ func TestCommand_parseFlags(t *testing.T) {
 		},
 		{
 			name:             "ValidFlag",
-			args:             []string{"-username", "octocat"},
+			args:             []string{"-username", "octocat", "-lang", "fr"},
 			expectedExitCode: command.Success,
 		},
 	}
@@ -85,13 +111,85 @@ func TestCommand_parseFlags(t *testing.T) {
 }
 
 func TestCommand_exec(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
 	tests := []struct {
 		name             string
+		interactive      bool
+		input            string
 		usernameFlag     string
+		langFlag         string
+		verboseFlag      bool
 		github           *MockGithubService
+		history          *MockHistoryService
 		expectedGreeting string
 		expectedExitCode int
 	}{
+		{
+			name:             "NoUsername",
+			usernameFlag:     "",
+			expectedGreeting: "",
+			expectedExitCode: command.ArgError,
+		},
+		{
+			name:             "InvalidUsername",
+			usernameFlag:     "-octocat",
+			expectedGreeting: "",
+			expectedExitCode: command.ArgError,
+		},
+		{
+			name:         "NoUsername_PromptFails",
+			interactive:  true,
+			input:        "",
+			usernameFlag: "",
+			history: &MockHistoryService{
+				RecentMocks: []RecentMock{
+					{OutUsernames: nil},
+				},
+			},
+			expectedGreeting: "",
+			expectedExitCode: command.GenericError,
+		},
+		{
+			name:         "NoUsername_InvalidInput",
+			interactive:  true,
+			input:        "-\n-\n-\n",
+			usernameFlag: "",
+			history: &MockHistoryService{
+				RecentMocks: []RecentMock{
+					{OutUsernames: nil},
+				},
+			},
+			expectedGreeting: "",
+			expectedExitCode: command.ArgError,
+		},
+		{
+			name:         "NoUsername_Prompted",
+			interactive:  true,
+			input:        "2\n",
+			usernameFlag: "",
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{
+						OutUser: &github.User{
+							ID:    1,
+							Login: "octocat",
+						},
+					},
+				},
+			},
+			history: &MockHistoryService{
+				RecentMocks: []RecentMock{
+					{OutUsernames: []string{"hubot", "octocat"}},
+				},
+				AddMocks: []AddMock{
+					{OutError: nil},
+				},
+			},
+			expectedGreeting: "Hello, octocat!",
+			expectedExitCode: command.Success,
+		},
 		{
 			name:         "GetUserFails",
 			usernameFlag: "octocat",
@@ -103,6 +201,40 @@ func TestCommand_exec(t *testing.T) {
 			expectedGreeting: "",
 			expectedExitCode: command.GenericError,
 		},
+		{
+			name:         "Interrupted",
+			usernameFlag: "octocat",
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{OutError: context.Canceled},
+				},
+			},
+			expectedGreeting: "",
+			expectedExitCode: command.Interrupted,
+		},
+		{
+			name:         "UserNotFound",
+			usernameFlag: "octocat",
+			verboseFlag:  true,
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{OutError: &github.Error{Kind: github.ErrNotFound, Err: errors.New("GET /users/octocat 404")}},
+				},
+			},
+			expectedGreeting: "",
+			expectedExitCode: command.NotFoundError,
+		},
+		{
+			name:         "GetUserTimesOut",
+			usernameFlag: "octocat",
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{OutError: &github.Error{Kind: github.ErrTimeout, Err: errors.New("i/o timeout")}},
+				},
+			},
+			expectedGreeting: "",
+			expectedExitCode: command.TimeoutError,
+		},
 		{
 			name:         "Success",
 			usernameFlag: "octocat",
@@ -118,19 +250,55 @@ func TestCommand_exec(t *testing.T) {
 					},
 				},
 			},
+			history: &MockHistoryService{
+				AddMocks: []AddMock{
+					{OutError: errors.New("permission denied")},
+				},
+			},
 			expectedGreeting: "Hello, Octocat!",
 			expectedExitCode: command.Success,
 		},
+		{
+			name:         "Success_Localized",
+			usernameFlag: "octocat",
+			langFlag:     "fr",
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{
+						OutUser: &github.User{
+							ID:    1,
+							Login: "octocat",
+						},
+					},
+				},
+			},
+			history: &MockHistoryService{
+				AddMocks: []AddMock{
+					{OutError: nil},
+				},
+			},
+			expectedGreeting: "Bonjour, octocat !",
+			expectedExitCode: command.Success,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			ui.InputReader = iotest.OneByteReader(strings.NewReader(tc.input))
+
 			c := &Command{
-				ui: cli.NewMockUi(),
+				ctx:     context.Background(),
+				ui:      ui,
+				config:  command.Config{Interactive: tc.interactive},
+				catalog: catalog,
 			}
 
 			c.flags.username = tc.usernameFlag
+			c.flags.lang = tc.langFlag
+			c.flags.verbose = tc.verboseFlag
 			c.services.github = tc.github
+			c.services.history = tc.history
 
 			exitCode := c.exec()
 
@@ -18,6 +18,23 @@ type (
 		GetUserIndex int
 		GetUserMocks []GetUserMock
 	}
+
+	RecentMock struct {
+		OutUsernames []string
+	}
+
+	AddMock struct {
+		InUsername string
+		OutError   error
+	}
+
+	MockHistoryService struct {
+		RecentIndex int
+		RecentMocks []RecentMock
+
+		AddIndex int
+		AddMocks []AddMock
+	}
 )
 
 func (m *MockGithubService) GetUser(ctx context.Context, username string) (*github.User, error) {
@@ -27,3 +44,16 @@ func (m *MockGithubService) GetUser(ctx context.Context, username string) (*gith
 	m.GetUserMocks[i].InUsername = username
 	return m.GetUserMocks[i].OutUser, m.GetUserMocks[i].OutError
 }
+
+func (m *MockHistoryService) Recent() []string {
+	i := m.RecentIndex
+	m.RecentIndex++
+	return m.RecentMocks[i].OutUsernames
+}
+
+func (m *MockHistoryService) Add(username string) error {
+	i := m.AddIndex
+	m.AddIndex++
+	m.AddMocks[i].InUsername = username
+	return m.AddMocks[i].OutError
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/cli"
+)
+
+// maxAttempts is the maximum number of attempts for answering a prompt with a valid value.
+const maxAttempts = 3
+
+// usernameRegexp matches alphanumeric characters and single hyphens not at the beginning or the end.
+var usernameRegexp = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`)
+
+// ErrInvalidInput is returned when a prompt is not answered with a valid value.
+var ErrInvalidInput = errors.New("no valid input is provided")
+
+// ValidateUsername checks whether a string is a valid GitHub username.
+func ValidateUsername(username string) error {
+	if len(username) > 39 || !usernameRegexp.MatchString(username) {
+		return fmt.Errorf("invalid GitHub username %q: only alphanumeric characters and single hyphens (not at the beginning or the end) are allowed", username)
+	}
+
+	return nil
+}
+
+// AskUsername prompts for a GitHub username using the given ui.
+// If there are recently used usernames, they are listed and can be selected by their numbers.
+// Invalid answers are reported and prompted again up to a maximum number of attempts.
+func AskUsername(ui cli.Ui, recent []string) (string, error) {
+	query := "GitHub username:"
+	if len(recent) > 0 {
+		ui.Output("Recently used usernames:")
+		for i, username := range recent {
+			ui.Output(fmt.Sprintf("  %d) %s", i+1, username))
+		}
+		query = "GitHub username (or number):"
+	}
+
+	for range maxAttempts {
+		answer, err := ui.Ask(query)
+		if err != nil {
+			return "", err
+		}
+
+		answer = strings.TrimSpace(answer)
+		if i, err := strconv.Atoi(answer); err == nil && i >= 1 && i <= len(recent) {
+			return recent[i-1], nil
+		}
+
+		if answer == "" {
+			ui.Error("No GitHub username is provided.")
+			continue
+		}
+
+		if err := ValidateUsername(answer); err != nil {
+			ui.Error(err.Error())
+			continue
+		}
+
+		return answer, nil
+	}
+
+	return "", ErrInvalidInput
+}
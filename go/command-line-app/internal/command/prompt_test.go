@@ -0,0 +1,109 @@
+package command
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateUsername(t *testing.T) {
+	tests := []struct {
+		name          string
+		username      string
+		expectedError string
+	}{
+		{"Valid", "octocat", ""},
+		{"Hyphen", "mona-lisa", ""},
+		{"Empty", "", `invalid GitHub username "": only alphanumeric characters and single hyphens (not at the beginning or the end) are allowed`},
+		{"LeadingHyphen", "-octocat", `invalid GitHub username "-octocat": only alphanumeric characters and single hyphens (not at the beginning or the end) are allowed`},
+		{"DoubleHyphen", "mona--lisa", `invalid GitHub username "mona--lisa": only alphanumeric characters and single hyphens (not at the beginning or the end) are allowed`},
+		{"TooLong", strings.Repeat("a", 40), `invalid GitHub username "` + strings.Repeat("a", 40) + `": only alphanumeric characters and single hyphens (not at the beginning or the end) are allowed`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateUsername(tc.username)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestAskUsername(t *testing.T) {
+	tests := []struct {
+		name             string
+		input            string
+		recent           []string
+		expectedUsername string
+		expectedError    error
+		expectedOutput   string
+		expectedErrors   string
+	}{
+		{
+			name:             "NoInput",
+			input:            "",
+			expectedUsername: "",
+			expectedError:    io.EOF,
+			expectedOutput:   "GitHub username:",
+		},
+		{
+			name:             "Valid",
+			input:            "octocat\n",
+			expectedUsername: "octocat",
+			expectedOutput:   "GitHub username:",
+		},
+		{
+			name:             "Reprompt",
+			input:            "\nocto cat\noctocat\n",
+			expectedUsername: "octocat",
+			expectedErrors:   "No GitHub username is provided.\ninvalid GitHub username \"octo cat\"",
+		},
+		{
+			name:           "TooManyAttempts",
+			input:          "-\n-\n-\noctocat\n",
+			expectedError:  ErrInvalidInput,
+			expectedErrors: `invalid GitHub username "-"`,
+		},
+		{
+			name:             "SelectRecent",
+			input:            "2\n",
+			recent:           []string{"octocat", "hubot"},
+			expectedUsername: "hubot",
+			expectedOutput:   "Recently used usernames:\n  1) octocat\n  2) hubot\nGitHub username (or number):",
+		},
+		{
+			name:             "NewWithRecent",
+			input:            "monalisa\n",
+			recent:           []string{"octocat"},
+			expectedUsername: "monalisa",
+			expectedOutput:   "  1) octocat",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			// cli.MockUi reads each answer using a new bufio.Reader, so the input is read one byte at a time.
+			ui.InputReader = iotest.OneByteReader(strings.NewReader(tc.input))
+
+			username, err := AskUsername(ui, tc.recent)
+
+			assert.Equal(t, tc.expectedUsername, username)
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+			assert.Contains(t, ui.OutputWriter.String(), tc.expectedOutput)
+			assert.Contains(t, ui.ErrorWriter.String(), tc.expectedErrors)
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package user
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"iter"
+
+	"github.com/mitchellh/cli"
+
+	"command-line-app/internal/command"
+	"command-line-app/internal/github"
+)
+
+const (
+	defaultLimit = 30
+	listHelp     = `
+  Use this command for listing the %[1]s of a GitHub user!
+  Items are fetched page by page and printed as they arrive.
+
+  Usage:  command-line-app user %[1]s [flags]
+
+  Flags:
+    -username  a GitHub username
+    -limit     the maximum number of %[1]s to list (default: %[2]d)
+    -all       list all %[1]s regardless of the limit
+    -verbose   print the underlying causes of errors
+
+  Examples:
+    command-line-app user %[1]s -username octocat
+    command-line-app user %[1]s -username octocat -limit 100
+    command-line-app user %[1]s -username octocat -all
+  `
+)
+
+// resource describes a kind of items that can be listed for a GitHub user.
+type resource struct {
+	name     string
+	synopsis string
+	list     func(context.Context, githubService, string) iter.Seq2[string, error]
+}
+
+var (
+	repos = resource{
+		name:     "repos",
+		synopsis: `List the public repositories of a GitHub user!`,
+		list: func(ctx context.Context, s githubService, username string) iter.Seq2[string, error] {
+			return format(s.ListRepos(ctx, username), func(r *github.Repository) string {
+				if r.Description == "" {
+					return r.FullName
+				}
+				return fmt.Sprintf("%s - %s", r.FullName, r.Description)
+			})
+		},
+	}
+
+	followers = resource{
+		name:     "followers",
+		synopsis: `List the followers of a GitHub user!`,
+		list: func(ctx context.Context, s githubService, username string) iter.Seq2[string, error] {
+			return format(s.ListFollowers(ctx, username), func(u *github.User) string {
+				return u.Login
+			})
+		},
+	}
+
+	orgs = resource{
+		name:     "orgs",
+		synopsis: `List the public organizations of a GitHub user!`,
+		list: func(ctx context.Context, s githubService, username string) iter.Seq2[string, error] {
+			return format(s.ListOrgs(ctx, username), func(o *github.Organization) string {
+				if o.Description == "" {
+					return o.Login
+				}
+				return fmt.Sprintf("%s - %s", o.Login, o.Description)
+			})
+		},
+	}
+)
+
+// format converts an iterator of items into an iterator of printable lines.
+func format[T any](seq iter.Seq2[*T, error], f func(*T) string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for item, err := range seq {
+			if err != nil {
+				yield("", err)
+				return
+			}
+
+			if !yield(f(item), nil) {
+				return
+			}
+		}
+	}
+}
+
+// ListCommand implements the cli.Command implementation.
+type ListCommand struct {
+	ctx      context.Context
+	ui       cli.Ui
+	config   command.Config
+	resource resource
+	flags    struct {
+		username string
+		limit    int
+		all      bool
+		verbose  bool
+	}
+	services struct {
+		github githubService
+	}
+	outputs struct {
+		count int
+	}
+}
+
+func newList(ctx context.Context, ui cli.Ui, config command.Config, r resource) *ListCommand {
+	return &ListCommand{
+		ctx:      ctx,
+		ui:       ui,
+		config:   config,
+		resource: r,
+	}
+}
+
+// NewReposFactory returns a cli.CommandFactory for creating a new command for listing repositories.
+func NewReposFactory(ctx context.Context, ui cli.Ui, config command.Config) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return newList(ctx, ui, config, repos), nil
+	}
+}
+
+// NewFollowersFactory returns a cli.CommandFactory for creating a new command for listing followers.
+func NewFollowersFactory(ctx context.Context, ui cli.Ui, config command.Config) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return newList(ctx, ui, config, followers), nil
+	}
+}
+
+// NewOrgsFactory returns a cli.CommandFactory for creating a new command for listing organizations.
+func NewOrgsFactory(ctx context.Context, ui cli.Ui, config command.Config) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return newList(ctx, ui, config, orgs), nil
+	}
+}
+
+// Synopsis returns a short one-line synopsis for the command.
+func (c *ListCommand) Synopsis() string {
+	return c.resource.synopsis
+}
+
+// Help returns a long help text including usage, description, and list of flags for the command.
+func (c *ListCommand) Help() string {
+	return fmt.Sprintf(listHelp, c.resource.name, defaultLimit)
+}
+
+// Run runs the actual command with the given command-line arguments.
+// This method is used as a proxy for creating dependencies and the actual command execution is delegated to the run method for testing purposes.
+func (c *ListCommand) Run(args []string) int {
+	if code := c.parseFlags(args); code != command.Success {
+		return code
+	}
+
+	github, err := github.NewService(c.config.GithubURL, c.config.RequestTimeout, c.config.Transport)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return command.GenericError
+	}
+
+	c.services.github = github
+
+	return c.exec()
+}
+
+func (c *ListCommand) parseFlags(args []string) int {
+	fs := flag.NewFlagSet("user "+c.resource.name, flag.ContinueOnError)
+	fs.StringVar(&c.flags.username, "username", "", "")
+	fs.IntVar(&c.flags.limit, "limit", defaultLimit, "")
+	fs.BoolVar(&c.flags.all, "all", false, "")
+	fs.BoolVar(&c.flags.verbose, "verbose", false, "")
+
+	fs.Usage = func() {
+		c.ui.Output(c.Help())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		// In case of error, the error and help will be printed by the Parse method
+		return command.FlagError
+	}
+
+	if !c.flags.all && c.flags.limit <= 0 {
+		c.ui.Error("The limit must be a positive number.")
+		return command.FlagError
+	}
+
+	return command.Success
+}
+
+// exec in an auxiliary method, so we can test the business logic with mock dependencies.
+func (c *ListCommand) exec() int {
+	if c.flags.username == "" {
+		c.ui.Error("No GitHub username is provided.")
+		return command.GenericError
+	}
+
+	for line, err := range c.resource.list(c.ctx, c.services.github, c.flags.username) {
+		if err != nil {
+			command.PrintError(c.ui, err, c.flags.verbose)
+			return command.ExitCode(err)
+		}
+
+		c.ui.Output(line)
+
+		if c.outputs.count++; !c.flags.all && c.outputs.count >= c.flags.limit {
+			break
+		}
+	}
+
+	return command.Success
+}
+
+// Count returns the number of listed items.
+func (c *ListCommand) Count() int {
+	return c.outputs.count
+}
@@ -0,0 +1,234 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+
+	"command-line-app/internal/command"
+	"command-line-app/internal/github"
+)
+
+func TestNewListFactories(t *testing.T) {
+	tests := []struct {
+		name             string
+		factory          func(context.Context, cli.Ui, command.Config) cli.CommandFactory
+		expectedResource string
+	}{
+		{"Repos", NewReposFactory, "repos"},
+		{"Followers", NewFollowersFactory, "followers"},
+		{"Orgs", NewOrgsFactory, "orgs"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c, err := tc.factory(context.Background(), ui, command.Config{})()
+
+			assert.NoError(t, err)
+			assert.NotNil(t, c)
+			assert.Equal(t, tc.expectedResource, c.(*ListCommand).resource.name)
+		})
+	}
+}
+
+func TestListCommand_Synopsis(t *testing.T) {
+	c := &ListCommand{resource: repos}
+	synopsis := c.Synopsis()
+
+	assert.NotEmpty(t, synopsis)
+}
+
+func TestListCommand_Help(t *testing.T) {
+	c := &ListCommand{resource: followers}
+	help := c.Help()
+
+	assert.Contains(t, help, "command-line-app user followers")
+	assert.Contains(t, help, "(default: 30)")
+}
+
+func TestListCommand_Run(t *testing.T) {
+	t.Run("InvalidFlag", func(t *testing.T) {
+		c := &ListCommand{ui: cli.NewMockUi(), resource: repos}
+		exitCode := c.Run([]string{"-undefined"})
+
+		assert.Equal(t, command.FlagError, exitCode)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		c := &ListCommand{
+			ctx:      context.Background(),
+			ui:       cli.NewMockUi(),
+			config:   command.Config{RequestTimeout: time.Second},
+			resource: repos,
+		}
+		c.Run([]string{})
+
+		assert.NotNil(t, c.services.github)
+	})
+}
+
+func TestListCommand_parseFlags(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		expectedExitCode int
+	}{
+		{
+			name:             "InvalidFlag",
+			args:             []string{"-undefined"},
+			expectedExitCode: command.FlagError,
+		},
+		{
+			name:             "InvalidLimit",
+			args:             []string{"-username", "octocat", "-limit", "0"},
+			expectedExitCode: command.FlagError,
+		},
+		{
+			name:             "AllWithoutLimit",
+			args:             []string{"-username", "octocat", "-limit", "0", "-all"},
+			expectedExitCode: command.Success,
+		},
+		{
+			name:             "ValidFlag",
+			args:             []string{"-username", "octocat", "-limit", "10"},
+			expectedExitCode: command.Success,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &ListCommand{ui: cli.NewMockUi(), resource: repos}
+			exitCode := c.parseFlags(tc.args)
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+		})
+	}
+}
+
+func TestListCommand_exec(t *testing.T) {
+	tests := []struct {
+		name             string
+		resource         resource
+		usernameFlag     string
+		limitFlag        int
+		allFlag          bool
+		github           *MockGithubService
+		expectedOutput   string
+		expectedCount    int
+		expectedExitCode int
+	}{
+		{
+			name:             "NoUsername",
+			resource:         repos,
+			usernameFlag:     "",
+			limitFlag:        defaultLimit,
+			github:           &MockGithubService{},
+			expectedOutput:   "",
+			expectedCount:    0,
+			expectedExitCode: command.GenericError,
+		},
+		{
+			name:         "ListFailsMidway",
+			resource:     repos,
+			usernameFlag: "octocat",
+			limitFlag:    defaultLimit,
+			github: &MockGithubService{
+				ListReposMocks: []ListReposMock{
+					{
+						OutRepos: []*github.Repository{
+							{FullName: "octocat/Hello-World"},
+						},
+						OutError: &github.Error{Kind: github.ErrRateLimited, Err: errors.New("GET /users/octocat/repos 429")},
+					},
+				},
+			},
+			expectedOutput:   "octocat/Hello-World\n",
+			expectedCount:    1,
+			expectedExitCode: command.RateLimitError,
+		},
+		{
+			name:         "Repos_Limited",
+			resource:     repos,
+			usernameFlag: "octocat",
+			limitFlag:    2,
+			github: &MockGithubService{
+				ListReposMocks: []ListReposMock{
+					{
+						OutRepos: []*github.Repository{
+							{FullName: "octocat/Hello-World", Description: "My first repository on GitHub!"},
+							{FullName: "octocat/Spoon-Knife"},
+							{FullName: "octocat/linguist"},
+						},
+					},
+				},
+			},
+			expectedOutput:   "octocat/Hello-World - My first repository on GitHub!\noctocat/Spoon-Knife\n",
+			expectedCount:    2,
+			expectedExitCode: command.Success,
+		},
+		{
+			name:         "Followers_All",
+			resource:     followers,
+			usernameFlag: "octocat",
+			limitFlag:    1,
+			allFlag:      true,
+			github: &MockGithubService{
+				ListFollowersMocks: []ListFollowersMock{
+					{
+						OutFollowers: []*github.User{
+							{Login: "hubot"},
+							{Login: "monalisa"},
+						},
+					},
+				},
+			},
+			expectedOutput:   "hubot\nmonalisa\n",
+			expectedCount:    2,
+			expectedExitCode: command.Success,
+		},
+		{
+			name:         "Orgs",
+			resource:     orgs,
+			usernameFlag: "octocat",
+			limitFlag:    defaultLimit,
+			github: &MockGithubService{
+				ListOrgsMocks: []ListOrgsMock{
+					{
+						OutOrgs: []*github.Organization{
+							{Login: "github", Description: "How people build software."},
+						},
+					},
+				},
+			},
+			expectedOutput:   "github - How people build software.\n",
+			expectedCount:    1,
+			expectedExitCode: command.Success,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ListCommand{
+				ctx:      context.Background(),
+				ui:       ui,
+				resource: tc.resource,
+			}
+			c.flags.username = tc.usernameFlag
+			c.flags.limit = tc.limitFlag
+			c.flags.all = tc.allFlag
+			c.services.github = tc.github
+
+			exitCode := c.exec()
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+			assert.Equal(t, tc.expectedOutput, ui.OutputWriter.String())
+			assert.Equal(t, tc.expectedCount, c.Count())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"context"
+	"iter"
+
+	"command-line-app/internal/github"
+)
+
+type (
+	GetUserMock struct {
+		InContext  context.Context
+		InUsername string
+		OutUser    *github.User
+		OutError   error
+	}
+
+	ListReposMock struct {
+		InContext  context.Context
+		InUsername string
+		OutRepos   []*github.Repository
+		OutError   error
+	}
+
+	ListFollowersMock struct {
+		InContext    context.Context
+		InUsername   string
+		OutFollowers []*github.User
+		OutError     error
+	}
+
+	ListOrgsMock struct {
+		InContext  context.Context
+		InUsername string
+		OutOrgs    []*github.Organization
+		OutError   error
+	}
+
+	MockGithubService struct {
+		GetUserIndex int
+		GetUserMocks []GetUserMock
+
+		ListReposIndex int
+		ListReposMocks []ListReposMock
+
+		ListFollowersIndex int
+		ListFollowersMocks []ListFollowersMock
+
+		ListOrgsIndex int
+		ListOrgsMocks []ListOrgsMock
+	}
+)
+
+// seq returns an iterator over the given items followed by the given error if not nil.
+func seq[T any](items []*T, err error) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for _, item := range items {
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func (m *MockGithubService) GetUser(ctx context.Context, username string) (*github.User, error) {
+	i := m.GetUserIndex
+	m.GetUserIndex++
+	m.GetUserMocks[i].InContext = ctx
+	m.GetUserMocks[i].InUsername = username
+	return m.GetUserMocks[i].OutUser, m.GetUserMocks[i].OutError
+}
+
+func (m *MockGithubService) ListRepos(ctx context.Context, username string) iter.Seq2[*github.Repository, error] {
+	i := m.ListReposIndex
+	m.ListReposIndex++
+	m.ListReposMocks[i].InContext = ctx
+	m.ListReposMocks[i].InUsername = username
+	return seq(m.ListReposMocks[i].OutRepos, m.ListReposMocks[i].OutError)
+}
+
+func (m *MockGithubService) ListFollowers(ctx context.Context, username string) iter.Seq2[*github.User, error] {
+	i := m.ListFollowersIndex
+	m.ListFollowersIndex++
+	m.ListFollowersMocks[i].InContext = ctx
+	m.ListFollowersMocks[i].InUsername = username
+	return seq(m.ListFollowersMocks[i].OutFollowers, m.ListFollowersMocks[i].OutError)
+}
+
+func (m *MockGithubService) ListOrgs(ctx context.Context, username string) iter.Seq2[*github.Organization, error] {
+	i := m.ListOrgsIndex
+	m.ListOrgsIndex++
+	m.ListOrgsMocks[i].InContext = ctx
+	m.ListOrgsMocks[i].InUsername = username
+	return seq(m.ListOrgsMocks[i].OutOrgs, m.ListOrgsMocks[i].OutError)
+}
@@ -0,0 +1,146 @@
+package user
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/mitchellh/cli"
+
+	"command-line-app/internal/command"
+	"command-line-app/internal/github"
+)
+
+const (
+	showSynopsis = `Show the profile of a GitHub user!`
+	showHelp     = `
+  Use this command for showing the profile of a GitHub user!
+
+  Usage:  command-line-app user show [flags]
+
+  Flags:
+    -username  a GitHub username
+    -verbose   print the underlying causes of errors
+
+  Examples:
+    command-line-app user show -username octocat
+  `
+)
+
+// ShowCommand implements the cli.Command implementation.
+type ShowCommand struct {
+	ctx    context.Context
+	ui     cli.Ui
+	config command.Config
+	flags  struct {
+		username string
+		verbose  bool
+	}
+	services struct {
+		github githubService
+	}
+	outputs struct {
+		user *github.User
+	}
+}
+
+// NewShow creates a new show command.
+// The given context is the root context for the command execution.
+func NewShow(ctx context.Context, ui cli.Ui, config command.Config) *ShowCommand {
+	return &ShowCommand{
+		ctx:    ctx,
+		ui:     ui,
+		config: config,
+	}
+}
+
+// NewShowFactory returns a cli.CommandFactory for creating a new show command.
+func NewShowFactory(ctx context.Context, ui cli.Ui, config command.Config) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewShow(ctx, ui, config), nil
+	}
+}
+
+// Synopsis returns a short one-line synopsis for the command.
+func (c *ShowCommand) Synopsis() string {
+	return showSynopsis
+}
+
+// Help returns a long help text including usage, description, and list of flags for the command.
+func (c *ShowCommand) Help() string {
+	return showHelp
+}
+
+// Run runs the actual command with the given command-line arguments.
+// This method is used as a proxy for creating dependencies and the actual command execution is delegated to the run method for testing purposes.
+func (c *ShowCommand) Run(args []string) int {
+	if code := c.parseFlags(args); code != command.Success {
+		return code
+	}
+
+	github, err := github.NewService(c.config.GithubURL, c.config.RequestTimeout, c.config.Transport)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return command.GenericError
+	}
+
+	c.services.github = github
+
+	return c.exec()
+}
+
+func (c *ShowCommand) parseFlags(args []string) int {
+	fs := flag.NewFlagSet("user show", flag.ContinueOnError)
+	fs.StringVar(&c.flags.username, "username", "", "")
+	fs.BoolVar(&c.flags.verbose, "verbose", false, "")
+
+	fs.Usage = func() {
+		c.ui.Output(c.Help())
+	}
+
+	if err := fs.Parse(args); err != nil {
+		// In case of error, the error and help will be printed by the Parse method
+		return command.FlagError
+	}
+
+	return command.Success
+}
+
+// exec in an auxiliary method, so we can test the business logic with mock dependencies.
+func (c *ShowCommand) exec() int {
+	if c.flags.username == "" {
+		c.ui.Error("No GitHub username is provided.")
+		return command.GenericError
+	}
+
+	user, err := c.services.github.GetUser(c.ctx, c.flags.username)
+	if err != nil {
+		command.PrintError(c.ui, err, c.flags.verbose)
+		return command.ExitCode(err)
+	}
+
+	c.outputs.user = user
+
+	c.ui.Output(fmt.Sprintf("Login:      %s", user.Login))
+	for _, field := range []struct{ label, value string }{
+		{"Name:      ", user.Name},
+		{"Email:     ", user.Email},
+		{"Company:   ", user.Company},
+		{"Location:  ", user.Location},
+		{"Bio:       ", user.Bio},
+	} {
+		if field.value != "" {
+			c.ui.Output(fmt.Sprintf("%s %s", field.label, field.value))
+		}
+	}
+	c.ui.Output(fmt.Sprintf("Repos:      %d", user.PublicRepos))
+	c.ui.Output(fmt.Sprintf("Followers:  %d", user.Followers))
+	c.ui.Output(fmt.Sprintf("Following:  %d", user.Following))
+
+	return command.Success
+}
+
+// User returns the GitHub user shown by the command.
+func (c *ShowCommand) User() *github.User {
+	return c.outputs.user
+}
@@ -0,0 +1,151 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+
+	"command-line-app/internal/command"
+	"command-line-app/internal/github"
+)
+
+func TestNewShow(t *testing.T) {
+	ui := cli.NewMockUi()
+	c := NewShow(context.Background(), ui, command.Config{})
+
+	assert.NotNil(t, c)
+}
+
+func TestNewShowFactory(t *testing.T) {
+	ui := cli.NewMockUi()
+	c, err := NewShowFactory(context.Background(), ui, command.Config{})()
+
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestShowCommand_Synopsis(t *testing.T) {
+	c := new(ShowCommand)
+	synopsis := c.Synopsis()
+
+	assert.NotEmpty(t, synopsis)
+}
+
+func TestShowCommand_Help(t *testing.T) {
+	c := new(ShowCommand)
+	help := c.Help()
+
+	assert.NotEmpty(t, help)
+}
+
+func TestShowCommand_Run(t *testing.T) {
+	t.Run("InvalidFlag", func(t *testing.T) {
+		c := &ShowCommand{ui: cli.NewMockUi()}
+		exitCode := c.Run([]string{"-undefined"})
+
+		assert.Equal(t, command.FlagError, exitCode)
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		c := &ShowCommand{
+			ctx:    context.Background(),
+			ui:     cli.NewMockUi(),
+			config: command.Config{RequestTimeout: time.Second},
+		}
+		c.Run([]string{})
+
+		assert.NotNil(t, c.services.github)
+	})
+}
+
+func TestShowCommand_exec(t *testing.T) {
+	tests := []struct {
+		name             string
+		usernameFlag     string
+		github           *MockGithubService
+		expectedUser     *github.User
+		expectedOutput   []string
+		expectedExitCode int
+	}{
+		{
+			name:             "NoUsername",
+			usernameFlag:     "",
+			github:           &MockGithubService{},
+			expectedUser:     nil,
+			expectedExitCode: command.GenericError,
+		},
+		{
+			name:         "UserNotFound",
+			usernameFlag: "octocat",
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{OutError: &github.Error{Kind: github.ErrNotFound, Err: errors.New("GET /users/octocat 404")}},
+				},
+			},
+			expectedUser:     nil,
+			expectedExitCode: command.NotFoundError,
+		},
+		{
+			name:         "Success",
+			usernameFlag: "octocat",
+			github: &MockGithubService{
+				GetUserMocks: []GetUserMock{
+					{
+						OutUser: &github.User{
+							ID:          1,
+							Login:       "octocat",
+							Name:        "The Octocat",
+							Company:     "@github",
+							PublicRepos: 8,
+							Followers:   100,
+							Following:   9,
+						},
+					},
+				},
+			},
+			expectedUser: &github.User{
+				ID:          1,
+				Login:       "octocat",
+				Name:        "The Octocat",
+				Company:     "@github",
+				PublicRepos: 8,
+				Followers:   100,
+				Following:   9,
+			},
+			expectedOutput: []string{
+				"Login:      octocat",
+				"Name:       The Octocat",
+				"Company:    @github",
+				"Repos:      8",
+				"Followers:  100",
+				"Following:  9",
+			},
+			expectedExitCode: command.Success,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := &ShowCommand{
+				ctx: context.Background(),
+				ui:  ui,
+			}
+			c.flags.username = tc.usernameFlag
+			c.services.github = tc.github
+
+			exitCode := c.exec()
+
+			assert.Equal(t, tc.expectedExitCode, exitCode)
+			assert.Equal(t, tc.expectedUser, c.User())
+			for _, line := range tc.expectedOutput {
+				assert.Contains(t, ui.OutputWriter.String(), line)
+			}
+			assert.NotContains(t, ui.OutputWriter.String(), "Email:")
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Package user implements the user command and its subcommands for querying GitHub users.
+package user
+
+import (
+	"context"
+	"iter"
+
+	"github.com/mitchellh/cli"
+
+	"command-line-app/internal/github"
+)
+
+const (
+	synopsis = `Show and list information about a GitHub user!`
+	help     = `
+  Use the subcommands of this command for querying information about a GitHub user!
+
+  Usage:  command-line-app user <subcommand> [flags]
+
+  Examples:
+    command-line-app user show -username octocat
+    command-line-app user repos -username octocat -limit 10
+    command-line-app user followers -username octocat -all
+  `
+)
+
+type (
+	githubService interface {
+		GetUser(context.Context, string) (*github.User, error)
+		ListRepos(context.Context, string) iter.Seq2[*github.Repository, error]
+		ListFollowers(context.Context, string) iter.Seq2[*github.User, error]
+		ListOrgs(context.Context, string) iter.Seq2[*github.Organization, error]
+	}
+)
+
+// Command implements the cli.Command implementation.
+// This command has no functionality of its own and only shows the help text for its subcommands.
+type Command struct{}
+
+// New creates a new command.
+func New() *Command {
+	return &Command{}
+}
+
+// NewFactory returns a cli.CommandFactory for creating a new command.
+func NewFactory() cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return New(), nil
+	}
+}
+
+// Synopsis returns a short one-line synopsis for the command.
+func (c *Command) Synopsis() string {
+	return synopsis
+}
+
+// Help returns a long help text including usage, description, and list of subcommands for the command.
+func (c *Command) Help() string {
+	return help
+}
+
+// Run shows the help text for the command.
+func (c *Command) Run(args []string) int {
+	return cli.RunResultHelp
+}
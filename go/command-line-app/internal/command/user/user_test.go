@@ -0,0 +1,42 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	c := New()
+
+	assert.NotNil(t, c)
+}
+
+func TestNewFactory(t *testing.T) {
+	c, err := NewFactory()()
+
+	assert.NoError(t, err)
+	assert.NotNil(t, c)
+}
+
+func TestCommand_Synopsis(t *testing.T) {
+	c := new(Command)
+	synopsis := c.Synopsis()
+
+	assert.NotEmpty(t, synopsis)
+}
+
+func TestCommand_Help(t *testing.T) {
+	c := new(Command)
+	help := c.Help()
+
+	assert.NotEmpty(t, help)
+}
+
+func TestCommand_Run(t *testing.T) {
+	c := new(Command)
+	exitCode := c.Run([]string{})
+
+	assert.Equal(t, cli.RunResultHelp, exitCode)
+}
@@ -0,0 +1,215 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Archive records requests and responses in the HTTP Archive (HAR) format.
+// See http://www.softwareishard.com/blog/har-12-spec
+type Archive struct {
+	mu      sync.Mutex
+	creator string
+	version string
+	entries []harEntry
+}
+
+// NewArchive creates a new archive.
+// creator and version identify the application creating the archive.
+func NewArchive(creator, version string) *Archive {
+	return &Archive{
+		creator: creator,
+		version: version,
+		entries: []harEntry{},
+	}
+}
+
+type (
+	har struct {
+		Log harLog `json:"log"`
+	}
+
+	harLog struct {
+		Version string     `json:"version"`
+		Creator harCreator `json:"creator"`
+		Entries []harEntry `json:"entries"`
+	}
+
+	harCreator struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+
+	harEntry struct {
+		StartedDateTime string      `json:"startedDateTime"`
+		Time            float64     `json:"time"`
+		Request         harRequest  `json:"request"`
+		Response        harResponse `json:"response"`
+		Cache           struct{}    `json:"cache"`
+		Timings         harTimings  `json:"timings"`
+		Comment         string      `json:"comment,omitempty"`
+	}
+
+	harRequest struct {
+		Method      string         `json:"method"`
+		URL         string         `json:"url"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		QueryString []harNameValue `json:"queryString"`
+		PostData    *harPostData   `json:"postData,omitempty"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harResponse struct {
+		Status      int            `json:"status"`
+		StatusText  string         `json:"statusText"`
+		HTTPVersion string         `json:"httpVersion"`
+		Cookies     []harNameValue `json:"cookies"`
+		Headers     []harNameValue `json:"headers"`
+		Content     harContent     `json:"content"`
+		RedirectURL string         `json:"redirectURL"`
+		HeadersSize int            `json:"headersSize"`
+		BodySize    int            `json:"bodySize"`
+	}
+
+	harNameValue struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+
+	harPostData struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harContent struct {
+		Size     int    `json:"size"`
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+	}
+
+	harTimings struct {
+		Send    float64 `json:"send"`
+		Wait    float64 `json:"wait"`
+		Receive float64 `json:"receive"`
+	}
+)
+
+// add records a request and its response.
+// If the request failed, resp is nil and the error is recorded as a comment on an empty response.
+func (a *Archive) add(start time.Time, duration time.Duration, req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, err error) {
+	ms := float64(duration) / float64(time.Millisecond)
+
+	if resp == nil {
+		resp = &http.Response{Header: http.Header{}}
+	}
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            ms,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(reqBody),
+		},
+		Response: harResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(resp.Header),
+			Content: harContent{
+				Size:     len(respBody),
+				MimeType: resp.Header.Get("Content-Type"),
+				Text:     string(respBody),
+			},
+			HeadersSize: -1,
+			BodySize:    len(respBody),
+		},
+		Timings: harTimings{
+			Send:    0,
+			Wait:    ms,
+			Receive: 0,
+		},
+	}
+
+	if err != nil {
+		entry.Comment = err.Error()
+	}
+
+	for name, vals := range req.URL.Query() {
+		for _, val := range vals {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{name, val})
+		}
+	}
+
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.entries = append(a.entries, entry)
+}
+
+// harHeaders converts HTTP headers to HAR headers with sensitive values redacted.
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for name, vals := range header {
+		for _, val := range vals {
+			headers = append(headers, harNameValue{name, redact(name, val)})
+		}
+	}
+
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Name < headers[j].Name
+	})
+
+	return headers
+}
+
+// Len returns the number of recorded entries.
+func (a *Archive) Len() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.entries)
+}
+
+// WriteFile writes the archive to a file.
+func (a *Archive) WriteFile(path string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	b, err := json.MarshalIndent(har{
+		Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{
+				Name:    a.creator,
+				Version: a.version,
+			},
+			Entries: a.entries,
+		},
+	}, "", "  ")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, b, 0o600)
+}
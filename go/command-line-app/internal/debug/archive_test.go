@@ -0,0 +1,71 @@
+package debug
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewArchive(t *testing.T) {
+	a := NewArchive("command-line-app", "0.1.0")
+
+	assert.NotNil(t, a)
+	assert.Equal(t, "command-line-app", a.creator)
+	assert.Equal(t, "0.1.0", a.version)
+	assert.Equal(t, 0, a.Len())
+}
+
+func TestArchive_WriteFile(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat/repos?per_page=100", nil)
+	req.Header.Set("Authorization", "token secret")
+
+	resp := &http.Response{
+		StatusCode: 200,
+		Proto:      "HTTP/1.1",
+		Header: http.Header{
+			"Content-Type": []string{"application/json"},
+		},
+		Body: io.NopCloser(strings.NewReader("[]")),
+	}
+
+	a := NewArchive("command-line-app", "0.1.0")
+	a.add(start, 150*time.Millisecond, req, nil, resp, []byte("[]"), nil)
+	a.add(start, 10*time.Millisecond, req, nil, nil, nil, errors.New("connection refused"))
+
+	path := filepath.Join(t.TempDir(), "debug.har")
+	err := a.WriteFile(path)
+	assert.NoError(t, err)
+
+	b, err := os.ReadFile(path)
+	assert.NoError(t, err)
+
+	h := new(har)
+	assert.NoError(t, json.Unmarshal(b, h))
+
+	assert.Equal(t, "1.2", h.Log.Version)
+	assert.Equal(t, harCreator{"command-line-app", "0.1.0"}, h.Log.Creator)
+	assert.Len(t, h.Log.Entries, 2)
+
+	entry := h.Log.Entries[0]
+	assert.Equal(t, "2026-01-02T03:04:05Z", entry.StartedDateTime)
+	assert.Equal(t, float64(150), entry.Time)
+	assert.Equal(t, "GET", entry.Request.Method)
+	assert.Equal(t, []harNameValue{{"Authorization", "token REDACTED"}}, entry.Request.Headers)
+	assert.Equal(t, []harNameValue{{"per_page", "100"}}, entry.Request.QueryString)
+	assert.Equal(t, 200, entry.Response.Status)
+	assert.Equal(t, harContent{Size: 2, MimeType: "application/json", Text: "[]"}, entry.Response.Content)
+
+	entry = h.Log.Entries[1]
+	assert.Equal(t, 0, entry.Response.Status)
+	assert.Equal(t, "connection refused", entry.Comment)
+}
@@ -0,0 +1,158 @@
+// Package debug provides tracing of HTTP requests and responses for debugging the calls to external services.
+package debug
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const redacted = "REDACTED"
+
+// sensitiveHeaders are the headers with values that are never logged or recorded.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// Options are optional configurations for creating a new transport.
+type Options struct {
+	// Output is where requests and responses are logged (not logged if nil).
+	Output io.Writer
+	// Bodies determines whether request and response bodies are logged too.
+	Bodies bool
+	// Archive is where requests and responses are recorded (not recorded if nil).
+	Archive *Archive
+}
+
+// Transport is an http.RoundTripper that logs and records the requests and responses of another http.RoundTripper.
+type Transport struct {
+	base    http.RoundTripper
+	mu      sync.Mutex
+	out     io.Writer
+	bodies  bool
+	archive *Archive
+}
+
+// NewTransport creates a new transport wrapping the given http.RoundTripper.
+func NewTransport(base http.RoundTripper, opts Options) *Transport {
+	return &Transport{
+		base:    base,
+		out:     opts.Output,
+		bodies:  opts.Bodies,
+		archive: opts.Archive,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	readBodies := t.bodies || t.archive != nil
+
+	// The request of the caller must not be modified, so the body is read into a clone of the request
+	var reqBody []byte
+	if readBodies && req.Body != nil && req.Body != http.NoBody {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(reqBody)), nil
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	duration := time.Since(start)
+
+	var respBody []byte
+	if err == nil && readBodies {
+		respBody, err = io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	}
+
+	t.log(req, reqBody, resp, respBody, duration, err)
+
+	if t.archive != nil {
+		t.archive.add(start, duration, req, reqBody, resp, respBody, err)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (t *Transport) log(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, duration time.Duration, err error) {
+	if t.out == nil {
+		return
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "--> %s %s\n", req.Method, req.URL)
+	writeHeaders(&b, req.Header)
+	if t.bodies && len(reqBody) > 0 {
+		fmt.Fprintf(&b, "\n%s\n", reqBody)
+	}
+
+	if err != nil {
+		fmt.Fprintf(&b, "<-- %s %s failed (%s): %s\n", req.Method, req.URL, duration.Round(time.Millisecond), err)
+	} else {
+		fmt.Fprintf(&b, "<-- %s %s (%s)\n", resp.Status, req.URL, duration.Round(time.Millisecond))
+		writeHeaders(&b, resp.Header)
+		if t.bodies && len(respBody) > 0 {
+			fmt.Fprintf(&b, "\n%s\n", bytes.TrimRight(respBody, "\n"))
+		}
+	}
+
+	// Concurrent requests should not interleave their logs
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, _ = io.WriteString(t.out, b.String())
+}
+
+func writeHeaders(w io.Writer, header http.Header) {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, val := range header[name] {
+			fmt.Fprintf(w, "    %s: %s\n", name, redact(name, val))
+		}
+	}
+}
+
+// redact hides the value of a sensitive header.
+// The authentication scheme (i.e. Bearer) is kept, so it is still possible to tell what kind of credentials is sent.
+func redact(name, val string) string {
+	name = http.CanonicalHeaderKey(name)
+	if !sensitiveHeaders[name] {
+		return val
+	}
+
+	if scheme, _, ok := strings.Cut(val, " "); ok && (name == "Authorization" || name == "Proxy-Authorization") {
+		return scheme + " " + redacted
+	}
+
+	return redacted
+}
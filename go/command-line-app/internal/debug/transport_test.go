@@ -0,0 +1,174 @@
+package debug
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport(t *testing.T) {
+	out := new(bytes.Buffer)
+	archive := NewArchive("test", "0.1.0")
+
+	tr := NewTransport(http.DefaultTransport, Options{
+		Output:  out,
+		Bodies:  true,
+		Archive: archive,
+	})
+
+	assert.NotNil(t, tr)
+	assert.Equal(t, http.DefaultTransport, tr.base)
+	assert.Equal(t, out, tr.out)
+	assert.True(t, tr.bodies)
+	assert.Equal(t, archive, tr.archive)
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"echo":` + string(b) + `}`))
+	}))
+	defer ts.Close()
+
+	closed := httptest.NewServer(nil)
+	closed.Close()
+
+	tests := []struct {
+		name             string
+		url              string
+		bodies           bool
+		expectedError    bool
+		expectedLogs     []string
+		unexpectedLogs   []string
+		expectedEntries  int
+		expectedRespBody string
+	}{
+		{
+			name:          "RequestFails",
+			url:           closed.URL,
+			expectedError: true,
+			expectedLogs: []string{
+				"--> POST " + closed.URL,
+				"<-- POST " + closed.URL + " failed",
+			},
+			expectedEntries: 1,
+		},
+		{
+			name:   "WithoutBodies",
+			url:    ts.URL,
+			bodies: false,
+			expectedLogs: []string{
+				"--> POST " + ts.URL,
+				"    Authorization: Bearer REDACTED",
+				"<-- 201 Created " + ts.URL,
+				"    Set-Cookie: REDACTED",
+			},
+			unexpectedLogs: []string{
+				"secret",
+				`{"id":1}`,
+			},
+			expectedEntries:  1,
+			expectedRespBody: `{"echo":{"id":1}}`,
+		},
+		{
+			name:   "WithBodies",
+			url:    ts.URL,
+			bodies: true,
+			expectedLogs: []string{
+				`{"id":1}`,
+				`{"echo":{"id":1}}`,
+			},
+			unexpectedLogs: []string{
+				"secret",
+			},
+			expectedEntries:  1,
+			expectedRespBody: `{"echo":{"id":1}}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			out := new(bytes.Buffer)
+			archive := NewArchive("test", "0.1.0")
+			client := &http.Client{
+				Transport: NewTransport(&http.Transport{}, Options{
+					Output:  out,
+					Bodies:  tc.bodies,
+					Archive: archive,
+				}),
+			}
+
+			req, _ := http.NewRequest("POST", tc.url, strings.NewReader(`{"id":1}`))
+			req.Header.Set("Authorization", "Bearer secret")
+			resp, err := client.Do(req)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				b, err := io.ReadAll(resp.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedRespBody, string(b))
+			}
+
+			for _, log := range tc.expectedLogs {
+				assert.Contains(t, out.String(), log)
+			}
+			for _, log := range tc.unexpectedLogs {
+				assert.NotContains(t, out.String(), log)
+			}
+			assert.Equal(t, tc.expectedEntries, archive.Len())
+		})
+	}
+}
+
+func TestTransport_RoundTrip_Request(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+
+	transport := NewTransport(&http.Transport{}, Options{Output: io.Discard, Bodies: true})
+
+	body := io.NopCloser(strings.NewReader(`{"id":1}`))
+	req, _ := http.NewRequest("POST", ts.URL, body)
+	resp, err := transport.RoundTrip(req)
+	assert.NoError(t, err)
+
+	b, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(b))
+
+	// The request of the caller is not modified
+	assert.True(t, req.Body == body)
+}
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        string
+		value         string
+		expectedValue string
+	}{
+		{"NotSensitive", "Accept", "application/json", "application/json"},
+		{"Authorization", "authorization", "token ghp_secret", "token REDACTED"},
+		{"AuthorizationWithoutScheme", "Authorization", "secret", "REDACTED"},
+		{"Cookie", "Cookie", "session=secret; theme=dark", "REDACTED"},
+		{"APIKey", "X-API-Key", "secret", "REDACTED"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedValue, redact(tc.header, tc.value))
+		})
+	}
+}
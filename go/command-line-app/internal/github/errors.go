@@ -0,0 +1,78 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gardenbed/basil/httpx"
+)
+
+var (
+	// ErrNotFound is the kind of errors when a requested GitHub resource does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrUnauthorized is the kind of errors when a request is not authenticated or not permitted.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrRateLimited is the kind of errors when the GitHub API rate limit is exceeded.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNetwork is the kind of errors when the GitHub API cannot be reached.
+	ErrNetwork = errors.New("network error")
+	// ErrTimeout is the kind of errors when a request to the GitHub API times out.
+	ErrTimeout = errors.New("timeout")
+)
+
+// Error is the error type returned by the service when a call to the GitHub API fails.
+// Kind is one of the Err* sentinel errors and can be checked using errors.Is.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Err)
+}
+
+// Is reports whether the error is of the target kind.
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// Unwrap returns the underlying cause of the error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newRequestError classifies an error returned by the HTTP client.
+// If the request is cancelled by the caller, the error is returned as is.
+func newRequestError(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return &Error{Kind: ErrTimeout, Err: err}
+	}
+
+	return &Error{Kind: ErrNetwork, Err: err}
+}
+
+// newResponseError classifies an unsuccessful HTTP response.
+func newResponseError(resp *http.Response) error {
+	err := httpx.NewClientError(resp)
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return &Error{Kind: ErrNotFound, Err: err}
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return &Error{Kind: ErrRateLimited, Err: err}
+	case resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0":
+		return &Error{Kind: ErrRateLimited, Err: err}
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return &Error{Kind: ErrUnauthorized, Err: err}
+	default:
+		return err
+	}
+}
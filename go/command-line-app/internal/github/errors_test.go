@@ -0,0 +1,147 @@
+package github
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutError struct{}
+
+func (e *timeoutError) Error() string   { return "i/o timeout" }
+func (e *timeoutError) Timeout() bool   { return true }
+func (e *timeoutError) Temporary() bool { return true }
+
+func TestError(t *testing.T) {
+	cause := errors.New("GET /users/octocat 404: Not Found")
+	err := &Error{Kind: ErrNotFound, Err: cause}
+
+	assert.EqualError(t, err, "not found: GET /users/octocat 404: Not Found")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	assert.False(t, errors.Is(err, ErrNetwork))
+	assert.True(t, errors.Is(err, cause))
+	assert.Equal(t, cause, errors.Unwrap(err))
+}
+
+func TestNewRequestError(t *testing.T) {
+	expiredCtx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name         string
+		ctx          context.Context
+		err          error
+		expectedKind error
+	}{
+		{
+			name:         "Network",
+			ctx:          context.Background(),
+			err:          errors.New("connection refused"),
+			expectedKind: ErrNetwork,
+		},
+		{
+			name:         "NetTimeout",
+			ctx:          context.Background(),
+			err:          &timeoutError{},
+			expectedKind: ErrTimeout,
+		},
+		{
+			name:         "DeadlineExceeded",
+			ctx:          expiredCtx,
+			err:          context.DeadlineExceeded,
+			expectedKind: ErrTimeout,
+		},
+		{
+			name:         "Canceled",
+			ctx:          cancelledCtx,
+			err:          context.Canceled,
+			expectedKind: context.Canceled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := newRequestError(tc.ctx, tc.err)
+
+			assert.True(t, errors.Is(err, tc.expectedKind))
+			assert.True(t, errors.Is(err, tc.err))
+		})
+	}
+}
+
+func TestNewResponseError(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat", nil)
+
+	tests := []struct {
+		name          string
+		statusCode    int
+		header        http.Header
+		expectedKind  error
+		expectedError string
+	}{
+		{
+			name:          "BadRequest",
+			statusCode:    400,
+			expectedKind:  nil,
+			expectedError: "GET /users/octocat 400: error",
+		},
+		{
+			name:          "Unauthorized",
+			statusCode:    401,
+			expectedKind:  ErrUnauthorized,
+			expectedError: "unauthorized: GET /users/octocat 401: error",
+		},
+		{
+			name:          "Forbidden",
+			statusCode:    403,
+			expectedKind:  ErrUnauthorized,
+			expectedError: "unauthorized: GET /users/octocat 403: error",
+		},
+		{
+			name:          "RateLimitExceeded",
+			statusCode:    403,
+			header:        http.Header{"X-Ratelimit-Remaining": []string{"0"}},
+			expectedKind:  ErrRateLimited,
+			expectedError: "rate limited: GET /users/octocat 403: error",
+		},
+		{
+			name:          "TooManyRequests",
+			statusCode:    429,
+			expectedKind:  ErrRateLimited,
+			expectedError: "rate limited: GET /users/octocat 429: error",
+		},
+		{
+			name:          "NotFound",
+			statusCode:    404,
+			expectedKind:  ErrNotFound,
+			expectedError: "not found: GET /users/octocat 404: error",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{
+				Request:    req,
+				StatusCode: tc.statusCode,
+				Header:     tc.header,
+				Body:       io.NopCloser(strings.NewReader(`{ "message": "error" }`)),
+			}
+
+			err := newResponseError(resp)
+
+			assert.EqualError(t, err, tc.expectedError)
+			if tc.expectedKind != nil {
+				assert.True(t, errors.Is(err, tc.expectedKind))
+			}
+		})
+	}
+}
@@ -4,44 +4,139 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"net/http"
+	"strings"
 	"time"
-
-	"github.com/gardenbed/basil/httpx"
 )
 
+// DefaultBaseURL is the base URL of the public GitHub REST API.
+const DefaultBaseURL = "https://api.github.com"
+
+const perPage = 100
+
 type httpClient interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
 // Service is used for calling an external service.
 type Service struct {
-	client httpClient
+	client  httpClient
+	baseURL string
 }
 
 // NewService creates a new service.
-func NewService() (*Service, error) {
+// baseURL is the base URL of the GitHub REST API (DefaultBaseURL if empty).
+// timeout is the maximum duration of each request to the GitHub API.
+// transport is used for sending the requests to the GitHub API (a new http.Transport if nil).
+func NewService(baseURL string, timeout time.Duration, transport http.RoundTripper) (*Service, error) {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+
+	if transport == nil {
+		transport = &http.Transport{}
+	}
+
 	client := &http.Client{
-		Timeout:   10 * time.Second,
-		Transport: &http.Transport{},
+		Timeout:   timeout,
+		Transport: transport,
 	}
 
 	return &Service{
-		client: client,
+		client:  client,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
 	}, nil
 }
 
 // User is the model for a GitHub user.
 type User struct {
-	ID    int    `json:"id"`
-	Login string `json:"login"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID          int    `json:"id"`
+	Login       string `json:"login"`
+	Email       string `json:"email"`
+	Name        string `json:"name"`
+	Company     string `json:"company"`
+	Location    string `json:"location"`
+	Bio         string `json:"bio"`
+	PublicRepos int    `json:"public_repos"`
+	Followers   int    `json:"followers"`
+	Following   int    `json:"following"`
+}
+
+// Repository is the model for a GitHub repository.
+type Repository struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	Language    string `json:"language"`
+	Stars       int    `json:"stargazers_count"`
+	Fork        bool   `json:"fork"`
+}
+
+// Organization is the model for a GitHub organization.
+type Organization struct {
+	ID          int    `json:"id"`
+	Login       string `json:"login"`
+	Description string `json:"description"`
 }
 
 // GetUser retrieves a GitHub user by username.
 func (s *Service) GetUser(ctx context.Context, username string) (*User, error) {
-	url := fmt.Sprintf("https://api.github.com/users/%s", username)
+	url := fmt.Sprintf("%s/users/%s", s.baseURL, username)
+
+	user := new(User)
+	if _, err := s.get(ctx, url, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListRepos returns an iterator over the public repositories of a GitHub user.
+func (s *Service) ListRepos(ctx context.Context, username string) iter.Seq2[*Repository, error] {
+	url := fmt.Sprintf("%s/users/%s/repos?per_page=%d", s.baseURL, username, perPage)
+	return list[Repository](ctx, s, url)
+}
+
+// ListFollowers returns an iterator over the followers of a GitHub user.
+func (s *Service) ListFollowers(ctx context.Context, username string) iter.Seq2[*User, error] {
+	url := fmt.Sprintf("%s/users/%s/followers?per_page=%d", s.baseURL, username, perPage)
+	return list[User](ctx, s, url)
+}
+
+// ListOrgs returns an iterator over the public organization memberships of a GitHub user.
+func (s *Service) ListOrgs(ctx context.Context, username string) iter.Seq2[*Organization, error] {
+	url := fmt.Sprintf("%s/users/%s/orgs?per_page=%d", s.baseURL, username, perPage)
+	return list[Organization](ctx, s, url)
+}
+
+// list returns an iterator over the items of a paginated GitHub API endpoint.
+// Pages are fetched lazily by following the next links in Link headers, so only one page is held in memory at a time.
+// If a page cannot be fetched, the error is yielded and the iteration stops.
+func list[T any](ctx context.Context, s *Service, url string) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		for next := url; next != ""; {
+			var page []*T
+			var err error
+
+			if next, err = s.get(ctx, next, &page); err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// get sends a GET request to the GitHub API and decodes the response body into v.
+// It returns the URL of the next page if the response is paginated.
+func (s *Service) get(ctx context.Context, url string, v any) (string, error) {
 	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Set("User-Agent", "command-line-app")
@@ -50,7 +145,7 @@ func (s *Service) GetUser(ctx context.Context, username string) (*User, error) {
 
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return "", newRequestError(ctx, err)
 	}
 
 	defer func() {
@@ -58,13 +153,31 @@ func (s *Service) GetUser(ctx context.Context, username string) (*User, error) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, httpx.NewClientError(resp)
+		return "", newResponseError(resp)
 	}
 
-	user := new(User)
-	if err := json.NewDecoder(resp.Body).Decode(user); err != nil {
-		return nil, err
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return "", err
 	}
 
-	return user, nil
+	return nextPage(resp.Header.Get("Link")), nil
+}
+
+// nextPage returns the URL of the next page from a Link header.
+// See https://docs.github.com/en/rest/using-the-rest-api/using-pagination-in-the-rest-api
+func nextPage(link string) string {
+	for _, part := range strings.Split(link, ",") {
+		segments := strings.Split(part, ";")
+		if len(segments) < 2 {
+			continue
+		}
+
+		for _, param := range segments[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return strings.Trim(strings.TrimSpace(segments[0]), "<>")
+			}
+		}
+	}
+
+	return ""
 }
@@ -7,8 +7,11 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"command-line-app/internal/fakegithub"
 )
 
 func TestNewService(t *testing.T) {
@@ -24,7 +27,7 @@ func TestNewService(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			s, err := NewService()
+			s, err := NewService("", 10*time.Second, nil)
 
 			if tc.expectedError == "" {
 				assert.NotNil(t, s)
@@ -57,7 +60,26 @@ func TestService_GetUser(t *testing.T) {
 			},
 			ctx:           context.Background(),
 			username:      "octocat",
-			expectedError: "http error",
+			expectedError: "network error: http error",
+		},
+		{
+			name: "UserNotFound",
+			client: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{
+						OutResponse: &http.Response{
+							Request:    req,
+							StatusCode: 404,
+							Body: io.NopCloser(
+								strings.NewReader(`{ "message": "Not Found" }`),
+							),
+						},
+					},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "octocat",
+			expectedError: "not found: GET /users/octocat 404: Not Found",
 		},
 		{
 			name: "UnexpectedStatusCode",
@@ -126,7 +148,8 @@ func TestService_GetUser(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			s := &Service{
-				client: tc.client,
+				client:  tc.client,
+				baseURL: DefaultBaseURL,
 			}
 
 			user, err := s.GetUser(tc.ctx, tc.username)
@@ -141,3 +164,275 @@ func TestService_GetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestService_ListRepos(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat/repos", nil)
+
+	page1 := &http.Response{
+		Request:    req,
+		StatusCode: 200,
+		Header: http.Header{
+			"Link": []string{`<https://api.github.com/user/1/repos?page=2>; rel="next", <https://api.github.com/user/1/repos?page=2>; rel="last"`},
+		},
+	}
+
+	page2 := &http.Response{
+		Request:    req,
+		StatusCode: 200,
+	}
+
+	tests := []struct {
+		name          string
+		client        *MockHTTPClient
+		limit         int
+		expectedRepos []string
+		expectedURLs  []string
+		expectedError string
+	}{
+		{
+			name: "FirstPageFails",
+			client: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{OutError: errors.New("http error")},
+				},
+			},
+			limit:         -1,
+			expectedRepos: nil,
+			expectedURLs:  []string{"https://api.github.com/users/octocat/repos?per_page=100"},
+			expectedError: "network error: http error",
+		},
+		{
+			name: "AllPages",
+			client: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{OutResponse: page1},
+					{OutResponse: page2},
+				},
+			},
+			limit:         -1,
+			expectedRepos: []string{"octocat/Hello-World", "octocat/Spoon-Knife", "octocat/linguist"},
+			expectedURLs: []string{
+				"https://api.github.com/users/octocat/repos?per_page=100",
+				"https://api.github.com/user/1/repos?page=2",
+			},
+		},
+		{
+			name: "StopEarly",
+			client: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{OutResponse: page1},
+				},
+			},
+			limit:         1,
+			expectedRepos: []string{"octocat/Hello-World"},
+			expectedURLs:  []string{"https://api.github.com/users/octocat/repos?per_page=100"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// Response bodies can be read only once
+			page1.Body = io.NopCloser(strings.NewReader(`[ { "id": 1, "name": "Hello-World", "full_name": "octocat/Hello-World" }, { "id": 2, "name": "Spoon-Knife", "full_name": "octocat/Spoon-Knife" } ]`))
+			page2.Body = io.NopCloser(strings.NewReader(`[ { "id": 3, "name": "linguist", "full_name": "octocat/linguist" } ]`))
+
+			s := &Service{
+				client:  tc.client,
+				baseURL: DefaultBaseURL,
+			}
+
+			var repos []string
+			var err error
+
+			for repo, e := range s.ListRepos(context.Background(), "octocat") {
+				if err = e; err != nil {
+					break
+				}
+
+				if repos = append(repos, repo.FullName); len(repos) == tc.limit {
+					break
+				}
+			}
+
+			var urls []string
+			for _, m := range tc.client.DoMocks {
+				urls = append(urls, m.InRequest.URL.String())
+			}
+
+			assert.Equal(t, tc.expectedRepos, repos)
+			assert.Equal(t, tc.expectedURLs, urls)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestService_ListFollowers(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat/followers", nil)
+
+	s := &Service{
+		baseURL: DefaultBaseURL,
+		client: &MockHTTPClient{
+			DoMocks: []DoMock{
+				{
+					OutResponse: &http.Response{
+						Request:    req,
+						StatusCode: 200,
+						Body:       io.NopCloser(strings.NewReader(`[ { "id": 2, "login": "hubot" } ]`)),
+					},
+				},
+			},
+		},
+	}
+
+	var followers []*User
+	for user, err := range s.ListFollowers(context.Background(), "octocat") {
+		assert.NoError(t, err)
+		followers = append(followers, user)
+	}
+
+	assert.Equal(t, []*User{{ID: 2, Login: "hubot"}}, followers)
+}
+
+func TestService_ListOrgs(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat/orgs", nil)
+
+	s := &Service{
+		baseURL: DefaultBaseURL,
+		client: &MockHTTPClient{
+			DoMocks: []DoMock{
+				{
+					OutResponse: &http.Response{
+						Request:    req,
+						StatusCode: 404,
+						Body:       io.NopCloser(strings.NewReader(`{ "message": "Not Found" }`)),
+					},
+				},
+			},
+		},
+	}
+
+	var orgs []*Organization
+	var err error
+	for org, e := range s.ListOrgs(context.Background(), "octocat") {
+		if err = e; err != nil {
+			break
+		}
+		orgs = append(orgs, org)
+	}
+
+	assert.Empty(t, orgs)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestNextPage(t *testing.T) {
+	tests := []struct {
+		name         string
+		link         string
+		expectedNext string
+	}{
+		{
+			name:         "Empty",
+			link:         "",
+			expectedNext: "",
+		},
+		{
+			name:         "LastPage",
+			link:         `<https://api.github.com/user/1/repos?page=1>; rel="prev", <https://api.github.com/user/1/repos?page=1>; rel="first"`,
+			expectedNext: "",
+		},
+		{
+			name:         "NextPage",
+			link:         `<https://api.github.com/user/1/repos?page=3>; rel="next", <https://api.github.com/user/1/repos?page=5>; rel="last"`,
+			expectedNext: "https://api.github.com/user/1/repos?page=3",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedNext, nextPage(tc.link))
+		})
+	}
+}
+
+func TestService_FakeGitHub(t *testing.T) {
+	octocat := &User{
+		ID:          583231,
+		Login:       "octocat",
+		Name:        "The Octocat",
+		Company:     "@github",
+		Location:    "San Francisco",
+		PublicRepos: 8,
+		Followers:   3,
+		Following:   9,
+	}
+
+	tests := []struct {
+		name          string
+		opts          []fakegithub.Option
+		username      string
+		expectedUser  *User
+		expectedRepos int
+		expectedError string
+	}{
+		{
+			name:          "UserNotFound",
+			username:      "ghost",
+			expectedError: "not found: GET /users/ghost 404: Not Found",
+		},
+		{
+			name:          "RateLimited",
+			opts:          []fakegithub.Option{fakegithub.WithRateLimit(1)},
+			username:      "octocat",
+			expectedUser:  octocat,
+			expectedError: "rate limited: GET /users/octocat/repos 403: API rate limit exceeded.",
+		},
+		{
+			name:          "ServerError",
+			opts:          []fakegithub.Option{fakegithub.WithError("/users/*/repos", 500)},
+			username:      "octocat",
+			expectedUser:  octocat,
+			expectedError: "GET /users/octocat/repos 500: Internal Server Error",
+		},
+		{
+			name:          "Success",
+			username:      "octocat",
+			expectedUser:  octocat,
+			expectedRepos: 8,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(tc.opts...)
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			s, err := NewService(fake.URL, time.Second, nil)
+			assert.NoError(t, err)
+
+			user, err := s.GetUser(context.Background(), tc.username)
+			if err == nil {
+				assert.Equal(t, tc.expectedUser, user)
+
+				var repos int
+				for _, err = range s.ListRepos(context.Background(), tc.username) {
+					if err != nil {
+						break
+					}
+					repos++
+				}
+				assert.Equal(t, tc.expectedRepos, repos)
+			}
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
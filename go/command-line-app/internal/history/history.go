@@ -0,0 +1,81 @@
+// Package history keeps track of the recently used GitHub usernames across command executions.
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// MaxEntries is the maximum number of recently used usernames kept in a history.
+const MaxEntries = 10
+
+// History is a list of recently used GitHub usernames persisted in a file.
+type History struct {
+	path string
+}
+
+// New creates a new history persisted in the given file.
+// If path is empty, nothing is persisted.
+func New(path string) *History {
+	return &History{
+		path: path,
+	}
+}
+
+// DefaultPath returns the default path of the history file in the user configuration directory.
+// If the user configuration directory cannot be determined, an empty path is returned.
+func DefaultPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, "command-line-app", "history")
+}
+
+// Recent returns the recently used usernames from the most recent to the least recent.
+// A missing or unreadable history file is treated as an empty history.
+func (h *History) Recent() []string {
+	if h.path == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(h.path)
+	if err != nil {
+		return nil
+	}
+
+	var usernames []string
+	for _, line := range strings.Split(string(b), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			usernames = append(usernames, line)
+		}
+	}
+
+	return usernames
+}
+
+// Add moves a username to the top of the history and persists the history.
+// The least recent usernames are dropped if the history has more than MaxEntries usernames.
+func (h *History) Add(username string) error {
+	if h.path == "" || username == "" {
+		return nil
+	}
+
+	usernames := slices.DeleteFunc(h.Recent(), func(u string) bool {
+		return strings.EqualFold(u, username)
+	})
+
+	usernames = append([]string{username}, usernames...)
+	if len(usernames) > MaxEntries {
+		usernames = usernames[:MaxEntries]
+	}
+
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(h.path, []byte(strings.Join(usernames, "\n")+"\n"), 0o600)
+}
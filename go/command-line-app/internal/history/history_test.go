@@ -0,0 +1,118 @@
+package history
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	h := New("history")
+
+	assert.NotNil(t, h)
+	assert.Equal(t, "history", h.path)
+}
+
+func TestDefaultPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/config")
+	t.Setenv("HOME", "/tmp/home")
+
+	path := DefaultPath()
+
+	assert.Contains(t, path, filepath.Join("command-line-app", "history"))
+}
+
+func TestHistory_Recent(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "history")
+	assert.NoError(t, os.WriteFile(path, []byte("octocat\n\n  hubot \n"), 0o600))
+
+	tests := []struct {
+		name              string
+		path              string
+		expectedUsernames []string
+	}{
+		{
+			name:              "NoPath",
+			path:              "",
+			expectedUsernames: nil,
+		},
+		{
+			name:              "NoFile",
+			path:              filepath.Join(dir, "missing"),
+			expectedUsernames: nil,
+		},
+		{
+			name:              "OK",
+			path:              path,
+			expectedUsernames: []string{"octocat", "hubot"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(tc.path)
+			usernames := h.Recent()
+
+			assert.Equal(t, tc.expectedUsernames, usernames)
+		})
+	}
+}
+
+func TestHistory_Add(t *testing.T) {
+	var many []string
+	for i := range MaxEntries {
+		many = append(many, fmt.Sprintf("user%d", i))
+	}
+
+	tests := []struct {
+		name              string
+		existing          []string
+		username          string
+		expectedUsernames []string
+	}{
+		{
+			name:              "Empty",
+			existing:          nil,
+			username:          "octocat",
+			expectedUsernames: []string{"octocat"},
+		},
+		{
+			name:              "Existing",
+			existing:          []string{"hubot", "OctoCat", "monalisa"},
+			username:          "octocat",
+			expectedUsernames: []string{"octocat", "hubot", "monalisa"},
+		},
+		{
+			name:              "Full",
+			existing:          many,
+			username:          "octocat",
+			expectedUsernames: append([]string{"octocat"}, many[:MaxEntries-1]...),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := New(filepath.Join(t.TempDir(), "config", "history"))
+			for i := len(tc.existing) - 1; i >= 0; i-- {
+				assert.NoError(t, h.Add(tc.existing[i]))
+			}
+
+			err := h.Add(tc.username)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedUsernames, h.Recent())
+		})
+	}
+
+	t.Run("NoPath", func(t *testing.T) {
+		h := New("")
+		err := h.Add("octocat")
+
+		assert.NoError(t, err)
+		assert.Nil(t, h.Recent())
+	})
+}
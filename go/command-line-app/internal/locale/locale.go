@@ -0,0 +1,171 @@
+// Package locale provides a catalog of greeting templates for different languages.
+package locale
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// DefaultLanguage is the language used when none of the requested languages are available.
+const DefaultLanguage = "en"
+
+const templateExt = ".tmpl"
+
+//go:embed templates/*.tmpl
+var embedded embed.FS
+
+// User is the data available to greeting templates.
+type User struct {
+	Login    string
+	Name     string
+	Company  string
+	Location string
+}
+
+// Catalog is a collection of greeting templates indexed by language.
+type Catalog struct {
+	templates map[string]*template.Template
+}
+
+// NewCatalog creates a new catalog from the embedded templates.
+// If dir is not empty, the templates in dir (named <language>.tmpl) are loaded too,
+// adding new languages or overriding the embedded templates for the same languages.
+func NewCatalog(dir string) (*Catalog, error) {
+	c := &Catalog{
+		templates: map[string]*template.Template{},
+	}
+
+	sub, _ := fs.Sub(embedded, "templates")
+	if err := c.load(sub); err != nil {
+		return nil, err
+	}
+
+	if dir != "" {
+		if err := c.load(os.DirFS(dir)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := c.templates[DefaultLanguage]; !ok {
+		return nil, fmt.Errorf("no template for default language %q", DefaultLanguage)
+	}
+
+	return c, nil
+}
+
+func (c *Catalog) load(fsys fs.FS) error {
+	files, err := fs.Glob(fsys, "*"+templateExt)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		b, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+
+		lang := strings.ToLower(strings.TrimSuffix(path.Base(file), templateExt))
+		tmpl, err := template.New(lang).Option("missingkey=error").Parse(string(b))
+		if err != nil {
+			return err
+		}
+
+		c.templates[lang] = tmpl
+	}
+
+	return nil
+}
+
+// Languages returns the sorted list of languages available in the catalog.
+func (c *Catalog) Languages() []string {
+	langs := make([]string, 0, len(c.templates))
+	for lang := range c.templates {
+		langs = append(langs, lang)
+	}
+
+	sort.Strings(langs)
+
+	return langs
+}
+
+// Match returns the available language that best matches the given preference.
+// The preference can be a single language tag (i.e. fr-CA) or an Accept-Language header value (i.e. fr-CA,fr;q=0.9,en;q=0.8).
+// If no language matches, the default language is returned.
+func (c *Catalog) Match(preference string) string {
+	for _, tag := range parsePreference(preference) {
+		if _, ok := c.templates[tag]; ok {
+			return tag
+		}
+
+		if base, _, found := strings.Cut(tag, "-"); found {
+			if _, ok := c.templates[base]; ok {
+				return base
+			}
+		}
+	}
+
+	return DefaultLanguage
+}
+
+// Greet renders a greeting for a user in the language that best matches the given preference.
+// If the user has no name, the login is used as the name.
+func (c *Catalog) Greet(preference string, user User) (string, error) {
+	if user.Name == "" {
+		user.Name = user.Login
+	}
+
+	var b strings.Builder
+	if err := c.templates[c.Match(preference)].Execute(&b, user); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// parsePreference parses a language preference into a list of lower-cased language tags ordered by quality.
+func parsePreference(preference string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var prefs []weighted
+	for _, part := range strings.Split(preference, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag, _, _ = strings.Cut(tag, ".") // i.e. en_US.UTF-8
+		tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+
+		if q > 0 {
+			prefs = append(prefs, weighted{tag, q})
+		}
+	}
+
+	sort.SliceStable(prefs, func(i, j int) bool {
+		return prefs[i].q > prefs[j].q
+	})
+
+	tags := make([]string, len(prefs))
+	for i, p := range prefs {
+		tags[i] = p.tag
+	}
+
+	return tags
+}
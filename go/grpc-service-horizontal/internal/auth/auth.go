@@ -0,0 +1,158 @@
+// Package auth implements pluggable authentication and authorization of API clients.
+//
+// Clients are authenticated by an Authenticator from the credentials of their requests,
+// such as static API keys or JWT bearer tokens, and the authenticated principal is stored in the request context.
+// Principals are authorized by the scopes granted to them.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// ErrNoCredentials is returned by an authenticator when a request has no credentials supported by the authenticator.
+	ErrNoCredentials = errors.New("no credentials")
+	// ErrUnauthenticated is returned when a request cannot be authenticated.
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied is returned when a principal is not authorized for a request.
+	ErrPermissionDenied = errors.New("permission denied")
+)
+
+const (
+	// MethodAPIKey is the authentication method for static API keys.
+	MethodAPIKey = "apikey"
+	// MethodJWT is the authentication method for JWT bearer tokens.
+	MethodJWT = "jwt"
+
+	// AllScopes is the scope granting all other scopes.
+	AllScopes = "*"
+)
+
+// Credentials are the credentials presented by a client.
+type Credentials struct {
+	// APIKey is a static API key.
+	APIKey string
+	// BearerToken is a bearer token from the Authorization header.
+	BearerToken string
+}
+
+// Principal is an authenticated client.
+type Principal struct {
+	// Subject identifies the client.
+	Subject string
+	// Method is the method used for authenticating the client.
+	Method string
+	// Scopes are the scopes granted to the client.
+	Scopes []string
+}
+
+// String implements the fmt.Stringer interface.
+func (p *Principal) String() string {
+	return fmt.Sprintf("Principal{subject=%s method=%s scopes=%s}", p.Subject, p.Method, strings.Join(p.Scopes, " "))
+}
+
+// HasScope determines whether a scope is granted to the principal.
+// An empty scope is granted to every principal.
+func (p *Principal) HasScope(scope string) bool {
+	return scope == "" || slices.Contains(p.Scopes, AllScopes) || slices.Contains(p.Scopes, scope)
+}
+
+type contextKey struct{}
+
+// NewContext returns a new context carrying a principal.
+func NewContext(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the principal stored in a context if any.
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(*Principal)
+	return p, ok
+}
+
+// Authenticator authenticates clients by their credentials.
+type Authenticator interface {
+	// Authenticate returns the principal for the credentials.
+	// ErrNoCredentials is returned if the credentials are not supported by the authenticator.
+	Authenticate(ctx context.Context, creds Credentials) (*Principal, error)
+}
+
+// Chain is an authenticator trying a list of authenticators in order.
+// The first authenticator supporting the credentials decides the outcome.
+type Chain []Authenticator
+
+// Authenticate implements the Authenticator interface.
+func (c Chain) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	for _, a := range c {
+		p, err := a.Authenticate(ctx, creds)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return p, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+// APIKeys is an authenticator for static API keys.
+// Clients authenticated by API keys are trusted and granted all scopes.
+type APIKeys struct {
+	// subjects are keyed by the hashes of API keys, so keys are not compared in variable time.
+	subjects map[[sha256.Size]byte]string
+}
+
+// NewAPIKeys creates a new authenticator for static API keys from a map of subjects to API keys.
+func NewAPIKeys(keys map[string]string) *APIKeys {
+	subjects := make(map[[sha256.Size]byte]string, len(keys))
+	for subject, key := range keys {
+		subjects[sha256.Sum256([]byte(key))] = subject
+	}
+
+	return &APIKeys{
+		subjects: subjects,
+	}
+}
+
+// ParseAPIKeys parses a comma-separated list of subject=key pairs.
+func ParseAPIKeys(s string) (map[string]string, error) {
+	keys := map[string]string{}
+
+	for i, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		// The pair is not included in the error, so keys are never logged
+		subject, key, ok := strings.Cut(pair, "=")
+		if !ok || subject == "" || key == "" {
+			return nil, fmt.Errorf("invalid api key #%d: expected subject=key", i+1)
+		}
+
+		keys[subject] = key
+	}
+
+	return keys, nil
+}
+
+// Authenticate implements the Authenticator interface.
+func (a *APIKeys) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.APIKey == "" {
+		return nil, ErrNoCredentials
+	}
+
+	subject, ok := a.subjects[sha256.Sum256([]byte(creds.APIKey))]
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid api key", ErrUnauthenticated)
+	}
+
+	return &Principal{
+		Subject: subject,
+		Method:  MethodAPIKey,
+		Scopes:  []string{AllScopes},
+	}, nil
+}
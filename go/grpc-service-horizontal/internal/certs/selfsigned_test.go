@@ -0,0 +1,58 @@
+package certs
+
+import (
+	"crypto/x509"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSelfSigned(t *testing.T) {
+	tests := []struct {
+		name                string
+		hosts               []string
+		expectedDNSNames    []string
+		expectedIPAddresses []net.IP
+	}{
+		{
+			name:                "Default",
+			hosts:               nil,
+			expectedDNSNames:    []string{"localhost"},
+			expectedIPAddresses: []net.IP{net.IPv4(127, 0, 0, 1).To4(), net.IPv6loopback},
+		},
+		{
+			name:                "WithHosts",
+			hosts:               []string{"greeting.local", "10.0.0.1"},
+			expectedDNSNames:    []string{"localhost", "greeting.local"},
+			expectedIPAddresses: []net.IP{net.IPv4(127, 0, 0, 1).To4(), net.IPv6loopback, net.IPv4(10, 0, 0, 1).To4()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cert, err := NewSelfSigned(tc.hosts...)
+			assert.NoError(t, err)
+
+			assert.Len(t, cert.Certificate, 1)
+			assert.NotNil(t, cert.PrivateKey)
+			assert.Equal(t, tc.expectedDNSNames, cert.Leaf.DNSNames)
+			assert.Equal(t, tc.expectedIPAddresses, cert.Leaf.IPAddresses)
+
+			// The certificate is trusted as its own certificate authority for both server and client identities
+			roots := x509.NewCertPool()
+			roots.AddCert(cert.Leaf)
+
+			for _, host := range tc.expectedDNSNames {
+				_, err = cert.Leaf.Verify(x509.VerifyOptions{
+					DNSName:     host,
+					Roots:       roots,
+					CurrentTime: time.Now(),
+					KeyUsages:   []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+				})
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
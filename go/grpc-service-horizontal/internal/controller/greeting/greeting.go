@@ -2,59 +2,230 @@ package greeting
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 
+	"github.com/gardenbed/basil/telemetry"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+
+	"grpc-service-horizontal/internal/breaker"
 	"grpc-service-horizontal/internal/entity"
+	githubentity "grpc-service-horizontal/internal/entity/github"
 	"grpc-service-horizontal/internal/gateway/github"
+	"grpc-service-horizontal/internal/locale"
 	"grpc-service-horizontal/internal/repository/usercache"
 )
 
+// DefaultMaxConcurrentLookups is the default maximum number of concurrent GitHub user lookups for greeting many users.
+const DefaultMaxConcurrentLookups = 8
+
 // Controller is the interface for greeting business logic.
 type Controller interface {
 	Greet(context.Context, *entity.GreetRequest) (*entity.GreetResponse, error)
+	GreetMany(context.Context, *entity.GreetManyRequest, func(*entity.GreetResult) error) error
+}
+
+// Options are optional configurations for creating a new controller.
+type Options struct {
+	// MaxConcurrentLookups is the maximum number of concurrent GitHub user lookups for greeting many users (DefaultMaxConcurrentLookups if zero).
+	MaxConcurrentLookups int
+	// Meter is used for creating the controller metrics (the meter of the global telemetry probe if nil).
+	Meter metric.Meter
 }
 
 // controller implements the Controller interface.
 type controller struct {
 	githubGateway       github.Gateway
 	usercacheRepository usercache.Repository
+	catalog             *locale.Catalog
+	maxLookups          int
+	lookups             singleflight.Group
+	coalesced           metric.Int64Counter
 }
 
 // NewController creates a new controller.
-func NewController(githubGateway github.Gateway, usercacheRepository usercache.Repository) (Controller, error) {
+func NewController(githubGateway github.Gateway, usercacheRepository usercache.Repository, catalog *locale.Catalog, opts Options) (Controller, error) {
+	if opts.MaxConcurrentLookups == 0 {
+		opts.MaxConcurrentLookups = DefaultMaxConcurrentLookups
+	}
+
+	if opts.Meter == nil {
+		opts.Meter = telemetry.Get().Meter()
+	}
+
+	coalesced, err := opts.Meter.Int64Counter(
+		"coalesced_github_user_lookups_total",
+		metric.WithDescription("The total number of GitHub user lookups served by an in-flight lookup for the same user"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &controller{
 		githubGateway:       githubGateway,
 		usercacheRepository: usercacheRepository,
+		catalog:             catalog,
+		maxLookups:          opts.MaxConcurrentLookups,
+		coalesced:           coalesced,
 	}, nil
 }
 
-// Greet creates a greeting for a given GitHub user!
+// Greet creates a greeting for a given GitHub user in the requested language!
 func (c *controller) Greet(ctx context.Context, req *entity.GreetRequest) (*entity.GreetResponse, error) {
-	name, err := c.getName(ctx, req.GithubUsername)
+	user, stale, err := c.getUser(ctx, req.GithubUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	greeting, err := c.catalog.Greet(req.Language, locale.User{
+		Login:    user.Login,
+		Name:     user.Name,
+		Company:  user.Company,
+		Location: user.Location,
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	greeting := fmt.Sprintf("Hello, %s!", name)
 	resp := &entity.GreetResponse{
 		Greeting: greeting,
+		Stale:    stale,
 	}
 
 	return resp, nil
 }
 
-func (c *controller) getName(ctx context.Context, username string) (string, error) {
-	name, err := c.usercacheRepository.Lookup(ctx, username)
-	if err == nil && name != "" {
-		return name, nil
+// GreetMany creates greetings for many GitHub users in the requested language and sends them one at a time.
+// Users are looked up concurrently, up to the maximum number of concurrent lookups,
+// and a greeting is sent as soon as its lookup completes.
+// Lookups wait while a greeting is being sent, so they are paced by the receiver (flow control).
+// A failure to greet a user is sent as the error of its result, but a failure to send a result is returned,
+// and all lookups are cancelled when it happens or when the context is done.
+func (c *controller) GreetMany(ctx context.Context, req *entity.GreetManyRequest, send func(*entity.GreetResult) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *entity.GreetResult)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(c.maxLookups)
+
+	go func() {
+		defer close(results)
+
+		for _, username := range req.GithubUsernames {
+			if gctx.Err() != nil {
+				break
+			}
+
+			g.Go(func() error {
+				select {
+				case results <- c.greetResult(gctx, username, req.Language):
+					return nil
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			})
+		}
+
+		_ = g.Wait()
+	}()
+
+	for res := range results {
+		// The pending lookups are cancelled and the results channel is closed once they return
+		if err := send(res); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
+}
+
+// greetResult creates a greeting result for a GitHub user.
+func (c *controller) greetResult(ctx context.Context, username, language string) *entity.GreetResult {
+	res := &entity.GreetResult{
+		GithubUsername: username,
+	}
+
+	resp, err := c.Greet(ctx, &entity.GreetRequest{
+		GithubUsername: username,
+		Language:       language,
+	})
+
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	res.Greeting = resp.Greeting
+	res.Stale = resp.Stale
+
+	return res
+}
+
+// getUser retrieves a GitHub user, coalescing concurrent lookups of the same user into a single lookup.
+// The shared lookup is not cancelled when a caller's context is done, so the other callers are not affected,
+// but each caller stops waiting and returns as soon as its own context is done.
+// While the circuit breaker for the GitHub API is open, the user is served from the stale cache if available and reported as stale.
+func (c *controller) getUser(ctx context.Context, username string) (*githubentity.User, bool, error) {
+	// GitHub usernames are case-insensitive
+	key := strings.ToLower(username)
+
+	var leader bool
+	ch := c.lookups.DoChan(key, func() (any, error) {
+		leader = true
+		return c.lookupUser(context.WithoutCancel(ctx), username)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared && !leader {
+			c.coalesced.Add(ctx, 1)
+		}
+
+		if errors.Is(res.Err, breaker.ErrOpen) {
+			if user, err := c.usercacheRepository.LookupStale(ctx, username); err == nil {
+				return user, true, nil
+			}
+		}
+
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+
+		return res.Val.(*githubentity.User), false, nil
+
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// lookupUser retrieves a GitHub user using a write-through cache.
+// Both users and the absence of users are cached; cache failures only result in calling the GitHub API.
+func (c *controller) lookupUser(ctx context.Context, username string) (*githubentity.User, error) {
+	user, err := c.usercacheRepository.Lookup(ctx, username)
+	if err == nil && user != nil {
+		return user, nil
+	}
+
+	if errors.Is(err, githubentity.ErrUserNotFound) {
+		return nil, err
+	}
+
+	user, err = c.githubGateway.GetUser(ctx, username)
+	if errors.Is(err, githubentity.ErrUserNotFound) {
+		_ = c.usercacheRepository.StoreNotFound(ctx, username)
+		return nil, err
 	}
 
-	user, err := c.githubGateway.GetUser(ctx, username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	_ = c.usercacheRepository.Store(ctx, username, user.Name)
+	_ = c.usercacheRepository.Store(ctx, username, user)
 
-	return user.Name, nil
+	return user, nil
 }
@@ -3,12 +3,23 @@ package greeting
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/stretchr/testify/assert"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
+	"grpc-service-horizontal/internal/breaker"
 	"grpc-service-horizontal/internal/entity"
 	githubentity "grpc-service-horizontal/internal/entity/github"
+	"grpc-service-horizontal/internal/fakegithub"
+	"grpc-service-horizontal/internal/gateway/github"
+	"grpc-service-horizontal/internal/locale"
+	"grpc-service-horizontal/internal/repository/usercache"
 )
 
 func TestNewController(t *testing.T) {
@@ -16,19 +27,21 @@ func TestNewController(t *testing.T) {
 		name                string
 		githubGateway       *MockGithubGateway
 		usercacheRepository *MockUserCacheRepository
+		catalog             *locale.Catalog
 		expectedError       string
 	}{
 		{
 			name:                "OK",
 			githubGateway:       &MockGithubGateway{},
 			usercacheRepository: &MockUserCacheRepository{},
+			catalog:             &locale.Catalog{},
 			expectedError:       "",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			c, err := NewController(tc.githubGateway, tc.usercacheRepository)
+			c, err := NewController(tc.githubGateway, tc.usercacheRepository, tc.catalog, Options{})
 
 			if tc.expectedError == "" {
 				assert.NotNil(t, c)
@@ -42,6 +55,9 @@ func TestNewController(t *testing.T) {
 }
 
 func TestController_Greet(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
 	tests := []struct {
 		name                string
 		githubGateway       *MockGithubGateway
@@ -55,7 +71,7 @@ func TestController_Greet(t *testing.T) {
 			name: "Success_FromCache",
 			usercacheRepository: &MockUserCacheRepository{
 				LookupMocks: []LookupMock{
-					{OutName: "Octocat"},
+					{OutUser: &githubentity.User{Login: "octocat", Name: "Octocat"}},
 				},
 			},
 			ctx: context.Background(),
@@ -67,6 +83,89 @@ func TestController_Greet(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "UserNotFound_FromCache",
+			usercacheRepository: &MockUserCacheRepository{
+				LookupMocks: []LookupMock{
+					{OutError: fmt.Errorf("%w: ghost", githubentity.ErrUserNotFound)},
+				},
+			},
+			ctx: context.Background(),
+			request: &entity.GreetRequest{
+				GithubUsername: "ghost",
+			},
+			expectedResponse: nil,
+			expectedError:    "github user not found: ghost",
+		},
+		{
+			name: "UserNotFound_FromAPI",
+			githubGateway: &MockGithubGateway{
+				GetUserMocks: []GetUserMock{
+					{OutError: fmt.Errorf("%w: ghost", githubentity.ErrUserNotFound)},
+				},
+			},
+			usercacheRepository: &MockUserCacheRepository{
+				LookupMocks: []LookupMock{
+					{OutError: errors.New("not found")},
+				},
+				StoreNotFoundMocks: []StoreNotFoundMock{
+					{OutError: nil},
+				},
+			},
+			ctx: context.Background(),
+			request: &entity.GreetRequest{
+				GithubUsername: "ghost",
+			},
+			expectedResponse: nil,
+			expectedError:    "github user not found: ghost",
+		},
+		{
+			name: "CircuitOpen_NoStaleUser",
+			githubGateway: &MockGithubGateway{
+				GetUserMocks: []GetUserMock{
+					{OutError: fmt.Errorf("github error: %w", breaker.ErrOpen)},
+				},
+			},
+			usercacheRepository: &MockUserCacheRepository{
+				LookupMocks: []LookupMock{
+					{OutError: errors.New("not found")},
+				},
+				LookupStaleMocks: []LookupStaleMock{
+					{OutError: errors.New("not found")},
+				},
+			},
+			ctx: context.Background(),
+			request: &entity.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: nil,
+			expectedError:    "github error: circuit breaker is open",
+		},
+		{
+			name: "CircuitOpen_StaleUser",
+			githubGateway: &MockGithubGateway{
+				GetUserMocks: []GetUserMock{
+					{OutError: fmt.Errorf("github error: %w", breaker.ErrOpen)},
+				},
+			},
+			usercacheRepository: &MockUserCacheRepository{
+				LookupMocks: []LookupMock{
+					{OutError: errors.New("not found")},
+				},
+				LookupStaleMocks: []LookupStaleMock{
+					{OutUser: &githubentity.User{Login: "octocat", Name: "Octocat"}},
+				},
+			},
+			ctx: context.Background(),
+			request: &entity.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: &entity.GreetResponse{
+				Greeting: "Hello, Octocat!",
+				Stale:    true,
+			},
+			expectedError: "",
+		},
 		{
 			name: "GetUserFails",
 			githubGateway: &MockGithubGateway{
@@ -116,6 +215,36 @@ func TestController_Greet(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "Success_Localized",
+			githubGateway: &MockGithubGateway{
+				GetUserMocks: []GetUserMock{
+					{
+						OutUser: &githubentity.User{
+							ID:    1,
+							Login: "octocat",
+						},
+					},
+				},
+			},
+			usercacheRepository: &MockUserCacheRepository{
+				LookupMocks: []LookupMock{
+					{OutError: errors.New("not found")},
+				},
+				StoreMocks: []StoreMock{
+					{OutError: nil},
+				},
+			},
+			ctx: context.Background(),
+			request: &entity.GreetRequest{
+				GithubUsername: "octocat",
+				Language:       "de-AT,de;q=0.9",
+			},
+			expectedResponse: &entity.GreetResponse{
+				Greeting: "Hallo, octocat!",
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
@@ -123,6 +252,7 @@ func TestController_Greet(t *testing.T) {
 			c := &controller{
 				githubGateway:       tc.githubGateway,
 				usercacheRepository: tc.usercacheRepository,
+				catalog:             catalog,
 			}
 
 			response, err := c.Greet(tc.ctx, tc.request)
@@ -137,3 +267,179 @@ func TestController_Greet(t *testing.T) {
 		})
 	}
 }
+
+func TestController_GreetMany(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name              string
+		ctx               context.Context
+		request           *entity.GreetManyRequest
+		sendErr           error
+		expectedGreetings map[string]string
+		expectedNotFound  []string
+		expectedError     error
+	}{
+		{
+			name: "Success",
+			ctx:  context.Background(),
+			request: &entity.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "ghost", "hubot"},
+			},
+			expectedGreetings: map[string]string{
+				"octocat": "Hello, The Octocat!",
+				"ghost":   "",
+				"hubot":   "Hello, hubot!",
+			},
+			expectedNotFound: []string{"ghost"},
+		},
+		{
+			name: "NoUsernames",
+			ctx:  context.Background(),
+			request: &entity.GreetManyRequest{
+				GithubUsernames: nil,
+			},
+			expectedGreetings: map[string]string{},
+		},
+		{
+			name: "SendFails",
+			ctx:  context.Background(),
+			request: &entity.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+			},
+			sendErr:           errors.New("stream closed"),
+			expectedGreetings: map[string]string{},
+			expectedError:     errors.New("stream closed"),
+		},
+		{
+			name: "Cancelled",
+			ctx:  cancelledCtx,
+			request: &entity.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+			},
+			expectedGreetings: map[string]string{},
+			expectedError:     context.Canceled,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(fakegithub.WithLatency(10 * time.Millisecond))
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			githubGateway, err := github.NewGateway(github.Options{BaseURL: fake.URL})
+			assert.NoError(t, err)
+
+			mr := miniredis.RunT(t)
+			usercacheRepository, err := usercache.NewRepository(mr.Addr(), usercache.Options{})
+			assert.NoError(t, err)
+
+			c, err := NewController(githubGateway, usercacheRepository, catalog, Options{MaxConcurrentLookups: 2})
+			assert.NoError(t, err)
+
+			greetings := map[string]string{}
+			var notFound []string
+			err = c.GreetMany(tc.ctx, tc.request, func(res *entity.GreetResult) error {
+				if tc.sendErr != nil {
+					return tc.sendErr
+				}
+
+				greetings[res.GithubUsername] = res.Greeting
+				if errors.Is(res.Err, githubentity.ErrUserNotFound) {
+					notFound = append(notFound, res.GithubUsername)
+				}
+				return nil
+			})
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedGreetings, greetings)
+			assert.Equal(t, tc.expectedNotFound, notFound)
+		})
+	}
+}
+
+func TestController_getUser_Coalescing(t *testing.T) {
+	fake, err := fakegithub.NewServer(fakegithub.WithLatency(200 * time.Millisecond))
+	assert.NoError(t, err)
+	defer fake.Close()
+
+	githubGateway, err := github.NewGateway(github.Options{BaseURL: fake.URL})
+	assert.NoError(t, err)
+
+	// Only the shared lookup is expected to use the cache
+	usercacheRepository := &MockUserCacheRepository{
+		LookupMocks: []LookupMock{
+			{OutError: errors.New("not found")},
+		},
+		StoreMocks: []StoreMock{
+			{OutError: nil},
+		},
+	}
+
+	reader := metricsdk.NewManualReader()
+	meter := metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("test")
+
+	ctrl, err := NewController(githubGateway, usercacheRepository, nil, Options{Meter: meter})
+	assert.NoError(t, err)
+	c := ctrl.(*controller)
+
+	const callers = 10
+
+	// The last caller joins the in-flight lookup and gives up before the lookup completes
+	cancelledCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	users := make([]*githubentity.User, callers)
+	errs := make([]error, callers)
+
+	for i := range callers {
+		ctx := context.Background()
+		if i == callers-1 {
+			time.Sleep(10 * time.Millisecond)
+			ctx = cancelledCtx
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users[i], _, errs[i] = c.getUser(ctx, "OctoCat")
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range callers - 1 {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "octocat", users[i].Login)
+	}
+
+	assert.ErrorIs(t, errs[callers-1], context.DeadlineExceeded)
+	assert.Nil(t, users[callers-1])
+
+	assert.Equal(t, 1, fake.Requests())
+	assert.Equal(t, 1, usercacheRepository.LookupIndex)
+	assert.Equal(t, 1, usercacheRepository.StoreIndex)
+	assert.Equal(t, int64(callers-2), coalescedCount(t, reader))
+}
+
+// coalescedCount returns the value of the coalesced lookups counter.
+func coalescedCount(t *testing.T, reader metricsdk.Reader) int64 {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "coalesced_github_user_lookups_total" {
+				return sum.DataPoints[0].Value
+			}
+		}
+	}
+
+	return 0
+}
@@ -1,16 +1,24 @@
 package github
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUserNotFound is returned when a GitHub user does not exist.
+var ErrUserNotFound = errors.New("github user not found")
 
 // User is the entity for a GitHub user.
 type User struct {
-	ID    int    `json:"id"`
-	Login string `json:"login"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID       int    `json:"id"`
+	Login    string `json:"login"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Company  string `json:"company"`
+	Location string `json:"location"`
 }
 
 // String implements the fmt.Stringer interface.
 func (u *User) String() string {
-	return fmt.Sprintf("User{id=%d login=%s email=%s name=%s}", u.ID, u.Login, u.Email, u.Name)
+	return fmt.Sprintf("User{id=%d login=%s email=%s name=%s company=%s location=%s}", u.ID, u.Login, u.Email, u.Name, u.Company, u.Location)
 }
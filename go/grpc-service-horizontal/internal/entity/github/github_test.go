@@ -15,12 +15,14 @@ func TestUser(t *testing.T) {
 		{
 			name: "OK",
 			entity: User{
-				ID:    1,
-				Login: "octocat",
-				Email: "octocat@example.com",
-				Name:  "Octocat",
+				ID:       1,
+				Login:    "octocat",
+				Email:    "octocat@example.com",
+				Name:     "Octocat",
+				Company:  "GitHub",
+				Location: "San Francisco",
 			},
-			expectedString: "User{id=1 login=octocat email=octocat@example.com name=Octocat}",
+			expectedString: "User{id=1 login=octocat email=octocat@example.com name=Octocat company=GitHub location=San Francisco}",
 		},
 	}
 
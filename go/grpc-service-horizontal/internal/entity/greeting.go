@@ -5,19 +5,50 @@ import "fmt"
 // GreetRequest is the domain model for a Greet request.
 type GreetRequest struct {
 	GithubUsername string
+	// Language is the preferred language of the greeting (i.e. a language tag or an Accept-Language value).
+	Language string
 }
 
 // String implements the fmt.Stringer interface.
 func (r *GreetRequest) String() string {
-	return fmt.Sprintf("GreetRequest{github_username=%s}", r.GithubUsername)
+	return fmt.Sprintf("GreetRequest{github_username=%s language=%s}", r.GithubUsername, r.Language)
 }
 
 // GreetResponse is the domain model for a Greet response.
 type GreetResponse struct {
 	Greeting string
+	// Stale is true if the greeting is created for a stale GitHub user because the GitHub API is unavailable.
+	Stale bool
 }
 
 // String implements the fmt.Stringer interface.
 func (r *GreetResponse) String() string {
-	return fmt.Sprintf("GreetResponse{greeting=%s}", r.Greeting)
+	return fmt.Sprintf("GreetResponse{greeting=%s stale=%t}", r.Greeting, r.Stale)
+}
+
+// GreetManyRequest is the domain model for a GreetMany request.
+type GreetManyRequest struct {
+	GithubUsernames []string
+	// Language is the preferred language of the greetings (i.e. a language tag or an Accept-Language value).
+	Language string
+}
+
+// String implements the fmt.Stringer interface.
+func (r *GreetManyRequest) String() string {
+	return fmt.Sprintf("GreetManyRequest{github_usernames=%v language=%s}", r.GithubUsernames, r.Language)
+}
+
+// GreetResult is the domain model for a greeting streamed for a GitHub user.
+type GreetResult struct {
+	GithubUsername string
+	Greeting       string
+	// Stale is true if the greeting is created for a stale GitHub user because the GitHub API is unavailable.
+	Stale bool
+	// Err is the failure to greet the GitHub user, if any.
+	Err error
+}
+
+// String implements the fmt.Stringer interface.
+func (r *GreetResult) String() string {
+	return fmt.Sprintf("GreetResult{github_username=%s greeting=%s stale=%t err=%v}", r.GithubUsername, r.Greeting, r.Stale, r.Err)
 }
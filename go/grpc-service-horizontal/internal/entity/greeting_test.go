@@ -1,6 +1,7 @@
 package entity
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -16,8 +17,9 @@ func TestGreetRequest(t *testing.T) {
 			name: "OK",
 			entity: GreetRequest{
 				GithubUsername: "octocat",
+				Language:       "fr",
 			},
-			expectedString: "GreetRequest{github_username=octocat}",
+			expectedString: "GreetRequest{github_username=octocat language=fr}",
 		},
 	}
 
@@ -39,7 +41,61 @@ func TestGreetResponse(t *testing.T) {
 			entity: GreetResponse{
 				Greeting: "Hello, Jane!",
 			},
-			expectedString: "GreetResponse{greeting=Hello, Jane!}",
+			expectedString: "GreetResponse{greeting=Hello, Jane! stale=false}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedString, tc.entity.String())
+		})
+	}
+}
+
+func TestGreetManyRequest(t *testing.T) {
+	tests := []struct {
+		name           string
+		entity         GreetManyRequest
+		expectedString string
+	}{
+		{
+			name: "OK",
+			entity: GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+				Language:        "fr",
+			},
+			expectedString: "GreetManyRequest{github_usernames=[octocat hubot] language=fr}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedString, tc.entity.String())
+		})
+	}
+}
+
+func TestGreetResult(t *testing.T) {
+	tests := []struct {
+		name           string
+		entity         GreetResult
+		expectedString string
+	}{
+		{
+			name: "OK",
+			entity: GreetResult{
+				GithubUsername: "jane",
+				Greeting:       "Hello, Jane!",
+			},
+			expectedString: "GreetResult{github_username=jane greeting=Hello, Jane! stale=false err=<nil>}",
+		},
+		{
+			name: "Error",
+			entity: GreetResult{
+				GithubUsername: "ghost",
+				Err:            errors.New("github user not found"),
+			},
+			expectedString: "GreetResult{github_username=ghost greeting= stale=false err=github user not found}",
 		},
 	}
 
@@ -0,0 +1,41 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// RateLimitClient is the domain model for a client identified for rate limiting.
+type RateLimitClient struct {
+	// Kind is the kind of identity, such as principal, subject, or ip.
+	Kind string
+	// ID is the identity of the client.
+	ID string
+}
+
+// String implements the fmt.Stringer interface.
+func (c *RateLimitClient) String() string {
+	return fmt.Sprintf("RateLimitClient{kind=%s id=%s}", c.Kind, c.ID)
+}
+
+// RateLimit is the domain model for the outcome of taking a token for a request of a client.
+type RateLimit struct {
+	// Allowed determines whether the request is allowed.
+	Allowed bool
+	// Limit is the number of requests allowed per period.
+	Limit int
+	// Period is the period for refilling the limit of requests.
+	Period time.Duration
+	// Remaining is the number of requests allowed right now.
+	Remaining int
+	// RetryAfter is the duration until the next request is allowed if the request is not allowed.
+	RetryAfter time.Duration
+	// Reset is the duration until all the requests are allowed again.
+	Reset time.Duration
+}
+
+// String implements the fmt.Stringer interface.
+func (r *RateLimit) String() string {
+	return fmt.Sprintf("RateLimit{allowed=%t limit=%d period=%s remaining=%d retry_after=%s reset=%s}",
+		r.Allowed, r.Limit, r.Period, r.Remaining, r.RetryAfter, r.Reset)
+}
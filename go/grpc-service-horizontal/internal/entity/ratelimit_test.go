@@ -0,0 +1,58 @@
+package entity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		entity         RateLimitClient
+		expectedString string
+	}{
+		{
+			name: "OK",
+			entity: RateLimitClient{
+				Kind: "ip",
+				ID:   "192.0.2.1",
+			},
+			expectedString: "RateLimitClient{kind=ip id=192.0.2.1}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedString, tc.entity.String())
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name           string
+		entity         RateLimit
+		expectedString string
+	}{
+		{
+			name: "OK",
+			entity: RateLimit{
+				Allowed:    false,
+				Limit:      60,
+				Period:     time.Minute,
+				Remaining:  0,
+				RetryAfter: time.Second,
+				Reset:      time.Minute,
+			},
+			expectedString: "RateLimit{allowed=false limit=60 period=1m0s remaining=0 retry_after=1s reset=1m0s}",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedString, tc.entity.String())
+		})
+	}
+}
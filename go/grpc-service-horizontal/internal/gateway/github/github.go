@@ -5,12 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gardenbed/basil/graceful"
 	"github.com/gardenbed/basil/health"
 	"github.com/gardenbed/basil/httpx"
+	"github.com/gardenbed/basil/telemetry"
+	"go.opentelemetry.io/otel/metric"
 
+	"grpc-service-horizontal/internal/breaker"
 	githubentity "grpc-service-horizontal/internal/entity/github"
 )
 
@@ -25,20 +29,64 @@ type httpClient interface {
 	Do(*http.Request) (*http.Response, error)
 }
 
+// DefaultBaseURL is the base URL of the public GitHub REST API.
+const DefaultBaseURL = "https://api.github.com"
+
+// Options are optional configurations for creating a new gateway.
+type Options struct {
+	// BaseURL is the base URL of the GitHub REST API (DefaultBaseURL if empty).
+	BaseURL string
+	// Breaker configures the circuit breaker for requests to the GitHub REST API.
+	Breaker breaker.Options
+	// Meter is used for reporting the state of the circuit breaker (the global meter if nil).
+	Meter metric.Meter
+}
+
 // gateway implements the Gateway interface.
+// Requests are sent through a circuit breaker, so requests fail fast while the GitHub API is failing.
 type gateway struct {
-	client httpClient
+	client  httpClient
+	breaker *breaker.Breaker
+	baseURL string
 }
 
 // NewGateway creates a new gateway.
-func NewGateway() (Gateway, error) {
+// The state of its circuit breaker is reported by the circuit_breaker_state gauge (0 closed, 1 open, and 2 half-open).
+func NewGateway(opts Options) (Gateway, error) {
+	if opts.BaseURL == "" {
+		opts.BaseURL = DefaultBaseURL
+	}
+
+	if opts.Meter == nil {
+		opts.Meter = telemetry.Get().Meter()
+	}
+
+	b := breaker.New(opts.Breaker)
+
+	state, err := opts.Meter.Int64ObservableGauge(
+		"circuit_breaker_state",
+		metric.WithDescription("The state of the circuit breaker for requests to the GitHub REST API (0 closed, 1 open, and 2 half-open)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := opts.Meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(state, int64(b.State()))
+		return nil
+	}, state); err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: &http.Transport{},
+		Transport: breaker.NewTransport(&http.Transport{}, b),
 	}
 
 	return &gateway{
-		client: client,
+		client:  client,
+		breaker: b,
+		baseURL: strings.TrimSuffix(opts.BaseURL, "/"),
 	}, nil
 }
 
@@ -58,13 +106,18 @@ func (g *gateway) Disconnect(ctx context.Context) error {
 }
 
 // HealthCheck checks the health of connection to the external service.
+// An open circuit breaker does not fail the health check, since the service still serves cached and stale users,
+// and failing the health checks of all replicas at once would take the whole service down with the GitHub API.
+// The GitHub API is reported as degraded by the circuit_breaker_state gauge instead.
 func (g *gateway) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
 // GetUser retrieves a GitHub user by username.
+// If the user does not exist, an error wrapping githubentity.ErrUserNotFound is returned.
+// While the circuit breaker is open, an error wrapping breaker.ErrOpen is returned.
 func (g *gateway) GetUser(ctx context.Context, username string) (*githubentity.User, error) {
-	url := fmt.Sprintf("https://api.github.com/users/%s", username)
+	url := fmt.Sprintf("%s/users/%s", g.baseURL, username)
 	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Set("User-Agent", "command-app")
@@ -80,6 +133,10 @@ func (g *gateway) GetUser(ctx context.Context, username string) (*githubentity.U
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", githubentity.ErrUserNotFound, username)
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		return nil, httpx.NewClientError(resp)
 	}
@@ -9,8 +9,12 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
 
+	"grpc-service-horizontal/internal/breaker"
 	githubentity "grpc-service-horizontal/internal/entity/github"
+	"grpc-service-horizontal/internal/fakegithub"
 )
 
 func TestNewGateway(t *testing.T) {
@@ -26,7 +30,7 @@ func TestNewGateway(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			g, err := NewGateway()
+			g, err := NewGateway(Options{})
 
 			if tc.expectedError == "" {
 				assert.NotNil(t, g)
@@ -109,35 +113,61 @@ func TestGateway_Disconnect(t *testing.T) {
 func TestGateway_HealthCheck(t *testing.T) {
 	tests := []struct {
 		name          string
-		client        *MockHTTPClient
-		ctx           context.Context
-		expectedError string
+		failures      int
+		expectedState int64
 	}{
 		{
-			name:          "OK",
-			client:        &MockHTTPClient{},
-			ctx:           context.Background(),
-			expectedError: "",
+			name:          "Closed",
+			failures:      0,
+			expectedState: int64(breaker.Closed),
+		},
+		{
+			// An open circuit degrades the service but does not fail the health check
+			name:          "Open",
+			failures:      1,
+			expectedState: int64(breaker.Open),
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			g := &gateway{
-				client: tc.client,
-			}
+			reader := metricsdk.NewManualReader()
+			meter := metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("test")
 
-			err := g.HealthCheck(tc.ctx)
+			g, err := NewGateway(Options{
+				Breaker: breaker.Options{FailureThreshold: 1},
+				Meter:   meter,
+			})
+			assert.NoError(t, err)
 
-			if tc.expectedError == "" {
+			for range tc.failures {
+				done, err := g.(*gateway).breaker.Allow()
 				assert.NoError(t, err)
-			} else {
-				assert.EqualError(t, err, tc.expectedError)
+				done(true)
 			}
+
+			assert.NoError(t, g.HealthCheck(context.Background()))
+			assert.Equal(t, tc.expectedState, breakerState(t, reader))
 		})
 	}
 }
 
+// breakerState returns the value of the circuit breaker state gauge.
+func breakerState(t *testing.T, reader metricsdk.Reader) int64 {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && m.Name == "circuit_breaker_state" {
+				return gauge.DataPoints[0].Value
+			}
+		}
+	}
+
+	return -1
+}
+
 func TestGateway_GetUser(t *testing.T) {
 	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat", nil)
 
@@ -242,3 +272,83 @@ func TestGateway_GetUser(t *testing.T) {
 		})
 	}
 }
+
+func TestGateway_GetUser_FakeGitHub(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          []fakegithub.Option
+		username      string
+		expectedUser  *githubentity.User
+		expectedError string
+	}{
+		{
+			name:          "UserNotFound",
+			username:      "ghost",
+			expectedError: "github user not found: ghost",
+		},
+		{
+			name:          "Success",
+			username:      "octocat",
+			expectedError: "",
+			expectedUser: &githubentity.User{
+				ID:       583231,
+				Login:    "octocat",
+				Name:     "The Octocat",
+				Company:  "@github",
+				Location: "San Francisco",
+			},
+		},
+		{
+			name:          "ServerError",
+			opts:          []fakegithub.Option{fakegithub.WithError("/users/*", 503)},
+			username:      "octocat",
+			expectedError: "GET /users/octocat 503: Service Unavailable",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(tc.opts...)
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			g, err := NewGateway(Options{BaseURL: fake.URL})
+			assert.NoError(t, err)
+
+			user, err := g.GetUser(context.Background(), tc.username)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedUser, user)
+			} else {
+				assert.Empty(t, user)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+			assert.Equal(t, 1, fake.Requests())
+		})
+	}
+}
+
+func TestGateway_GetUser_CircuitOpen(t *testing.T) {
+	fake, err := fakegithub.NewServer(fakegithub.WithError("/users/*", 503))
+	assert.NoError(t, err)
+	defer fake.Close()
+
+	g, err := NewGateway(Options{
+		BaseURL: fake.URL,
+		Breaker: breaker.Options{FailureThreshold: 1},
+	})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+
+	// The first failure opens the circuit
+	_, err = g.GetUser(ctx, "octocat")
+	assert.EqualError(t, err, "GET /users/octocat 503: Service Unavailable")
+	assert.NoError(t, g.HealthCheck(ctx))
+
+	user, err := g.GetUser(ctx, "octocat")
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, breaker.ErrOpen)
+	assert.Equal(t, 1, fake.Requests())
+}
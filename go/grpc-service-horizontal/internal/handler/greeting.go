@@ -2,12 +2,26 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"grpc-service-horizontal/internal/controller/greeting"
+	"grpc-service-horizontal/internal/entity"
+	githubentity "grpc-service-horizontal/internal/entity/github"
 	"grpc-service-horizontal/internal/idl/greetingpb"
 	"grpc-service-horizontal/internal/mapper"
+	"grpc-service-horizontal/internal/problem"
+	"grpc-service-horizontal/internal/validation"
 )
 
+// StaleHeader is the header metadata set when a greeting is created for a stale GitHub user.
+const StaleHeader = "x-stale"
+
 // GreetingHandler is an alias for the gRPC server interface.
 type GreetingHandler = greetingpb.GreetingServiceServer
 
@@ -24,21 +38,160 @@ func NewGreetingHandler(greetingController greeting.Controller) (GreetingHandler
 }
 
 // Greet is the handler for GreetingService::Greet endpoint.
+// The language of the greeting is negotiated using the accept-language metadata.
+// Failures are responded with gRPC status codes and error details.
 func (h *greetingHandler) Greet(ctx context.Context, req *greetingpb.GreetRequest) (*greetingpb.GreetResponse, error) {
 	domainReq, err := mapper.GreetRequestIDLToDomain(req)
 	if err != nil {
-		return nil, err
+		return nil, problem.Err(err)
 	}
 
+	domainReq.Language = language(ctx)
+
 	domainResp, err := h.greetingController.Greet(ctx, domainReq)
 	if err != nil {
-		return nil, err
+		return nil, greetError(err)
 	}
 
 	resp, err := mapper.GreetResponseDomainToIDL(domainResp)
 	if err != nil {
-		return nil, err
+		return nil, problem.Err(err)
+	}
+
+	if domainResp.Stale {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(StaleHeader, "true"))
 	}
 
 	return resp, nil
 }
+
+// GreetMany is the handler for GreetingService::GreetMany endpoint.
+// Greetings are sent as soon as they are created, and failures to greet users are sent as the error status of their results.
+func (h *greetingHandler) GreetMany(req *greetingpb.GreetManyRequest, stream greetingpb.GreetingService_GreetManyServer) error {
+	ctx := stream.Context()
+
+	domainReq, err := mapper.GreetManyRequestIDLToDomain(req)
+	if err != nil {
+		return problem.Err(err)
+	}
+
+	domainReq.Language = language(ctx)
+
+	err = h.greetingController.GreetMany(ctx, domainReq, func(domainRes *entity.GreetResult) error {
+		res, err := greetResult(domainRes)
+		if err != nil {
+			return err
+		}
+
+		return stream.Send(res)
+	})
+
+	// The errors of sending results are already gRPC status errors
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return problem.Err(err)
+}
+
+// GreetChat is the handler for GreetingService::GreetChat endpoint.
+// A greeting is sent for every request received, in the same order,
+// and failures to greet users are sent as the error status of their results.
+// Requests are not received while a greeting is being created, so clients are paced by the lookups (flow control).
+// Invalid requests are sent as the error status of their results too, so they do not end the stream.
+func (h *greetingHandler) GreetChat(stream greetingpb.GreetingService_GreetChatServer) error {
+	ctx := stream.Context()
+	lang := language(ctx)
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		var invalid *validation.InvalidMessageError
+		if errors.As(err, &invalid) {
+			res := &greetingpb.GreetResult{
+				GithubUsername: invalid.Message.(*greetingpb.GreetRequest).GithubUsername,
+				Error:          invalid.GRPCStatus().Proto(),
+			}
+
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		res, err := greetResult(h.greetChat(ctx, lang, req))
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// greetChat creates a greeting result for a request received by GreetChat.
+func (h *greetingHandler) greetChat(ctx context.Context, lang string, req *greetingpb.GreetRequest) *entity.GreetResult {
+	domainRes := &entity.GreetResult{
+		GithubUsername: req.GithubUsername,
+	}
+
+	domainReq, err := mapper.GreetRequestIDLToDomain(req)
+	if err != nil {
+		domainRes.Err = err
+		return domainRes
+	}
+
+	domainReq.Language = lang
+
+	domainResp, err := h.greetingController.Greet(ctx, domainReq)
+	if err != nil {
+		domainRes.Err = err
+		return domainRes
+	}
+
+	domainRes.Greeting = domainResp.Greeting
+	domainRes.Stale = domainResp.Stale
+
+	return domainRes
+}
+
+// greetResult transforms a greeting result to its IDL-specific representation with the gRPC status of its error.
+func greetResult(domainRes *entity.GreetResult) (*greetingpb.GreetResult, error) {
+	if domainRes.Err != nil {
+		domainRes.Err = greetError(domainRes.Err)
+	}
+
+	res, err := mapper.GreetResultDomainToIDL(domainRes)
+	if err != nil {
+		return nil, problem.Err(err)
+	}
+
+	return res, nil
+}
+
+// greetError returns the gRPC status error for a failure to greet a GitHub user.
+func greetError(err error) error {
+	if errors.Is(err, githubentity.ErrUserNotFound) {
+		return problem.Err(problem.Wrap(problem.KindNotFound, err))
+	}
+
+	return problem.Err(err)
+}
+
+// language returns the languages accepted by a call from the accept-language metadata.
+func language(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		return strings.Join(md.Get("accept-language"), ",")
+	}
+
+	return ""
+}
@@ -3,12 +3,22 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"grpc-service-horizontal/internal/breaker"
 	"grpc-service-horizontal/internal/entity"
+	githubentity "grpc-service-horizontal/internal/entity/github"
 	"grpc-service-horizontal/internal/idl/greetingpb"
+	"grpc-service-horizontal/internal/problem"
+	"grpc-service-horizontal/internal/validation"
 )
 
 func TestNewGreetingHandler(t *testing.T) {
@@ -46,6 +56,9 @@ func TestGreetingHandler_Greet(t *testing.T) {
 		ctx                context.Context
 		request            *greetingpb.GreetRequest
 		expectedResponse   *greetingpb.GreetResponse
+		expectedLanguage   string
+		expectedStale      []string
+		expectedCode       codes.Code
 		expectedError      string
 	}{
 		{
@@ -53,7 +66,53 @@ func TestGreetingHandler_Greet(t *testing.T) {
 			ctx:              context.Background(),
 			request:          nil,
 			expectedResponse: nil,
-			expectedError:    "greet request cannot be nil",
+			expectedCode:     codes.Internal,
+			expectedError:    "rpc error: code = Internal desc = internal error",
+		},
+		{
+			name: "UserNotFound",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutError: fmt.Errorf("%w: ghost", githubentity.ErrUserNotFound)},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "ghost",
+			},
+			expectedResponse: nil,
+			expectedCode:     codes.NotFound,
+			expectedError:    "rpc error: code = NotFound desc = github user not found: ghost",
+		},
+		{
+			name: "UpstreamUnavailable",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutError: fmt.Errorf("degraded: %w", breaker.ErrOpen)},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: nil,
+			expectedCode:     codes.Unavailable,
+			expectedError:    "rpc error: code = Unavailable desc = an upstream service is unavailable",
+		},
+		{
+			name: "DeadlineExceeded",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutError: context.DeadlineExceeded},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: nil,
+			expectedCode:     codes.DeadlineExceeded,
+			expectedError:    "rpc error: code = DeadlineExceeded desc = deadline exceeded",
 		},
 		{
 			name: "ControllerFails",
@@ -67,7 +126,8 @@ func TestGreetingHandler_Greet(t *testing.T) {
 				GithubUsername: "octocat",
 			},
 			expectedResponse: nil,
-			expectedError:    "controller error",
+			expectedCode:     codes.Internal,
+			expectedError:    "rpc error: code = Internal desc = internal error",
 		},
 		{
 			name: "ResponseMappingFails",
@@ -81,7 +141,8 @@ func TestGreetingHandler_Greet(t *testing.T) {
 				GithubUsername: "octocat",
 			},
 			expectedResponse: nil,
-			expectedError:    "greet response cannot be nil",
+			expectedCode:     codes.Internal,
+			expectedError:    "rpc error: code = Internal desc = internal error",
 		},
 		{
 			name: "Success",
@@ -103,6 +164,49 @@ func TestGreetingHandler_Greet(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "Success_Localized",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{
+						OutResponse: &entity.GreetResponse{
+							Greeting: "Bonjour, Octocat !",
+						},
+					},
+				},
+			},
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "fr")),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: &greetingpb.GreetResponse{
+				Greeting: "Bonjour, Octocat !",
+			},
+			expectedLanguage: "fr",
+			expectedError:    "",
+		},
+		{
+			name: "Success_Stale",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{
+						OutResponse: &entity.GreetResponse{
+							Greeting: "Hello, Octocat!",
+							Stale:    true,
+						},
+					},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: &greetingpb.GreetResponse{
+				Greeting: "Hello, Octocat!",
+			},
+			expectedStale: []string{"true"},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
@@ -111,11 +215,16 @@ func TestGreetingHandler_Greet(t *testing.T) {
 				greetingController: tc.greetingController,
 			}
 
-			response, err := handler.Greet(tc.ctx, tc.request)
+			stream := new(serverTransportStream)
+			ctx := grpc.NewContextWithServerTransportStream(tc.ctx, stream)
+			response, err := handler.Greet(ctx, tc.request)
 
+			assert.Equal(t, tc.expectedCode, status.Code(err))
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
 				assert.Equal(t, tc.expectedResponse, response)
+				assert.Equal(t, tc.expectedLanguage, tc.greetingController.GreetMocks[0].InRequest.Language)
+				assert.Equal(t, tc.expectedStale, stream.header.Get(StaleHeader))
 			} else {
 				assert.Nil(t, response)
 				assert.EqualError(t, err, tc.expectedError)
@@ -123,3 +232,314 @@ func TestGreetingHandler_Greet(t *testing.T) {
 		})
 	}
 }
+
+// serverTransportStream is a grpc.ServerTransportStream for capturing the header metadata set by handlers.
+type serverTransportStream struct {
+	header metadata.MD
+}
+
+func (s *serverTransportStream) Method() string {
+	return "/greeting.GreetingService/Greet"
+}
+
+func (s *serverTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *serverTransportStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+// greetManyStream is a greetingpb.GreetingService_GreetManyServer for capturing the results sent by handlers.
+type greetManyStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	results []*greetingpb.GreetResult
+	sendErr error
+}
+
+func (s *greetManyStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *greetManyStream) Send(res *greetingpb.GreetResult) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+
+	s.results = append(s.results, res)
+	return nil
+}
+
+func TestGreetingHandler_GreetMany(t *testing.T) {
+	tests := []struct {
+		name               string
+		greetingController *MockGreetingController
+		ctx                context.Context
+		request            *greetingpb.GreetManyRequest
+		sendErr            error
+		expectedGreetings  []string
+		expectedCodes      []codes.Code
+		expectedLanguage   string
+		expectedError      string
+	}{
+		{
+			name:          "RequestMappingFails",
+			ctx:           context.Background(),
+			request:       nil,
+			expectedError: "rpc error: code = Internal desc = internal error",
+		},
+		{
+			name: "ControllerFails",
+			greetingController: &MockGreetingController{
+				GreetManyMocks: []GreetManyMock{
+					{OutError: context.Canceled},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat"},
+			},
+			expectedError: "rpc error: code = Canceled desc = call canceled",
+		},
+		{
+			name: "SendFails",
+			greetingController: &MockGreetingController{
+				GreetManyMocks: []GreetManyMock{
+					{
+						OutResults: []*entity.GreetResult{
+							{GithubUsername: "octocat", Greeting: "Hello, Octocat!"},
+						},
+					},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat"},
+			},
+			sendErr:       status.Error(codes.Unavailable, "transport is closing"),
+			expectedError: "rpc error: code = Unavailable desc = transport is closing",
+		},
+		{
+			name: "ResultMappingFails",
+			greetingController: &MockGreetingController{
+				GreetManyMocks: []GreetManyMock{
+					{
+						OutResults: []*entity.GreetResult{
+							{GithubUsername: "octocat"},
+						},
+					},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat"},
+			},
+			expectedError: "rpc error: code = Internal desc = internal error",
+		},
+		{
+			name: "Success",
+			greetingController: &MockGreetingController{
+				GreetManyMocks: []GreetManyMock{
+					{
+						OutResults: []*entity.GreetResult{
+							{GithubUsername: "octocat", Greeting: "Bonjour, Octocat !"},
+							{GithubUsername: "ghost", Err: fmt.Errorf("%w: ghost", githubentity.ErrUserNotFound)},
+							{GithubUsername: "hubot", Err: errors.New("controller error")},
+						},
+					},
+				},
+			},
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "fr")),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "ghost", "hubot"},
+			},
+			expectedGreetings: []string{"Bonjour, Octocat !", "", ""},
+			expectedCodes:     []codes.Code{codes.OK, codes.NotFound, codes.Internal},
+			expectedLanguage:  "fr",
+			expectedError:     "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &greetingHandler{
+				greetingController: tc.greetingController,
+			}
+
+			stream := &greetManyStream{ctx: tc.ctx, sendErr: tc.sendErr}
+			err := handler.GreetMany(tc.request, stream)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedLanguage, tc.greetingController.GreetManyMocks[0].InRequest.Language)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			var greetings []string
+			var resultCodes []codes.Code
+			for _, res := range stream.results {
+				greetings = append(greetings, res.Greeting)
+				resultCodes = append(resultCodes, codes.Code(res.GetError().GetCode()))
+			}
+
+			assert.Equal(t, tc.expectedGreetings, greetings)
+			assert.Equal(t, tc.expectedCodes, resultCodes)
+		})
+	}
+}
+
+// greetChatStream is a greetingpb.GreetingService_GreetChatServer receiving requests and capturing the results sent by handlers.
+// Invalid requests are received with an error, as they are by the validation interceptor.
+type greetChatStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*greetingpb.GreetRequest
+	recvErr  error
+	results  []*greetingpb.GreetResult
+	sendErr  error
+}
+
+func (s *greetChatStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *greetChatStream) Recv() (*greetingpb.GreetRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, s.recvErr
+	}
+
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+
+	if err := validation.Validate(req); err != nil {
+		return nil, &validation.InvalidMessageError{Message: req, Err: problem.Err(err)}
+	}
+
+	return req, nil
+}
+
+func (s *greetChatStream) Send(res *greetingpb.GreetResult) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+
+	s.results = append(s.results, res)
+	return nil
+}
+
+func TestGreetingHandler_GreetChat(t *testing.T) {
+	tests := []struct {
+		name               string
+		greetingController *MockGreetingController
+		ctx                context.Context
+		requests           []*greetingpb.GreetRequest
+		recvErr            error
+		sendErr            error
+		expectedUsernames  []string
+		expectedGreetings  []string
+		expectedCodes      []codes.Code
+		expectedLanguage   string
+		expectedError      string
+	}{
+		{
+			name:               "RecvFails",
+			greetingController: &MockGreetingController{},
+			ctx:                context.Background(),
+			recvErr:            status.Error(codes.Canceled, "context canceled"),
+			expectedError:      "rpc error: code = Canceled desc = context canceled",
+		},
+		{
+			name: "SendFails",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutResponse: &entity.GreetResponse{Greeting: "Hello, Octocat!"}},
+				},
+			},
+			ctx: context.Background(),
+			requests: []*greetingpb.GreetRequest{
+				{GithubUsername: "octocat"},
+			},
+			recvErr:       io.EOF,
+			sendErr:       status.Error(codes.Unavailable, "transport is closing"),
+			expectedError: "rpc error: code = Unavailable desc = transport is closing",
+		},
+		{
+			name: "Success",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutResponse: &entity.GreetResponse{Greeting: "Bonjour, Octocat !", Stale: true}},
+					{OutError: fmt.Errorf("%w: ghost", githubentity.ErrUserNotFound)},
+				},
+			},
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "fr")),
+			requests: []*greetingpb.GreetRequest{
+				{GithubUsername: "octocat"},
+				{GithubUsername: "ghost"},
+			},
+			recvErr:           io.EOF,
+			expectedUsernames: []string{"octocat", "ghost"},
+			expectedGreetings: []string{"Bonjour, Octocat !", ""},
+			expectedCodes:     []codes.Code{codes.OK, codes.NotFound},
+			expectedLanguage:  "fr",
+			expectedError:     "",
+		},
+		{
+			name: "InvalidRequest",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutResponse: &entity.GreetResponse{Greeting: "Hello, Octocat!"}},
+				},
+			},
+			ctx: context.Background(),
+			requests: []*greetingpb.GreetRequest{
+				{GithubUsername: "-hubot"},
+				{GithubUsername: "octocat"},
+			},
+			recvErr:           io.EOF,
+			expectedUsernames: []string{"-hubot", "octocat"},
+			expectedGreetings: []string{"", "Hello, Octocat!"},
+			expectedCodes:     []codes.Code{codes.InvalidArgument, codes.OK},
+			expectedError:     "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := &greetingHandler{
+				greetingController: tc.greetingController,
+			}
+
+			stream := &greetChatStream{ctx: tc.ctx, requests: tc.requests, recvErr: tc.recvErr, sendErr: tc.sendErr}
+			err := handler.GreetChat(stream)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			var usernames, greetings []string
+			var resultCodes []codes.Code
+			for _, res := range stream.results {
+				usernames = append(usernames, res.GithubUsername)
+				greetings = append(greetings, res.Greeting)
+				resultCodes = append(resultCodes, codes.Code(res.GetError().GetCode()))
+			}
+
+			assert.Equal(t, tc.expectedUsernames, usernames)
+			assert.Equal(t, tc.expectedGreetings, greetings)
+			assert.Equal(t, tc.expectedCodes, resultCodes)
+
+			for _, mock := range tc.greetingController.GreetMocks {
+				assert.Equal(t, tc.expectedLanguage, mock.InRequest.Language)
+			}
+		})
+	}
+}
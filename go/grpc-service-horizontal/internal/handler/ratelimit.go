@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gardenbed/basil/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"grpc-service-horizontal/internal/auth"
+	"grpc-service-horizontal/internal/entity"
+	"grpc-service-horizontal/internal/idl/greetingpb"
+	"grpc-service-horizontal/internal/problem"
+	"grpc-service-horizontal/internal/repository/ratelimit"
+)
+
+// healthMethodPrefix is the prefix of the methods of the standard health service.
+// Health checks are not rate limited, so probes are never rejected.
+var healthMethodPrefix = "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+// RateLimitInterceptor is a gRPC server interceptor for rate limiting calls per client.
+type RateLimitInterceptor struct {
+	ratelimitRepository ratelimit.Repository
+	logger              telemetry.Logger
+}
+
+// NewRateLimitInterceptor creates a new interceptor for rate limiting calls per client.
+// If the logger is nil, the logger of the global telemetry probe is used.
+func NewRateLimitInterceptor(ratelimitRepository ratelimit.Repository, logger telemetry.Logger) *RateLimitInterceptor {
+	if logger == nil {
+		logger = telemetry.Get().Logger()
+	}
+
+	return &RateLimitInterceptor{
+		ratelimitRepository: ratelimitRepository,
+		logger:              logger,
+	}
+}
+
+// ServerOptions returns the gRPC server options for rate limiting unary and stream calls.
+func (i *RateLimitInterceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unaryInterceptor),
+		grpc.ChainStreamInterceptor(i.streamInterceptor),
+	}
+}
+
+// unaryInterceptor rejects calls exceeding the limit of their clients with the ResourceExhausted code and a RetryInfo detail.
+// Responses carry ratelimit-limit, ratelimit-remaining, ratelimit-reset, and ratelimit-policy header metadata,
+// and rejected responses carry a retry-after header metadata too.
+// If the rate limit repository is unavailable, calls are allowed.
+func (i *RateLimitInterceptor) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(ctx, req)
+	}
+
+	md, err := i.take(ctx, cost(req))
+	if md != nil {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// streamInterceptor rate limits every message received by a stream just as a unary call,
+// so a long-lived stream cannot make more requests than its client is allowed to.
+// Once a message exceeds the limit of its client, receiving it fails with the ResourceExhausted code and the stream ends.
+// The rate limit metadata of the first message is sent as the header metadata,
+// and the rate limit metadata of a rejected message is sent as the trailer metadata.
+func (i *RateLimitInterceptor) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(srv, ss)
+	}
+
+	return handler(srv, &rateLimitStream{
+		ServerStream: ss,
+		interceptor:  i,
+	})
+}
+
+// rateLimitStream is a grpc.ServerStream rate limiting the messages it receives.
+type rateLimitStream struct {
+	grpc.ServerStream
+	interceptor *RateLimitInterceptor
+	header      bool
+}
+
+// RecvMsg implements the grpc.ServerStream interface.
+func (s *rateLimitStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	md, err := s.interceptor.take(s.Context(), cost(m))
+	if md != nil {
+		if !s.header {
+			_ = s.SetHeader(md)
+			s.header = true
+		} else if err != nil {
+			s.SetTrailer(md)
+		}
+	}
+
+	return err
+}
+
+// cost returns the number of requests a request message is worth.
+// A GreetMany request is worth a request per GitHub username, so batching does not bypass the limit.
+func cost(m any) int {
+	if req, ok := m.(*greetingpb.GreetManyRequest); ok {
+		return len(req.GithubUsernames)
+	}
+
+	return 1
+}
+
+// take returns the rate limit header metadata for a call worth a number of requests and an error if the call is rejected.
+// If the rate limit repository is unavailable, the call is allowed without any header metadata.
+func (i *RateLimitInterceptor) take(ctx context.Context, tokens int) (metadata.MD, error) {
+	rateLimit, err := i.ratelimitRepository.Take(ctx, rateLimitClient(ctx), tokens)
+	if err != nil {
+		i.logger.Warn("rate limiting failed, allowing request", "error", err)
+		return nil, nil
+	}
+
+	md := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(rateLimit.Limit),
+		"ratelimit-remaining", strconv.Itoa(rateLimit.Remaining),
+		"ratelimit-reset", strconv.Itoa(seconds(rateLimit.Reset)),
+		"ratelimit-policy", strconv.Itoa(rateLimit.Limit)+";w="+strconv.Itoa(seconds(rateLimit.Period)),
+	)
+
+	if !rateLimit.Allowed {
+		md.Set("retry-after", strconv.Itoa(seconds(rateLimit.RetryAfter)))
+		err := problem.New(problem.KindRateLimited, fmt.Sprintf("rate limit exceeded, retry after %ds", seconds(rateLimit.RetryAfter)))
+		err.RetryDelay = rateLimit.RetryAfter
+		return md, problem.Err(err)
+	}
+
+	return md, nil
+}
+
+// AuthFailureInterceptor is a gRPC server interceptor for limiting the failed authentications of clients, so credentials cannot be brute forced.
+// It is used before authentication, so clients are identified by the subjects of their verified TLS certificates or their IP addresses.
+type AuthFailureInterceptor struct {
+	ratelimitRepository ratelimit.Repository
+	logger              telemetry.Logger
+}
+
+// NewAuthFailureInterceptor creates a new interceptor for limiting the failed authentications of clients.
+// If the logger is nil, the logger of the global telemetry probe is used.
+func NewAuthFailureInterceptor(ratelimitRepository ratelimit.Repository, logger telemetry.Logger) *AuthFailureInterceptor {
+	if logger == nil {
+		logger = telemetry.Get().Logger()
+	}
+
+	return &AuthFailureInterceptor{
+		ratelimitRepository: ratelimitRepository,
+		logger:              logger,
+	}
+}
+
+// ServerOptions returns the gRPC server options for limiting failed authentications of unary and stream calls.
+// They must be chained before the authentication interceptors.
+func (i *AuthFailureInterceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unaryInterceptor),
+		grpc.ChainStreamInterceptor(i.streamInterceptor),
+	}
+}
+
+// unaryInterceptor rejects calls of clients exceeding their limit of failed authentications with the ResourceExhausted code,
+// a RetryInfo detail, and a retry-after header metadata.
+// Calls failing with the Unauthenticated code are counted as failed authentications.
+// If the rate limit repository is unavailable, calls are allowed.
+func (i *AuthFailureInterceptor) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(ctx, req)
+	}
+
+	client := rateLimitClient(ctx)
+
+	md, err := i.check(ctx, client)
+	if md != nil {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := handler(ctx, req)
+	i.count(ctx, client, err)
+
+	return res, err
+}
+
+// streamInterceptor rejects streams of clients exceeding their limit of failed authentications with the ResourceExhausted code,
+// a RetryInfo detail, and a retry-after header metadata.
+// Streams failing with the Unauthenticated code are counted as failed authentications.
+// If the rate limit repository is unavailable, streams are allowed.
+func (i *AuthFailureInterceptor) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(srv, ss)
+	}
+
+	ctx := ss.Context()
+	client := rateLimitClient(ctx)
+
+	md, err := i.check(ctx, client)
+	if md != nil {
+		_ = ss.SetHeader(md)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = handler(srv, ss)
+	i.count(ctx, client, err)
+
+	return err
+}
+
+// check returns the retry-after header metadata and an error if a client exceeded its limit of failed authentications.
+func (i *AuthFailureInterceptor) check(ctx context.Context, client *entity.RateLimitClient) (metadata.MD, error) {
+	rateLimit, err := i.ratelimitRepository.Peek(ctx, client)
+	if err != nil {
+		i.logger.Warn("rate limiting failed authentications failed, allowing request", "error", err)
+		return nil, nil
+	}
+
+	if !rateLimit.Allowed {
+		err := problem.New(problem.KindRateLimited, fmt.Sprintf("too many failed authentications, retry after %ds", seconds(rateLimit.RetryAfter)))
+		err.RetryDelay = rateLimit.RetryAfter
+		return metadata.Pairs("retry-after", strconv.Itoa(seconds(rateLimit.RetryAfter))), problem.Err(err)
+	}
+
+	return nil, nil
+}
+
+// count takes a token from the bucket of a client if its call failed authentication.
+func (i *AuthFailureInterceptor) count(ctx context.Context, client *entity.RateLimitClient, err error) {
+	if status.Code(err) != codes.Unauthenticated {
+		return
+	}
+
+	if _, err := i.ratelimitRepository.Take(ctx, client, 1); err != nil {
+		i.logger.Warn("rate limiting failed authentications failed", "error", err)
+	}
+}
+
+// rateLimitClient identifies the client of a call by its authenticated principal, the subject of its verified TLS certificate, or its IP address.
+// Calls are authenticated before being rate limited, so the principal is in the call context if authentication is enabled.
+// Clients are never identified by their credentials, since a client presenting a new credential for every call would get a new bucket every time.
+func rateLimitClient(ctx context.Context) *entity.RateLimitClient {
+	if p, ok := auth.FromContext(ctx); ok {
+		return &entity.RateLimitClient{Kind: "principal", ID: p.Method + ":" + p.Subject}
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return &entity.RateLimitClient{Kind: "ip", ID: "unknown"}
+	}
+
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		if chains := info.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+			return &entity.RateLimitClient{Kind: "subject", ID: chains[0][0].Subject.String()}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	return &entity.RateLimitClient{Kind: "ip", ID: host}
+}
+
+// seconds rounds a duration up to whole seconds for metadata.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
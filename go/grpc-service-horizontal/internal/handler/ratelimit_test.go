@@ -0,0 +1,560 @@
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"grpc-service-horizontal/internal/auth"
+	"grpc-service-horizontal/internal/entity"
+	"grpc-service-horizontal/internal/idl/greetingpb"
+	"grpc-service-horizontal/internal/repository/ratelimit"
+)
+
+func TestNewRateLimitInterceptor(t *testing.T) {
+	i := NewRateLimitInterceptor(&MockRateLimitRepository{}, nil)
+
+	assert.NotNil(t, i)
+	assert.NotNil(t, i.logger)
+	assert.Len(t, i.ServerOptions(), 2)
+}
+
+func TestRateLimitInterceptor_unaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name                string
+		ratelimitRepository *MockRateLimitRepository
+		expectedResponse    any
+		expectedHeader      map[string]string
+		expectedRetry       time.Duration
+		expectedError       string
+	}{
+		{
+			name: "TakeFails",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutError: errors.New("redis error")},
+				},
+			},
+			expectedResponse: "response",
+			expectedHeader:   map[string]string{},
+			expectedError:    "",
+		},
+		{
+			name: "Allowed",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{
+						OutRateLimit: &entity.RateLimit{
+							Allowed:   true,
+							Limit:     60,
+							Period:    time.Minute,
+							Remaining: 59,
+							Reset:     time.Second,
+						},
+					},
+				},
+			},
+			expectedResponse: "response",
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "59",
+				"ratelimit-reset":     "1",
+				"ratelimit-policy":    "60;w=60",
+			},
+			expectedError: "",
+		},
+		{
+			name: "Rejected",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{
+						OutRateLimit: &entity.RateLimit{
+							Allowed:    false,
+							Limit:      60,
+							Period:     time.Minute,
+							Remaining:  0,
+							RetryAfter: 500 * time.Millisecond,
+							Reset:      time.Minute,
+						},
+					},
+				},
+			},
+			expectedResponse: nil,
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "0",
+				"ratelimit-reset":     "60",
+				"ratelimit-policy":    "60;w=60",
+				"retry-after":         "1",
+			},
+			expectedRetry: 500 * time.Millisecond,
+			expectedError: "rpc error: code = ResourceExhausted desc = rate limit exceeded, retry after 1s",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i := NewRateLimitInterceptor(tc.ratelimitRepository, nil)
+			info := &grpc.UnaryServerInfo{FullMethod: "/greeting.GreetingService/Greet"}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return "response", nil
+			}
+
+			stream := new(serverTransportStream)
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			response, err := i.unaryInterceptor(ctx, "request", info, handler)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+				details := status.Convert(err).Details()
+				if assert.Len(t, details, 2) {
+					assert.Equal(t, tc.expectedRetry, details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+				}
+			}
+
+			assert.Equal(t, tc.expectedResponse, response)
+			assert.Len(t, stream.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, stream.header.Get(key), key)
+			}
+		})
+	}
+}
+
+// serverStream is a grpc.ServerStream receiving messages and capturing the metadata set by interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	messages []proto.Message
+	header   metadata.MD
+	trailer  metadata.MD
+}
+
+func (s *serverStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if len(s.messages) == 0 {
+		return io.EOF
+	}
+
+	proto.Merge(m.(proto.Message), s.messages[0])
+	s.messages = s.messages[1:]
+	return nil
+}
+
+func (s *serverStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func TestRateLimitInterceptor_streamInterceptor(t *testing.T) {
+	greetRequest := &greetingpb.GreetRequest{GithubUsername: "octocat"}
+	greetManyRequest := &greetingpb.GreetManyRequest{GithubUsernames: []string{"octocat", "hubot"}}
+
+	allowed := &entity.RateLimit{
+		Allowed:   true,
+		Limit:     60,
+		Period:    time.Minute,
+		Remaining: 59,
+		Reset:     time.Second,
+	}
+
+	rejected := &entity.RateLimit{
+		Allowed:    false,
+		Limit:      60,
+		Period:     time.Minute,
+		Remaining:  0,
+		RetryAfter: 500 * time.Millisecond,
+		Reset:      time.Minute,
+	}
+
+	tests := []struct {
+		name                string
+		ratelimitRepository *MockRateLimitRepository
+		messages            []proto.Message
+		expectedReceived    int
+		expectedTokens      []int
+		expectedHeader      map[string]string
+		expectedTrailer     map[string]string
+		expectedError       string
+	}{
+		{
+			name: "TakeFails",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutError: errors.New("redis error")},
+					{OutError: errors.New("redis error")},
+				},
+			},
+			messages:         []proto.Message{greetRequest, greetRequest},
+			expectedReceived: 2,
+			expectedTokens:   []int{1, 1},
+			expectedHeader:   map[string]string{},
+			expectedTrailer:  map[string]string{},
+			expectedError:    "",
+		},
+		{
+			name: "Allowed",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutRateLimit: allowed},
+					{OutRateLimit: allowed},
+				},
+			},
+			messages:         []proto.Message{greetRequest, greetRequest},
+			expectedReceived: 2,
+			expectedTokens:   []int{1, 1},
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "59",
+				"ratelimit-reset":     "1",
+				"ratelimit-policy":    "60;w=60",
+			},
+			expectedTrailer: map[string]string{},
+			expectedError:   "",
+		},
+		{
+			name: "Rejected",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutRateLimit: allowed},
+					{OutRateLimit: rejected},
+				},
+			},
+			messages:         []proto.Message{greetRequest, greetRequest, greetRequest},
+			expectedReceived: 1,
+			expectedTokens:   []int{1, 1},
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "59",
+				"ratelimit-reset":     "1",
+				"ratelimit-policy":    "60;w=60",
+			},
+			expectedTrailer: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "0",
+				"ratelimit-reset":     "60",
+				"ratelimit-policy":    "60;w=60",
+				"retry-after":         "1",
+			},
+			expectedError: "rpc error: code = ResourceExhausted desc = rate limit exceeded, retry after 1s",
+		},
+		{
+			name: "FirstRejected",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutRateLimit: rejected},
+				},
+			},
+			messages:         []proto.Message{greetRequest},
+			expectedReceived: 0,
+			expectedTokens:   []int{1},
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "0",
+				"ratelimit-reset":     "60",
+				"ratelimit-policy":    "60;w=60",
+				"retry-after":         "1",
+			},
+			expectedTrailer: map[string]string{},
+			expectedError:   "rpc error: code = ResourceExhausted desc = rate limit exceeded, retry after 1s",
+		},
+		{
+			name: "GreetMany",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutRateLimit: allowed},
+				},
+			},
+			messages:         []proto.Message{greetManyRequest},
+			expectedReceived: 1,
+			expectedTokens:   []int{2},
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "60",
+				"ratelimit-remaining": "59",
+				"ratelimit-reset":     "1",
+				"ratelimit-policy":    "60;w=60",
+			},
+			expectedTrailer: map[string]string{},
+			expectedError:   "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i := NewRateLimitInterceptor(tc.ratelimitRepository, nil)
+			info := &grpc.StreamServerInfo{FullMethod: "/greeting.GreetingService/GreetChat"}
+
+			var received int
+			handler := func(srv any, ss grpc.ServerStream) error {
+				for _, m := range tc.messages {
+					if err := ss.RecvMsg(m.ProtoReflect().New().Interface()); err != nil {
+						return err
+					}
+					received++
+				}
+				return nil
+			}
+
+			stream := &serverStream{messages: tc.messages}
+			err := i.streamInterceptor(nil, stream, info, handler)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			assert.Equal(t, tc.expectedReceived, received)
+
+			var tokens []int
+			for _, m := range tc.ratelimitRepository.TakeMocks[:tc.ratelimitRepository.TakeIndex] {
+				tokens = append(tokens, m.InTokens)
+			}
+			assert.Equal(t, tc.expectedTokens, tokens)
+
+			assert.Len(t, stream.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, stream.header.Get(key), key)
+			}
+			assert.Len(t, stream.trailer, len(tc.expectedTrailer))
+			for key, val := range tc.expectedTrailer {
+				assert.Equal(t, []string{val}, stream.trailer.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestNewAuthFailureInterceptor(t *testing.T) {
+	i := NewAuthFailureInterceptor(&MockRateLimitRepository{}, nil)
+
+	assert.NotNil(t, i)
+	assert.NotNil(t, i.logger)
+	assert.Len(t, i.ServerOptions(), 2)
+}
+
+func TestAuthFailureInterceptor_unaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name                string
+		ratelimitRepository *MockRateLimitRepository
+		handlerError        error
+		expectedHeader      map[string]string
+		expectedRetry       time.Duration
+		expectedTakes       int
+		expectedError       string
+	}{
+		{
+			name: "PeekFails",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutError: errors.New("redis error")},
+				},
+			},
+			handlerError:   nil,
+			expectedHeader: map[string]string{},
+			expectedTakes:  0,
+			expectedError:  "",
+		},
+		{
+			name: "Authenticated",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+			},
+			handlerError:   nil,
+			expectedHeader: map[string]string{},
+			expectedTakes:  0,
+			expectedError:  "",
+		},
+		{
+			name: "Unauthenticated",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+				TakeMocks: []TakeMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+			},
+			handlerError:   status.Error(codes.Unauthenticated, "unauthenticated"),
+			expectedHeader: map[string]string{},
+			expectedTakes:  1,
+			expectedError:  "rpc error: code = Unauthenticated desc = unauthenticated",
+		},
+		{
+			name: "Rejected",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{
+						OutRateLimit: &entity.RateLimit{
+							Allowed:    false,
+							RetryAfter: 500 * time.Millisecond,
+						},
+					},
+				},
+			},
+			handlerError: status.Error(codes.Unauthenticated, "unauthenticated"),
+			expectedHeader: map[string]string{
+				"retry-after": "1",
+			},
+			expectedRetry: 500 * time.Millisecond,
+			expectedTakes: 0,
+			expectedError: "rpc error: code = ResourceExhausted desc = too many failed authentications, retry after 1s",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i := NewAuthFailureInterceptor(tc.ratelimitRepository, nil)
+			info := &grpc.UnaryServerInfo{FullMethod: "/greeting.GreetingService/Greet"}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return "response", tc.handlerError
+			}
+
+			stream := new(serverTransportStream)
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			_, err := i.unaryInterceptor(ctx, "request", info, handler)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+				if tc.expectedRetry > 0 {
+					details := status.Convert(err).Details()
+					if assert.Len(t, details, 2) {
+						assert.Equal(t, tc.expectedRetry, details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+					}
+				}
+			}
+
+			assert.Equal(t, tc.expectedTakes, tc.ratelimitRepository.TakeIndex)
+			assert.Len(t, stream.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, stream.header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestAuthFailureInterceptor_RepeatedFailures(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Now())
+
+	ratelimitRepository, err := ratelimit.NewRepository(mr.Addr(), ratelimit.Options{Limit: 2, Period: time.Minute})
+	assert.NoError(t, err)
+	defer ratelimitRepository.Disconnect(context.Background())
+
+	i := NewAuthFailureInterceptor(ratelimitRepository, nil)
+	info := &grpc.StreamServerInfo{FullMethod: "/greeting.GreetingService/GreetChat"}
+
+	// Streams are counted the same as unary calls
+	greetChat := func(handlerError error) error {
+		return i.streamInterceptor(nil, new(serverStream), info, func(srv any, ss grpc.ServerStream) error {
+			return handlerError
+		})
+	}
+
+	// Authenticated streams are not counted
+	for range 3 {
+		assert.NoError(t, greetChat(nil))
+	}
+
+	for range 2 {
+		assert.Equal(t, codes.Unauthenticated, status.Code(greetChat(status.Error(codes.Unauthenticated, "unauthenticated"))))
+	}
+
+	// Once the limit is exceeded, even authenticated streams are rejected before authentication
+	assert.Equal(t, codes.ResourceExhausted, status.Code(greetChat(nil)))
+}
+
+func TestRateLimitClient(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		expectedClient *entity.RateLimitClient
+	}{
+		{
+			name:           "Principal",
+			ctx:            auth.NewContext(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), &auth.Principal{Subject: "client", Method: auth.MethodJWT}),
+			expectedClient: &entity.RateLimitClient{Kind: "principal", ID: "jwt:client"},
+		},
+		{
+			// Credentials are not verified by the interceptor, so they never identify a client
+			name: "UnverifiedAPIKey",
+			ctx: metadata.NewIncomingContext(
+				peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+				metadata.Pairs("x-api-key", "secret"),
+			),
+			expectedClient: &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+		},
+		{
+			name: "Subject",
+			ctx: peer.NewContext(context.Background(), &peer.Peer{
+				Addr: addr,
+				AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{
+						VerifiedChains: [][]*x509.Certificate{
+							{{Subject: pkix.Name{CommonName: "client"}}},
+						},
+					},
+				},
+			}),
+			expectedClient: &entity.RateLimitClient{Kind: "subject", ID: "CN=client"},
+		},
+		{
+			name:           "IP",
+			ctx:            peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+			expectedClient: &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+		},
+		{
+			name:           "NoPeer",
+			ctx:            context.Background(),
+			expectedClient: &entity.RateLimitClient{Kind: "ip", ID: "unknown"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedClient, rateLimitClient(tc.ctx))
+		})
+	}
+}
+
+func TestRateLimitInterceptor_unaryInterceptor_HealthMethods(t *testing.T) {
+	i := NewRateLimitInterceptor(&MockRateLimitRepository{}, nil)
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+
+	response, err := i.unaryInterceptor(context.Background(), "request", info, func(ctx context.Context, req any) (any, error) {
+		return "response", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "response", response)
+}
@@ -3,6 +3,8 @@ package mapper
 import (
 	"errors"
 
+	"google.golang.org/grpc/status"
+
 	"grpc-service-horizontal/internal/entity"
 	"grpc-service-horizontal/internal/idl/greetingpb"
 )
@@ -13,10 +15,6 @@ func GreetRequestIDLToDomain(req *greetingpb.GreetRequest) (*entity.GreetRequest
 		return nil, errors.New("greet request cannot be nil")
 	}
 
-	if req.GithubUsername == "" {
-		return nil, errors.New("github username cannot be empty")
-	}
-
 	return &entity.GreetRequest{
 		GithubUsername: req.GithubUsername,
 	}, nil
@@ -36,3 +34,39 @@ func GreetResponseDomainToIDL(resp *entity.GreetResponse) (*greetingpb.GreetResp
 		Greeting: resp.Greeting,
 	}, nil
 }
+
+// GreetManyRequestIDLToDomain transforms the IDL-specific (wire or transport protocol) representation of GreetManyRequest to its domain-specific representation.
+func GreetManyRequestIDLToDomain(req *greetingpb.GreetManyRequest) (*entity.GreetManyRequest, error) {
+	if req == nil {
+		return nil, errors.New("greet many request cannot be nil")
+	}
+
+	return &entity.GreetManyRequest{
+		GithubUsernames: req.GithubUsernames,
+	}, nil
+}
+
+// GreetResultDomainToIDL transforms the domain-specific representation of GreetResult to its IDL-specific (wire or transport protocol) representation.
+// The error of a result is transformed to its gRPC status, so it is expected to be a gRPC status error.
+func GreetResultDomainToIDL(res *entity.GreetResult) (*greetingpb.GreetResult, error) {
+	if res == nil {
+		return nil, errors.New("greet result cannot be nil")
+	}
+
+	if res.Err != nil {
+		return &greetingpb.GreetResult{
+			GithubUsername: res.GithubUsername,
+			Error:          status.Convert(res.Err).Proto(),
+		}, nil
+	}
+
+	if res.Greeting == "" {
+		return nil, errors.New("greeting cannot be empty")
+	}
+
+	return &greetingpb.GreetResult{
+		GithubUsername: res.GithubUsername,
+		Greeting:       res.Greeting,
+		Stale:          res.Stale,
+	}, nil
+}
@@ -5,6 +5,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"grpc-service-horizontal/internal/entity"
 	"grpc-service-horizontal/internal/idl/greetingpb"
@@ -23,12 +25,6 @@ func TestGreetRequestIDLToDomain(t *testing.T) {
 			expectedReq:   nil,
 			expectedError: errors.New("greet request cannot be nil"),
 		},
-		{
-			name:          "EmptyGithubUsername",
-			req:           &greetingpb.GreetRequest{},
-			expectedReq:   nil,
-			expectedError: errors.New("github username cannot be empty"),
-		},
 		{
 			name: "OK",
 			req: &greetingpb.GreetRequest{
@@ -91,3 +87,97 @@ func TestGreetResponseDomainToIDL(t *testing.T) {
 		})
 	}
 }
+
+func TestGreetManyRequestIDLToDomain(t *testing.T) {
+	tests := []struct {
+		name          string
+		req           *greetingpb.GreetManyRequest
+		expectedReq   *entity.GreetManyRequest
+		expectedError error
+	}{
+		{
+			name:          "NilRequest",
+			req:           nil,
+			expectedReq:   nil,
+			expectedError: errors.New("greet many request cannot be nil"),
+		},
+		{
+			name: "OK",
+			req: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+			},
+			expectedReq: &entity.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := GreetManyRequestIDLToDomain(tc.req)
+
+			assert.Equal(t, tc.expectedReq, req)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestGreetResultDomainToIDL(t *testing.T) {
+	tests := []struct {
+		name          string
+		res           *entity.GreetResult
+		expectedRes   *greetingpb.GreetResult
+		expectedError error
+	}{
+		{
+			name:          "NilResult",
+			res:           nil,
+			expectedRes:   nil,
+			expectedError: errors.New("greet result cannot be nil"),
+		},
+		{
+			name: "EmptyGreeting",
+			res: &entity.GreetResult{
+				GithubUsername: "jane",
+			},
+			expectedRes:   nil,
+			expectedError: errors.New("greeting cannot be empty"),
+		},
+		{
+			name: "Error",
+			res: &entity.GreetResult{
+				GithubUsername: "ghost",
+				Err:            status.Error(codes.NotFound, "github user not found"),
+			},
+			expectedRes: &greetingpb.GreetResult{
+				GithubUsername: "ghost",
+				Error:          status.New(codes.NotFound, "github user not found").Proto(),
+			},
+			expectedError: nil,
+		},
+		{
+			name: "OK",
+			res: &entity.GreetResult{
+				GithubUsername: "jane",
+				Greeting:       "Hello, Jane!",
+				Stale:          true,
+			},
+			expectedRes: &greetingpb.GreetResult{
+				GithubUsername: "jane",
+				Greeting:       "Hello, Jane!",
+				Stale:          true,
+			},
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := GreetResultDomainToIDL(tc.res)
+
+			assert.Equal(t, tc.expectedRes, res)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
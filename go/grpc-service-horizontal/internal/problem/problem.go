@@ -0,0 +1,215 @@
+// Package problem maps errors to gRPC statuses with rich error details.
+// Errors are classified into a small set of kinds, each with a gRPC code and a stable reason,
+// and responded with ErrorInfo, BadRequest, and RetryInfo details.
+package problem
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/gardenbed/basil/httpx"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"grpc-service-horizontal/internal/breaker"
+)
+
+const (
+	// Domain is the logical grouping of the reasons of errors.
+	Domain = "greeting"
+
+	// DefaultRetryDelay is the default delay advised to clients before retrying calls failed by unavailable upstream services.
+	DefaultRetryDelay = time.Second
+)
+
+// Kind is the kind of an error.
+type Kind string
+
+const (
+	// KindValidation is the kind of errors for invalid requests.
+	KindValidation Kind = "validation"
+	// KindNotFound is the kind of errors for resources that do not exist.
+	KindNotFound Kind = "not-found"
+	// KindRateLimited is the kind of errors for calls exceeding the rate limit of their clients.
+	KindRateLimited Kind = "rate-limited"
+	// KindUpstreamUnavailable is the kind of errors for upstream services failing or being unavailable.
+	KindUpstreamUnavailable Kind = "upstream-unavailable"
+	// KindDeadlineExceeded is the kind of errors for calls not completed before their deadlines.
+	KindDeadlineExceeded Kind = "deadline-exceeded"
+	// KindCanceled is the kind of errors for calls canceled by their clients.
+	KindCanceled Kind = "canceled"
+	// KindInternal is the kind of unexpected errors.
+	KindInternal Kind = "internal"
+)
+
+// kindInfo is the static information of a kind of errors.
+type kindInfo struct {
+	code   codes.Code
+	reason string
+	// message replaces the error message, so the internals of the service are not exposed to clients.
+	message string
+}
+
+var kinds = map[Kind]kindInfo{
+	KindValidation:          {codes.InvalidArgument, "VALIDATION_FAILED", ""},
+	KindNotFound:            {codes.NotFound, "NOT_FOUND", ""},
+	KindRateLimited:         {codes.ResourceExhausted, "RATE_LIMITED", ""},
+	KindUpstreamUnavailable: {codes.Unavailable, "UPSTREAM_UNAVAILABLE", "an upstream service is unavailable"},
+	KindDeadlineExceeded:    {codes.DeadlineExceeded, "DEADLINE_EXCEEDED", "deadline exceeded"},
+	KindCanceled:            {codes.Canceled, "CANCELED", "call canceled"},
+	KindInternal:            {codes.Internal, "INTERNAL", "internal error"},
+}
+
+// Code returns the gRPC status code for the kind.
+func (k Kind) Code() codes.Code {
+	return kinds[k].code
+}
+
+// Reason returns the stable reason for the kind.
+func (k Kind) Reason() string {
+	return kinds[k].reason
+}
+
+// Violation is a field-level validation error.
+type Violation struct {
+	Field   string
+	Message string
+}
+
+// Error is an error of a known kind.
+type Error struct {
+	Kind       Kind
+	Violations []Violation
+	// RetryDelay is the delay advised to clients before retrying the call (not advised if zero).
+	RetryDelay time.Duration
+	err        error
+}
+
+// New creates a new error of a given kind.
+func New(kind Kind, message string) *Error {
+	return &Error{
+		Kind: kind,
+		err:  errors.New(message),
+	}
+}
+
+// Wrap marks an error as a given kind.
+func Wrap(kind Kind, err error) *Error {
+	return &Error{
+		Kind: kind,
+		err:  err,
+	}
+}
+
+// Validation creates a new validation error with field-level violations.
+func Validation(message string, violations ...Violation) *Error {
+	return &Error{
+		Kind:       KindValidation,
+		Violations: violations,
+		err:        errors.New(message),
+	}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Status maps an error to a gRPC status.
+// Errors that are already gRPC statuses are returned as they are.
+// Errors of unknown kinds are classified by their types; upstream failures are reported as unavailable upstreams,
+// and other errors are reported as internal errors.
+func Status(err error) *status.Status {
+	if err == nil {
+		return nil
+	}
+
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	e := classify(err)
+	info := kinds[e.Kind]
+
+	message := info.message
+	if message == "" {
+		message = err.Error()
+	}
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{
+			Reason: info.reason,
+			Domain: Domain,
+		},
+	}
+
+	if len(e.Violations) > 0 {
+		br := new(errdetails.BadRequest)
+		for _, v := range e.Violations {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       v.Field,
+				Description: v.Message,
+			})
+		}
+		details = append(details, br)
+	}
+
+	retryDelay := e.RetryDelay
+	if retryDelay == 0 && e.Kind == KindUpstreamUnavailable {
+		retryDelay = DefaultRetryDelay
+	}
+
+	if retryDelay > 0 {
+		details = append(details, &errdetails.RetryInfo{
+			RetryDelay: durationpb.New(retryDelay),
+		})
+	}
+
+	st := status.New(info.code, message)
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+
+	return st
+}
+
+// Err maps an error to a gRPC status error.
+func Err(err error) error {
+	return Status(err).Err()
+}
+
+// classify determines the kind of an error.
+func classify(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		if _, ok := kinds[e.Kind]; ok {
+			return e
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return Wrap(KindDeadlineExceeded, err)
+	}
+
+	if errors.Is(err, context.Canceled) {
+		return Wrap(KindCanceled, err)
+	}
+
+	var clientErr *httpx.ClientError
+	var urlErr *url.Error
+	if errors.Is(err, breaker.ErrOpen) || errors.As(err, &clientErr) || errors.As(err, &urlErr) {
+		return Wrap(KindUpstreamUnavailable, err)
+	}
+
+	return Wrap(KindInternal, err)
+}
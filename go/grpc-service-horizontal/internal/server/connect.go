@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"grpc-service-horizontal/internal/auth"
+	"grpc-service-horizontal/internal/idl/greetingpb"
+	"grpc-service-horizontal/internal/idl/greetingpb/greetingpbconnect"
+)
+
+// connectForwardedHeaders are the HTTP request headers forwarded as gRPC request metadata.
+var connectForwardedHeaders = []string{auth.APIKeyMetadata, "authorization"}
+
+// NewConnect creates an http handler serving the gRPC services over the Connect, gRPC-Web, and gRPC protocols.
+// Calls are made to the gRPC services over a connection to them (see GRPC.LocalConn),
+// so they are intercepted just as native gRPC calls.
+// The x-api-key and authorization headers are forwarded as request metadata,
+// and the response header and trailer metadata are forwarded as response headers and trailers.
+func NewConnect(conn grpc.ClientConnInterface) http.Handler {
+	_, handler := greetingpbconnect.NewGreetingServiceHandler(&connectGreetingService{
+		client: greetingpb.NewGreetingServiceClient(conn),
+	})
+
+	return withForwardedTLS(handler)
+}
+
+// connectGreetingService implements the greetingpbconnect.GreetingServiceHandler interface.
+type connectGreetingService struct {
+	client greetingpb.GreetingServiceClient
+}
+
+// Greet implements the greeting.GreetingService/Greet method.
+func (s *connectGreetingService) Greet(ctx context.Context, req *connect.Request[greetingpb.GreetRequest]) (*connect.Response[greetingpb.GreetResponse], error) {
+	ctx = metadata.NewOutgoingContext(ctx, connectMetadata(ctx, req.Header(), req.Peer()))
+
+	var header, trailer metadata.MD
+	resp, err := s.client.Greet(ctx, req.Msg, grpc.Header(&header), grpc.Trailer(&trailer))
+	if err != nil {
+		connectErr := connectError(err)
+		copyMetadata(connectErr.Meta(), header)
+		copyMetadata(connectErr.Meta(), trailer)
+		return nil, connectErr
+	}
+
+	res := connect.NewResponse(resp)
+	copyMetadata(res.Header(), header)
+	copyMetadata(res.Trailer(), trailer)
+
+	return res, nil
+}
+
+// GreetMany implements the greeting.GreetingService/GreetMany method.
+func (s *connectGreetingService) GreetMany(ctx context.Context, req *connect.Request[greetingpb.GreetManyRequest], stream *connect.ServerStream[greetingpb.GreetResult]) error {
+	ctx = metadata.NewOutgoingContext(ctx, connectMetadata(ctx, req.Header(), req.Peer()))
+
+	cs, err := s.client.GreetMany(ctx, req.Msg)
+	if err != nil {
+		return connectError(err)
+	}
+
+	return forwardResults(cs, stream.ResponseHeader(), stream.ResponseTrailer(), stream.Send)
+}
+
+// GreetChat implements the greeting.GreetingService/GreetChat method.
+// Bidirectional streams require HTTP/2, so this method is not available over gRPC-Web or HTTP/1.1.
+func (s *connectGreetingService) GreetChat(ctx context.Context, stream *connect.BidiStream[greetingpb.GreetRequest, greetingpb.GreetResult]) error {
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ctx, connectMetadata(ctx, stream.RequestHeader(), stream.Peer())))
+	defer cancel()
+
+	cs, err := s.client.GreetChat(ctx)
+	if err != nil {
+		return connectError(err)
+	}
+
+	// Requests are forwarded concurrently with the results, so neither side waits for the other.
+	go func() {
+		for {
+			req, err := stream.Receive()
+			if errors.Is(err, io.EOF) {
+				_ = cs.CloseSend()
+				return
+			}
+
+			if err != nil {
+				cancel()
+				return
+			}
+
+			// The stream has ended if sending fails, and its status is returned by receiving the results
+			if err := cs.Send(req); err != nil {
+				return
+			}
+		}
+	}()
+
+	return forwardResults(cs, stream.ResponseHeader(), stream.ResponseTrailer(), stream.Send)
+}
+
+// resultStream is a gRPC client stream receiving greeting results.
+type resultStream interface {
+	grpc.ClientStream
+	Recv() (*greetingpb.GreetResult, error)
+}
+
+// forwardResults sends the results received from a gRPC stream until it ends,
+// and forwards its header and trailer metadata as response headers and trailers.
+// Results are received only as fast as they are sent, so flow control applies end to end.
+func forwardResults(cs resultStream, header, trailer http.Header, send func(*greetingpb.GreetResult) error) error {
+	if md, err := cs.Header(); err == nil {
+		copyMetadata(header, md)
+	}
+
+	for {
+		res, err := cs.Recv()
+		if err == io.EOF {
+			copyMetadata(trailer, cs.Trailer())
+			return nil
+		}
+
+		if err != nil {
+			connectErr := connectError(err)
+			copyMetadata(connectErr.Meta(), cs.Trailer())
+			return connectErr
+		}
+
+		if err := send(res); err != nil {
+			return err
+		}
+	}
+}
+
+// connectMetadata returns the gRPC request metadata for a call.
+// Like the gateway, the address of the HTTP client is appended to the x-forwarded-for metadata,
+// and the key of the TLS connection state of the HTTP client is set as the x-forwarded-tls metadata.
+func connectMetadata(ctx context.Context, h http.Header, p connect.Peer) metadata.MD {
+	md := metadata.Join(forwardedTLSMetadataFromContext(ctx))
+	for _, key := range connectForwardedHeaders {
+		if vals := h.Values(key); len(vals) > 0 {
+			md.Set(key, vals...)
+		}
+	}
+
+	if host, _, err := net.SplitHostPort(p.Addr); err == nil {
+		if fwd := h.Get(forwardedForMetadata); fwd != "" {
+			md.Set(forwardedForMetadata, fwd+", "+host)
+		} else {
+			md.Set(forwardedForMetadata, host)
+		}
+	}
+
+	return md
+}
+
+// connectError converts a gRPC status error to a Connect error with the same code, message, and details.
+func connectError(err error) *connect.Error {
+	st := status.Convert(err)
+	connectErr := connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+
+	for _, d := range st.Proto().GetDetails() {
+		if detail, err := connect.NewErrorDetail(d); err == nil {
+			connectErr.AddDetail(detail)
+		}
+	}
+
+	return connectErr
+}
+
+// copyMetadata copies gRPC metadata to HTTP headers.
+// The reserved content-type and grpc- prefixed metadata are not copied, since they belong to the gRPC call.
+func copyMetadata(h http.Header, md metadata.MD) {
+	for key, vals := range md {
+		if key == "content-type" || strings.HasPrefix(key, "grpc-") {
+			continue
+		}
+
+		for _, val := range vals {
+			if strings.HasSuffix(key, "-bin") {
+				val = connect.EncodeBinaryHeader([]byte(val))
+			}
+			h.Add(key, val)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"grpc-service-horizontal/internal/auth"
+	"grpc-service-horizontal/internal/idl/greetingpb"
+)
+
+const (
+	// forwardedForMetadata is the request metadata the gateway appends the address of HTTP clients to.
+	forwardedForMetadata = "x-forwarded-for"
+	// forwardedTLSMetadata is the request metadata carrying the key of the TLS connection state of an HTTP client.
+	forwardedTLSMetadata = "x-forwarded-tls"
+)
+
+// forwardedTLS holds the TLS connection states of the HTTP clients of in-flight requests by random keys.
+// The in-process server only trusts the keys found here, so HTTP clients cannot forge TLS connection states.
+var forwardedTLS sync.Map
+
+// forwardedTLSKey is the context key for the key of the TLS connection state of an HTTP client.
+type forwardedTLSKey struct{}
+
+// NewGateway creates an http handler serving the gRPC services as REST/JSON APIs defined by their google.api.http annotations.
+// Requests and responses are encoded using the protobuf JSON mapping, and gRPC status codes are mapped to HTTP status codes.
+// The x-api-key and authorization headers are forwarded as request metadata, and so are the headers prefixed with Grpc-Metadata-.
+// The rate limit header metadata are forwarded as response headers, and other header metadata are prefixed with Grpc-Metadata-.
+func NewGateway(ctx context.Context, conn *grpc.ClientConn) (http.Handler, error) {
+	mux := runtime.NewServeMux(
+		runtime.WithIncomingHeaderMatcher(incomingHeaderMatcher),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeaderMatcher),
+		runtime.WithMetadata(func(ctx context.Context, _ *http.Request) metadata.MD {
+			return forwardedTLSMetadataFromContext(ctx)
+		}),
+	)
+
+	if err := greetingpb.RegisterGreetingServiceHandler(ctx, mux, conn); err != nil {
+		return nil, err
+	}
+
+	return withForwardedTLS(mux), nil
+}
+
+// withForwardedTLS wraps an http handler with holding the TLS connection state of every request until the request is handled,
+// so the in-process server can identify the HTTP client by its TLS connection state (e.g. its verified certificate).
+func withForwardedTLS(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		key := rand.Text()
+		forwardedTLS.Store(key, r.TLS)
+		defer forwardedTLS.Delete(key)
+
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), forwardedTLSKey{}, key)))
+	})
+}
+
+// forwardedTLSMetadataFromContext returns the request metadata carrying the key of the TLS connection state of an HTTP client if any.
+func forwardedTLSMetadataFromContext(ctx context.Context) metadata.MD {
+	if key, ok := ctx.Value(forwardedTLSKey{}).(string); ok {
+		return metadata.Pairs(forwardedTLSMetadata, key)
+	}
+
+	return nil
+}
+
+// incomingHeaderMatcher determines the HTTP request headers forwarded as gRPC request metadata.
+func incomingHeaderMatcher(key string) (string, bool) {
+	if strings.EqualFold(key, auth.APIKeyMetadata) {
+		return auth.APIKeyMetadata, true
+	}
+
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// outgoingHeaderMatcher determines the gRPC response header metadata forwarded as HTTP response headers.
+func outgoingHeaderMatcher(key string) (string, bool) {
+	if strings.HasPrefix(key, "ratelimit-") || key == "retry-after" {
+		return key, true
+	}
+
+	return runtime.MetadataHeaderPrefix + key, true
+}
+
+// forwardedPeer is a grpc.UnaryServerInterceptor for the in-process server.
+// The calls transcoded by the gateway all have the in-process peer,
+// so the peer address is replaced by the address of the HTTP client, which the gateway appends to the x-forwarded-for metadata,
+// and the peer auth info is replaced by the TLS connection state of the HTTP client, which the gateway holds by the x-forwarded-tls metadata.
+func forwardedPeer(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return handler(withForwardedPeer(ctx), req)
+}
+
+// forwardedPeerStream is the grpc.StreamServerInterceptor equivalent of forwardedPeer.
+func forwardedPeerStream(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{
+		ServerStream: ss,
+		ctx:          withForwardedPeer(ss.Context()),
+	})
+}
+
+// serverStream is a grpc.ServerStream with a different context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func withForwardedPeer(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	p := new(peer.Peer)
+	if local, ok := peer.FromContext(ctx); ok {
+		*p = *local
+	}
+
+	addr, addrOK := forwardedAddr(md)
+	if addrOK {
+		p.Addr = addr
+	}
+
+	state, stateOK := forwardedTLSState(md)
+	if stateOK {
+		p.AuthInfo = credentials.TLSInfo{
+			State: *state,
+			CommonAuthInfo: credentials.CommonAuthInfo{
+				SecurityLevel: credentials.PrivacyAndIntegrity,
+			},
+		}
+	}
+
+	if !addrOK && !stateOK {
+		return ctx
+	}
+
+	return peer.NewContext(ctx, p)
+}
+
+// forwardedAddr returns the address of the HTTP client appended to the x-forwarded-for metadata.
+func forwardedAddr(md metadata.MD) (net.Addr, bool) {
+	vals := md.Get(forwardedForMetadata)
+	if len(vals) == 0 {
+		return nil, false
+	}
+
+	addrs := strings.Split(vals[len(vals)-1], ",")
+	addr, err := netip.ParseAddr(strings.TrimSpace(addrs[len(addrs)-1]))
+	if err != nil {
+		return nil, false
+	}
+
+	return net.TCPAddrFromAddrPort(netip.AddrPortFrom(addr, 0)), true
+}
+
+// forwardedTLSState returns the TLS connection state of the HTTP client held by the x-forwarded-tls metadata.
+func forwardedTLSState(md metadata.MD) (*tls.ConnectionState, bool) {
+	for _, key := range md.Get(forwardedTLSMetadata) {
+		if state, ok := forwardedTLS.Load(key); ok {
+			return state.(*tls.ConnectionState), true
+		}
+	}
+
+	return nil, false
+}
@@ -3,18 +3,43 @@ package server
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"fmt"
 	"net"
+	"time"
 
+	"github.com/gardenbed/basil/health"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/test/bufconn"
 
+	"grpc-service-horizontal/internal/certs"
 	"grpc-service-horizontal/internal/handler"
 	"grpc-service-horizontal/internal/idl/greetingpb"
 )
 
-const defaultGRPCPort = 9090
+const (
+	defaultGRPCPort = 9090
+
+	// localBufferSize is the buffer size of the in-memory listener for in-process calls.
+	localBufferSize = 1 << 20
+)
+
+// Security is a transport security mode for a gRPC server.
+type Security string
+
+const (
+	// SecurityInsecure serves plaintext gRPC for local use.
+	SecurityInsecure Security = "insecure"
+	// SecurityDev serves TLS using a self-signed certificate generated in memory for development (see NewDevTLSConfig).
+	SecurityDev Security = "dev"
+	// SecurityTLS serves TLS using the certificate of a TLS configuration.
+	SecurityTLS Security = "tls"
+	// SecurityMTLS serves TLS and requires client certificates verified by the client CAs of a TLS configuration.
+	SecurityMTLS Security = "mtls"
+)
 
 // grpcServer is an interface for grpc.Server struct.
 type grpcServer interface {
@@ -26,6 +51,11 @@ type grpcServer interface {
 type GRPC struct {
 	addr   string
 	server grpcServer
+	health *healthServer
+
+	// local serves the gRPC services in-process for the gateway transcoding other protocols to gRPC.
+	local    grpcServer
+	localLis *bufconn.Listener
 }
 
 // GRPCOptions are optional settings for creating a grpc server.
@@ -33,10 +63,23 @@ type GRPCOptions struct {
 	// The port number for the gRPC server.
 	// The default port number is 9090.
 	Port uint16
-	// A TLS certificate for the gRPC server identity.
-	TLSCert *tls.Certificate
-	// A pool of certificate authorities for verifying gRPC client identities.
-	ClientCA *x509.CertPool
+	// The transport security mode for the gRPC server.
+	// The default mode is SecurityInsecure.
+	Security Security
+	// A TLS configuration for the gRPC server identity and verifying gRPC client identities.
+	// It is required for the SecurityTLS and SecurityMTLS modes and must be nil for the SecurityInsecure mode.
+	// It is optional for the SecurityDev mode, and a self-signed certificate is generated if not set.
+	TLSConfig *tls.Config
+	// Health checkers determining the status of the gRPC services for the grpc.health.v1.Health service.
+	HealthCheckers []health.Checker
+	// The timeout for health checking the health checkers.
+	// The default timeout is 5 seconds.
+	HealthCheckTimeout time.Duration
+	// The interval for health checking watched services.
+	// The default interval is 5 seconds.
+	HealthWatchInterval time.Duration
+	// Whether to register the gRPC server reflection service.
+	Reflection bool
 	// Additional options for the gRPC server.
 	Options []grpc.ServerOption
 }
@@ -47,34 +90,167 @@ func NewGRPC(greetingHandler handler.GreetingHandler, opts GRPCOptions) (*GRPC,
 		opts.Port = defaultGRPCPort
 	}
 
-	tlsConfig := new(tls.Config)
-	if opts.TLSCert != nil {
-		tlsConfig.Certificates = []tls.Certificate{*opts.TLSCert}
+	if opts.Security == "" {
+		opts.Security = SecurityInsecure
 	}
-	if opts.ClientCA != nil {
-		tlsConfig.ClientCAs = opts.ClientCA
-		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+	creds, err := serverCredentials(opts.Security, opts.TLSConfig)
+	if err != nil {
+		return nil, err
 	}
 
 	grpcOpts := []grpc.ServerOption{
-		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.Creds(creds),
 	}
 	grpcOpts = append(grpcOpts, opts.Options...)
 
 	server := grpc.NewServer(grpcOpts...)
 	greetingpb.RegisterGreetingServiceServer(server, greetingHandler)
 
+	// The status of every service registered so far is determined by the health checkers
+	services := make([]string, 0)
+	for name := range server.GetServiceInfo() {
+		services = append(services, name)
+	}
+
+	healthServer := newHealthServer(opts.HealthCheckers, services, opts.HealthCheckTimeout, opts.HealthWatchInterval)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	if opts.Reflection {
+		reflection.Register(server)
+	}
+
+	// The in-process server does not need transport security, but it intercepts calls just as the network server.
+	localOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(forwardedPeer),
+		grpc.ChainStreamInterceptor(forwardedPeerStream),
+	}
+	localOpts = append(localOpts, opts.Options...)
+
+	local := grpc.NewServer(localOpts...)
+	greetingpb.RegisterGreetingServiceServer(local, greetingHandler)
+
 	return &GRPC{
-		addr:   fmt.Sprintf(":%d", opts.Port),
-		server: server,
+		addr:     fmt.Sprintf(":%d", opts.Port),
+		server:   server,
+		health:   healthServer,
+		local:    local,
+		localLis: bufconn.Listen(localBufferSize),
 	}, nil
 }
 
+// serverCredentials returns the transport credentials for a security mode.
+func serverCredentials(security Security, tlsConfig *tls.Config) (credentials.TransportCredentials, error) {
+	tlsConfig, err := serverTLSConfig(security, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig == nil {
+		return insecure.NewCredentials(), nil
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// serverTLSConfig returns the TLS configuration for a security mode.
+// It returns nil for the SecurityInsecure mode.
+func serverTLSConfig(security Security, tlsConfig *tls.Config) (*tls.Config, error) {
+	switch security {
+	case SecurityInsecure:
+		if tlsConfig != nil {
+			return nil, fmt.Errorf("%s security mode does not use a tls config", security)
+		}
+	case SecurityDev:
+	case SecurityTLS, SecurityMTLS:
+		if tlsConfig == nil {
+			return nil, fmt.Errorf("%s security mode requires a tls config", security)
+		}
+	default:
+		return nil, fmt.Errorf("unknown security mode %q: expected %s, %s, %s, or %s", security, SecurityInsecure, SecurityDev, SecurityTLS, SecurityMTLS)
+	}
+
+	switch security {
+	case SecurityDev:
+		if tlsConfig != nil {
+			return tlsConfig, nil
+		}
+		return NewDevTLSConfig()
+
+	case SecurityTLS:
+		return tlsConfig, nil
+
+	case SecurityMTLS:
+		return requireClientCert(tlsConfig)
+
+	default:
+		return nil, nil
+	}
+}
+
+// NewDevTLSConfig creates a TLS configuration with a self-signed certificate generated in memory for the SecurityDev mode.
+// The gRPC and HTTP servers should share the configuration, so they present the same certificate.
+func NewDevTLSConfig() (*tls.Config, error) {
+	cert, err := certs.NewSelfSigned()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate self-signed certificate: %w", err)
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// requireClientCert returns a copy of a TLS configuration that requires and verifies client certificates.
+// Handshakes fail if the configuration does not have client CAs, so client certificates are never verified by the system roots.
+func requireClientCert(tlsConfig *tls.Config) (*tls.Config, error) {
+	c := tlsConfig.Clone()
+	c.ClientAuth = tls.RequireAndVerifyClientCert
+
+	// The configuration is provided per connection (e.g. for reloading certificates)
+	if getConfigForClient := c.GetConfigForClient; getConfigForClient != nil {
+		c.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			cc, err := getConfigForClient(hello)
+			if err != nil || cc == nil {
+				return cc, err
+			}
+
+			if cc.ClientCAs == nil {
+				return nil, fmt.Errorf("%s security mode requires client cas", SecurityMTLS)
+			}
+
+			cc.ClientAuth = tls.RequireAndVerifyClientCert
+			return cc, nil
+		}
+
+		return c, nil
+	}
+
+	if c.ClientCAs == nil {
+		return nil, fmt.Errorf("%s security mode requires client cas", SecurityMTLS)
+	}
+
+	return c, nil
+}
+
 // String returns the name of the server.
 func (s *GRPC) String() string {
 	return "grpc-server"
 }
 
+// LocalConn creates a client connection to the gRPC services served in-process.
+// Calls made over this connection go through the same interceptors as the calls over the network.
+func (s *GRPC) LocalConn() (*grpc.ClientConn, error) {
+	return grpc.NewClient("passthrough:///local",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return s.localLis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+}
+
 // ListenAndServe starts listening for incoming requests synchronously.
 // It blocks the current goroutine until an error is returned.
 func (s *GRPC) ListenAndServe() error {
@@ -83,17 +259,31 @@ func (s *GRPC) ListenAndServe() error {
 		return err
 	}
 
+	if s.local != nil {
+		go func() {
+			_ = s.local.Serve(s.localLis)
+		}()
+	}
+
 	// Synchronous/Blocking
 	return s.server.Serve(lis)
 }
 
 // Shutdown gracefully stops the server.
+// All services are reported as not serving by the health service as soon as the shutdown begins.
 // It stops accepting new conenctions and blocks the current goroutine until all the pending requests are completed.
 // If the context is cancelled, an error will be returned.
 func (s *GRPC) Shutdown(ctx context.Context) error {
+	if s.health != nil {
+		s.health.shutdown()
+	}
+
 	done := make(chan struct{}, 1)
 	go func() {
 		s.server.GracefulStop()
+		if s.local != nil {
+			s.local.GracefulStop()
+		}
 		done <- struct{}{}
 	}()
 
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gardenbed/basil/health"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// checker is a health.Checker for testing.
+type checker struct {
+	name  string
+	check func(context.Context) error
+}
+
+func newChecker(name string, err error) *checker {
+	return &checker{
+		name: name,
+		check: func(context.Context) error {
+			return err
+		},
+	}
+}
+
+func (c *checker) String() string {
+	return c.name
+}
+
+func (c *checker) HealthCheck(ctx context.Context) error {
+	return c.check(ctx)
+}
+
+func TestHealthServer_Check(t *testing.T) {
+	const service = "greeting.GreetingService"
+
+	tests := []struct {
+		name           string
+		checkers       []health.Checker
+		shutdown       bool
+		service        string
+		expectedStatus healthpb.HealthCheckResponse_ServingStatus
+		expectedError  string
+	}{
+		{
+			name:           "Server_Serving",
+			checkers:       []health.Checker{newChecker("redis-client", nil), newChecker("http-client", nil)},
+			service:        "",
+			expectedStatus: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:           "Service_Serving",
+			checkers:       []health.Checker{newChecker("redis-client", nil), newChecker("http-client", nil)},
+			service:        service,
+			expectedStatus: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:           "Service_NotServing",
+			checkers:       []health.Checker{newChecker("redis-client", errors.New("redis error")), newChecker("http-client", nil)},
+			service:        service,
+			expectedStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:           "Checker_Serving",
+			checkers:       []health.Checker{newChecker("redis-client", errors.New("redis error")), newChecker("http-client", nil)},
+			service:        "http-client",
+			expectedStatus: healthpb.HealthCheckResponse_SERVING,
+		},
+		{
+			name:           "Checker_NotServing",
+			checkers:       []health.Checker{newChecker("redis-client", errors.New("redis error")), newChecker("http-client", nil)},
+			service:        "redis-client",
+			expectedStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:           "ShuttingDown",
+			checkers:       []health.Checker{newChecker("redis-client", nil)},
+			shutdown:       true,
+			service:        service,
+			expectedStatus: healthpb.HealthCheckResponse_NOT_SERVING,
+		},
+		{
+			name:          "UnknownService",
+			checkers:      []health.Checker{newChecker("redis-client", nil)},
+			service:       "unknown",
+			expectedError: `rpc error: code = NotFound desc = unknown service "unknown"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newHealthServer(tc.checkers, []string{service}, 0, 0)
+			if tc.shutdown {
+				s.shutdown()
+			}
+
+			resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{Service: tc.service})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStatus, resp.Status)
+			} else {
+				assert.Nil(t, resp)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestHealthServer_Check_Timeout(t *testing.T) {
+	slow := &checker{
+		name: "slow-client",
+		check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	s := newHealthServer([]health.Checker{slow}, nil, 10*time.Millisecond, 0)
+	resp, err := s.Check(context.Background(), &healthpb.HealthCheckRequest{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
+
+func TestHealthServer_List(t *testing.T) {
+	s := newHealthServer([]health.Checker{
+		newChecker("redis-client", errors.New("redis error")),
+		newChecker("http-client", nil),
+	}, []string{"greeting.GreetingService"}, 0, 0)
+
+	resp, err := s.List(context.Background(), &healthpb.HealthListRequest{})
+	assert.NoError(t, err)
+
+	statuses := map[string]healthpb.HealthCheckResponse_ServingStatus{}
+	for name, st := range resp.Statuses {
+		statuses[name] = st.Status
+	}
+
+	assert.Equal(t, map[string]healthpb.HealthCheckResponse_ServingStatus{
+		"":                         healthpb.HealthCheckResponse_NOT_SERVING,
+		"greeting.GreetingService": healthpb.HealthCheckResponse_NOT_SERVING,
+		"redis-client":             healthpb.HealthCheckResponse_NOT_SERVING,
+		"http-client":              healthpb.HealthCheckResponse_SERVING,
+	}, statuses)
+}
+
+// assertWatch asserts the next status received on a watch stream.
+func assertWatch(t *testing.T, stream healthpb.Health_WatchClient, expected healthpb.HealthCheckResponse_ServingStatus) {
+	resp, err := stream.Recv()
+	if assert.NoError(t, err) {
+		assert.Equal(t, expected, resp.Status)
+	}
+}
+
+func TestHealthServer_Watch_UnknownService(t *testing.T) {
+	s, conn := newBufconnGRPC(t, GRPCOptions{})
+	defer s.server.GracefulStop()
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{Service: "unknown"})
+	assert.NoError(t, err)
+	assertWatch(t, stream, healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+}
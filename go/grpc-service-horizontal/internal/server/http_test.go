@@ -2,11 +2,18 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+
+	"grpc-service-horizontal/internal/certs"
 )
 
 func TestNewHTTP(t *testing.T) {
@@ -23,6 +30,14 @@ func TestNewHTTP(t *testing.T) {
 			}),
 			opts: HTTPOptions{},
 		},
+		{
+			name: "NoTLSConfig",
+			healthHandler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+			opts:          HTTPOptions{Security: SecurityTLS},
+			expectedError: "tls security mode requires a tls config",
+		},
 	}
 
 	for _, tc := range tests {
@@ -40,6 +55,377 @@ func TestNewHTTP(t *testing.T) {
 	}
 }
 
+func TestNewHTTP_Limits(t *testing.T) {
+	tests := []struct {
+		name                      string
+		opts                      HTTPOptions
+		expectedReadHeaderTimeout time.Duration
+		expectedIdleTimeout       time.Duration
+		expectedMaxHeaderBytes    int
+	}{
+		{
+			name:                      "Defaults",
+			opts:                      HTTPOptions{},
+			expectedReadHeaderTimeout: DefaultReadHeaderTimeout,
+			expectedIdleTimeout:       DefaultIdleTimeout,
+			expectedMaxHeaderBytes:    DefaultMaxHeaderBytes,
+		},
+		{
+			name: "Custom",
+			opts: HTTPOptions{
+				ReadHeaderTimeout: time.Second,
+				ReadTimeout:       2 * time.Second,
+				WriteTimeout:      3 * time.Second,
+				IdleTimeout:       4 * time.Second,
+				MaxHeaderBytes:    1024,
+			},
+			expectedReadHeaderTimeout: time.Second,
+			expectedIdleTimeout:       4 * time.Second,
+			expectedMaxHeaderBytes:    1024,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			healthHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			s, err := NewHTTP(healthHandler, tc.opts)
+			assert.NoError(t, err)
+
+			server := s.server.(*http.Server)
+			assert.Equal(t, tc.expectedReadHeaderTimeout, server.ReadHeaderTimeout)
+			// The read and write timeouts are set per request, so streams are not cut off
+			assert.Zero(t, server.ReadTimeout)
+			assert.Zero(t, server.WriteTimeout)
+			assert.Equal(t, tc.expectedIdleTimeout, server.IdleTimeout)
+			assert.Equal(t, tc.expectedMaxHeaderBytes, server.MaxHeaderBytes)
+		})
+	}
+}
+
+func TestNewHTTP_Routes(t *testing.T) {
+	healthHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	connect := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	tests := []struct {
+		name               string
+		gateway            http.Handler
+		path               string
+		expectedStatusCode int
+	}{
+		{"Health", nil, "/health", 200},
+		{"NoGateway", nil, "/v1/greet", 404},
+		{"Health_WithGateway", gateway, "/health", 200},
+		{"Gateway", gateway, "/v1/greet", 202},
+		{"Connect", nil, "/greeting.GreetingService/Greet", 201},
+		{"Connect_WithGateway", gateway, "/greeting.GreetingService/Greet", 201},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewHTTP(healthHandler, HTTPOptions{Gateway: tc.gateway, Connect: connect})
+			assert.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			s.server.(*http.Server).Handler.ServeHTTP(w, httptest.NewRequest("POST", tc.path, nil))
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+		})
+	}
+}
+
+func TestNewHTTP_Deadlines(t *testing.T) {
+	healthHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	slow := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		expectedError bool
+	}{
+		{"Gateway", "/v1/greet", true},
+		{"Unary", "/greeting.GreetingService/Greet", true},
+		{"ServerStream", "/greeting.GreetingService/GreetMany", false},
+		{"BidiStream", "/greeting.GreetingService/GreetChat", false},
+	}
+
+	s, err := NewHTTP(healthHandler, HTTPOptions{
+		WriteTimeout: 50 * time.Millisecond,
+		Gateway:      slow,
+		Connect:      slow,
+	})
+	assert.NoError(t, err)
+
+	ts := httptest.NewServer(s.server.(*http.Server).Handler)
+	defer ts.Close()
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := http.Post(ts.URL+tc.path, "application/json", nil)
+
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+				res.Body.Close()
+			}
+		})
+	}
+}
+
+func TestNewHTTP_Protocols(t *testing.T) {
+	tests := []struct {
+		name                     string
+		opts                     HTTPOptions
+		expectedHTTP2            bool
+		expectedUnencryptedHTTP2 bool
+		expectedTLS              bool
+	}{
+		{
+			name:                     "Insecure",
+			opts:                     HTTPOptions{},
+			expectedHTTP2:            false,
+			expectedUnencryptedHTTP2: true,
+			expectedTLS:              false,
+		},
+		{
+			name:                     "Dev",
+			opts:                     HTTPOptions{Security: SecurityDev},
+			expectedHTTP2:            true,
+			expectedUnencryptedHTTP2: false,
+			expectedTLS:              true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewHTTP(http.NotFoundHandler(), tc.opts)
+			assert.NoError(t, err)
+
+			protocols := s.server.(*http.Server).Protocols
+			assert.True(t, protocols.HTTP1())
+			assert.Equal(t, tc.expectedHTTP2, protocols.HTTP2())
+			assert.Equal(t, tc.expectedUnencryptedHTTP2, protocols.UnencryptedHTTP2())
+			assert.Equal(t, tc.expectedTLS, s.tls)
+		})
+	}
+}
+
+func TestNewHTTP_SharedDevTLSConfig(t *testing.T) {
+	tlsConfig, err := NewDevTLSConfig()
+	assert.NoError(t, err)
+
+	s, err := NewHTTP(http.NotFoundHandler(), HTTPOptions{
+		Security:  SecurityDev,
+		TLSConfig: tlsConfig,
+	})
+	assert.NoError(t, err)
+
+	// The HTTP server presents the same self-signed certificate as the gRPC server
+	assert.Same(t, tlsConfig, s.server.(*http.Server).TLSConfig)
+}
+
+func TestHTTP_Security(t *testing.T) {
+	serverCert, err := certs.NewSelfSigned()
+	assert.NoError(t, err)
+
+	clientCert, err := certs.NewSelfSigned()
+	assert.NoError(t, err)
+
+	serverCAs := x509.NewCertPool()
+	serverCAs.AddCert(serverCert.Leaf)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.Leaf)
+
+	tests := []struct {
+		name               string
+		opts               HTTPOptions
+		scheme             string
+		clientTLSConfig    *tls.Config
+		expectedStatusCode int
+		expectedError      bool
+	}{
+		{
+			name:               "Insecure",
+			opts:               HTTPOptions{Security: SecurityInsecure},
+			scheme:             "http",
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "Dev",
+			opts:               HTTPOptions{Security: SecurityDev},
+			scheme:             "https",
+			clientTLSConfig:    &tls.Config{InsecureSkipVerify: true},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "TLS",
+			opts: HTTPOptions{
+				Security:  SecurityTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}},
+			},
+			scheme:             "https",
+			clientTLSConfig:    &tls.Config{ServerName: "localhost", RootCAs: serverCAs},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "TLS_PlaintextClient",
+			opts: HTTPOptions{
+				Security:  SecurityTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}},
+			},
+			scheme:             "http",
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "MTLS",
+			opts: HTTPOptions{
+				Security:  SecurityMTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}, ClientCAs: clientCAs},
+			},
+			scheme: "https",
+			clientTLSConfig: &tls.Config{
+				ServerName:   "localhost",
+				RootCAs:      serverCAs,
+				Certificates: []tls.Certificate{*clientCert},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "MTLS_NoClientCert",
+			opts: HTTPOptions{
+				Security:  SecurityMTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}, ClientCAs: clientCAs},
+			},
+			scheme:          "https",
+			clientTLSConfig: &tls.Config{ServerName: "localhost", RootCAs: serverCAs},
+			expectedError:   true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewHTTP(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}), tc.opts)
+			assert.NoError(t, err)
+
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			assert.NoError(t, err)
+
+			server := s.server.(*http.Server)
+			go func() {
+				if s.tls {
+					_ = server.ServeTLS(lis, "", "")
+				} else {
+					_ = server.Serve(lis)
+				}
+			}()
+			defer server.Close()
+
+			client := &http.Client{
+				Transport: &http.Transport{TLSClientConfig: tc.clientTLSConfig},
+			}
+
+			resp, err := client.Get(tc.scheme + "://" + lis.Addr().String() + "/health")
+
+			if tc.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+				resp.Body.Close()
+			}
+		})
+	}
+}
+
+func TestNewHTTP_CORS(t *testing.T) {
+	connect := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		name                string
+		cors                CORSOptions
+		origin              string
+		expectedAllowOrigin string
+		expectedMaxAge      string
+	}{
+		{
+			name:                "Disabled",
+			cors:                CORSOptions{},
+			origin:              "https://app.example.com",
+			expectedAllowOrigin: "",
+		},
+		{
+			name:                "AllowedOrigin",
+			cors:                CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			origin:              "https://app.example.com",
+			expectedAllowOrigin: "https://app.example.com",
+			expectedMaxAge:      "7200",
+		},
+		{
+			name:                "AllowedOrigin_MaxAge",
+			cors:                CORSOptions{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: time.Minute},
+			origin:              "https://app.example.com",
+			expectedAllowOrigin: "https://app.example.com",
+			expectedMaxAge:      "60",
+		},
+		{
+			name:                "AllOrigins",
+			cors:                CORSOptions{AllowedOrigins: []string{"*"}},
+			origin:              "https://other.example.com",
+			expectedAllowOrigin: "*",
+			expectedMaxAge:      "7200",
+		},
+		{
+			name:                "DisallowedOrigin",
+			cors:                CORSOptions{AllowedOrigins: []string{"https://app.example.com"}},
+			origin:              "https://evil.example.com",
+			expectedAllowOrigin: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewHTTP(http.NotFoundHandler(), HTTPOptions{
+				Connect: connect,
+				CORS:    tc.cors,
+			})
+			assert.NoError(t, err)
+
+			r := httptest.NewRequest("OPTIONS", "/greeting.GreetingService/Greet", nil)
+			r.Header.Set("Origin", tc.origin)
+			r.Header.Set("Access-Control-Request-Method", "POST")
+			r.Header.Set("Access-Control-Request-Headers", "connect-protocol-version,content-type,x-api-key")
+			w := httptest.NewRecorder()
+			s.server.(*http.Server).Handler.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedAllowOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tc.expectedMaxAge, w.Header().Get("Access-Control-Max-Age"))
+		})
+	}
+}
+
 func TestHTTP_String(t *testing.T) {
 	s := new(HTTP)
 	assert.Equal(t, "http-server", s.String())
@@ -73,6 +459,30 @@ func TestHTTP_ListenAndServe(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "TLS_ListenFails",
+			s: &HTTP{
+				server: &MockHTTPServer{
+					ListenAndServeTLSMocks: []ListenAndServeTLSMock{
+						{OutError: errors.New("error on listening")},
+					},
+				},
+				tls: true,
+			},
+			expectedError: "error on listening",
+		},
+		{
+			name: "TLS_ServerClosed",
+			s: &HTTP{
+				server: &MockHTTPServer{
+					ListenAndServeTLSMocks: []ListenAndServeTLSMock{
+						{OutError: http.ErrServerClosed},
+					},
+				},
+				tls: true,
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
@@ -0,0 +1,111 @@
+// Package streaming observes the messages of streaming gRPC calls.
+// Calls are observed as a whole by the telemetry interceptors, so every message sent or received is also
+// counted, logged at the debug level, and recorded as an event on the span of its call.
+package streaming
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/gardenbed/basil/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Interceptor is a gRPC server interceptor for observing the messages of streaming calls.
+type Interceptor struct {
+	sent     metric.Int64Counter
+	received metric.Int64Counter
+}
+
+// NewInterceptor creates a new interceptor creating the message metrics using a meter.
+func NewInterceptor(meter metric.Meter) (*Interceptor, error) {
+	sent, err := meter.Int64Counter(
+		"grpc_stream_messages_sent_total",
+		metric.WithDescription("The total number of messages sent by grpc stream handlers (server-side)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	received, err := meter.Int64Counter(
+		"grpc_stream_messages_received_total",
+		metric.WithDescription("The total number of messages received by grpc stream handlers (server-side)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Interceptor{
+		sent:     sent,
+		received: received,
+	}, nil
+}
+
+// ServerOptions returns the gRPC server options for observing the messages of stream calls.
+func (i *Interceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainStreamInterceptor(i.streamInterceptor),
+	}
+}
+
+func (i *Interceptor) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{
+		ServerStream: ss,
+		interceptor:  i,
+		method:       info.FullMethod,
+	})
+}
+
+// serverStream is a grpc.ServerStream observing the messages sent and received.
+type serverStream struct {
+	grpc.ServerStream
+	interceptor *Interceptor
+	method      string
+	sent        atomic.Int64
+	received    atomic.Int64
+}
+
+func (s *serverStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	s.observe(s.interceptor.sent, "sent", s.sent.Add(1), err)
+	return err
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+
+	// The end of the stream is not a message
+	if err == io.EOF {
+		return err
+	}
+
+	s.observe(s.interceptor.received, "received", s.received.Add(1), err)
+	return err
+}
+
+// observe reports a message sent or received with its sequence number in the stream.
+func (s *serverStream) observe(counter metric.Int64Counter, direction string, seq int64, err error) {
+	ctx := s.Context()
+	success := err == nil
+
+	counter.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("method", s.method),
+		attribute.Bool("success", success),
+	))
+
+	trace.SpanFromContext(ctx).AddEvent("message "+direction, trace.WithAttributes(
+		attribute.Int64("message.seq", seq),
+		attribute.Bool("success", success),
+	))
+
+	fields := []any{"grpc.method", s.method, "message.seq", seq}
+	if err != nil {
+		fields = append(fields, "grpc.error", err.Error())
+	}
+
+	// The logger is contextualized with the call by the telemetry interceptor
+	telemetry.LoggerFromContext(ctx).Debug("message "+direction, fields...)
+}
@@ -0,0 +1,157 @@
+package streaming
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"google.golang.org/grpc"
+)
+
+// fakeStream is a grpc.ServerStream receiving a number of messages and sending messages with an error.
+type fakeStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	messages int
+	sendErr  error
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) SendMsg(m any) error {
+	return s.sendErr
+}
+
+func (s *fakeStream) RecvMsg(m any) error {
+	if s.messages == 0 {
+		return io.EOF
+	}
+
+	s.messages--
+	return nil
+}
+
+func TestNewInterceptor(t *testing.T) {
+	meter := metricsdk.NewMeterProvider().Meter("test")
+	i, err := NewInterceptor(meter)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, i)
+}
+
+func TestInterceptor_ServerOptions(t *testing.T) {
+	meter := metricsdk.NewMeterProvider().Meter("test")
+	i, err := NewInterceptor(meter)
+	assert.NoError(t, err)
+
+	assert.Len(t, i.ServerOptions(), 1)
+}
+
+func TestInterceptor(t *testing.T) {
+	const method = "/greeting.GreetingService/GreetChat"
+
+	tests := []struct {
+		name             string
+		received         int
+		sendErr          error
+		expectedReceived int64
+		expectedSent     int64
+		expectedSuccess  bool
+		expectedEvents   []string
+	}{
+		{
+			name:             "Success",
+			received:         2,
+			sendErr:          nil,
+			expectedReceived: 2,
+			expectedSent:     2,
+			expectedSuccess:  true,
+			expectedEvents:   []string{"message received", "message sent", "message received", "message sent"},
+		},
+		{
+			name:             "SendFails",
+			received:         1,
+			sendErr:          errors.New("stream closed"),
+			expectedReceived: 1,
+			expectedSent:     1,
+			expectedSuccess:  false,
+			expectedEvents:   []string{"message received", "message sent"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := metricsdk.NewManualReader()
+			meter := metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("test")
+
+			recorder := tracetest.NewSpanRecorder()
+			tracer := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(recorder)).Tracer("test")
+
+			i, err := NewInterceptor(meter)
+			assert.NoError(t, err)
+
+			ctx, span := tracer.Start(context.Background(), "GreetChat")
+			ss := &fakeStream{ctx: ctx, messages: tc.received, sendErr: tc.sendErr}
+			info := &grpc.StreamServerInfo{FullMethod: method}
+
+			// The handler echoes every message received until the end of the stream
+			err = i.streamInterceptor(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+				for {
+					if err := ss.RecvMsg(nil); err == io.EOF {
+						return nil
+					}
+
+					if err := ss.SendMsg(nil); err != nil {
+						return err
+					}
+				}
+			})
+			span.End()
+
+			assert.Equal(t, tc.sendErr, err)
+
+			var rm metricdata.ResourceMetrics
+			assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+			counts := map[string]int64{}
+			for _, sm := range rm.ScopeMetrics {
+				for _, m := range sm.Metrics {
+					for _, dp := range m.Data.(metricdata.Sum[int64]).DataPoints {
+						methodAttr, _ := dp.Attributes.Value("method")
+						assert.Equal(t, method, methodAttr.AsString())
+
+						if m.Name == "grpc_stream_messages_sent_total" {
+							successAttr, _ := dp.Attributes.Value("success")
+							assert.Equal(t, tc.expectedSuccess, successAttr.AsBool())
+						}
+
+						counts[m.Name] += dp.Value
+					}
+				}
+			}
+
+			assert.Equal(t, tc.expectedReceived, counts["grpc_stream_messages_received_total"])
+			assert.Equal(t, tc.expectedSent, counts["grpc_stream_messages_sent_total"])
+
+			spans := recorder.Ended()
+			if assert.Len(t, spans, 1) {
+				var events []string
+				for _, e := range spans[0].Events() {
+					events = append(events, e.Name)
+				}
+
+				assert.Equal(t, tc.expectedEvents, events)
+				assert.Contains(t, spans[0].Events()[0].Attributes, attribute.Int64("message.seq", 1))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"grpc-service-horizontal/internal/problem"
+)
+
+// Interceptor is a gRPC server interceptor for validating requests.
+type Interceptor struct{}
+
+// NewInterceptor creates a new interceptor validating requests against the constraints declared in the IDL files.
+// The constraints of every message of the files are checked, so unsupported constraints are reported on startup.
+func NewInterceptor(files ...protoreflect.FileDescriptor) (*Interceptor, error) {
+	seen := map[protoreflect.FullName]bool{}
+	for _, fd := range files {
+		messages := fd.Messages()
+		for i := 0; i < messages.Len(); i++ {
+			if err := check(messages.Get(i), seen); err != nil {
+				return nil, fmt.Errorf("invalid constraints in %s: %w", fd.Path(), err)
+			}
+		}
+	}
+
+	return &Interceptor{}, nil
+}
+
+// ServerOptions returns the gRPC server options for validating the requests of unary and stream calls.
+func (i *Interceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unaryInterceptor),
+		grpc.ChainStreamInterceptor(i.streamInterceptor),
+	}
+}
+
+// validateRequest validates a request if it is a protobuf message.
+// Invalid requests fail with the InvalidArgument code and a BadRequest detail with a violation for every invalid field.
+func validateRequest(req any) error {
+	m, ok := req.(proto.Message)
+	if !ok {
+		return nil
+	}
+
+	if err := Validate(m); err != nil {
+		return problem.Err(err)
+	}
+
+	return nil
+}
+
+func (i *Interceptor) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (i *Interceptor) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return handler(srv, &serverStream{ServerStream: ss})
+}
+
+// InvalidMessageError is the error of receiving an invalid message on a stream.
+// The message is received nevertheless, so a handler can report the failure of the message and keep receiving,
+// and the call fails with the InvalidArgument code if the error is returned by a handler instead.
+type InvalidMessageError struct {
+	Message proto.Message
+	Err     error
+}
+
+func (e *InvalidMessageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *InvalidMessageError) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus returns the gRPC status of the validation error.
+func (e *InvalidMessageError) GRPCStatus() *status.Status {
+	return status.Convert(e.Err)
+}
+
+// serverStream is a grpc.ServerStream validating every message received.
+// An invalid message is received with an InvalidMessageError.
+type serverStream struct {
+	grpc.ServerStream
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	if err := validateRequest(m); err != nil {
+		return &InvalidMessageError{Message: m.(proto.Message), Err: err}
+	}
+
+	return nil
+}
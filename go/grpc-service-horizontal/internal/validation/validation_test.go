@@ -0,0 +1,192 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"grpc-service-horizontal/internal/idl/greetingpb"
+	"grpc-service-horizontal/internal/problem"
+)
+
+// newUnsupportedMessage creates a message with a field having a constraint not supported by the package.
+func newUnsupportedMessage(t *testing.T) proto.Message {
+	opts := new(descriptorpb.FieldOptions)
+	proto.SetExtension(opts, validate.E_Field, &validate.FieldRules{
+		Type: &validate.FieldRules_Int32{
+			Int32: &validate.Int32Rules{
+				LessThan: &validate.Int32Rules_Lt{Lt: 10},
+			},
+		},
+	})
+
+	return newMessage(t, &descriptorpb.DescriptorProto{
+		Name: proto.String("Request"),
+		Field: []*descriptorpb.FieldDescriptorProto{
+			newInt32Field(opts, nil),
+		},
+	})
+}
+
+// newMessageRulesMessage creates a message having message constraints.
+func newMessageRulesMessage(t *testing.T) proto.Message {
+	opts := new(descriptorpb.MessageOptions)
+	proto.SetExtension(opts, validate.E_Message, &validate.MessageRules{
+		Cel: []*validate.Rule{{Id: proto.String("count"), Expression: proto.String("this.count < 10")}},
+	})
+
+	return newMessage(t, &descriptorpb.DescriptorProto{
+		Name:    proto.String("Request"),
+		Field:   []*descriptorpb.FieldDescriptorProto{newInt32Field(nil, nil)},
+		Options: opts,
+	})
+}
+
+// newOneofRulesMessage creates a message having a oneof with constraints.
+func newOneofRulesMessage(t *testing.T) proto.Message {
+	opts := new(descriptorpb.OneofOptions)
+	proto.SetExtension(opts, validate.E_Oneof, &validate.OneofRules{Required: proto.Bool(true)})
+
+	return newMessage(t, &descriptorpb.DescriptorProto{
+		Name:      proto.String("Request"),
+		Field:     []*descriptorpb.FieldDescriptorProto{newInt32Field(nil, proto.Int32(0))},
+		OneofDecl: []*descriptorpb.OneofDescriptorProto{{Name: proto.String("value"), Options: opts}},
+	})
+}
+
+// newInt32Field creates the descriptor of an int32 count field, in a oneof if the oneof index is not nil.
+func newInt32Field(opts *descriptorpb.FieldOptions, oneofIndex *int32) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:       proto.String("count"),
+		Number:     proto.Int32(1),
+		Label:      descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:       descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(),
+		JsonName:   proto.String("count"),
+		Options:    opts,
+		OneofIndex: oneofIndex,
+	}
+}
+
+// newMessage creates a dynamic message from a message descriptor.
+func newMessage(t *testing.T, md *descriptorpb.DescriptorProto) proto.Message {
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:        proto.String("test.proto"),
+		Package:     proto.String("test"),
+		Syntax:      proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{md},
+	}, nil)
+	assert.NoError(t, err)
+
+	return dynamicpb.NewMessage(fd.Messages().Get(0))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name               string
+		m                  proto.Message
+		expectedError      string
+		expectedViolations []problem.Violation
+	}{
+		{
+			name:               "Valid",
+			m:                  &greetingpb.GreetRequest{GithubUsername: "octo-cat"},
+			expectedError:      "",
+			expectedViolations: nil,
+		},
+		{
+			name:          "Required",
+			m:             &greetingpb.GreetRequest{GithubUsername: ""},
+			expectedError: "invalid greeting.GreetRequest",
+			expectedViolations: []problem.Violation{
+				{Field: "github_username", Message: "value is required"},
+			},
+		},
+		{
+			name:          "TooLongAndInvalidPattern",
+			m:             &greetingpb.GreetRequest{GithubUsername: strings.Repeat("a", 39) + "-"},
+			expectedError: "invalid greeting.GreetRequest",
+			expectedViolations: []problem.Violation{
+				{Field: "github_username", Message: "value length must be at most 39 characters"},
+				{Field: "github_username", Message: "value does not match regex pattern `^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`"},
+			},
+		},
+		{
+			name:               "ValidItems",
+			m:                  &greetingpb.GreetManyRequest{GithubUsernames: []string{"octocat", "hubot"}},
+			expectedError:      "",
+			expectedViolations: nil,
+		},
+		{
+			name:          "InvalidItems",
+			m:             &greetingpb.GreetManyRequest{GithubUsernames: []string{"octocat", "", "octo--cat"}},
+			expectedError: "invalid greeting.GreetManyRequest",
+			expectedViolations: []problem.Violation{
+				{Field: "github_usernames[1]", Message: "value length must be at least 1 characters"},
+				{Field: "github_usernames[1]", Message: "value does not match regex pattern `^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`"},
+				{Field: "github_usernames[2]", Message: "value does not match regex pattern `^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$`"},
+			},
+		},
+		{
+			name:          "TooManyItems",
+			m:             &greetingpb.GreetManyRequest{GithubUsernames: slices.Repeat([]string{"octocat"}, 101)},
+			expectedError: "invalid greeting.GreetManyRequest",
+			expectedViolations: []problem.Violation{
+				{Field: "github_usernames", Message: "value must contain no more than 100 item(s)"},
+			},
+		},
+		{
+			name: "NestedMessage",
+			m: &greetingpb.GreetResult{
+				GithubUsername: "octocat",
+				Error:          &status.Status{Code: 5, Message: "not found"},
+			},
+			expectedError:      "",
+			expectedViolations: nil,
+		},
+		{
+			name:               "UnsupportedConstraint",
+			m:                  newUnsupportedMessage(t),
+			expectedError:      "constraint buf.validate.FieldRules.int32 is not supported",
+			expectedViolations: nil,
+		},
+		{
+			name:               "UnsupportedMessageConstraint",
+			m:                  newMessageRulesMessage(t),
+			expectedError:      "constraint buf.validate.MessageRules.cel is not supported",
+			expectedViolations: nil,
+		},
+		{
+			name:               "UnsupportedOneofConstraint",
+			m:                  newOneofRulesMessage(t),
+			expectedError:      "constraint buf.validate.OneofRules.required is not supported",
+			expectedViolations: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.m)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.EqualError(t, err, tc.expectedError)
+
+			var e *problem.Error
+			if tc.expectedViolations != nil && assert.ErrorAs(t, err, &e) {
+				assert.Equal(t, problem.KindValidation, e.Kind)
+				assert.Equal(t, tc.expectedViolations, e.Violations)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/gardenbed/basil/config"
 	"github.com/gardenbed/basil/graceful"
@@ -11,11 +14,20 @@ import (
 	"github.com/gardenbed/basil/telemetry"
 	grpctelemetry "github.com/gardenbed/basil/telemetry/grpc"
 
+	"grpc-service-horizontal/internal/auth"
+	"grpc-service-horizontal/internal/breaker"
+	"grpc-service-horizontal/internal/certs"
 	"grpc-service-horizontal/internal/controller/greeting"
 	"grpc-service-horizontal/internal/gateway/github"
 	"grpc-service-horizontal/internal/handler"
+	"grpc-service-horizontal/internal/idl/greetingpb"
+	"grpc-service-horizontal/internal/locale"
+	"grpc-service-horizontal/internal/recovery"
+	"grpc-service-horizontal/internal/repository/ratelimit"
 	"grpc-service-horizontal/internal/repository/usercache"
 	"grpc-service-horizontal/internal/server"
+	"grpc-service-horizontal/internal/streaming"
+	"grpc-service-horizontal/internal/validation"
 	"grpc-service-horizontal/metadata"
 )
 
@@ -31,6 +43,40 @@ var configs = struct {
 	LogLevel               string
 	OpenTelemetryCollector string
 	RedisAddress           string
+	TemplatesDir           string
+	GithubURL              string
+	CacheNamespace         string
+	CacheTTL               time.Duration
+	NegativeCacheTTL       time.Duration
+	StaleCacheTTL          time.Duration
+	MaxConcurrentLookups   int
+	BreakerFailures        int
+	BreakerOpenTimeout     time.Duration
+	BreakerSuccesses       int
+	RateLimit              int
+	RateLimitPeriod        time.Duration
+	RateLimitBurst         int
+	AuthFailureLimit       int
+	AuthFailurePeriod      time.Duration
+	APIKeys                string
+	JWKSFile               string
+	JWKSURL                string `flag:"jwks.url" env:"JWKS_URL" fileenv:"JWKS_URL_FILE"`
+	JWTIssuer              string
+	JWTAudience            string
+	AuthScope              string
+	GRPCSecurity           string
+	GRPCReflection         bool
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSClientCAFile        string
+	TLSReloadInterval      time.Duration
+	HTTPReadHeaderTimeout  time.Duration
+	HTTPReadTimeout        time.Duration
+	HTTPWriteTimeout       time.Duration
+	HTTPIdleTimeout        time.Duration
+	HTTPMaxHeaderBytes     int
+	CORSAllowedOrigins     []string
+	CORSMaxAge             time.Duration
 }{
 	// Default Values
 	HTTPPort:               8080,
@@ -43,6 +89,40 @@ var configs = struct {
 	LogLevel:               "debug",
 	OpenTelemetryCollector: "localhost:55680",
 	RedisAddress:           "localhost:6379",
+	TemplatesDir:           "",
+	GithubURL:              github.DefaultBaseURL,
+	CacheNamespace:         "grpc-service-horizontal",
+	CacheTTL:               usercache.DefaultTTL,
+	NegativeCacheTTL:       usercache.DefaultNegativeTTL,
+	StaleCacheTTL:          usercache.DefaultStaleTTL,
+	MaxConcurrentLookups:   greeting.DefaultMaxConcurrentLookups,
+	BreakerFailures:        breaker.DefaultFailureThreshold,
+	BreakerOpenTimeout:     breaker.DefaultOpenTimeout,
+	BreakerSuccesses:       breaker.DefaultSuccessThreshold,
+	RateLimit:              ratelimit.DefaultLimit,
+	RateLimitPeriod:        ratelimit.DefaultPeriod,
+	RateLimitBurst:         0,
+	AuthFailureLimit:       ratelimit.DefaultFailureLimit,
+	AuthFailurePeriod:      ratelimit.DefaultPeriod,
+	APIKeys:                "",
+	JWKSFile:               "",
+	JWKSURL:                "",
+	JWTIssuer:              "",
+	JWTAudience:            "",
+	AuthScope:              "",
+	GRPCSecurity:           string(server.SecurityInsecure),
+	GRPCReflection:         false,
+	TLSCertFile:            "",
+	TLSKeyFile:             "",
+	TLSClientCAFile:        "",
+	TLSReloadInterval:      certs.DefaultReloadInterval,
+	HTTPReadHeaderTimeout:  server.DefaultReadHeaderTimeout,
+	HTTPReadTimeout:        server.DefaultReadTimeout,
+	HTTPWriteTimeout:       server.DefaultWriteTimeout,
+	HTTPIdleTimeout:        server.DefaultIdleTimeout,
+	HTTPMaxHeaderBytes:     server.DefaultMaxHeaderBytes,
+	CORSAllowedOrigins:     nil,
+	CORSMaxAge:             server.DefaultCORSMaxAge,
 }
 
 func main() {
@@ -81,7 +161,18 @@ func main() {
 
 	// CREATE GATEWAYS
 
-	githubGateway, err := github.NewGateway()
+	githubGateway, err := github.NewGateway(github.Options{
+		BaseURL: configs.GithubURL,
+		Breaker: breaker.Options{
+			FailureThreshold: configs.BreakerFailures,
+			OpenTimeout:      configs.BreakerOpenTimeout,
+			SuccessThreshold: configs.BreakerSuccesses,
+			OnStateChange: func(from, to breaker.State) {
+				probe.Logger().Warn("github circuit breaker state changed", "from", from.String(), "to", to.String())
+			},
+		},
+		Meter: probe.Meter(),
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create github gateway", "error", err)
 		panic(err)
@@ -89,15 +180,50 @@ func main() {
 
 	// CREATE REPOSITORIES
 
-	usercacheRepository, err := usercache.NewRepository(configs.RedisAddress)
+	usercacheRepository, err := usercache.NewRepository(configs.RedisAddress, usercache.Options{
+		Namespace:   configs.CacheNamespace,
+		TTL:         configs.CacheTTL,
+		NegativeTTL: configs.NegativeCacheTTL,
+		StaleTTL:    configs.StaleCacheTTL,
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create user cache repository", "error", err)
 		panic(err)
 	}
 
+	ratelimitRepository, err := ratelimit.NewRepository(configs.RedisAddress, ratelimit.Options{
+		Namespace: configs.Name,
+		Limit:     configs.RateLimit,
+		Period:    configs.RateLimitPeriod,
+		Burst:     configs.RateLimitBurst,
+	})
+	if err != nil {
+		probe.Logger().Error("failed to create rate limit repository", "error", err)
+		panic(err)
+	}
+
+	authFailureRepository, err := ratelimit.NewRepository(configs.RedisAddress, ratelimit.Options{
+		Namespace: configs.Name + "-auth",
+		Limit:     configs.AuthFailureLimit,
+		Period:    configs.AuthFailurePeriod,
+	})
+	if err != nil {
+		probe.Logger().Error("failed to create auth failure repository", "error", err)
+		panic(err)
+	}
+
 	// CREATE CONTROLLERS
 
-	greetingController, err := greeting.NewController(githubGateway, usercacheRepository)
+	catalog, err := locale.NewCatalog(configs.TemplatesDir)
+	if err != nil {
+		probe.Logger().Error("failed to create greeting templates catalog", "error", err)
+		panic(err)
+	}
+
+	greetingController, err := greeting.NewController(githubGateway, usercacheRepository, catalog, greeting.Options{
+		MaxConcurrentLookups: configs.MaxConcurrentLookups,
+		Meter:                probe.Meter(),
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create greeting controller", "error", err)
 		panic(err)
@@ -111,11 +237,61 @@ func main() {
 		panic(err)
 	}
 
+	rateLimitInterceptor := handler.NewRateLimitInterceptor(ratelimitRepository, probe.Logger())
+
 	// CREATE SERVERS
 
+	recoveryInterceptor := recovery.NewInterceptor(probe.Logger())
+
+	streamingInterceptor, err := streaming.NewInterceptor(probe.Meter())
+	if err != nil {
+		probe.Logger().Error("failed to create streaming interceptor", "error", err)
+		panic(err)
+	}
+
+	grpcOpts := append(serverInterceptor.ServerOptions(), recoveryInterceptor.ServerOptions()...)
+	grpcOpts = append(grpcOpts, streamingInterceptor.ServerOptions()...)
+
+	authenticator, err := newAuthenticator(ctx)
+	if err != nil {
+		probe.Logger().Error("failed to create authenticator", "error", err)
+		panic(err)
+	}
+
+	if authenticator != nil {
+		// Failed authentications are limited before authentication, so credentials cannot be brute forced
+		authFailureInterceptor := handler.NewAuthFailureInterceptor(authFailureRepository, probe.Logger())
+		grpcOpts = append(grpcOpts, authFailureInterceptor.ServerOptions()...)
+		grpcOpts = append(grpcOpts, auth.NewInterceptor(authenticator, configs.AuthScope).ServerOptions()...)
+	} else {
+		probe.Logger().Warn("authentication is disabled because no api keys or jwks are configured")
+	}
+
+	// Calls are rate limited once they are authenticated, so clients are identified by their principals
+	grpcOpts = append(grpcOpts, rateLimitInterceptor.ServerOptions()...)
+
+	validationInterceptor, err := validation.NewInterceptor(greetingpb.File_greetingpb_greeting_proto)
+	if err != nil {
+		probe.Logger().Error("failed to create validation interceptor", "error", err)
+		panic(err)
+	}
+
+	// Requests are validated against the constraints declared in the IDL once they are authenticated and rate limited
+	grpcOpts = append(grpcOpts, validationInterceptor.ServerOptions()...)
+
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		probe.Logger().Error("failed to create tls config", "error", err)
+		panic(err)
+	}
+
 	grpcServer, err := server.NewGRPC(greetingHandler, server.GRPCOptions{
-		Port:    configs.GRPCPort,
-		Options: serverInterceptor.ServerOptions(),
+		Port:           configs.GRPCPort,
+		Security:       server.Security(configs.GRPCSecurity),
+		TLSConfig:      tlsConfig,
+		HealthCheckers: []health.Checker{githubGateway, usercacheRepository, ratelimitRepository, authFailureRepository},
+		Reflection:     configs.GRPCReflection,
+		Options:        grpcOpts,
 	})
 
 	if err != nil {
@@ -123,13 +299,41 @@ func main() {
 		panic(err)
 	}
 
+	// Create an HTTP gateway for serving the gRPC services as REST/JSON APIs
+	// and an HTTP handler for serving the gRPC services over the Connect and gRPC-Web protocols through the in-process gRPC server
+	grpcConn, err := grpcServer.LocalConn()
+	if err != nil {
+		probe.Logger().Error("failed to create in-process grpc connection", "error", err)
+		panic(err)
+	}
+
+	httpGateway, err := server.NewGateway(ctx, grpcConn)
+	if err != nil {
+		probe.Logger().Error("failed to create http gateway", "error", err)
+		panic(err)
+	}
+
 	// Create an HTTP health handler for health checking the service by external systems
 	health.SetLogger(probe.Logger())
-	health.RegisterChecker(githubGateway, usercacheRepository)
+	health.RegisterChecker(githubGateway, usercacheRepository, ratelimitRepository, authFailureRepository)
 	healthHandler := health.HandlerFunc()
 
+	// The HTTP server serves the same services as the gRPC server, so it is secured the same way
 	httpServer, err := server.NewHTTP(healthHandler, server.HTTPOptions{
-		Port: configs.HTTPPort,
+		Port:              configs.HTTPPort,
+		Security:          server.Security(configs.GRPCSecurity),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: configs.HTTPReadHeaderTimeout,
+		ReadTimeout:       configs.HTTPReadTimeout,
+		WriteTimeout:      configs.HTTPWriteTimeout,
+		IdleTimeout:       configs.HTTPIdleTimeout,
+		MaxHeaderBytes:    configs.HTTPMaxHeaderBytes,
+		Gateway:           httpGateway,
+		Connect:           server.NewConnect(grpcConn),
+		CORS: server.CORSOptions{
+			AllowedOrigins: configs.CORSAllowedOrigins,
+			MaxAge:         configs.CORSMaxAge,
+		},
 	})
 
 	if err != nil {
@@ -141,9 +345,89 @@ func main() {
 	// Gracefully, retry the lost connections
 	// Gracefully, disconnect the clients and shutdown the servers on termination signals
 	graceful.SetLogger(probe.Logger())
-	graceful.RegisterClient(githubGateway, usercacheRepository)
+	graceful.RegisterClient(githubGateway, usercacheRepository, ratelimitRepository, authFailureRepository)
 	graceful.RegisterServer(grpcServer, httpServer)
 	code := graceful.StartAndWait()
 
 	os.Exit(code)
 }
+
+// newAuthenticator creates an authenticator for the configured API keys and JWKS.
+// If neither API keys nor a JWKS is configured, authentication is disabled and nil is returned.
+func newAuthenticator(ctx context.Context) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	keys, err := auth.ParseAPIKeys(configs.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > 0 {
+		chain = append(chain, auth.NewAPIKeys(keys))
+	}
+
+	if configs.JWKSFile != "" || configs.JWKSURL != "" {
+		jwt, err := auth.NewJWT(ctx, auth.JWTOptions{
+			JWKSFile: configs.JWKSFile,
+			JWKSURL:  configs.JWKSURL,
+			Issuer:   configs.JWTIssuer,
+			Audience: configs.JWTAudience,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, jwt)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	return chain, nil
+}
+
+// newTLSConfig creates a TLS configuration for the configured security mode and certificate files.
+// The configuration is shared by the gRPC and HTTP servers, so they present the same certificate.
+// The insecure and dev security modes do not use certificate files.
+// A self-signed certificate is generated for the dev security mode, and nil is returned for the insecure security mode.
+func newTLSConfig() (*tls.Config, error) {
+	security := server.Security(configs.GRPCSecurity)
+
+	switch security {
+	case server.SecurityTLS, server.SecurityMTLS:
+	default:
+		if configs.TLSCertFile != "" || configs.TLSKeyFile != "" || configs.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("tls files require the %s or %s security mode, but the security mode is %q", server.SecurityTLS, server.SecurityMTLS, security)
+		}
+
+		if security == server.SecurityDev {
+			return server.NewDevTLSConfig()
+		}
+		return nil, nil
+	}
+
+	if configs.TLSCertFile == "" || configs.TLSKeyFile == "" {
+		return nil, fmt.Errorf("%s security mode requires tls cert file and key file", security)
+	}
+
+	if security == server.SecurityTLS && configs.TLSClientCAFile != "" {
+		return nil, fmt.Errorf("tls client ca file requires the %s security mode", server.SecurityMTLS)
+	}
+
+	if security == server.SecurityMTLS && configs.TLSClientCAFile == "" {
+		return nil, fmt.Errorf("%s security mode requires tls client ca file", security)
+	}
+
+	reloader, err := certs.NewReloader(certs.Options{
+		CertFile:       configs.TLSCertFile,
+		KeyFile:        configs.TLSKeyFile,
+		ClientCAFile:   configs.TLSClientCAFile,
+		ReloadInterval: configs.TLSReloadInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader.TLSConfig(), nil
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_String(t *testing.T) {
+	p := &Principal{Subject: "octocat", Method: MethodJWT, Scopes: []string{"greet", "admin"}}
+	assert.Equal(t, "Principal{subject=octocat method=jwt scopes=greet admin}", p.String())
+}
+
+func TestPrincipal_HasScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		principal      *Principal
+		scope          string
+		expectedResult bool
+	}{
+		{"NoScope", &Principal{}, "", true},
+		{"MissingScope", &Principal{Scopes: []string{"read"}}, "greet", false},
+		{"GrantedScope", &Principal{Scopes: []string{"read", "greet"}}, "greet", true},
+		{"AllScopes", &Principal{Scopes: []string{AllScopes}}, "greet", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedResult, tc.principal.HasScope(tc.scope))
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	p, ok := FromContext(context.Background())
+	assert.False(t, ok)
+	assert.Nil(t, p)
+
+	expected := &Principal{Subject: "octocat"}
+	p, ok = FromContext(NewContext(context.Background(), expected))
+	assert.True(t, ok)
+	assert.Equal(t, expected, p)
+}
+
+// authenticatorFunc is a function implementing the Authenticator interface.
+type authenticatorFunc func(context.Context, Credentials) (*Principal, error)
+
+func (f authenticatorFunc) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	return f(ctx, creds)
+}
+
+func TestChain_Authenticate(t *testing.T) {
+	noCredentials := authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+		return nil, ErrNoCredentials
+	})
+
+	fails := authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+		return nil, errors.New("authentication error")
+	})
+
+	succeeds := authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+		return &Principal{Subject: "octocat"}, nil
+	})
+
+	tests := []struct {
+		name              string
+		chain             Chain
+		expectedPrincipal *Principal
+		expectedError     string
+	}{
+		{
+			name:              "Empty",
+			chain:             Chain{},
+			expectedPrincipal: nil,
+			expectedError:     "no credentials",
+		},
+		{
+			name:              "NoCredentials",
+			chain:             Chain{noCredentials, noCredentials},
+			expectedPrincipal: nil,
+			expectedError:     "no credentials",
+		},
+		{
+			name:              "Fails",
+			chain:             Chain{noCredentials, fails, succeeds},
+			expectedPrincipal: nil,
+			expectedError:     "authentication error",
+		},
+		{
+			name:              "Succeeds",
+			chain:             Chain{noCredentials, succeeds, fails},
+			expectedPrincipal: &Principal{Subject: "octocat"},
+			expectedError:     "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := tc.chain.Authenticate(context.Background(), Credentials{})
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPrincipal, p)
+			} else {
+				assert.Nil(t, p)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	tests := []struct {
+		name          string
+		s             string
+		expectedKeys  map[string]string
+		expectedError string
+	}{
+		{
+			name:          "Empty",
+			s:             "",
+			expectedKeys:  map[string]string{},
+			expectedError: "",
+		},
+		{
+			name:          "Invalid",
+			s:             "alice=secret,bob",
+			expectedKeys:  nil,
+			expectedError: "invalid api key #2: expected subject=key",
+		},
+		{
+			name:          "OK",
+			s:             "alice=secret, bob=s3cr3t=,",
+			expectedKeys:  map[string]string{"alice": "secret", "bob": "s3cr3t="},
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := ParseAPIKeys(tc.s)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedKeys, keys)
+			} else {
+				assert.Nil(t, keys)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestAPIKeys_Authenticate(t *testing.T) {
+	tests := []struct {
+		name              string
+		creds             Credentials
+		expectedPrincipal *Principal
+		expectedError     string
+	}{
+		{
+			name:              "NoAPIKey",
+			creds:             Credentials{BearerToken: "token"},
+			expectedPrincipal: nil,
+			expectedError:     "no credentials",
+		},
+		{
+			name:              "InvalidAPIKey",
+			creds:             Credentials{APIKey: "invalid"},
+			expectedPrincipal: nil,
+			expectedError:     "unauthenticated: invalid api key",
+		},
+		{
+			name:  "OK",
+			creds: Credentials{APIKey: "secret"},
+			expectedPrincipal: &Principal{
+				Subject: "alice",
+				Method:  MethodAPIKey,
+				Scopes:  []string{AllScopes},
+			},
+			expectedError: "",
+		},
+	}
+
+	a := NewAPIKeys(map[string]string{"alice": "secret", "bob": "s3cr3t"})
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := a.Authenticate(context.Background(), tc.creds)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPrincipal, p)
+			} else {
+				assert.Nil(t, p)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
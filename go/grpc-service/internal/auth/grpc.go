@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadata is the request metadata carrying a static API key.
+const APIKeyMetadata = "x-api-key"
+
+// healthMethodPrefix is the prefix of the methods of the standard health service.
+// Health checks are not authenticated, so probes do not need credentials.
+var healthMethodPrefix = "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+// Interceptor is a gRPC server interceptor for authenticating and authorizing calls.
+type Interceptor struct {
+	authenticator Authenticator
+	scope         string
+}
+
+// NewInterceptor creates a new interceptor authenticating calls using an authenticator.
+// Authenticated principals must be granted a scope to be authorized (all principals are authorized if empty).
+func NewInterceptor(authenticator Authenticator, scope string) *Interceptor {
+	return &Interceptor{
+		authenticator: authenticator,
+		scope:         scope,
+	}
+}
+
+// ServerOptions returns the gRPC server options for authenticating unary and stream calls.
+func (i *Interceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unaryInterceptor),
+		grpc.ChainStreamInterceptor(i.streamInterceptor),
+	}
+}
+
+// authenticate returns a new context carrying the principal of a call.
+// Unauthenticated calls fail with the Unauthenticated code and unauthorized calls with the PermissionDenied code.
+func (i *Interceptor) authenticate(ctx context.Context) (context.Context, error) {
+	p, err := i.authenticator.Authenticate(ctx, grpcCredentials(ctx))
+	if errors.Is(err, ErrNoCredentials) {
+		return nil, status.Errorf(codes.Unauthenticated, "%s: %s", ErrUnauthenticated, err)
+	}
+
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if !p.HasScope(i.scope) {
+		return nil, status.Errorf(codes.PermissionDenied, "%s: missing scope %s", ErrPermissionDenied, i.scope)
+	}
+
+	return NewContext(ctx, p), nil
+}
+
+func (i *Interceptor) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(ctx, req)
+	}
+
+	ctx, err := i.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+func (i *Interceptor) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(srv, ss)
+	}
+
+	ctx, err := i.authenticate(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
+
+// serverStream is a grpc.ServerStream with a context carrying the principal of a call.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// grpcCredentials returns the credentials of a call from the x-api-key and authorization metadata.
+func grpcCredentials(ctx context.Context) Credentials {
+	var creds Credentials
+
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return creds
+	}
+
+	if vals := md.Get(APIKeyMetadata); len(vals) > 0 {
+		creds.APIKey = vals[0]
+	}
+
+	if vals := md.Get("authorization"); len(vals) > 0 {
+		creds.BearerToken = bearerToken(vals[0])
+	}
+
+	return creds
+}
+
+// bearerToken returns the token from an authorization value using the Bearer scheme.
+func bearerToken(authorization string) string {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// fakeServerStream is a grpc.ServerStream for testing stream interceptors.
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestInterceptor_ServerOptions(t *testing.T) {
+	i := NewInterceptor(Chain{}, "")
+	assert.Len(t, i.ServerOptions(), 2)
+}
+
+func TestInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator Authenticator
+		scope         string
+		md            metadata.MD
+		expectedError string
+		expectedCreds Credentials
+	}{
+		{
+			name: "NoCredentials",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return nil, ErrNoCredentials
+			}),
+			md:            nil,
+			expectedError: "rpc error: code = Unauthenticated desc = unauthenticated: no credentials",
+			expectedCreds: Credentials{},
+		},
+		{
+			name: "Unauthenticated",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return nil, errors.New("unauthenticated: invalid api key")
+			}),
+			md:            metadata.Pairs("x-api-key", "invalid"),
+			expectedError: "rpc error: code = Unauthenticated desc = unauthenticated: invalid api key",
+			expectedCreds: Credentials{APIKey: "invalid"},
+		},
+		{
+			name: "PermissionDenied",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return &Principal{Subject: "octocat", Scopes: []string{"read"}}, nil
+			}),
+			scope:         "greet",
+			md:            metadata.Pairs("authorization", "Bearer token"),
+			expectedError: "rpc error: code = PermissionDenied desc = permission denied: missing scope greet",
+			expectedCreds: Credentials{BearerToken: "token"},
+		},
+		{
+			name: "Authorized",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return &Principal{Subject: "octocat", Scopes: []string{"greet"}}, nil
+			}),
+			scope:         "greet",
+			md:            metadata.Pairs("x-api-key", "secret", "authorization", "bearer token"),
+			expectedError: "",
+			expectedCreds: Credentials{APIKey: "secret", BearerToken: "token"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var creds Credentials
+			authenticator := authenticatorFunc(func(ctx context.Context, c Credentials) (*Principal, error) {
+				creds = c
+				return tc.authenticator.Authenticate(ctx, c)
+			})
+
+			i := NewInterceptor(authenticator, tc.scope)
+
+			ctx := context.Background()
+			if tc.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tc.md)
+			}
+
+			t.Run("Unary", func(t *testing.T) {
+				resp, err := i.unaryInterceptor(ctx, "request", &grpc.UnaryServerInfo{}, func(ctx context.Context, req any) (any, error) {
+					p, _ := FromContext(ctx)
+					return p.Subject, nil
+				})
+
+				if tc.expectedError == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, "octocat", resp)
+				} else {
+					assert.Nil(t, resp)
+					assert.EqualError(t, err, tc.expectedError)
+				}
+
+				assert.Equal(t, tc.expectedCreds, creds)
+			})
+
+			t.Run("Stream", func(t *testing.T) {
+				var subject string
+				err := i.streamInterceptor(nil, &fakeServerStream{ctx: ctx}, &grpc.StreamServerInfo{}, func(srv any, ss grpc.ServerStream) error {
+					p, _ := FromContext(ss.Context())
+					subject = p.Subject
+					return nil
+				})
+
+				if tc.expectedError == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, "octocat", subject)
+				} else {
+					assert.EqualError(t, err, tc.expectedError)
+				}
+
+				assert.Equal(t, tc.expectedCreds, creds)
+			})
+		})
+	}
+}
+
+func TestInterceptor_HealthMethods(t *testing.T) {
+	authenticator := authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+		return nil, ErrNoCredentials
+	})
+
+	i := NewInterceptor(authenticator, "greet")
+
+	resp, err := i.unaryInterceptor(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}, func(ctx context.Context, req any) (any, error) {
+		return "response", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "response", resp)
+
+	err = i.streamInterceptor(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{FullMethod: "/grpc.health.v1.Health/Watch"}, func(srv any, ss grpc.ServerStream) error {
+		return nil
+	})
+
+	assert.NoError(t, err)
+}
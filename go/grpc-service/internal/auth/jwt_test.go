@@ -0,0 +1,434 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// signer is a private key for signing test tokens.
+type signer struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+func newSigners(t *testing.T) []signer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	return []signer{
+		{kid: "rsa", method: jwt.SigningMethodRS256, key: rsaKey},
+		{kid: "ec", method: jwt.SigningMethodES256, key: ecKey},
+		{kid: "ed", method: jwt.SigningMethodEdDSA, key: edKey},
+	}
+}
+
+// jwksJSON encodes the public keys of signers as a JSON Web Key Set.
+func jwksJSON(t *testing.T, signers ...signer) []byte {
+	enc := base64.RawURLEncoding
+
+	var keys []map[string]string
+	for _, s := range signers {
+		switch pub := s.key.Public().(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "RSA", "kid": s.kid, "use": "sig",
+				"n": enc.EncodeToString(pub.N.Bytes()),
+				"e": enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case *ecdsa.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "EC", "kid": s.kid, "crv": "P-256",
+				"x": enc.EncodeToString(pub.X.FillBytes(make([]byte, 32))),
+				"y": enc.EncodeToString(pub.Y.FillBytes(make([]byte, 32))),
+			})
+		case ed25519.PublicKey:
+			keys = append(keys, map[string]string{
+				"kty": "OKP", "kid": s.kid, "crv": "Ed25519",
+				"x": enc.EncodeToString(pub),
+			})
+		}
+	}
+
+	data, err := json.Marshal(map[string]any{"keys": keys})
+	assert.NoError(t, err)
+
+	return data
+}
+
+func sign(t *testing.T, s signer, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(s.method, claims)
+	token.Header["kid"] = s.kid
+
+	signed, err := token.SignedString(s.key)
+	assert.NoError(t, err)
+
+	return signed
+}
+
+func writeJWKS(t *testing.T, data []byte) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestNewJWT(t *testing.T) {
+	signers := newSigners(t)
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	missing := filepath.Join(t.TempDir(), "missing.json")
+
+	tests := []struct {
+		name          string
+		opts          JWTOptions
+		expectedError string
+	}{
+		{
+			name:          "NoJWKS",
+			opts:          JWTOptions{},
+			expectedError: "exactly one of jwks file or jwks url is required",
+		},
+		{
+			name:          "BothJWKS",
+			opts:          JWTOptions{JWKSFile: "jwks.json", JWKSURL: "http://localhost/jwks.json"},
+			expectedError: "exactly one of jwks file or jwks url is required",
+		},
+		{
+			name:          "MissingFile",
+			opts:          JWTOptions{JWKSFile: missing},
+			expectedError: "failed to read jwks file: open " + missing + ": no such file or directory",
+		},
+		{
+			name:          "URLNotFound",
+			opts:          JWTOptions{JWKSURL: notFound.URL},
+			expectedError: "failed to fetch jwks: unexpected status code 404",
+		},
+		{
+			name:          "InvalidJSON",
+			opts:          JWTOptions{JWKSFile: writeJWKS(t, []byte("{"))},
+			expectedError: "invalid jwks: unexpected end of JSON input",
+		},
+		{
+			name:          "NoKeys",
+			opts:          JWTOptions{JWKSFile: writeJWKS(t, []byte(`{"keys":[{"kty":"RSA","use":"enc"}]}`))},
+			expectedError: "invalid jwks: no signing keys",
+		},
+		{
+			name:          "InvalidKey",
+			opts:          JWTOptions{JWKSFile: writeJWKS(t, []byte(`{"keys":[{"kty":"EC","kid":"k1","crv":"P-192"}]}`))},
+			expectedError: `invalid jwks: key "k1": unsupported curve "P-192"`,
+		},
+		{
+			name:          "OK",
+			opts:          JWTOptions{JWKSFile: writeJWKS(t, jwksJSON(t, signers...))},
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			j, err := NewJWT(context.Background(), tc.opts)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, j)
+				assert.Len(t, j.keys, len(signers))
+			} else {
+				assert.Nil(t, j)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestJWT_Authenticate(t *testing.T) {
+	signers := newSigners(t)
+	unknown := newSigners(t)[0]
+	unknown.kid = "unknown"
+
+	now := time.Now()
+	valid := func(extra jwt.MapClaims) jwt.MapClaims {
+		claims := jwt.MapClaims{
+			"sub": "octocat",
+			"iss": "https://issuer.example.com",
+			"aud": "greeting",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+		for k, v := range extra {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name              string
+		creds             Credentials
+		expectedPrincipal *Principal
+		expectedError     string
+	}{
+		{
+			name:          "NoToken",
+			creds:         Credentials{APIKey: "secret"},
+			expectedError: "no credentials",
+		},
+		{
+			name:          "Malformed",
+			creds:         Credentials{BearerToken: "not-a-token"},
+			expectedError: "unauthenticated: token is malformed: token contains an invalid number of segments",
+		},
+		{
+			name:          "UnknownKey",
+			creds:         Credentials{BearerToken: sign(t, unknown, valid(nil))},
+			expectedError: `unauthenticated: token is unverifiable: error while executing keyfunc: unknown key "unknown"`,
+		},
+		{
+			name:          "Expired",
+			creds:         Credentials{BearerToken: sign(t, signers[0], valid(jwt.MapClaims{"exp": now.Add(-time.Hour).Unix()}))},
+			expectedError: "unauthenticated: token has invalid claims: token is expired",
+		},
+		{
+			name:          "NoExpiration",
+			creds:         Credentials{BearerToken: sign(t, signers[0], valid(jwt.MapClaims{"exp": nil}))},
+			expectedError: "unauthenticated: token has invalid claims: token is missing required claim: exp claim is required",
+		},
+		{
+			name:          "WrongIssuer",
+			creds:         Credentials{BearerToken: sign(t, signers[0], valid(jwt.MapClaims{"iss": "https://evil.example.com"}))},
+			expectedError: "unauthenticated: token has invalid claims: token has invalid issuer",
+		},
+		{
+			name:          "WrongAudience",
+			creds:         Credentials{BearerToken: sign(t, signers[0], valid(jwt.MapClaims{"aud": "other"}))},
+			expectedError: "unauthenticated: token has invalid claims: token has invalid audience",
+		},
+		{
+			name:          "NoSubject",
+			creds:         Credentials{BearerToken: sign(t, signers[0], valid(jwt.MapClaims{"sub": ""}))},
+			expectedError: "unauthenticated: token has no subject",
+		},
+		{
+			name:  "RSA",
+			creds: Credentials{BearerToken: sign(t, signers[0], valid(jwt.MapClaims{"scope": "greet read"}))},
+			expectedPrincipal: &Principal{
+				Subject: "octocat",
+				Method:  MethodJWT,
+				Scopes:  []string{"greet", "read"},
+			},
+		},
+		{
+			name:  "EC",
+			creds: Credentials{BearerToken: sign(t, signers[1], valid(jwt.MapClaims{"scp": []string{"greet"}}))},
+			expectedPrincipal: &Principal{
+				Subject: "octocat",
+				Method:  MethodJWT,
+				Scopes:  []string{"greet"},
+			},
+		},
+		{
+			name:  "Ed25519",
+			creds: Credentials{BearerToken: sign(t, signers[2], valid(nil))},
+			expectedPrincipal: &Principal{
+				Subject: "octocat",
+				Method:  MethodJWT,
+				Scopes:  nil,
+			},
+		},
+	}
+
+	j, err := NewJWT(context.Background(), JWTOptions{
+		JWKSFile: writeJWKS(t, jwksJSON(t, signers...)),
+		Issuer:   "https://issuer.example.com",
+		Audience: "greeting",
+	})
+	assert.NoError(t, err)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := j.Authenticate(context.Background(), tc.creds)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedPrincipal, p)
+			} else {
+				assert.Nil(t, p)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestJWT_Authenticate_Refresh(t *testing.T) {
+	signers := newSigners(t)
+
+	var jwks atomic.Value
+	jwks.Store(jwksJSON(t, signers[0]))
+
+	var fetches atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		_, _ = w.Write(jwks.Load().([]byte))
+	}))
+	defer ts.Close()
+
+	j, err := NewJWT(context.Background(), JWTOptions{
+		JWKSURL:             ts.URL,
+		JWKSRefreshInterval: time.Minute,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now := time.Now()
+	j.now = func() time.Time { return now }
+
+	// The keys are rotated
+	jwks.Store(jwksJSON(t, signers[1]))
+	token := sign(t, signers[1], jwt.MapClaims{"sub": "octocat", "exp": now.Add(time.Hour).Unix()})
+
+	// Keys are not refreshed more than once per refresh interval
+	_, err = j.Authenticate(context.Background(), Credentials{BearerToken: token})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, int32(1), fetches.Load())
+
+	now = now.Add(time.Minute)
+	p, err := j.Authenticate(context.Background(), Credentials{BearerToken: token})
+	assert.NoError(t, err)
+	assert.Equal(t, "octocat", p.Subject)
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWT_Authenticate_FailedRefresh(t *testing.T) {
+	signers := newSigners(t)
+
+	var jwks atomic.Value
+	jwks.Store(jwksJSON(t, signers[0]))
+
+	var fetches atomic.Int32
+	var down atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write(jwks.Load().([]byte))
+	}))
+	defer ts.Close()
+
+	j, err := NewJWT(context.Background(), JWTOptions{
+		JWKSURL:             ts.URL,
+		JWKSRefreshInterval: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	now := time.Now().Add(time.Minute)
+	j.now = func() time.Time { return now }
+
+	// The keys are rotated while the JWKS URL is down
+	jwks.Store(jwksJSON(t, signers[1]))
+	down.Store(true)
+	token := sign(t, signers[1], jwt.MapClaims{"sub": "octocat", "exp": now.Add(time.Hour).Unix()})
+
+	_, err = j.Authenticate(context.Background(), Credentials{BearerToken: token})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	// Failed refreshes are not retried more than once per refresh interval
+	_, err = j.Authenticate(context.Background(), Credentials{BearerToken: token})
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	down.Store(false)
+	now = now.Add(time.Minute)
+	p, err := j.Authenticate(context.Background(), Credentials{BearerToken: token})
+	assert.NoError(t, err)
+	assert.Equal(t, "octocat", p.Subject)
+	assert.Equal(t, int32(3), fetches.Load())
+}
+
+func TestJWT_Authenticate_ConcurrentRefresh(t *testing.T) {
+	signers := newSigners(t)
+
+	var jwks atomic.Value
+	jwks.Store(jwksJSON(t, signers[0]))
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		_, _ = w.Write(jwks.Load().([]byte))
+	}))
+	defer ts.Close()
+
+	j, err := NewJWT(context.Background(), JWTOptions{
+		JWKSURL:             ts.URL,
+		JWKSRefreshInterval: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	now := time.Now().Add(time.Minute)
+	j.now = func() time.Time { return now }
+
+	// The keys are rotated
+	jwks.Store(jwksJSON(t, signers[1]))
+	known := sign(t, signers[0], jwt.MapClaims{"sub": "octocat", "exp": now.Add(time.Hour).Unix()})
+	unknown := sign(t, signers[1], jwt.MapClaims{"sub": "octocat", "exp": now.Add(time.Hour).Unix()})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := j.Authenticate(context.Background(), Credentials{BearerToken: unknown})
+			errs <- err
+		}()
+	}
+
+	// Known keys are served while the keys are being refreshed
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+	_, err = j.Authenticate(context.Background(), Credentials{BearerToken: known})
+	assert.NoError(t, err)
+
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int32(2), fetches.Load())
+}
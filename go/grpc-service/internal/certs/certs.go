@@ -0,0 +1,168 @@
+// Package certs provides TLS configurations for servers using certificate files that are reloaded when changed.
+//
+// The files are checked for changes on TLS handshakes at most once per reload interval,
+// so rotated certificates and CA bundles are picked up without restarting the service.
+// If reloading fails (e.g. a certificate is rotated before its key), the previous certificates are used.
+// Self-signed certificates can also be generated in memory for development.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gardenbed/basil/telemetry"
+)
+
+// DefaultReloadInterval is the default interval for checking certificate files for changes.
+const DefaultReloadInterval = 10 * time.Second
+
+// Options are configurations for creating a new reloader.
+type Options struct {
+	// CertFile is the path to a PEM-encoded certificate (chain) for the server identity.
+	CertFile string
+	// KeyFile is the path to the PEM-encoded private key of the certificate.
+	KeyFile string
+	// ClientCAFile is the path to a PEM-encoded bundle of certificate authorities for verifying client certificates.
+	// If set, clients are required to present a certificate signed by one of these authorities (mTLS).
+	ClientCAFile string
+	// ReloadInterval is the interval for checking the files for changes (DefaultReloadInterval if zero).
+	ReloadInterval time.Duration
+	// Logger is used for reporting reloads (the global logger if nil).
+	Logger telemetry.Logger
+}
+
+// Reloader loads a certificate and a client CA bundle from files and reloads them when the files change.
+type Reloader struct {
+	mu      sync.Mutex
+	opts    Options
+	now     func() time.Time
+	checked time.Time
+	stamp   string
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+}
+
+// NewReloader creates a new reloader and loads the certificate files.
+func NewReloader(opts Options) (*Reloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, errors.New("tls cert file and key file are required")
+	}
+
+	if opts.ReloadInterval <= 0 {
+		opts.ReloadInterval = DefaultReloadInterval
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = telemetry.Get().Logger()
+	}
+
+	r := &Reloader{
+		opts: opts,
+		now:  time.Now,
+	}
+
+	if _, err := r.reload(); err != nil {
+		return nil, err
+	}
+
+	r.checked = r.now()
+
+	return r, nil
+}
+
+// files returns the paths to all certificate files.
+func (r *Reloader) files() []string {
+	files := []string{r.opts.CertFile, r.opts.KeyFile}
+	if r.opts.ClientCAFile != "" {
+		files = append(files, r.opts.ClientCAFile)
+	}
+
+	return files
+}
+
+// reload loads the certificate files if any of them has changed since the last load.
+func (r *Reloader) reload() (bool, error) {
+	var stamps []string
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return false, err
+		}
+		stamps = append(stamps, fmt.Sprintf("%s:%d:%d", file, info.Size(), info.ModTime().UnixNano()))
+	}
+
+	stamp := strings.Join(stamps, ",")
+	if stamp == r.stamp {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.opts.CertFile, r.opts.KeyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+
+	var pool *x509.CertPool
+	if r.opts.ClientCAFile != "" {
+		data, err := os.ReadFile(r.opts.ClientCAFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read client ca file: %w", err)
+		}
+
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return false, errors.New("invalid client ca file: no certificates")
+		}
+	}
+
+	r.stamp, r.cert, r.pool = stamp, &cert, pool
+
+	return true, nil
+}
+
+// get returns the current certificate and client CA bundle, and reloads them if the reload interval has elapsed.
+func (r *Reloader) get() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := r.now(); now.Sub(r.checked) >= r.opts.ReloadInterval {
+		r.checked = now
+		if reloaded, err := r.reload(); err != nil {
+			r.opts.Logger.Warn("failed to reload tls certificates", "error", err)
+		} else if reloaded {
+			r.opts.Logger.Info("tls certificates reloaded", "cert", r.opts.CertFile)
+		}
+	}
+
+	return r.cert, r.pool
+}
+
+// TLSConfig returns a TLS configuration for servers using the current certificate and client CA bundle on every handshake.
+// The configuration negotiates HTTP/2 and HTTP/1.1, so it can be used for both HTTP and gRPC servers.
+func (r *Reloader) TLSConfig() *tls.Config {
+	config := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		cert, pool := r.get()
+
+		c := config.Clone()
+		c.GetConfigForClient = nil
+		c.Certificates = []tls.Certificate{*cert}
+		if pool != nil {
+			c.ClientCAs = pool
+			c.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+
+		return c, nil
+	}
+
+	return config
+}
@@ -4,23 +4,61 @@ import (
 	"context"
 	"net/http"
 	"time"
+
+	"github.com/gardenbed/basil/telemetry"
+	"go.opentelemetry.io/otel/metric"
+
+	"grpc-service/internal/breaker"
 )
 
 // HTTP is an http.Client that implements the graceful.Client and graceful.Client health.Checker interfaces.
+// Requests are sent through a circuit breaker, so requests fail fast while the external service is failing.
 type HTTP struct {
 	*http.Client
+	breaker *breaker.Breaker
+}
+
+// HTTPOptions are optional settings for creating an http client.
+type HTTPOptions struct {
+	// Breaker configures the circuit breaker for requests.
+	Breaker breaker.Options
+	// Meter is used for reporting the state of the circuit breaker (the global meter if nil).
+	Meter metric.Meter
 }
 
 // NewHTTP creates a new http client.
-func NewHTTP() *HTTP {
+// The state of its circuit breaker is reported by the circuit_breaker_state gauge (0 closed, 1 open, and 2 half-open).
+func NewHTTP(opts HTTPOptions) (*HTTP, error) {
+	if opts.Meter == nil {
+		opts.Meter = telemetry.Get().Meter()
+	}
+
+	b := breaker.New(opts.Breaker)
+
+	state, err := opts.Meter.Int64ObservableGauge(
+		"circuit_breaker_state",
+		metric.WithDescription("The state of the circuit breaker for requests to the external service (0 closed, 1 open, and 2 half-open)"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := opts.Meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveInt64(state, int64(b.State()))
+		return nil
+	}, state); err != nil {
+		return nil, err
+	}
+
 	client := &http.Client{
 		Timeout:   10 * time.Second,
-		Transport: &http.Transport{},
+		Transport: breaker.NewTransport(&http.Transport{}, b),
 	}
 
 	return &HTTP{
-		Client: client,
-	}
+		Client:  client,
+		breaker: b,
+	}, nil
 }
 
 // String returns a name for the client.
@@ -39,6 +77,9 @@ func (c *HTTP) Disconnect(ctx context.Context) error {
 }
 
 // HealthCheck checks the health of connection to the external service.
+// An open circuit breaker does not fail the health check, since the service still serves cached and stale data,
+// and failing the health checks of all replicas at once would take the whole service down with the external service.
+// The external service is reported as degraded by the circuit_breaker_state gauge instead.
 func (c *HTTP) HealthCheck(ctx context.Context) error {
 	return nil
 }
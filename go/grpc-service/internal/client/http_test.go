@@ -5,10 +5,15 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"grpc-service/internal/breaker"
 )
 
 func TestNewHTTP(t *testing.T) {
-	c := NewHTTP()
+	c, err := NewHTTP(HTTPOptions{})
+	assert.NoError(t, err)
 	assert.NotNil(t, c)
 }
 
@@ -34,10 +39,60 @@ func TestHTTP_Disconnect(t *testing.T) {
 	assert.NoError(t, err)
 }
 
-func TestGateway_HealthCheck(t *testing.T) {
-	c := &HTTP{}
-	ctx := context.Background()
-	err := c.HealthCheck(ctx)
+func TestHTTP_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		expectedState int64
+	}{
+		{
+			name:          "Closed",
+			failures:      0,
+			expectedState: int64(breaker.Closed),
+		},
+		{
+			// An open circuit degrades the service but does not fail the health check
+			name:          "Open",
+			failures:      1,
+			expectedState: int64(breaker.Open),
+		},
+	}
 
-	assert.NoError(t, err)
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader := metricsdk.NewManualReader()
+			meter := metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("test")
+
+			c, err := NewHTTP(HTTPOptions{
+				Breaker: breaker.Options{FailureThreshold: 1},
+				Meter:   meter,
+			})
+			assert.NoError(t, err)
+
+			for range tc.failures {
+				done, err := c.breaker.Allow()
+				assert.NoError(t, err)
+				done(true)
+			}
+
+			assert.NoError(t, c.HealthCheck(context.Background()))
+			assert.Equal(t, tc.expectedState, breakerState(t, reader))
+		})
+	}
+}
+
+// breakerState returns the value of the circuit breaker state gauge.
+func breakerState(t *testing.T, reader metricsdk.Reader) int64 {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if gauge, ok := m.Data.(metricdata.Gauge[int64]); ok && m.Name == "circuit_breaker_state" {
+				return gauge.DataPoints[0].Value
+			}
+		}
+	}
+
+	return -1
 }
@@ -2,21 +2,25 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.26.0
+// 	protoc-gen-go v1.36.10
 // 	protoc        v3.19.1
 // source: greetingpb/greeting.proto
 
 package greetingpb
 
 import (
+	_ "buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
 	context "context"
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	status "google.golang.org/genproto/googleapis/rpc/status"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
-	status "google.golang.org/grpc/status"
+	status1 "google.golang.org/grpc/status"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
 	sync "sync"
+	unsafe "unsafe"
 )
 
 const (
@@ -26,21 +30,22 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// Requests are validated against the constraints of their fields before they are handled.
+// See https://protovalidate.com for the constraints.
 type GreetRequest struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// A GitHub username consists of alphanumeric characters and single hyphens,
+	// cannot begin or end with a hyphen, and is at most 39 characters long.
 	GithubUsername string `protobuf:"bytes,1,opt,name=github_username,json=githubUsername,proto3" json:"github_username,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *GreetRequest) Reset() {
 	*x = GreetRequest{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_greetingpb_greeting_proto_msgTypes[0]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_greetingpb_greeting_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GreetRequest) String() string {
@@ -51,7 +56,7 @@ func (*GreetRequest) ProtoMessage() {}
 
 func (x *GreetRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_greetingpb_greeting_proto_msgTypes[0]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -74,20 +79,17 @@ func (x *GreetRequest) GetGithubUsername() string {
 }
 
 type GreetResponse struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Greeting      string                 `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
 	unknownFields protoimpl.UnknownFields
-
-	Greeting string `protobuf:"bytes,1,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *GreetResponse) Reset() {
 	*x = GreetResponse{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_greetingpb_greeting_proto_msgTypes[1]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
-	}
+	mi := &file_greetingpb_greeting_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
 func (x *GreetResponse) String() string {
@@ -98,7 +100,7 @@ func (*GreetResponse) ProtoMessage() {}
 
 func (x *GreetResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_greetingpb_greeting_proto_msgTypes[1]
-	if protoimpl.UnsafeEnabled && x != nil {
+	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
 			ms.StoreMessageInfo(mi)
@@ -120,54 +122,173 @@ func (x *GreetResponse) GetGreeting() string {
 	return ""
 }
 
-var File_greetingpb_greeting_proto protoreflect.FileDescriptor
+type GreetManyRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	GithubUsernames []string               `protobuf:"bytes,1,rep,name=github_usernames,json=githubUsernames,proto3" json:"github_usernames,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *GreetManyRequest) Reset() {
+	*x = GreetManyRequest{}
+	mi := &file_greetingpb_greeting_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GreetManyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GreetManyRequest) ProtoMessage() {}
+
+func (x *GreetManyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_greetingpb_greeting_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GreetManyRequest.ProtoReflect.Descriptor instead.
+func (*GreetManyRequest) Descriptor() ([]byte, []int) {
+	return file_greetingpb_greeting_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GreetManyRequest) GetGithubUsernames() []string {
+	if x != nil {
+		return x.GithubUsernames
+	}
+	return nil
+}
+
+// A failure to greet a name does not end the stream and is reported as an error instead.
+type GreetResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	GithubUsername string                 `protobuf:"bytes,1,opt,name=github_username,json=githubUsername,proto3" json:"github_username,omitempty"`
+	Greeting       string                 `protobuf:"bytes,2,opt,name=greeting,proto3" json:"greeting,omitempty"`
+	Stale          bool                   `protobuf:"varint,3,opt,name=stale,proto3" json:"stale,omitempty"`
+	Error          *status.Status         `protobuf:"bytes,4,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *GreetResult) Reset() {
+	*x = GreetResult{}
+	mi := &file_greetingpb_greeting_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GreetResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GreetResult) ProtoMessage() {}
+
+func (x *GreetResult) ProtoReflect() protoreflect.Message {
+	mi := &file_greetingpb_greeting_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GreetResult.ProtoReflect.Descriptor instead.
+func (*GreetResult) Descriptor() ([]byte, []int) {
+	return file_greetingpb_greeting_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GreetResult) GetGithubUsername() string {
+	if x != nil {
+		return x.GithubUsername
+	}
+	return ""
+}
+
+func (x *GreetResult) GetGreeting() string {
+	if x != nil {
+		return x.Greeting
+	}
+	return ""
+}
+
+func (x *GreetResult) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
 
-var file_greetingpb_greeting_proto_rawDesc = []byte{
-	0x0a, 0x19, 0x67, 0x72, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x70, 0x62, 0x2f, 0x67, 0x72, 0x65,
-	0x65, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x08, 0x67, 0x72, 0x65,
-	0x65, 0x74, 0x69, 0x6e, 0x67, 0x22, 0x37, 0x0a, 0x0c, 0x47, 0x72, 0x65, 0x65, 0x74, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x27, 0x0a, 0x0f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x5f,
-	0x75, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e,
-	0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x55, 0x73, 0x65, 0x72, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x2b,
-	0x0a, 0x0d, 0x47, 0x72, 0x65, 0x65, 0x74, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
-	0x1a, 0x0a, 0x08, 0x67, 0x72, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x08, 0x67, 0x72, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x32, 0x4b, 0x0a, 0x0f, 0x47,
-	0x72, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x38,
-	0x0a, 0x05, 0x47, 0x72, 0x65, 0x65, 0x74, 0x12, 0x16, 0x2e, 0x67, 0x72, 0x65, 0x65, 0x74, 0x69,
-	0x6e, 0x67, 0x2e, 0x47, 0x72, 0x65, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a,
-	0x17, 0x2e, 0x67, 0x72, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x2e, 0x47, 0x72, 0x65, 0x65, 0x74,
-	0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x42, 0x2f, 0x5a, 0x2d, 0x67, 0x72, 0x70, 0x63,
-	0x2d, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2d, 0x76, 0x65, 0x72, 0x74, 0x69, 0x63, 0x61,
-	0x6c, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x69, 0x64, 0x6c, 0x2f, 0x67,
-	0x72, 0x65, 0x65, 0x74, 0x69, 0x6e, 0x67, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
-	0x33,
+func (x *GreetResult) GetError() *status.Status {
+	if x != nil {
+		return x.Error
+	}
+	return nil
 }
 
+var File_greetingpb_greeting_proto protoreflect.FileDescriptor
+
+const file_greetingpb_greeting_proto_rawDesc = "" +
+	"\n" +
+	"\x19greetingpb/greeting.proto\x12\bgreeting\x1a\x1bbuf/validate/validate.proto\x1a\x1cgoogle/api/annotations.proto\x1a\x17google/rpc/status.proto\"c\n" +
+	"\fGreetRequest\x12S\n" +
+	"\x0fgithub_username\x18\x01 \x01(\tB*\xbaH'\xc8\x01\x01r\"\x18'2\x1e^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$R\x0egithubUsername\"+\n" +
+	"\rGreetResponse\x12\x1a\n" +
+	"\bgreeting\x18\x01 \x01(\tR\bgreeting\"o\n" +
+	"\x10GreetManyRequest\x12[\n" +
+	"\x10github_usernames\x18\x01 \x03(\tB0\xbaH-\x92\x01*\x10d\"&r$\x10\x01\x18'2\x1e^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$R\x0fgithubUsernames\"\x92\x01\n" +
+	"\vGreetResult\x12'\n" +
+	"\x0fgithub_username\x18\x01 \x01(\tR\x0egithubUsername\x12\x1a\n" +
+	"\bgreeting\x18\x02 \x01(\tR\bgreeting\x12\x14\n" +
+	"\x05stale\x18\x03 \x01(\bR\x05stale\x12(\n" +
+	"\x05error\x18\x04 \x01(\v2\x12.google.rpc.StatusR\x05error2\xe3\x01\n" +
+	"\x0fGreetingService\x12N\n" +
+	"\x05Greet\x12\x16.greeting.GreetRequest\x1a\x17.greeting.GreetResponse\"\x14\x82\xd3\xe4\x93\x02\x0e:\x01*\"\t/v1/greet\x12@\n" +
+	"\tGreetMany\x12\x1a.greeting.GreetManyRequest\x1a\x15.greeting.GreetResult0\x01\x12>\n" +
+	"\tGreetChat\x12\x16.greeting.GreetRequest\x1a\x15.greeting.GreetResult(\x010\x01B&Z$grpc-service/internal/idl/greetingpbb\x06proto3"
+
 var (
 	file_greetingpb_greeting_proto_rawDescOnce sync.Once
-	file_greetingpb_greeting_proto_rawDescData = file_greetingpb_greeting_proto_rawDesc
+	file_greetingpb_greeting_proto_rawDescData []byte
 )
 
 func file_greetingpb_greeting_proto_rawDescGZIP() []byte {
 	file_greetingpb_greeting_proto_rawDescOnce.Do(func() {
-		file_greetingpb_greeting_proto_rawDescData = protoimpl.X.CompressGZIP(file_greetingpb_greeting_proto_rawDescData)
+		file_greetingpb_greeting_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_greetingpb_greeting_proto_rawDesc), len(file_greetingpb_greeting_proto_rawDesc)))
 	})
 	return file_greetingpb_greeting_proto_rawDescData
 }
 
-var file_greetingpb_greeting_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
-var file_greetingpb_greeting_proto_goTypes = []interface{}{
-	(*GreetRequest)(nil),  // 0: greeting.GreetRequest
-	(*GreetResponse)(nil), // 1: greeting.GreetResponse
+var file_greetingpb_greeting_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_greetingpb_greeting_proto_goTypes = []any{
+	(*GreetRequest)(nil),     // 0: greeting.GreetRequest
+	(*GreetResponse)(nil),    // 1: greeting.GreetResponse
+	(*GreetManyRequest)(nil), // 2: greeting.GreetManyRequest
+	(*GreetResult)(nil),      // 3: greeting.GreetResult
+	(*status.Status)(nil),    // 4: google.rpc.Status
 }
 var file_greetingpb_greeting_proto_depIdxs = []int32{
-	0, // 0: greeting.GreetingService.Greet:input_type -> greeting.GreetRequest
-	1, // 1: greeting.GreetingService.Greet:output_type -> greeting.GreetResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	4, // 0: greeting.GreetResult.error:type_name -> google.rpc.Status
+	0, // 1: greeting.GreetingService.Greet:input_type -> greeting.GreetRequest
+	2, // 2: greeting.GreetingService.GreetMany:input_type -> greeting.GreetManyRequest
+	0, // 3: greeting.GreetingService.GreetChat:input_type -> greeting.GreetRequest
+	1, // 4: greeting.GreetingService.Greet:output_type -> greeting.GreetResponse
+	3, // 5: greeting.GreetingService.GreetMany:output_type -> greeting.GreetResult
+	3, // 6: greeting.GreetingService.GreetChat:output_type -> greeting.GreetResult
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_greetingpb_greeting_proto_init() }
@@ -175,39 +296,13 @@ func file_greetingpb_greeting_proto_init() {
 	if File_greetingpb_greeting_proto != nil {
 		return
 	}
-	if !protoimpl.UnsafeEnabled {
-		file_greetingpb_greeting_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GreetRequest); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-		file_greetingpb_greeting_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
-			switch v := v.(*GreetResponse); i {
-			case 0:
-				return &v.state
-			case 1:
-				return &v.sizeCache
-			case 2:
-				return &v.unknownFields
-			default:
-				return nil
-			}
-		}
-	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_greetingpb_greeting_proto_rawDesc,
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_greetingpb_greeting_proto_rawDesc), len(file_greetingpb_greeting_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -216,7 +311,6 @@ func file_greetingpb_greeting_proto_init() {
 		MessageInfos:      file_greetingpb_greeting_proto_msgTypes,
 	}.Build()
 	File_greetingpb_greeting_proto = out.File
-	file_greetingpb_greeting_proto_rawDesc = nil
 	file_greetingpb_greeting_proto_goTypes = nil
 	file_greetingpb_greeting_proto_depIdxs = nil
 }
@@ -234,7 +328,14 @@ const _ = grpc.SupportPackageIsVersion6
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type GreetingServiceClient interface {
 	// Creates and returns a greeting for a given name.
+	// It is also served as POST /v1/greet with a JSON body on the HTTP port.
 	Greet(ctx context.Context, in *GreetRequest, opts ...grpc.CallOption) (*GreetResponse, error)
+	// Creates and streams greetings for a list of names.
+	// A greeting is sent as soon as its lookup completes, so greetings may arrive in a different order.
+	GreetMany(ctx context.Context, in *GreetManyRequest, opts ...grpc.CallOption) (GreetingService_GreetManyClient, error)
+	// Creates and streams a greeting for every name received.
+	// Greetings are sent in the same order as the names are received.
+	GreetChat(ctx context.Context, opts ...grpc.CallOption) (GreetingService_GreetChatClient, error)
 }
 
 type greetingServiceClient struct {
@@ -254,10 +355,80 @@ func (c *greetingServiceClient) Greet(ctx context.Context, in *GreetRequest, opt
 	return out, nil
 }
 
+func (c *greetingServiceClient) GreetMany(ctx context.Context, in *GreetManyRequest, opts ...grpc.CallOption) (GreetingService_GreetManyClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_GreetingService_serviceDesc.Streams[0], "/greeting.GreetingService/GreetMany", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &greetingServiceGreetManyClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type GreetingService_GreetManyClient interface {
+	Recv() (*GreetResult, error)
+	grpc.ClientStream
+}
+
+type greetingServiceGreetManyClient struct {
+	grpc.ClientStream
+}
+
+func (x *greetingServiceGreetManyClient) Recv() (*GreetResult, error) {
+	m := new(GreetResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *greetingServiceClient) GreetChat(ctx context.Context, opts ...grpc.CallOption) (GreetingService_GreetChatClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_GreetingService_serviceDesc.Streams[1], "/greeting.GreetingService/GreetChat", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &greetingServiceGreetChatClient{stream}
+	return x, nil
+}
+
+type GreetingService_GreetChatClient interface {
+	Send(*GreetRequest) error
+	Recv() (*GreetResult, error)
+	grpc.ClientStream
+}
+
+type greetingServiceGreetChatClient struct {
+	grpc.ClientStream
+}
+
+func (x *greetingServiceGreetChatClient) Send(m *GreetRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *greetingServiceGreetChatClient) Recv() (*GreetResult, error) {
+	m := new(GreetResult)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // GreetingServiceServer is the server API for GreetingService service.
 type GreetingServiceServer interface {
 	// Creates and returns a greeting for a given name.
+	// It is also served as POST /v1/greet with a JSON body on the HTTP port.
 	Greet(context.Context, *GreetRequest) (*GreetResponse, error)
+	// Creates and streams greetings for a list of names.
+	// A greeting is sent as soon as its lookup completes, so greetings may arrive in a different order.
+	GreetMany(*GreetManyRequest, GreetingService_GreetManyServer) error
+	// Creates and streams a greeting for every name received.
+	// Greetings are sent in the same order as the names are received.
+	GreetChat(GreetingService_GreetChatServer) error
 }
 
 // UnimplementedGreetingServiceServer can be embedded to have forward compatible implementations.
@@ -265,7 +436,13 @@ type UnimplementedGreetingServiceServer struct {
 }
 
 func (*UnimplementedGreetingServiceServer) Greet(context.Context, *GreetRequest) (*GreetResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Greet not implemented")
+	return nil, status1.Errorf(codes.Unimplemented, "method Greet not implemented")
+}
+func (*UnimplementedGreetingServiceServer) GreetMany(*GreetManyRequest, GreetingService_GreetManyServer) error {
+	return status1.Errorf(codes.Unimplemented, "method GreetMany not implemented")
+}
+func (*UnimplementedGreetingServiceServer) GreetChat(GreetingService_GreetChatServer) error {
+	return status1.Errorf(codes.Unimplemented, "method GreetChat not implemented")
 }
 
 func RegisterGreetingServiceServer(s *grpc.Server, srv GreetingServiceServer) {
@@ -290,6 +467,53 @@ func _GreetingService_Greet_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _GreetingService_GreetMany_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GreetManyRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(GreetingServiceServer).GreetMany(m, &greetingServiceGreetManyServer{stream})
+}
+
+type GreetingService_GreetManyServer interface {
+	Send(*GreetResult) error
+	grpc.ServerStream
+}
+
+type greetingServiceGreetManyServer struct {
+	grpc.ServerStream
+}
+
+func (x *greetingServiceGreetManyServer) Send(m *GreetResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _GreetingService_GreetChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(GreetingServiceServer).GreetChat(&greetingServiceGreetChatServer{stream})
+}
+
+type GreetingService_GreetChatServer interface {
+	Send(*GreetResult) error
+	Recv() (*GreetRequest, error)
+	grpc.ServerStream
+}
+
+type greetingServiceGreetChatServer struct {
+	grpc.ServerStream
+}
+
+func (x *greetingServiceGreetChatServer) Send(m *GreetResult) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *greetingServiceGreetChatServer) Recv() (*GreetRequest, error) {
+	m := new(GreetRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 var _GreetingService_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "greeting.GreetingService",
 	HandlerType: (*GreetingServiceServer)(nil),
@@ -299,6 +523,18 @@ var _GreetingService_serviceDesc = grpc.ServiceDesc{
 			Handler:    _GreetingService_Greet_Handler,
 		},
 	},
-	Streams:  []grpc.StreamDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GreetMany",
+			Handler:       _GreetingService_GreetMany_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "GreetChat",
+			Handler:       _GreetingService_GreetChat_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
 	Metadata: "greetingpb/greeting.proto",
 }
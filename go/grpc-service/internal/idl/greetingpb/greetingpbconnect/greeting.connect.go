@@ -0,0 +1,180 @@
+// https://developers.google.com/protocol-buffers/docs/proto3
+
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: greetingpb/greeting.proto
+
+package greetingpbconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	greetingpb "grpc-service/internal/idl/greetingpb"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// GreetingServiceName is the fully-qualified name of the GreetingService service.
+	GreetingServiceName = "greeting.GreetingService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// GreetingServiceGreetProcedure is the fully-qualified name of the GreetingService's Greet RPC.
+	GreetingServiceGreetProcedure = "/greeting.GreetingService/Greet"
+	// GreetingServiceGreetManyProcedure is the fully-qualified name of the GreetingService's GreetMany
+	// RPC.
+	GreetingServiceGreetManyProcedure = "/greeting.GreetingService/GreetMany"
+	// GreetingServiceGreetChatProcedure is the fully-qualified name of the GreetingService's GreetChat
+	// RPC.
+	GreetingServiceGreetChatProcedure = "/greeting.GreetingService/GreetChat"
+)
+
+// GreetingServiceClient is a client for the greeting.GreetingService service.
+type GreetingServiceClient interface {
+	// Creates and returns a greeting for a given name.
+	// It is also served as POST /v1/greet with a JSON body on the HTTP port.
+	Greet(context.Context, *connect.Request[greetingpb.GreetRequest]) (*connect.Response[greetingpb.GreetResponse], error)
+	// Creates and streams greetings for a list of names.
+	// A greeting is sent as soon as its lookup completes, so greetings may arrive in a different order.
+	GreetMany(context.Context, *connect.Request[greetingpb.GreetManyRequest]) (*connect.ServerStreamForClient[greetingpb.GreetResult], error)
+	// Creates and streams a greeting for every name received.
+	// Greetings are sent in the same order as the names are received.
+	GreetChat(context.Context) *connect.BidiStreamForClient[greetingpb.GreetRequest, greetingpb.GreetResult]
+}
+
+// NewGreetingServiceClient constructs a client for the greeting.GreetingService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewGreetingServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) GreetingServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	greetingServiceMethods := greetingpb.File_greetingpb_greeting_proto.Services().ByName("GreetingService").Methods()
+	return &greetingServiceClient{
+		greet: connect.NewClient[greetingpb.GreetRequest, greetingpb.GreetResponse](
+			httpClient,
+			baseURL+GreetingServiceGreetProcedure,
+			connect.WithSchema(greetingServiceMethods.ByName("Greet")),
+			connect.WithClientOptions(opts...),
+		),
+		greetMany: connect.NewClient[greetingpb.GreetManyRequest, greetingpb.GreetResult](
+			httpClient,
+			baseURL+GreetingServiceGreetManyProcedure,
+			connect.WithSchema(greetingServiceMethods.ByName("GreetMany")),
+			connect.WithClientOptions(opts...),
+		),
+		greetChat: connect.NewClient[greetingpb.GreetRequest, greetingpb.GreetResult](
+			httpClient,
+			baseURL+GreetingServiceGreetChatProcedure,
+			connect.WithSchema(greetingServiceMethods.ByName("GreetChat")),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// greetingServiceClient implements GreetingServiceClient.
+type greetingServiceClient struct {
+	greet     *connect.Client[greetingpb.GreetRequest, greetingpb.GreetResponse]
+	greetMany *connect.Client[greetingpb.GreetManyRequest, greetingpb.GreetResult]
+	greetChat *connect.Client[greetingpb.GreetRequest, greetingpb.GreetResult]
+}
+
+// Greet calls greeting.GreetingService.Greet.
+func (c *greetingServiceClient) Greet(ctx context.Context, req *connect.Request[greetingpb.GreetRequest]) (*connect.Response[greetingpb.GreetResponse], error) {
+	return c.greet.CallUnary(ctx, req)
+}
+
+// GreetMany calls greeting.GreetingService.GreetMany.
+func (c *greetingServiceClient) GreetMany(ctx context.Context, req *connect.Request[greetingpb.GreetManyRequest]) (*connect.ServerStreamForClient[greetingpb.GreetResult], error) {
+	return c.greetMany.CallServerStream(ctx, req)
+}
+
+// GreetChat calls greeting.GreetingService.GreetChat.
+func (c *greetingServiceClient) GreetChat(ctx context.Context) *connect.BidiStreamForClient[greetingpb.GreetRequest, greetingpb.GreetResult] {
+	return c.greetChat.CallBidiStream(ctx)
+}
+
+// GreetingServiceHandler is an implementation of the greeting.GreetingService service.
+type GreetingServiceHandler interface {
+	// Creates and returns a greeting for a given name.
+	// It is also served as POST /v1/greet with a JSON body on the HTTP port.
+	Greet(context.Context, *connect.Request[greetingpb.GreetRequest]) (*connect.Response[greetingpb.GreetResponse], error)
+	// Creates and streams greetings for a list of names.
+	// A greeting is sent as soon as its lookup completes, so greetings may arrive in a different order.
+	GreetMany(context.Context, *connect.Request[greetingpb.GreetManyRequest], *connect.ServerStream[greetingpb.GreetResult]) error
+	// Creates and streams a greeting for every name received.
+	// Greetings are sent in the same order as the names are received.
+	GreetChat(context.Context, *connect.BidiStream[greetingpb.GreetRequest, greetingpb.GreetResult]) error
+}
+
+// NewGreetingServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewGreetingServiceHandler(svc GreetingServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	greetingServiceMethods := greetingpb.File_greetingpb_greeting_proto.Services().ByName("GreetingService").Methods()
+	greetingServiceGreetHandler := connect.NewUnaryHandler(
+		GreetingServiceGreetProcedure,
+		svc.Greet,
+		connect.WithSchema(greetingServiceMethods.ByName("Greet")),
+		connect.WithHandlerOptions(opts...),
+	)
+	greetingServiceGreetManyHandler := connect.NewServerStreamHandler(
+		GreetingServiceGreetManyProcedure,
+		svc.GreetMany,
+		connect.WithSchema(greetingServiceMethods.ByName("GreetMany")),
+		connect.WithHandlerOptions(opts...),
+	)
+	greetingServiceGreetChatHandler := connect.NewBidiStreamHandler(
+		GreetingServiceGreetChatProcedure,
+		svc.GreetChat,
+		connect.WithSchema(greetingServiceMethods.ByName("GreetChat")),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/greeting.GreetingService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case GreetingServiceGreetProcedure:
+			greetingServiceGreetHandler.ServeHTTP(w, r)
+		case GreetingServiceGreetManyProcedure:
+			greetingServiceGreetManyHandler.ServeHTTP(w, r)
+		case GreetingServiceGreetChatProcedure:
+			greetingServiceGreetChatHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedGreetingServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedGreetingServiceHandler struct{}
+
+func (UnimplementedGreetingServiceHandler) Greet(context.Context, *connect.Request[greetingpb.GreetRequest]) (*connect.Response[greetingpb.GreetResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("greeting.GreetingService.Greet is not implemented"))
+}
+
+func (UnimplementedGreetingServiceHandler) GreetMany(context.Context, *connect.Request[greetingpb.GreetManyRequest], *connect.ServerStream[greetingpb.GreetResult]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("greeting.GreetingService.GreetMany is not implemented"))
+}
+
+func (UnimplementedGreetingServiceHandler) GreetChat(context.Context, *connect.BidiStream[greetingpb.GreetRequest, greetingpb.GreetResult]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("greeting.GreetingService.GreetChat is not implemented"))
+}
@@ -0,0 +1,222 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gardenbed/basil/httpx"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"grpc-service/internal/breaker"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		kind           Kind
+		expectedCode   codes.Code
+		expectedReason string
+	}{
+		{KindValidation, codes.InvalidArgument, "VALIDATION_FAILED"},
+		{KindNotFound, codes.NotFound, "NOT_FOUND"},
+		{KindRateLimited, codes.ResourceExhausted, "RATE_LIMITED"},
+		{KindUpstreamUnavailable, codes.Unavailable, "UPSTREAM_UNAVAILABLE"},
+		{KindDeadlineExceeded, codes.DeadlineExceeded, "DEADLINE_EXCEEDED"},
+		{KindCanceled, codes.Canceled, "CANCELED"},
+		{KindInternal, codes.Internal, "INTERNAL"},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			assert.Equal(t, tc.expectedCode, tc.kind.Code())
+			assert.Equal(t, tc.expectedReason, tc.kind.Reason())
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("github user not found")
+
+	tests := []struct {
+		name               string
+		err                *Error
+		expectedKind       Kind
+		expectedViolations []Violation
+		expectedError      string
+	}{
+		{
+			name:          "New",
+			err:           New(KindRateLimited, "rate limit exceeded"),
+			expectedKind:  KindRateLimited,
+			expectedError: "rate limit exceeded",
+		},
+		{
+			name:          "Wrap",
+			err:           Wrap(KindNotFound, cause),
+			expectedKind:  KindNotFound,
+			expectedError: "github user not found",
+		},
+		{
+			name:               "Validation",
+			err:                Validation("github username cannot be empty", Violation{"github_username", "cannot be empty"}),
+			expectedKind:       KindValidation,
+			expectedViolations: []Violation{{"github_username", "cannot be empty"}},
+			expectedError:      "github username cannot be empty",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedKind, tc.err.Kind)
+			assert.Equal(t, tc.expectedViolations, tc.err.Violations)
+			assert.EqualError(t, tc.err, tc.expectedError)
+		})
+	}
+
+	assert.True(t, errors.Is(Wrap(KindNotFound, cause), cause))
+}
+
+func TestStatus(t *testing.T) {
+	errorInfo := func(reason string) *errdetails.ErrorInfo {
+		return &errdetails.ErrorInfo{Reason: reason, Domain: Domain}
+	}
+
+	retryInfo := func(d time.Duration) *errdetails.RetryInfo {
+		return &errdetails.RetryInfo{RetryDelay: durationpb.New(d)}
+	}
+
+	tests := []struct {
+		name            string
+		err             error
+		expectedCode    codes.Code
+		expectedMessage string
+		expectedDetails []proto.Message
+	}{
+		{
+			name:            "Nil",
+			err:             nil,
+			expectedCode:    codes.OK,
+			expectedMessage: "",
+			expectedDetails: nil,
+		},
+		{
+			name:            "Status",
+			err:             status.Error(codes.Unauthenticated, "missing credentials"),
+			expectedCode:    codes.Unauthenticated,
+			expectedMessage: "missing credentials",
+			expectedDetails: nil,
+		},
+		{
+			name:            "Validation",
+			err:             Validation("github username cannot be empty", Violation{"github_username", "cannot be empty"}),
+			expectedCode:    codes.InvalidArgument,
+			expectedMessage: "github username cannot be empty",
+			expectedDetails: []proto.Message{
+				errorInfo("VALIDATION_FAILED"),
+				&errdetails.BadRequest{
+					FieldViolations: []*errdetails.BadRequest_FieldViolation{
+						{Field: "github_username", Description: "cannot be empty"},
+					},
+				},
+			},
+		},
+		{
+			name:            "NotFound",
+			err:             fmt.Errorf("%w: ghost", Wrap(KindNotFound, errors.New("github user not found"))),
+			expectedCode:    codes.NotFound,
+			expectedMessage: "github user not found: ghost",
+			expectedDetails: []proto.Message{errorInfo("NOT_FOUND")},
+		},
+		{
+			name:            "RateLimited",
+			err:             &Error{Kind: KindRateLimited, RetryDelay: 30 * time.Second, err: errors.New("rate limit exceeded")},
+			expectedCode:    codes.ResourceExhausted,
+			expectedMessage: "rate limit exceeded",
+			expectedDetails: []proto.Message{errorInfo("RATE_LIMITED"), retryInfo(30 * time.Second)},
+		},
+		{
+			name:            "CircuitBreakerOpen",
+			err:             fmt.Errorf("degraded: %w", breaker.ErrOpen),
+			expectedCode:    codes.Unavailable,
+			expectedMessage: "an upstream service is unavailable",
+			expectedDetails: []proto.Message{errorInfo("UPSTREAM_UNAVAILABLE"), retryInfo(DefaultRetryDelay)},
+		},
+		{
+			name: "UpstreamClientError",
+			err: httpx.NewClientError(&http.Response{
+				StatusCode: 502,
+				Request:    httptest.NewRequest("GET", "/users/octocat", nil),
+			}),
+			expectedCode:    codes.Unavailable,
+			expectedMessage: "an upstream service is unavailable",
+			expectedDetails: []proto.Message{errorInfo("UPSTREAM_UNAVAILABLE"), retryInfo(DefaultRetryDelay)},
+		},
+		{
+			name:            "UpstreamConnectionError",
+			err:             &url.Error{Op: "Get", URL: "https://api.github.com/users/octocat", Err: errors.New("connection refused")},
+			expectedCode:    codes.Unavailable,
+			expectedMessage: "an upstream service is unavailable",
+			expectedDetails: []proto.Message{errorInfo("UPSTREAM_UNAVAILABLE"), retryInfo(DefaultRetryDelay)},
+		},
+		{
+			name:            "DeadlineExceeded",
+			err:             &url.Error{Op: "Get", URL: "https://api.github.com/users/octocat", Err: context.DeadlineExceeded},
+			expectedCode:    codes.DeadlineExceeded,
+			expectedMessage: "deadline exceeded",
+			expectedDetails: []proto.Message{errorInfo("DEADLINE_EXCEEDED")},
+		},
+		{
+			name:            "Canceled",
+			err:             context.Canceled,
+			expectedCode:    codes.Canceled,
+			expectedMessage: "call canceled",
+			expectedDetails: []proto.Message{errorInfo("CANCELED")},
+		},
+		{
+			name:            "UnknownKind",
+			err:             Wrap(Kind("unknown"), errors.New("something went wrong")),
+			expectedCode:    codes.Internal,
+			expectedMessage: "internal error",
+			expectedDetails: []proto.Message{errorInfo("INTERNAL")},
+		},
+		{
+			name:            "Internal",
+			err:             errors.New("something went wrong"),
+			expectedCode:    codes.Internal,
+			expectedMessage: "internal error",
+			expectedDetails: []proto.Message{errorInfo("INTERNAL")},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			st := Status(tc.err)
+
+			assert.Equal(t, tc.expectedCode, st.Code())
+			assert.Equal(t, tc.expectedMessage, st.Message())
+			assertDetails(t, tc.expectedDetails, st.Details())
+
+			st, ok := status.FromError(Err(tc.err))
+			assert.True(t, ok)
+			assert.Equal(t, tc.expectedCode, st.Code())
+		})
+	}
+}
+
+func assertDetails(t *testing.T, expected []proto.Message, details []any) {
+	if assert.Len(t, details, len(expected)) {
+		for i, d := range details {
+			assert.True(t, proto.Equal(expected[i], d.(proto.Message)), "detail %d: %v", i, d)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"grpc-service/internal/problem"
+)
+
+// FailureLimiter limits the failed authentications of clients, so credentials cannot be brute forced.
+// It is used before authentication, so clients are identified by the subjects of their verified TLS certificates or their IP addresses.
+type FailureLimiter struct {
+	limiter *Limiter
+}
+
+// NewFailureLimiter creates a new limiter of failed authentications storing buckets using a Redis client.
+// Every failed authentication takes a token from the bucket of its client,
+// and calls are rejected before authentication when the bucket is empty.
+func NewFailureLimiter(client redis.Scripter, opts Options) *FailureLimiter {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultFailureLimit
+	}
+
+	return &FailureLimiter{
+		limiter: New(client, opts),
+	}
+}
+
+// ServerOptions returns the gRPC server options for limiting failed authentications of unary and stream calls.
+// They must be chained before the authentication interceptors.
+func (f *FailureLimiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(f.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(f.StreamInterceptor),
+	}
+}
+
+// UnaryInterceptor is a grpc.UnaryServerInterceptor.
+// It rejects calls of clients exceeding their limit of failed authentications with the ResourceExhausted code,
+// a RetryInfo detail, and a retry-after header metadata.
+// Calls failing with the Unauthenticated code are counted as failed authentications.
+func (f *FailureLimiter) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(ctx, req)
+	}
+
+	c := grpcClient(ctx)
+
+	md, err := f.check(ctx, c)
+	if md != nil {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := handler(ctx, req)
+	f.count(ctx, c, err)
+
+	return res, err
+}
+
+// StreamInterceptor is a grpc.StreamServerInterceptor.
+// It rejects streams of clients exceeding their limit of failed authentications with the ResourceExhausted code,
+// a RetryInfo detail, and a retry-after header metadata.
+// Streams failing with the Unauthenticated code are counted as failed authentications.
+func (f *FailureLimiter) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(srv, ss)
+	}
+
+	ctx := ss.Context()
+	c := grpcClient(ctx)
+
+	md, err := f.check(ctx, c)
+	if md != nil {
+		_ = ss.SetHeader(md)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = handler(srv, ss)
+	f.count(ctx, c, err)
+
+	return err
+}
+
+// check returns the retry-after header metadata and an error if a client exceeded its limit of failed authentications.
+// If rate limiting fails, the call is allowed.
+func (f *FailureLimiter) check(ctx context.Context, c Client) (metadata.MD, error) {
+	res, err := f.limiter.Peek(ctx, c)
+	if err != nil {
+		f.limiter.logger.Warn("rate limiting failed authentications failed, allowing request", "error", err)
+		return nil, nil
+	}
+
+	if !res.Allowed {
+		err := problem.New(problem.KindRateLimited, fmt.Sprintf("too many failed authentications, retry after %ds", seconds(res.RetryAfter)))
+		err.RetryDelay = res.RetryAfter
+		return metadata.Pairs("retry-after", strconv.Itoa(seconds(res.RetryAfter))), problem.Err(err)
+	}
+
+	return nil, nil
+}
+
+// count takes a token from the bucket of a client if its call failed authentication.
+func (f *FailureLimiter) count(ctx context.Context, c Client, err error) {
+	if status.Code(err) != codes.Unauthenticated {
+		return
+	}
+
+	if _, err := f.limiter.Allow(ctx, c); err != nil {
+		f.limiter.logger.Warn("rate limiting failed authentications failed", "error", err)
+	}
+}
@@ -0,0 +1,185 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewFailureLimiter(t *testing.T) {
+	f := NewFailureLimiter(nil, Options{})
+
+	assert.NotNil(t, f)
+	assert.Equal(t, DefaultFailureLimit, f.limiter.limit)
+	assert.Equal(t, DefaultFailureLimit, f.limiter.burst)
+}
+
+func TestFailureLimiter_ServerOptions(t *testing.T) {
+	f := NewFailureLimiter(nil, Options{})
+	assert.Len(t, f.ServerOptions(), 2)
+}
+
+func TestFailureLimiter_UnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name           string
+		redisDown      bool
+		handlerError   error
+		requests       int
+		expectedCode   codes.Code
+		expectedRetry  time.Duration
+		expectedHeader map[string]string
+	}{
+		{
+			name:           "Authenticated",
+			handlerError:   nil,
+			requests:       5,
+			expectedCode:   codes.OK,
+			expectedHeader: map[string]string{},
+		},
+		{
+			name:           "PermissionDenied",
+			handlerError:   status.Error(codes.PermissionDenied, "permission denied"),
+			requests:       5,
+			expectedCode:   codes.PermissionDenied,
+			expectedHeader: map[string]string{},
+		},
+		{
+			name:           "Unauthenticated",
+			handlerError:   status.Error(codes.Unauthenticated, "unauthenticated"),
+			requests:       1,
+			expectedCode:   codes.Unauthenticated,
+			expectedHeader: map[string]string{},
+		},
+		{
+			name:          "RepeatedlyUnauthenticated",
+			handlerError:  status.Error(codes.Unauthenticated, "unauthenticated"),
+			requests:      2,
+			expectedCode:  codes.ResourceExhausted,
+			expectedRetry: 30 * time.Second,
+			expectedHeader: map[string]string{
+				"retry-after": "30",
+			},
+		},
+		{
+			name:           "RedisFails",
+			redisDown:      true,
+			handlerError:   status.Error(codes.Unauthenticated, "unauthenticated"),
+			requests:       5,
+			expectedCode:   codes.Unauthenticated,
+			expectedHeader: map[string]string{},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			defer redisClient.Close()
+
+			if tc.redisDown {
+				mr.Close()
+			}
+
+			f := NewFailureLimiter(redisClient, Options{Limit: 2, Period: time.Minute})
+			info := &grpc.UnaryServerInfo{FullMethod: "/greeting.GreetingService/Greet"}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return "response", tc.handlerError
+			}
+
+			for range tc.requests {
+				ctx := grpc.NewContextWithServerTransportStream(context.Background(), new(serverTransportStream))
+				_, _ = f.UnaryInterceptor(ctx, "request", info, handler)
+			}
+
+			stream := new(serverTransportStream)
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			_, err := f.UnaryInterceptor(ctx, "request", info, handler)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedRetry > 0 {
+				details := status.Convert(err).Details()
+				if assert.Len(t, details, 2) {
+					assert.Equal(t, tc.expectedRetry, details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+				}
+			}
+
+			assert.Len(t, stream.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, stream.header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestFailureLimiter_StreamInterceptor(t *testing.T) {
+	tests := []struct {
+		name           string
+		handlerError   error
+		requests       int
+		expectedCode   codes.Code
+		expectedHeader map[string]string
+	}{
+		{
+			name:           "Authenticated",
+			handlerError:   nil,
+			requests:       5,
+			expectedCode:   codes.OK,
+			expectedHeader: map[string]string{},
+		},
+		{
+			name:           "Unauthenticated",
+			handlerError:   status.Error(codes.Unauthenticated, "unauthenticated"),
+			requests:       1,
+			expectedCode:   codes.Unauthenticated,
+			expectedHeader: map[string]string{},
+		},
+		{
+			name:         "RepeatedlyUnauthenticated",
+			handlerError: status.Error(codes.Unauthenticated, "unauthenticated"),
+			requests:     2,
+			expectedCode: codes.ResourceExhausted,
+			expectedHeader: map[string]string{
+				"retry-after": "30",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			f := NewFailureLimiter(redisClient, Options{Limit: 2, Period: time.Minute})
+			info := &grpc.StreamServerInfo{FullMethod: "/greeting.GreetingService/GreetChat"}
+			handler := func(srv any, ss grpc.ServerStream) error {
+				return tc.handlerError
+			}
+
+			for range tc.requests {
+				_ = f.StreamInterceptor(nil, new(serverStream), info, handler)
+			}
+
+			ss := new(serverStream)
+			err := f.StreamInterceptor(nil, ss, info, handler)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Len(t, ss.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, ss.header.Get(key), key)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"grpc-service/internal/auth"
+	"grpc-service/internal/problem"
+)
+
+// healthMethodPrefix is the prefix of the methods of the standard health service.
+// Health checks are not rate limited, so probes are never rejected.
+var healthMethodPrefix = "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+// ServerOptions returns the gRPC server options for rate limiting unary and stream calls.
+func (l *Limiter) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(l.UnaryInterceptor),
+		grpc.ChainStreamInterceptor(l.StreamInterceptor),
+	}
+}
+
+// UnaryInterceptor is a grpc.UnaryServerInterceptor.
+// It rejects calls exceeding the limit of their clients with the ResourceExhausted code and a RetryInfo detail.
+// Responses carry ratelimit-limit, ratelimit-remaining, ratelimit-reset, and ratelimit-policy header metadata,
+// and rejected responses carry a retry-after header metadata too.
+func (l *Limiter) UnaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(ctx, req)
+	}
+
+	md, err := l.allow(ctx, cost(req))
+	if md != nil {
+		_ = grpc.SetHeader(ctx, md)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// StreamInterceptor is a grpc.StreamServerInterceptor.
+// Every message received by a stream is rate limited just as a unary call,
+// so a long-lived stream cannot make more requests than its client is allowed to.
+// Once a message exceeds the limit of its client, receiving it fails with the ResourceExhausted code and the stream ends.
+// The rate limit metadata of the first message is sent as the header metadata,
+// and the rate limit metadata of a rejected message is sent as the trailer metadata.
+func (l *Limiter) StreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if strings.HasPrefix(info.FullMethod, healthMethodPrefix) {
+		return handler(srv, ss)
+	}
+
+	return handler(srv, &stream{
+		ServerStream: ss,
+		limiter:      l,
+	})
+}
+
+// stream is a grpc.ServerStream rate limiting the messages it receives.
+type stream struct {
+	grpc.ServerStream
+	limiter *Limiter
+	header  bool
+}
+
+// RecvMsg implements the grpc.ServerStream interface.
+func (s *stream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	md, err := s.limiter.allow(s.Context(), cost(m))
+	if md != nil {
+		if !s.header {
+			_ = s.SetHeader(md)
+			s.header = true
+		} else if err != nil {
+			s.SetTrailer(md)
+		}
+	}
+
+	return err
+}
+
+// batchRequest is implemented by requests greeting many GitHub users at once (e.g. GreetManyRequest).
+type batchRequest interface {
+	GetGithubUsernames() []string
+}
+
+// cost returns the number of requests a message is worth.
+// A batch request is worth a request per GitHub username, so batching does not bypass the limit.
+func cost(m any) int {
+	if r, ok := m.(batchRequest); ok {
+		return len(r.GetGithubUsernames())
+	}
+
+	return 1
+}
+
+// allow returns the rate limit header metadata for a call worth n requests and an error if the call is rejected.
+// If rate limiting fails, the call is allowed without any header metadata.
+func (l *Limiter) allow(ctx context.Context, n int) (metadata.MD, error) {
+	res, err := l.AllowN(ctx, grpcClient(ctx), n)
+	if err != nil {
+		l.logger.Warn("rate limiting failed, allowing request", "error", err)
+		return nil, nil
+	}
+
+	md := metadata.Pairs(
+		"ratelimit-limit", strconv.Itoa(res.Limit),
+		"ratelimit-remaining", strconv.Itoa(res.Remaining),
+		"ratelimit-reset", strconv.Itoa(seconds(res.Reset)),
+		"ratelimit-policy", strconv.Itoa(res.Limit)+";w="+strconv.Itoa(seconds(res.Period)),
+	)
+
+	if !res.Allowed {
+		md.Set("retry-after", strconv.Itoa(seconds(res.RetryAfter)))
+		err := problem.New(problem.KindRateLimited, fmt.Sprintf("rate limit exceeded, retry after %ds", seconds(res.RetryAfter)))
+		err.RetryDelay = res.RetryAfter
+		return md, problem.Err(err)
+	}
+
+	return md, nil
+}
+
+// grpcClient identifies the client of a call by its authenticated principal, the subject of its verified TLS certificate, or its IP address.
+// Calls are authenticated before being rate limited, so the principal is in the call context if authentication is enabled.
+func grpcClient(ctx context.Context) Client {
+	if p, ok := auth.FromContext(ctx); ok {
+		return Principal(p)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return IP("unknown")
+	}
+
+	if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		if chains := info.State.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+			return Subject(chains[0][0].Subject.String())
+		}
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	return IP(host)
+}
@@ -0,0 +1,380 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"grpc-service/internal/auth"
+	"grpc-service/internal/idl/greetingpb"
+)
+
+// serverTransportStream is a grpc.ServerTransportStream for capturing the header metadata set by interceptors.
+type serverTransportStream struct {
+	header metadata.MD
+}
+
+func (s *serverTransportStream) Method() string {
+	return "/greeting.GreetingService/Greet"
+}
+
+func (s *serverTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *serverTransportStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+// serverStream is a grpc.ServerStream receiving messages and capturing the metadata set by interceptors.
+type serverStream struct {
+	grpc.ServerStream
+	messages []proto.Message
+	header   metadata.MD
+	trailer  metadata.MD
+}
+
+func (s *serverStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *serverStream) RecvMsg(m any) error {
+	if len(s.messages) == 0 {
+		return io.EOF
+	}
+
+	proto.Merge(m.(proto.Message), s.messages[0])
+	s.messages = s.messages[1:]
+	return nil
+}
+
+func (s *serverStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func TestLimiter_ServerOptions(t *testing.T) {
+	l := New(nil, Options{})
+	assert.Len(t, l.ServerOptions(), 2)
+}
+
+func TestLimiter_UnaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name             string
+		redisDown        bool
+		requests         int
+		expectedResponse any
+		expectedHeader   map[string]string
+		expectedRetry    time.Duration
+		expectedError    string
+	}{
+		{
+			name:             "Allowed",
+			requests:         0,
+			expectedResponse: "response",
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "1",
+				"ratelimit-reset":     "30",
+				"ratelimit-policy":    "2;w=60",
+			},
+			expectedError: "",
+		},
+		{
+			name:             "Rejected",
+			requests:         2,
+			expectedResponse: nil,
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "0",
+				"ratelimit-reset":     "60",
+				"ratelimit-policy":    "2;w=60",
+				"retry-after":         "30",
+			},
+			expectedRetry: 30 * time.Second,
+			expectedError: "rpc error: code = ResourceExhausted desc = rate limit exceeded, retry after 30s",
+		},
+		{
+			name:             "RedisFails",
+			redisDown:        true,
+			requests:         0,
+			expectedResponse: "response",
+			expectedHeader:   map[string]string{},
+			expectedError:    "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			defer redisClient.Close()
+
+			if tc.redisDown {
+				mr.Close()
+			}
+
+			l := New(redisClient, Options{Limit: 2, Period: time.Minute})
+			info := &grpc.UnaryServerInfo{FullMethod: "/greeting.GreetingService/Greet"}
+			handler := func(ctx context.Context, req any) (any, error) {
+				return "response", nil
+			}
+
+			for range tc.requests {
+				ctx := grpc.NewContextWithServerTransportStream(context.Background(), new(serverTransportStream))
+				_, _ = l.UnaryInterceptor(ctx, "request", info, handler)
+			}
+
+			stream := new(serverTransportStream)
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			response, err := l.UnaryInterceptor(ctx, "request", info, handler)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+				details := status.Convert(err).Details()
+				if assert.Len(t, details, 2) {
+					assert.Equal(t, tc.expectedRetry, details[1].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+				}
+			}
+
+			assert.Equal(t, tc.expectedResponse, response)
+			assert.Len(t, stream.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, stream.header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestLimiter_StreamInterceptor(t *testing.T) {
+	greetRequest := &greetingpb.GreetRequest{GithubUsername: "octocat"}
+	greetManyRequest := &greetingpb.GreetManyRequest{GithubUsernames: []string{"octocat", "hubot"}}
+
+	tests := []struct {
+		name             string
+		redisDown        bool
+		messages         []proto.Message
+		expectedReceived int
+		expectedHeader   map[string]string
+		expectedTrailer  map[string]string
+		expectedError    string
+	}{
+		{
+			name:             "Allowed",
+			messages:         []proto.Message{greetRequest},
+			expectedReceived: 1,
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "1",
+				"ratelimit-reset":     "30",
+				"ratelimit-policy":    "2;w=60",
+			},
+			expectedTrailer: map[string]string{},
+			expectedError:   "",
+		},
+		{
+			name:             "Rejected",
+			messages:         []proto.Message{greetRequest, greetRequest, greetRequest},
+			expectedReceived: 2,
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "1",
+				"ratelimit-reset":     "30",
+				"ratelimit-policy":    "2;w=60",
+			},
+			expectedTrailer: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "0",
+				"ratelimit-reset":     "60",
+				"ratelimit-policy":    "2;w=60",
+				"retry-after":         "30",
+			},
+			expectedError: "rpc error: code = ResourceExhausted desc = rate limit exceeded, retry after 30s",
+		},
+		{
+			name:             "Batch",
+			messages:         []proto.Message{greetManyRequest},
+			expectedReceived: 1,
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "0",
+				"ratelimit-reset":     "60",
+				"ratelimit-policy":    "2;w=60",
+			},
+			expectedTrailer: map[string]string{},
+			expectedError:   "",
+		},
+		{
+			name:             "BatchRejected",
+			messages:         []proto.Message{greetRequest, greetManyRequest},
+			expectedReceived: 1,
+			expectedHeader: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "1",
+				"ratelimit-reset":     "30",
+				"ratelimit-policy":    "2;w=60",
+			},
+			expectedTrailer: map[string]string{
+				"ratelimit-limit":     "2",
+				"ratelimit-remaining": "1",
+				"ratelimit-reset":     "30",
+				"ratelimit-policy":    "2;w=60",
+				"retry-after":         "30",
+			},
+			expectedError: "rpc error: code = ResourceExhausted desc = rate limit exceeded, retry after 30s",
+		},
+		{
+			name:             "RedisFails",
+			redisDown:        true,
+			messages:         []proto.Message{greetRequest, greetRequest, greetRequest},
+			expectedReceived: 3,
+			expectedHeader:   map[string]string{},
+			expectedTrailer:  map[string]string{},
+			expectedError:    "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			defer redisClient.Close()
+
+			if tc.redisDown {
+				mr.Close()
+			}
+
+			l := New(redisClient, Options{Limit: 2, Period: time.Minute})
+			info := &grpc.StreamServerInfo{FullMethod: "/greeting.GreetingService/GreetChat"}
+
+			var received int
+			handler := func(srv any, ss grpc.ServerStream) error {
+				for _, m := range tc.messages {
+					if err := ss.RecvMsg(m.ProtoReflect().New().Interface()); err != nil {
+						return err
+					}
+					received++
+				}
+				return nil
+			}
+
+			stream := &serverStream{messages: tc.messages}
+			err := l.StreamInterceptor(nil, stream, info, handler)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			assert.Equal(t, tc.expectedReceived, received)
+			assert.Len(t, stream.header, len(tc.expectedHeader))
+			for key, val := range tc.expectedHeader {
+				assert.Equal(t, []string{val}, stream.header.Get(key), key)
+			}
+			assert.Len(t, stream.trailer, len(tc.expectedTrailer))
+			for key, val := range tc.expectedTrailer {
+				assert.Equal(t, []string{val}, stream.trailer.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestGRPCClient(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.ParseIP("192.0.2.1"), Port: 1234}
+	principal := &auth.Principal{Subject: "client", Method: auth.MethodJWT}
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		expectedClient Client
+	}{
+		{
+			name:           "Principal",
+			ctx:            auth.NewContext(peer.NewContext(context.Background(), &peer.Peer{Addr: addr}), principal),
+			expectedClient: Principal(principal),
+		},
+		{
+			// Credentials are not verified by the limiter, so they never identify a client
+			name: "UnverifiedAPIKey",
+			ctx: metadata.NewIncomingContext(
+				peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+				metadata.Pairs("x-api-key", "secret"),
+			),
+			expectedClient: IP("192.0.2.1"),
+		},
+		{
+			name: "Subject",
+			ctx: peer.NewContext(context.Background(), &peer.Peer{
+				Addr: addr,
+				AuthInfo: credentials.TLSInfo{
+					State: tls.ConnectionState{
+						VerifiedChains: [][]*x509.Certificate{
+							{{Subject: pkix.Name{CommonName: "client"}}},
+						},
+					},
+				},
+			}),
+			expectedClient: Subject("CN=client"),
+		},
+		{
+			name:           "IP",
+			ctx:            peer.NewContext(context.Background(), &peer.Peer{Addr: addr}),
+			expectedClient: IP("192.0.2.1"),
+		},
+		{
+			name:           "NoPeer",
+			ctx:            context.Background(),
+			expectedClient: IP("unknown"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedClient, grpcClient(tc.ctx))
+		})
+	}
+}
+
+func TestLimiter_UnaryInterceptor_HealthMethods(t *testing.T) {
+	l := New(nil, Options{})
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+
+	response, err := l.UnaryInterceptor(context.Background(), "request", info, func(ctx context.Context, req any) (any, error) {
+		return "response", nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "response", response)
+}
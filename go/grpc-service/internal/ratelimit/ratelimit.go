@@ -0,0 +1,216 @@
+// Package ratelimit implements distributed per-client rate limiting using token buckets stored in Redis.
+//
+// Each client has a bucket holding up to a burst of tokens, refilled at a rate of limit tokens per period.
+// Every request takes one or more tokens from the bucket of its client, and requests are rejected when the bucket does not hold enough tokens.
+// Buckets are updated atomically by a Lua script using the clock of Redis, so limits hold across replicas.
+// If Redis is unavailable, requests are allowed, so an outage of Redis does not become an outage of the service.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gardenbed/basil/telemetry"
+	"github.com/redis/go-redis/v9"
+
+	"grpc-service/internal/auth"
+)
+
+const (
+	// DefaultNamespace is the default prefix for the keys of buckets.
+	DefaultNamespace = "ratelimit"
+	// DefaultLimit is the default number of requests allowed per period.
+	DefaultLimit = 60
+	// DefaultPeriod is the default period for refilling the limit of requests.
+	DefaultPeriod = time.Minute
+	// DefaultFailureLimit is the default number of failed authentications allowed per period.
+	DefaultFailureLimit = 10
+
+	// version is bumped whenever the format of buckets changes.
+	version = "v1"
+)
+
+// script takes a number of tokens from a bucket and returns whether the request is allowed,
+// the remaining tokens, the milliseconds until the tokens are available, and the milliseconds until the bucket is full.
+// Taking no tokens checks whether a token is available without taking it.
+var script = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+local need = math.max(cost, 1)
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * limit / period)
+
+local allowed = 0
+local retry = 0
+if tokens >= need then
+	allowed = 1
+	tokens = tokens - cost
+else
+	retry = math.ceil((need - tokens) * period / limit)
+end
+
+local reset = math.ceil((burst - tokens) * period / limit)
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+
+return { allowed, math.floor(tokens), retry, reset }
+`)
+
+// Options are optional configurations for creating a new limiter.
+type Options struct {
+	// Namespace is the prefix for the keys of buckets (DefaultNamespace if empty).
+	Namespace string
+	// Limit is the number of requests allowed per period (DefaultLimit if zero).
+	Limit int
+	// Period is the period for refilling the limit of requests (DefaultPeriod if zero).
+	Period time.Duration
+	// Burst is the maximum number of requests allowed at once (Limit if zero).
+	Burst int
+	// Logger is used for reporting failures of Redis (the global logger if nil).
+	Logger telemetry.Logger
+}
+
+// Result is the outcome of taking a token for a request.
+type Result struct {
+	// Allowed determines whether the request is allowed.
+	Allowed bool
+	// Limit is the number of requests allowed per period.
+	Limit int
+	// Period is the period for refilling the limit of requests.
+	Period time.Duration
+	// Remaining is the number of requests allowed right now.
+	Remaining int
+	// RetryAfter is the duration until the next request is allowed if the request is not allowed.
+	RetryAfter time.Duration
+	// Reset is the duration until all the requests are allowed again.
+	Reset time.Duration
+}
+
+// Limiter is a distributed rate limiter.
+type Limiter struct {
+	client    redis.Scripter
+	namespace string
+	limit     int
+	period    time.Duration
+	burst     int
+	logger    telemetry.Logger
+}
+
+// New creates a new limiter storing buckets using a Redis client.
+func New(client redis.Scripter, opts Options) *Limiter {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	if opts.Period <= 0 {
+		opts.Period = DefaultPeriod
+	}
+
+	if opts.Burst <= 0 {
+		opts.Burst = opts.Limit
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = telemetry.Get().Logger()
+	}
+
+	return &Limiter{
+		client:    client,
+		namespace: opts.Namespace,
+		limit:     opts.Limit,
+		period:    opts.Period,
+		burst:     opts.Burst,
+		logger:    opts.Logger,
+	}
+}
+
+// Client identifies a client for rate limiting.
+type Client struct {
+	// Kind is the kind of identity, such as principal, subject, or ip.
+	Kind string
+	// ID is the identity of the client.
+	ID string
+}
+
+// Principal identifies a client by its authenticated principal.
+// Clients are never identified by their credentials, since credentials are not verified by the limiter,
+// and a client presenting a new credential for every request would get a new bucket every time.
+func Principal(p *auth.Principal) Client {
+	return Client{Kind: "principal", ID: p.Method + ":" + p.Subject}
+}
+
+// Subject identifies a client by the subject of its TLS certificate.
+func Subject(subject string) Client {
+	return Client{Kind: "subject", ID: subject}
+}
+
+// IP identifies a client by its IP address.
+func IP(ip string) Client {
+	return Client{Kind: "ip", ID: ip}
+}
+
+func (l *Limiter) key(c Client) string {
+	return fmt.Sprintf("%s:%s:bucket:%s:%s", l.namespace, version, c.Kind, c.ID)
+}
+
+// Allow takes a token from the bucket of a client for a request.
+func (l *Limiter) Allow(ctx context.Context, c Client) (*Result, error) {
+	return l.AllowN(ctx, c, 1)
+}
+
+// AllowN takes n tokens from the bucket of a client for a request worth n requests.
+// Requests worth more than the burst take the whole burst, so they are allowed once the bucket is full.
+func (l *Limiter) AllowN(ctx context.Context, c Client, n int) (*Result, error) {
+	return l.take(ctx, c, min(max(n, 1), l.burst))
+}
+
+// Peek checks whether a token is available in the bucket of a client without taking it.
+func (l *Limiter) Peek(ctx context.Context, c Client) (*Result, error) {
+	return l.take(ctx, c, 0)
+}
+
+func (l *Limiter) take(ctx context.Context, c Client, n int) (*Result, error) {
+	period := max(l.period.Milliseconds(), 1)
+
+	vals, err := script.Run(ctx, l.client, []string{l.key(c)}, l.burst, l.limit, period, n).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vals) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", vals)
+	}
+
+	return &Result{
+		Allowed:    vals[0] == 1,
+		Limit:      l.limit,
+		Period:     l.period,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+		Reset:      time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
+
+// seconds rounds a duration up to whole seconds for headers.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
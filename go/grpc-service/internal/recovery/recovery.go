@@ -0,0 +1,65 @@
+// Package recovery recovers from panics in gRPC handlers.
+// A recovered panic is logged with its stack trace, and the call fails with the Internal code instead of crashing the server.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/gardenbed/basil/telemetry"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Interceptor is a gRPC server interceptor for recovering from panics in handlers.
+type Interceptor struct {
+	logger telemetry.Logger
+}
+
+// NewInterceptor creates a new interceptor logging recovered panics using a logger.
+func NewInterceptor(logger telemetry.Logger) *Interceptor {
+	return &Interceptor{
+		logger: logger,
+	}
+}
+
+// ServerOptions returns the gRPC server options for recovering from panics in unary and stream handlers.
+func (i *Interceptor) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.unaryInterceptor),
+		grpc.ChainStreamInterceptor(i.streamInterceptor),
+	}
+}
+
+// recovered logs a recovered panic and returns an error with the Internal code.
+func (i *Interceptor) recovered(method string, val any) error {
+	i.logger.Error("recovered from panic",
+		"panic", fmt.Sprint(val),
+		"method", method,
+		"stack", string(debug.Stack()),
+	)
+
+	return status.Error(codes.Internal, "internal error")
+}
+
+func (i *Interceptor) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if val := recover(); val != nil {
+			resp, err = nil, i.recovered(info.FullMethod, val)
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+func (i *Interceptor) streamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if val := recover(); val != nil {
+			err = i.recovered(info.FullMethod, val)
+		}
+	}()
+
+	return handler(srv, ss)
+}
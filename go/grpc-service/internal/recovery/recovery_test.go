@@ -0,0 +1,102 @@
+package recovery
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gardenbed/basil/telemetry"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+// logger is a telemetry.Logger recording error logs for testing.
+type logger struct {
+	telemetry.Logger
+	messages []string
+	kvs      [][]any
+}
+
+func (l *logger) Error(message string, kv ...any) {
+	l.messages = append(l.messages, message)
+	l.kvs = append(l.kvs, kv)
+}
+
+func TestInterceptor_ServerOptions(t *testing.T) {
+	i := NewInterceptor(new(logger))
+	assert.Len(t, i.ServerOptions(), 2)
+}
+
+func TestInterceptor(t *testing.T) {
+	const method = "/greeting.GreetingService/Greet"
+
+	tests := []struct {
+		name          string
+		panic         any
+		expectedError string
+		expectedPanic string
+	}{
+		{
+			name:          "NoPanic",
+			panic:         nil,
+			expectedError: "",
+			expectedPanic: "",
+		},
+		{
+			name:          "Panic",
+			panic:         "something went wrong",
+			expectedError: "rpc error: code = Internal desc = internal error",
+			expectedPanic: "something went wrong",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			verify := func(t *testing.T, l *logger, err error) {
+				if tc.expectedError == "" {
+					assert.NoError(t, err)
+					assert.Empty(t, l.messages)
+				} else {
+					assert.EqualError(t, err, tc.expectedError)
+					assert.Equal(t, []string{"recovered from panic"}, l.messages)
+					kv := l.kvs[0]
+					assert.Equal(t, []any{"panic", tc.expectedPanic, "method", method, "stack"}, kv[:len(kv)-1])
+					assert.True(t, strings.Contains(kv[len(kv)-1].(string), "recovery.TestInterceptor"))
+				}
+			}
+
+			t.Run("Unary", func(t *testing.T) {
+				l := new(logger)
+				i := NewInterceptor(l)
+
+				resp, err := i.unaryInterceptor(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req any) (any, error) {
+					if tc.panic != nil {
+						panic(tc.panic)
+					}
+					return "response", nil
+				})
+
+				verify(t, l, err)
+				if tc.expectedError == "" {
+					assert.Equal(t, "response", resp)
+				} else {
+					assert.Nil(t, resp)
+				}
+			})
+
+			t.Run("Stream", func(t *testing.T) {
+				l := new(logger)
+				i := NewInterceptor(l)
+
+				err := i.streamInterceptor(nil, nil, &grpc.StreamServerInfo{FullMethod: method}, func(srv any, ss grpc.ServerStream) error {
+					if tc.panic != nil {
+						panic(tc.panic)
+					}
+					return nil
+				})
+
+				verify(t, l, err)
+			})
+		})
+	}
+}
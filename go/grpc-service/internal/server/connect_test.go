@@ -0,0 +1,385 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"grpc-service/internal/idl/greetingpb"
+	"grpc-service/internal/idl/greetingpb/greetingpbconnect"
+	"grpc-service/internal/problem"
+)
+
+// newConnectServer creates an http test server serving a greeting service over the Connect, gRPC-Web, and gRPC protocols
+// through the in-process grpc server.
+func newConnectServer(t *testing.T, greetingService greetingpb.GreetingServiceServer, opts GRPCOptions) *httptest.Server {
+	s, err := NewGRPC(greetingService, opts)
+	assert.NoError(t, err)
+
+	go func() {
+		_ = s.local.Serve(s.localLis)
+	}()
+
+	conn, err := s.LocalConn()
+	assert.NoError(t, err)
+
+	healthHandler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	h, err := NewHTTP(healthHandler, HTTPOptions{
+		Connect: NewConnect(conn),
+	})
+	assert.NoError(t, err)
+
+	server := h.server.(*http.Server)
+	ts := httptest.NewUnstartedServer(server.Handler)
+	ts.Config.Protocols = server.Protocols
+	ts.Start()
+
+	t.Cleanup(func() {
+		ts.Close()
+		_ = conn.Close()
+		_ = s.Shutdown(context.Background())
+	})
+
+	return ts
+}
+
+// newConnectClient creates a greeting client for a protocol.
+func newConnectClient(ts *httptest.Server, protocol string) greetingpbconnect.GreetingServiceClient {
+	switch protocol {
+	case "gRPC-Web":
+		return greetingpbconnect.NewGreetingServiceClient(ts.Client(), ts.URL, connect.WithGRPCWeb())
+	case "gRPC":
+		// The gRPC protocol requires HTTP/2, which is served without TLS (h2c)
+		return greetingpbconnect.NewGreetingServiceClient(newH2CClient(), ts.URL, connect.WithGRPC())
+	default:
+		return greetingpbconnect.NewGreetingServiceClient(ts.Client(), ts.URL)
+	}
+}
+
+// newH2CClient creates an http client using HTTP/2 without TLS (h2c).
+func newH2CClient() *http.Client {
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &http.Client{
+		Transport: &http.Transport{Protocols: protocols},
+	}
+}
+
+func TestConnect(t *testing.T) {
+	protocols := []string{"Connect", "gRPC-Web", "gRPC"}
+
+	tests := []struct {
+		name             string
+		greetMock        GreetMock
+		expectedResponse *greetingpb.GreetResponse
+		expectedCode     connect.Code
+		expectedMessage  string
+		expectedReason   string
+	}{
+		{
+			name: "Success",
+			greetMock: GreetMock{
+				OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"},
+			},
+			expectedResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"},
+		},
+		{
+			name: "UserNotFound",
+			greetMock: GreetMock{
+				OutError: problem.Err(problem.Wrap(problem.KindNotFound, errors.New("github user not found"))),
+			},
+			expectedCode:    connect.CodeNotFound,
+			expectedMessage: "github user not found",
+			expectedReason:  "NOT_FOUND",
+		},
+		{
+			name: "InternalError",
+			greetMock: GreetMock{
+				OutError: problem.Err(errors.New("something went wrong")),
+			},
+			expectedCode:    connect.CodeInternal,
+			expectedMessage: "internal error",
+			expectedReason:  "INTERNAL",
+		},
+	}
+
+	for _, protocol := range protocols {
+		for _, tc := range tests {
+			t.Run(protocol+"_"+tc.name, func(t *testing.T) {
+				greetingService := &MockGreetingService{
+					GreetMocks: []GreetMock{tc.greetMock},
+				}
+
+				// The interceptors of the gRPC server intercept the calls over other protocols too
+				var interceptedMethod string
+				interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+					interceptedMethod = info.FullMethod
+					_ = grpc.SetHeader(ctx, metadata.Pairs("ratelimit-remaining", "9"))
+					return handler(ctx, req)
+				}
+
+				ts := newConnectServer(t, greetingService, GRPCOptions{
+					Options: []grpc.ServerOption{
+						grpc.ChainUnaryInterceptor(interceptor),
+					},
+				})
+
+				req := connect.NewRequest(&greetingpb.GreetRequest{GithubUsername: "octocat"})
+				req.Header().Set("X-API-Key", "secret")
+				req.Header().Set("X-Ignored", "ignored")
+
+				res, err := newConnectClient(ts, protocol).Greet(context.Background(), req)
+
+				assert.Equal(t, greetingpbconnect.GreetingServiceGreetProcedure, interceptedMethod)
+				assert.Equal(t, "octocat", greetingService.GreetMocks[0].InRequest.GithubUsername)
+
+				md, _ := metadata.FromIncomingContext(greetingService.GreetMocks[0].InContext)
+				assert.Equal(t, []string{"secret"}, md.Get("x-api-key"))
+				assert.Equal(t, []string{"127.0.0.1"}, md.Get("x-forwarded-for"))
+				assert.Empty(t, md.Get("x-ignored"))
+
+				if tc.expectedResponse != nil {
+					assert.NoError(t, err)
+					assert.Equal(t, tc.expectedResponse.Greeting, res.Msg.Greeting)
+					assert.Equal(t, "9", res.Header().Get("Ratelimit-Remaining"))
+					return
+				}
+
+				var connectErr *connect.Error
+				if assert.True(t, errors.As(err, &connectErr)) {
+					assert.Equal(t, tc.expectedCode, connectErr.Code())
+					assert.Equal(t, tc.expectedMessage, connectErr.Message())
+					assert.Equal(t, "9", connectErr.Meta().Get("Ratelimit-Remaining"))
+
+					if details := connectErr.Details(); assert.Len(t, details, 1) {
+						detail, err := details[0].Value()
+						assert.NoError(t, err)
+						if info, ok := detail.(*errdetails.ErrorInfo); assert.True(t, ok) {
+							assert.Equal(t, tc.expectedReason, info.Reason)
+							assert.Equal(t, problem.Domain, info.Domain)
+						}
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestConnect_ForwardedTLS(t *testing.T) {
+	greetingService := &MockGreetingService{
+		GreetMocks: []GreetMock{
+			{OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"}},
+		},
+	}
+
+	var callPeer *peer.Peer
+	interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		callPeer, _ = peer.FromContext(ctx)
+		return handler(ctx, req)
+	}
+
+	s, err := NewGRPC(greetingService, GRPCOptions{
+		Options: []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(interceptor),
+		},
+	})
+	assert.NoError(t, err)
+	defer s.Shutdown(context.Background())
+
+	go func() {
+		_ = s.local.Serve(s.localLis)
+	}()
+
+	conn, err := s.LocalConn()
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	ts := httptest.NewTLSServer(NewConnect(conn))
+	defer ts.Close()
+
+	client := greetingpbconnect.NewGreetingServiceClient(ts.Client(), ts.URL)
+	_, err = client.Greet(context.Background(), connect.NewRequest(&greetingpb.GreetRequest{GithubUsername: "octocat"}))
+	assert.NoError(t, err)
+
+	// The in-process server sees the TLS connection state of the HTTP client
+	if assert.NotNil(t, callPeer) {
+		info, ok := callPeer.AuthInfo.(credentials.TLSInfo)
+		if assert.True(t, ok) {
+			assert.True(t, info.State.HandshakeComplete)
+		}
+	}
+}
+
+func TestCopyMetadata(t *testing.T) {
+	h := http.Header{}
+	copyMetadata(h, metadata.MD{
+		"content-type":         {"application/grpc"},
+		"grpc-accept-encoding": {"gzip"},
+		"ratelimit-remaining":  {"9"},
+		"trace-bin":            {"\x00\x01"},
+	})
+
+	assert.Equal(t, http.Header{
+		"Ratelimit-Remaining": {"9"},
+		"Trace-Bin":           {"AAE"},
+	}, h)
+}
+
+func TestConnect_GreetMany(t *testing.T) {
+	protocols := []string{"Connect", "gRPC-Web", "gRPC"}
+
+	tests := []struct {
+		name            string
+		greetManyMock   GreetManyMock
+		expectedResults []string
+		expectedCode    connect.Code
+	}{
+		{
+			name: "Success",
+			greetManyMock: GreetManyMock{
+				OutResults: []*greetingpb.GreetResult{
+					{GithubUsername: "octocat", Greeting: "Hello, Octocat!"},
+					{GithubUsername: "hubot", Greeting: "Hello, Hubot!"},
+				},
+			},
+			expectedResults: []string{"Hello, Octocat!", "Hello, Hubot!"},
+		},
+		{
+			name: "Canceled",
+			greetManyMock: GreetManyMock{
+				OutResults: []*greetingpb.GreetResult{
+					{GithubUsername: "octocat", Greeting: "Hello, Octocat!"},
+				},
+				OutError: problem.Err(context.Canceled),
+			},
+			expectedResults: []string{"Hello, Octocat!"},
+			expectedCode:    connect.CodeCanceled,
+		},
+	}
+
+	for _, protocol := range protocols {
+		for _, tc := range tests {
+			t.Run(protocol+"_"+tc.name, func(t *testing.T) {
+				greetingService := &MockGreetingService{
+					GreetManyMocks: []GreetManyMock{tc.greetManyMock},
+				}
+
+				var interceptedMethod string
+				interceptor := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+					interceptedMethod = info.FullMethod
+					_ = ss.SetHeader(metadata.Pairs("ratelimit-remaining", "9"))
+					return handler(srv, ss)
+				}
+
+				ts := newConnectServer(t, greetingService, GRPCOptions{
+					Options: []grpc.ServerOption{
+						grpc.ChainStreamInterceptor(interceptor),
+					},
+				})
+
+				req := connect.NewRequest(&greetingpb.GreetManyRequest{GithubUsernames: []string{"octocat", "hubot"}})
+				req.Header().Set("X-API-Key", "secret")
+
+				stream, err := newConnectClient(ts, protocol).GreetMany(context.Background(), req)
+				assert.NoError(t, err)
+
+				var results []string
+				for stream.Receive() {
+					results = append(results, stream.Msg().Greeting)
+				}
+
+				assert.Equal(t, tc.expectedResults, results)
+				assert.Equal(t, "9", stream.ResponseHeader().Get("Ratelimit-Remaining"))
+				if tc.expectedCode == 0 {
+					assert.NoError(t, stream.Err())
+				} else {
+					assert.Equal(t, tc.expectedCode, connect.CodeOf(stream.Err()))
+				}
+				assert.NoError(t, stream.Close())
+
+				assert.Equal(t, greetingpbconnect.GreetingServiceGreetManyProcedure, interceptedMethod)
+				assert.Equal(t, []string{"octocat", "hubot"}, greetingService.GreetManyMocks[0].InRequest.GithubUsernames)
+
+				ctx := greetingService.GreetManyMocks[0].InContext
+				md, _ := metadata.FromIncomingContext(ctx)
+				assert.Equal(t, []string{"secret"}, md.Get("x-api-key"))
+
+				if p, ok := peer.FromContext(ctx); assert.True(t, ok) {
+					assert.Equal(t, "127.0.0.1:0", p.Addr.String())
+				}
+			})
+		}
+	}
+}
+
+func TestConnect_GreetChat(t *testing.T) {
+	// Bidirectional streams require HTTP/2
+	protocols := map[string]connect.ClientOption{
+		"Connect": connect.WithProtoJSON(),
+		"gRPC":    connect.WithGRPC(),
+	}
+
+	for protocol, opt := range protocols {
+		t.Run(protocol, func(t *testing.T) {
+			greetingService := &MockGreetingService{
+				GreetChatMocks: []GreetChatMock{
+					{
+						OutResults: []*greetingpb.GreetResult{
+							{GithubUsername: "octocat", Greeting: "Hello, Octocat!"},
+							{GithubUsername: "hubot", Greeting: "Hello, Hubot!"},
+						},
+					},
+				},
+			}
+
+			ts := newConnectServer(t, greetingService, GRPCOptions{})
+			client := greetingpbconnect.NewGreetingServiceClient(newH2CClient(), ts.URL, opt)
+
+			stream := client.GreetChat(context.Background())
+			stream.RequestHeader().Set("X-API-Key", "secret")
+
+			// Every result is received before sending the next request
+			var results []string
+			for _, username := range []string{"octocat", "hubot"} {
+				assert.NoError(t, stream.Send(&greetingpb.GreetRequest{GithubUsername: username}))
+
+				res, err := stream.Receive()
+				assert.NoError(t, err)
+				results = append(results, res.Greeting)
+			}
+
+			assert.NoError(t, stream.CloseRequest())
+
+			_, err := stream.Receive()
+			assert.ErrorIs(t, err, io.EOF)
+			assert.NoError(t, stream.CloseResponse())
+
+			assert.Equal(t, []string{"Hello, Octocat!", "Hello, Hubot!"}, results)
+
+			mock := greetingService.GreetChatMocks[0]
+			if assert.Len(t, mock.InRequests, 2) {
+				assert.Equal(t, "octocat", mock.InRequests[0].GithubUsername)
+				assert.Equal(t, "hubot", mock.InRequests[1].GithubUsername)
+			}
+
+			md, _ := metadata.FromIncomingContext(mock.InContext)
+			assert.Equal(t, []string{"secret"}, md.Get("x-api-key"))
+			assert.Equal(t, []string{"127.0.0.1"}, md.Get("x-forwarded-for"))
+		})
+	}
+}
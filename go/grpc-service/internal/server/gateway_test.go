@@ -0,0 +1,383 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"grpc-service/internal/idl/greetingpb"
+	"grpc-service/internal/problem"
+)
+
+// newBufconnGateway creates a new gateway calling a greeting service through the in-process grpc server.
+func newBufconnGateway(t *testing.T, greetingService greetingpb.GreetingServiceServer, opts GRPCOptions) (*GRPC, http.Handler) {
+	s, err := NewGRPC(greetingService, opts)
+	assert.NoError(t, err)
+
+	go func() {
+		_ = s.local.Serve(s.localLis)
+	}()
+
+	conn, err := s.LocalConn()
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	gateway, err := NewGateway(context.Background(), conn)
+	assert.NoError(t, err)
+
+	return s, gateway
+}
+
+func TestGateway(t *testing.T) {
+	tests := []struct {
+		name               string
+		greetingService    *MockGreetingService
+		method             string
+		path               string
+		body               string
+		expectedStatusCode int
+		expectedBody       string
+		expectedGRPCCode   int
+		expectedRequest    *greetingpb.GreetRequest
+	}{
+		{
+			name: "Success",
+			greetingService: &MockGreetingService{
+				GreetMocks: []GreetMock{
+					{OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"}},
+				},
+			},
+			method:             "POST",
+			path:               "/v1/greet",
+			body:               `{"githubUsername": "octocat"}`,
+			expectedStatusCode: 200,
+			expectedBody:       `{"greeting":"Hello, Octocat!"}`,
+			expectedRequest:    &greetingpb.GreetRequest{GithubUsername: "octocat"},
+		},
+		{
+			name: "ProtoFieldName",
+			greetingService: &MockGreetingService{
+				GreetMocks: []GreetMock{
+					{OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"}},
+				},
+			},
+			method:             "POST",
+			path:               "/v1/greet",
+			body:               `{"github_username": "octocat"}`,
+			expectedStatusCode: 200,
+			expectedBody:       `{"greeting":"Hello, Octocat!"}`,
+			expectedRequest:    &greetingpb.GreetRequest{GithubUsername: "octocat"},
+		},
+		{
+			name:               "InvalidBody",
+			greetingService:    &MockGreetingService{},
+			method:             "POST",
+			path:               "/v1/greet",
+			body:               `{"githubUsername": 1}`,
+			expectedStatusCode: 400,
+			expectedGRPCCode:   3,
+		},
+		{
+			name:               "MethodNotImplemented",
+			greetingService:    &MockGreetingService{},
+			method:             "GET",
+			path:               "/v1/greet",
+			expectedStatusCode: 501,
+			expectedGRPCCode:   12,
+		},
+		{
+			name:               "NotFoundPath",
+			greetingService:    &MockGreetingService{},
+			method:             "POST",
+			path:               "/v1/unknown",
+			expectedStatusCode: 404,
+			expectedGRPCCode:   5,
+		},
+		{
+			name: "UserNotFound",
+			greetingService: &MockGreetingService{
+				GreetMocks: []GreetMock{
+					{OutError: problem.Err(problem.Wrap(problem.KindNotFound, errors.New("github user not found")))},
+				},
+			},
+			method:             "POST",
+			path:               "/v1/greet",
+			body:               `{"githubUsername": "ghost"}`,
+			expectedStatusCode: 404,
+			expectedGRPCCode:   5,
+			expectedRequest:    &greetingpb.GreetRequest{GithubUsername: "ghost"},
+		},
+		{
+			name: "UpstreamUnavailable",
+			greetingService: &MockGreetingService{
+				GreetMocks: []GreetMock{
+					{OutError: problem.Err(problem.New(problem.KindUpstreamUnavailable, "github is down"))},
+				},
+			},
+			method:             "POST",
+			path:               "/v1/greet",
+			body:               `{"githubUsername": "octocat"}`,
+			expectedStatusCode: 503,
+			expectedGRPCCode:   14,
+			expectedRequest:    &greetingpb.GreetRequest{GithubUsername: "octocat"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, gateway := newBufconnGateway(t, tc.greetingService, GRPCOptions{})
+			defer s.Shutdown(context.Background())
+
+			r := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, r)
+
+			assert.Equal(t, tc.expectedStatusCode, w.Code)
+			assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			if tc.expectedBody != "" {
+				assert.JSONEq(t, tc.expectedBody, w.Body.String())
+			} else {
+				var st struct {
+					Code int `json:"code"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &st))
+				assert.Equal(t, tc.expectedGRPCCode, st.Code)
+			}
+
+			if tc.expectedRequest != nil {
+				assert.Equal(t, tc.expectedRequest.GithubUsername, tc.greetingService.GreetMocks[0].InRequest.GithubUsername)
+			}
+		})
+	}
+}
+
+func TestGateway_Metadata(t *testing.T) {
+	greetingService := &MockGreetingService{
+		GreetMocks: []GreetMock{
+			{OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"}},
+		},
+	}
+
+	var callPeer *peer.Peer
+	interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		callPeer, _ = peer.FromContext(ctx)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("ratelimit-remaining", "9", "x-request-id", "1234"))
+		return handler(ctx, req)
+	}
+
+	s, gateway := newBufconnGateway(t, greetingService, GRPCOptions{
+		Options: []grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(interceptor),
+		},
+	})
+	defer s.Shutdown(context.Background())
+
+	r := httptest.NewRequest("POST", "/v1/greet", strings.NewReader(`{"githubUsername": "octocat"}`))
+	r.RemoteAddr = "192.0.2.10:54321"
+	r.Header.Set("X-API-Key", "secret")
+	r.Header.Set("Authorization", "Bearer token")
+	r.Header.Set("Grpc-Metadata-Tenant", "acme")
+	r.Header.Set("Cookie", "session=1")
+	w := httptest.NewRecorder()
+	gateway.ServeHTTP(w, r)
+
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "9", w.Header().Get("Ratelimit-Remaining"))
+	assert.Equal(t, "1234", w.Header().Get("Grpc-Metadata-X-Request-Id"))
+
+	md, ok := metadata.FromIncomingContext(greetingService.GreetMocks[0].InContext)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"secret"}, md.Get("x-api-key"))
+	assert.Equal(t, []string{"Bearer token"}, md.Get("authorization"))
+	assert.Equal(t, []string{"acme"}, md.Get("tenant"))
+	assert.Empty(t, md.Get("cookie"))
+
+	if assert.NotNil(t, callPeer) {
+		assert.Equal(t, "192.0.2.10:0", callPeer.Addr.String())
+	}
+}
+
+func TestGateway_ForwardedTLS(t *testing.T) {
+	clientTLS := &tls.ConnectionState{
+		HandshakeComplete: true,
+		VerifiedChains: [][]*x509.Certificate{
+			{{Subject: pkix.Name{CommonName: "client"}}},
+		},
+	}
+
+	tests := []struct {
+		name            string
+		tls             *tls.ConnectionState
+		header          map[string]string
+		expectedSubject string
+	}{
+		{
+			name:            "NoTLS",
+			tls:             nil,
+			expectedSubject: "",
+		},
+		{
+			name:            "TLS",
+			tls:             clientTLS,
+			expectedSubject: "CN=client",
+		},
+		{
+			name: "ForgedKey",
+			tls:  nil,
+			header: map[string]string{
+				"Grpc-Metadata-X-Forwarded-Tls": "forged",
+			},
+			expectedSubject: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			greetingService := &MockGreetingService{
+				GreetMocks: []GreetMock{
+					{OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"}},
+				},
+			}
+
+			var callPeer *peer.Peer
+			interceptor := func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				callPeer, _ = peer.FromContext(ctx)
+				return handler(ctx, req)
+			}
+
+			s, gateway := newBufconnGateway(t, greetingService, GRPCOptions{
+				Options: []grpc.ServerOption{
+					grpc.ChainUnaryInterceptor(interceptor),
+				},
+			})
+			defer s.Shutdown(context.Background())
+
+			r := httptest.NewRequest("POST", "/v1/greet", strings.NewReader(`{"githubUsername": "octocat"}`))
+			r.TLS = tc.tls
+			for key, val := range tc.header {
+				r.Header.Set(key, val)
+			}
+			w := httptest.NewRecorder()
+			gateway.ServeHTTP(w, r)
+
+			assert.Equal(t, 200, w.Code)
+			if assert.NotNil(t, callPeer) {
+				info, ok := callPeer.AuthInfo.(credentials.TLSInfo)
+				if tc.expectedSubject == "" {
+					assert.False(t, ok)
+				} else if assert.True(t, ok) {
+					assert.Equal(t, tc.expectedSubject, info.State.VerifiedChains[0][0].Subject.String())
+				}
+			}
+
+			// The TLS connection state is only held until the request is handled
+			forwardedTLS.Range(func(key, _ any) bool {
+				t.Errorf("unexpected forwarded tls key %v", key)
+				return true
+			})
+		})
+	}
+}
+
+func TestWithForwardedPeer(t *testing.T) {
+	localPeer := &peer.Peer{
+		Addr: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1234},
+	}
+
+	clientTLS := &tls.ConnectionState{ServerName: "client"}
+	forwardedTLS.Store("key", clientTLS)
+	defer forwardedTLS.Delete("key")
+
+	tests := []struct {
+		name         string
+		md           metadata.MD
+		expectedAddr string
+		expectedTLS  *tls.ConnectionState
+	}{
+		{
+			name:         "NoMetadata",
+			md:           nil,
+			expectedAddr: "127.0.0.1:1234",
+		},
+		{
+			name:         "NoForwardedFor",
+			md:           metadata.Pairs("x-api-key", "secret"),
+			expectedAddr: "127.0.0.1:1234",
+		},
+		{
+			name:         "InvalidForwardedFor",
+			md:           metadata.Pairs("x-forwarded-for", "unknown"),
+			expectedAddr: "127.0.0.1:1234",
+		},
+		{
+			name:         "ForwardedFor",
+			md:           metadata.Pairs("x-forwarded-for", "192.0.2.10"),
+			expectedAddr: "192.0.2.10:0",
+		},
+		{
+			name:         "ForwardedForIPv6",
+			md:           metadata.Pairs("x-forwarded-for", "2001:db8::1"),
+			expectedAddr: "[2001:db8::1]:0",
+		},
+		{
+			name:         "ForwardedForProxies",
+			md:           metadata.Pairs("x-forwarded-for", "203.0.113.7, 198.51.100.3, 192.0.2.10"),
+			expectedAddr: "192.0.2.10:0",
+		},
+		{
+			name:         "ForwardedTLS",
+			md:           metadata.Pairs("x-forwarded-for", "192.0.2.10", "x-forwarded-tls", "key"),
+			expectedAddr: "192.0.2.10:0",
+			expectedTLS:  clientTLS,
+		},
+		{
+			name:         "ForwardedTLSOnly",
+			md:           metadata.Pairs("x-forwarded-tls", "key"),
+			expectedAddr: "127.0.0.1:1234",
+			expectedTLS:  clientTLS,
+		},
+		{
+			name:         "UnknownForwardedTLS",
+			md:           metadata.Pairs("x-forwarded-tls", "unknown"),
+			expectedAddr: "127.0.0.1:1234",
+			expectedTLS:  nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), localPeer)
+			if tc.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tc.md)
+			}
+
+			p, ok := peer.FromContext(withForwardedPeer(ctx))
+			assert.True(t, ok)
+			assert.Equal(t, tc.expectedAddr, p.Addr.String())
+
+			if tc.expectedTLS == nil {
+				assert.Nil(t, p.AuthInfo)
+			} else if info, ok := p.AuthInfo.(credentials.TLSInfo); assert.True(t, ok) {
+				assert.Equal(t, *tc.expectedTLS, info.State)
+				assert.Equal(t, credentials.PrivacyAndIntegrity, info.SecurityLevel)
+			}
+		})
+	}
+}
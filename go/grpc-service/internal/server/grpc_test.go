@@ -4,10 +4,25 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gardenbed/basil/health"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
 
+	"grpc-service/internal/certs"
 	"grpc-service/internal/idl/greetingpb"
 )
 
@@ -25,14 +40,82 @@ func TestNewGRPC(t *testing.T) {
 			expectedError:   "",
 		},
 		{
-			name:            "WithTLS",
+			name:            "Insecure_WithTLSConfig",
 			greetingService: &MockGreetingService{},
 			opts: GRPCOptions{
-				TLSCert:  &tls.Certificate{},
-				ClientCA: x509.NewCertPool(),
+				Security:  SecurityInsecure,
+				TLSConfig: &tls.Config{},
+			},
+			expectedError: "insecure security mode does not use a tls config",
+		},
+		{
+			name:            "Dev",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security: SecurityDev,
+			},
+			expectedError: "",
+		},
+		{
+			name:            "Dev_WithTLSConfig",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security:  SecurityDev,
+				TLSConfig: &tls.Config{},
+			},
+			expectedError: "",
+		},
+		{
+			name:            "TLS",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security:  SecurityTLS,
+				TLSConfig: &tls.Config{},
 			},
 			expectedError: "",
 		},
+		{
+			name:            "TLS_NoTLSConfig",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security: SecurityTLS,
+			},
+			expectedError: "tls security mode requires a tls config",
+		},
+		{
+			name:            "MTLS",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security:  SecurityMTLS,
+				TLSConfig: &tls.Config{ClientCAs: x509.NewCertPool()},
+			},
+			expectedError: "",
+		},
+		{
+			name:            "MTLS_NoTLSConfig",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security: SecurityMTLS,
+			},
+			expectedError: "mtls security mode requires a tls config",
+		},
+		{
+			name:            "MTLS_NoClientCAs",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security:  SecurityMTLS,
+				TLSConfig: &tls.Config{},
+			},
+			expectedError: "mtls security mode requires client cas",
+		},
+		{
+			name:            "UnknownSecurity",
+			greetingService: &MockGreetingService{},
+			opts: GRPCOptions{
+				Security: "plaintext",
+			},
+			expectedError: `unknown security mode "plaintext": expected insecure, dev, tls, or mtls`,
+		},
 	}
 
 	for _, tc := range tests {
@@ -50,6 +133,157 @@ func TestNewGRPC(t *testing.T) {
 	}
 }
 
+func TestNewDevTLSConfig(t *testing.T) {
+	tlsConfig, err := NewDevTLSConfig()
+
+	assert.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.Equal(t, []string{"h2", "http/1.1"}, tlsConfig.NextProtos)
+}
+
+func TestGRPC_Security(t *testing.T) {
+	serverCert, err := certs.NewSelfSigned()
+	assert.NoError(t, err)
+
+	clientCert, err := certs.NewSelfSigned()
+	assert.NoError(t, err)
+
+	serverCAs := x509.NewCertPool()
+	serverCAs.AddCert(serverCert.Leaf)
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert.Leaf)
+
+	tests := []struct {
+		name         string
+		opts         GRPCOptions
+		clientCreds  credentials.TransportCredentials
+		expectedCode codes.Code
+	}{
+		{
+			name:         "Insecure",
+			opts:         GRPCOptions{Security: SecurityInsecure},
+			clientCreds:  insecure.NewCredentials(),
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Dev",
+			opts:         GRPCOptions{Security: SecurityDev},
+			clientCreds:  credentials.NewTLS(&tls.Config{InsecureSkipVerify: true}),
+			expectedCode: codes.OK,
+		},
+		{
+			name: "TLS",
+			opts: GRPCOptions{
+				Security:  SecurityTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}},
+			},
+			clientCreds:  credentials.NewTLS(&tls.Config{ServerName: "localhost", RootCAs: serverCAs}),
+			expectedCode: codes.OK,
+		},
+		{
+			name: "TLS_InsecureClient",
+			opts: GRPCOptions{
+				Security:  SecurityTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}},
+			},
+			clientCreds:  insecure.NewCredentials(),
+			expectedCode: codes.Unavailable,
+		},
+		{
+			name: "MTLS",
+			opts: GRPCOptions{
+				Security:  SecurityMTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}, ClientCAs: clientCAs},
+			},
+			clientCreds: credentials.NewTLS(&tls.Config{
+				ServerName:   "localhost",
+				RootCAs:      serverCAs,
+				Certificates: []tls.Certificate{*clientCert},
+			}),
+			expectedCode: codes.OK,
+		},
+		{
+			name: "MTLS_NoClientCert",
+			opts: GRPCOptions{
+				Security:  SecurityMTLS,
+				TLSConfig: &tls.Config{Certificates: []tls.Certificate{*serverCert}, ClientCAs: clientCAs},
+			},
+			clientCreds:  credentials.NewTLS(&tls.Config{ServerName: "localhost", RootCAs: serverCAs}),
+			expectedCode: codes.Unavailable,
+		}, {
+			name: "MTLS_ConfigForClient",
+			opts: GRPCOptions{
+				Security: SecurityMTLS,
+				TLSConfig: &tls.Config{
+					GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+						return &tls.Config{Certificates: []tls.Certificate{*serverCert}, ClientCAs: clientCAs}, nil
+					},
+				},
+			},
+			clientCreds: credentials.NewTLS(&tls.Config{
+				ServerName:   "localhost",
+				RootCAs:      serverCAs,
+				Certificates: []tls.Certificate{*clientCert},
+			}),
+			expectedCode: codes.OK,
+		},
+		{
+			name: "MTLS_ConfigForClient_NoClientCAs",
+			opts: GRPCOptions{
+				Security: SecurityMTLS,
+				TLSConfig: &tls.Config{
+					GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+						return &tls.Config{Certificates: []tls.Certificate{*serverCert}}, nil
+					},
+				},
+			},
+			clientCreds: credentials.NewTLS(&tls.Config{
+				ServerName:   "localhost",
+				RootCAs:      serverCAs,
+				Certificates: []tls.Certificate{*clientCert},
+			}),
+			expectedCode: codes.Unavailable,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			greeting := &MockGreetingService{
+				GreetMocks: []GreetMock{
+					{OutResponse: &greetingpb.GreetResponse{Greeting: "Hello, Octocat!"}},
+				},
+			}
+
+			s, err := NewGRPC(greeting, tc.opts)
+			assert.NoError(t, err)
+
+			lis := bufconn.Listen(1 << 20)
+			go func() {
+				_ = s.server.Serve(lis)
+			}()
+			defer s.server.GracefulStop()
+
+			conn, err := grpc.NewClient("passthrough:///bufnet",
+				grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+					return lis.DialContext(ctx)
+				}),
+				grpc.WithTransportCredentials(tc.clientCreds),
+			)
+			assert.NoError(t, err)
+			defer conn.Close()
+
+			client := greetingpb.NewGreetingServiceClient(conn)
+			resp, err := client.Greet(context.Background(), &greetingpb.GreetRequest{GithubUsername: "octocat"})
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedCode == codes.OK {
+				assert.Equal(t, "Hello, Octocat!", resp.Greeting)
+			}
+		})
+	}
+}
+
 func TestGRPC_String(t *testing.T) {
 	s := new(GRPC)
 	assert.Equal(t, "grpc-server", s.String())
@@ -80,11 +314,28 @@ func TestGRPC_ListenAndServe(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "Successful_WithLocal",
+			s: &GRPC{
+				addr: "127.0.0.1:",
+				server: &MockGRPCServer{
+					ServeMocks: []ServeMock{
+						{OutError: nil},
+					},
+				},
+				local:    grpc.NewServer(),
+				localLis: bufconn.Listen(localBufferSize),
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			err := tc.s.ListenAndServe()
+			if tc.s.local != nil {
+				tc.s.local.GracefulStop()
+			}
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
@@ -114,6 +365,16 @@ func TestGRPC_Shutdown(t *testing.T) {
 			ctx:           context.Background(),
 			expectedError: "",
 		},
+		{
+			name: "Successful_WithLocal",
+			s: &GRPC{
+				addr:   "127.0.0.1:",
+				server: &MockGRPCServer{},
+				local:  &MockGRPCServer{},
+			},
+			ctx:           context.Background(),
+			expectedError: "",
+		},
 		{
 			name: "ContextCancelled",
 			s: &GRPC{
@@ -137,3 +398,122 @@ func TestGRPC_Shutdown(t *testing.T) {
 		})
 	}
 }
+
+// newBufconnGRPC creates a new grpc server serving on an in-memory listener and a plaintext client connection to it.
+func newBufconnGRPC(t *testing.T, opts GRPCOptions) (*GRPC, *grpc.ClientConn) {
+	s, err := NewGRPC(&MockGreetingService{}, opts)
+	assert.NoError(t, err)
+
+	lis := bufconn.Listen(1 << 20)
+	go func() {
+		_ = s.server.Serve(lis)
+	}()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+
+	return s, conn
+}
+
+func TestGRPC_Health(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+
+	redis := &checker{
+		name: "redis-client",
+		check: func(context.Context) error {
+			if !healthy.Load() {
+				return errors.New("redis error")
+			}
+			return nil
+		},
+	}
+
+	s, conn := newBufconnGRPC(t, GRPCOptions{
+		HealthCheckers:      []health.Checker{redis},
+		HealthWatchInterval: 10 * time.Millisecond,
+	})
+	defer conn.Close()
+
+	client := healthpb.NewHealthClient(conn)
+	req := &healthpb.HealthCheckRequest{Service: "greeting.GreetingService"}
+
+	resp, err := client.Check(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	stream, err := client.Watch(context.Background(), req)
+	assert.NoError(t, err)
+	assertWatch(t, stream, healthpb.HealthCheckResponse_SERVING)
+
+	healthy.Store(false)
+	assertWatch(t, stream, healthpb.HealthCheckResponse_NOT_SERVING)
+
+	healthy.Store(true)
+	assertWatch(t, stream, healthpb.HealthCheckResponse_SERVING)
+
+	// Watch streams end as soon as the shutdown begins, so they do not block the graceful stop
+	assert.NoError(t, s.Shutdown(context.Background()))
+	assertWatch(t, stream, healthpb.HealthCheckResponse_NOT_SERVING)
+	_, err = stream.Recv()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestGRPC_Reflection(t *testing.T) {
+	tests := []struct {
+		name             string
+		reflection       bool
+		expectedCode     codes.Code
+		expectedServices []string
+	}{
+		{
+			name:         "Disabled",
+			reflection:   false,
+			expectedCode: codes.Unimplemented,
+		},
+		{
+			name:         "Enabled",
+			reflection:   true,
+			expectedCode: codes.OK,
+			expectedServices: []string{
+				"greeting.GreetingService",
+				"grpc.health.v1.Health",
+				"grpc.reflection.v1.ServerReflection",
+				"grpc.reflection.v1alpha.ServerReflection",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, conn := newBufconnGRPC(t, GRPCOptions{Reflection: tc.reflection})
+			defer s.server.GracefulStop()
+			defer conn.Close()
+
+			stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+			assert.NoError(t, err)
+
+			err = stream.Send(&reflectionpb.ServerReflectionRequest{
+				MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+			})
+			assert.NoError(t, err)
+
+			resp, err := stream.Recv()
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			_ = stream.CloseSend()
+
+			if tc.expectedCode == codes.OK {
+				var services []string
+				for _, svc := range resp.GetListServicesResponse().Service {
+					services = append(services, svc.Name)
+				}
+				assert.ElementsMatch(t, tc.expectedServices, services)
+			}
+		})
+	}
+}
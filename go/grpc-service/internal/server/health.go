@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gardenbed/basil/health"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// DefaultHealthCheckTimeout is the default timeout for health checking the health checkers.
+	DefaultHealthCheckTimeout = 5 * time.Second
+	// DefaultHealthWatchInterval is the default interval for health checking the watched services.
+	DefaultHealthWatchInterval = 5 * time.Second
+)
+
+// healthServer implements the standard gRPC health checking protocol (grpc.health.v1.Health).
+// The overall server ("") and every registered gRPC service are serving when all health checkers are healthy,
+// and every health checker can be checked as a service by its name.
+// All services are not serving as soon as the server is shutting down.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+
+	checkers      []health.Checker
+	services      []string
+	timeout       time.Duration
+	watchInterval time.Duration
+
+	shutdownOnce sync.Once
+	shuttingDown chan struct{}
+}
+
+func newHealthServer(checkers []health.Checker, services []string, timeout, watchInterval time.Duration) *healthServer {
+	if timeout == 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+
+	if watchInterval == 0 {
+		watchInterval = DefaultHealthWatchInterval
+	}
+
+	return &healthServer{
+		checkers:      checkers,
+		services:      append([]string{""}, services...),
+		timeout:       timeout,
+		watchInterval: watchInterval,
+		shuttingDown:  make(chan struct{}),
+	}
+}
+
+// shutdown marks all services as not serving and ends the watch streams, so they do not block a graceful stop.
+func (s *healthServer) shutdown() {
+	s.shutdownOnce.Do(func() {
+		close(s.shuttingDown)
+	})
+}
+
+func (s *healthServer) isShuttingDown() bool {
+	select {
+	case <-s.shuttingDown:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkersFor returns the health checkers determining the status of a service.
+func (s *healthServer) checkersFor(service string) ([]health.Checker, bool) {
+	for _, name := range s.services {
+		if service == name {
+			return s.checkers, true
+		}
+	}
+
+	for _, c := range s.checkers {
+		if service == c.String() {
+			return []health.Checker{c}, true
+		}
+	}
+
+	return nil, false
+}
+
+// status health checks a set of health checkers concurrently.
+func (s *healthServer) status(ctx context.Context, checkers []health.Checker) healthpb.HealthCheckResponse_ServingStatus {
+	if s.isShuttingDown() {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	group, ctx := errgroup.WithContext(ctx)
+	for _, c := range checkers {
+		group.Go(func() error {
+			return c.HealthCheck(ctx)
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	return healthpb.HealthCheckResponse_SERVING
+}
+
+// Check implements the grpc.health.v1.Health/Check method.
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	checkers, ok := s.checkersFor(req.Service)
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown service %q", req.Service)
+	}
+
+	return &healthpb.HealthCheckResponse{
+		Status: s.status(ctx, checkers),
+	}, nil
+}
+
+// List implements the grpc.health.v1.Health/List method.
+func (s *healthServer) List(ctx context.Context, _ *healthpb.HealthListRequest) (*healthpb.HealthListResponse, error) {
+	names := append([]string{}, s.services...)
+	for _, c := range s.checkers {
+		names = append(names, c.String())
+	}
+	sort.Strings(names)
+
+	resp := &healthpb.HealthListResponse{
+		Statuses: make(map[string]*healthpb.HealthCheckResponse, len(names)),
+	}
+
+	for _, name := range names {
+		checkers, _ := s.checkersFor(name)
+		resp.Statuses[name] = &healthpb.HealthCheckResponse{
+			Status: s.status(ctx, checkers),
+		}
+	}
+
+	return resp, nil
+}
+
+// Watch implements the grpc.health.v1.Health/Watch method.
+// The status of the service is sent immediately and whenever it changes.
+// Unknown services are reported as SERVICE_UNKNOWN rather than failing the call, as required by the protocol.
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(s.watchInterval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		current := healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+		if checkers, ok := s.checkersFor(req.Service); ok {
+			current = s.status(ctx, checkers)
+		}
+
+		if current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.shuttingDown:
+			if last != healthpb.HealthCheckResponse_NOT_SERVING {
+				_ = stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING})
+			}
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
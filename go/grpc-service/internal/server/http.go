@@ -2,21 +2,58 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"time"
+
+	"github.com/rs/cors"
+
+	"grpc-service/internal/idl/greetingpb/greetingpbconnect"
+)
+
+const (
+	defaultHTTPPort = 8080
+
+	// DefaultReadHeaderTimeout is the default maximum duration for reading the headers of a request.
+	DefaultReadHeaderTimeout = 5 * time.Second
+	// DefaultReadTimeout is the default maximum duration for reading an entire unary request.
+	DefaultReadTimeout = 15 * time.Second
+	// DefaultWriteTimeout is the default maximum duration for handling a unary request and writing its response.
+	DefaultWriteTimeout = 30 * time.Second
+	// DefaultIdleTimeout is the default maximum duration for waiting for the next request on a keep-alive connection.
+	DefaultIdleTimeout = 2 * time.Minute
+	// DefaultMaxHeaderBytes is the default maximum size of the headers of a request.
+	DefaultMaxHeaderBytes = 64 << 10
+	// DefaultCORSMaxAge is the default duration for caching the results of CORS preflight requests.
+	DefaultCORSMaxAge = 2 * time.Hour
 )
 
-const defaultHTTPPort = 8080
+var (
+	// corsAllowedHeaders are the request headers of the Connect, gRPC-Web, and REST/JSON protocols and the credentials.
+	corsAllowedHeaders = []string{
+		"Content-Type", "Connect-Protocol-Version", "Connect-Timeout-Ms", "Grpc-Timeout", "X-Grpc-Web", "X-User-Agent",
+		"Authorization", "X-API-Key",
+	}
+
+	// corsExposedHeaders are the response headers of the gRPC-Web protocol and the rate limit headers.
+	corsExposedHeaders = []string{
+		"Grpc-Status", "Grpc-Message", "Grpc-Status-Details-Bin",
+		"Ratelimit-Limit", "Ratelimit-Remaining", "Ratelimit-Reset", "Ratelimit-Policy", "Retry-After",
+	}
+)
 
 // httpServer is an interface for http.Server struct.
 type httpServer interface {
 	ListenAndServe() error
+	ListenAndServeTLS(certFile, keyFile string) error
 	Shutdown(ctx context.Context) error
 }
 
 // HTTP is an http server implementing the graceful.Server interface.
 type HTTP struct {
 	server httpServer
+	tls    bool
 }
 
 // HTTPOptions are optional settings for creating an http server.
@@ -24,6 +61,49 @@ type HTTPOptions struct {
 	// The port number for the HTTP server.
 	// The default port number is 8080.
 	Port uint16
+	// The transport security mode for the HTTP server.
+	// It should be the same as the mode of the gRPC server, since the HTTP server serves the same services.
+	// The default mode is SecurityInsecure.
+	Security Security
+	// A TLS configuration for the HTTP server identity and verifying HTTP client identities.
+	// It is required for the SecurityTLS and SecurityMTLS modes and must be nil for the SecurityInsecure mode.
+	// It is optional for the SecurityDev mode, and a self-signed certificate is generated if not set.
+	TLSConfig *tls.Config
+	// The maximum duration for reading the headers of a request.
+	// The default timeout is DefaultReadHeaderTimeout.
+	ReadHeaderTimeout time.Duration
+	// The maximum duration for reading an entire unary request, including the body.
+	// Streaming requests are not limited, so long-lived streams are not cut off.
+	// The default timeout is DefaultReadTimeout.
+	ReadTimeout time.Duration
+	// The maximum duration for handling a unary request and writing its response.
+	// Streaming requests are not limited, so long-lived streams are not cut off.
+	// The default timeout is DefaultWriteTimeout.
+	WriteTimeout time.Duration
+	// The maximum duration for waiting for the next request on a keep-alive connection.
+	// The default timeout is DefaultIdleTimeout.
+	IdleTimeout time.Duration
+	// The maximum size of the headers of a request.
+	// The default size is DefaultMaxHeaderBytes.
+	MaxHeaderBytes int
+	// An http handler serving the REST/JSON APIs of the gRPC services (see NewGateway).
+	// The APIs are not served if not set.
+	Gateway http.Handler
+	// An http handler serving the gRPC services over the Connect and gRPC-Web protocols (see NewConnect).
+	// The protocols are not served if not set.
+	Connect http.Handler
+	// Cross-origin resource sharing settings for calling the APIs from browsers.
+	CORS CORSOptions
+}
+
+// CORSOptions are settings for cross-origin resource sharing (CORS).
+type CORSOptions struct {
+	// The origins allowed to call the APIs (e.g. https://app.example.com or *).
+	// CORS is disabled if empty.
+	AllowedOrigins []string
+	// The duration for caching the results of preflight requests.
+	// The default duration is DefaultCORSMaxAge.
+	MaxAge time.Duration
 }
 
 // NewHTTP creates a new http Server.
@@ -32,19 +112,109 @@ func NewHTTP(healthHandler http.Handler, opts HTTPOptions) (*HTTP, error) {
 		opts.Port = defaultHTTPPort
 	}
 
+	if opts.ReadHeaderTimeout == 0 {
+		opts.ReadHeaderTimeout = DefaultReadHeaderTimeout
+	}
+
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = DefaultWriteTimeout
+	}
+
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if opts.MaxHeaderBytes == 0 {
+		opts.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+
+	if opts.CORS.MaxAge == 0 {
+		opts.CORS.MaxAge = DefaultCORSMaxAge
+	}
+
+	if opts.Security == "" {
+		opts.Security = SecurityInsecure
+	}
+
+	tlsConfig, err := serverTLSConfig(opts.Security, opts.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	api := http.NewServeMux()
+
+	// The REST/JSON APIs only serve unary calls
+	if opts.Gateway != nil {
+		api.Handle("/", withDeadlines(opts.Gateway, opts.ReadTimeout, opts.WriteTimeout))
+	}
+
+	// Only the unary procedures are given deadlines, so GreetMany and GreetChat streams are not cut off
+	if opts.Connect != nil {
+		api.Handle("/"+greetingpbconnect.GreetingServiceName+"/", opts.Connect)
+		api.Handle(greetingpbconnect.GreetingServiceGreetProcedure, withDeadlines(opts.Connect, opts.ReadTimeout, opts.WriteTimeout))
+	}
+
 	mux := http.NewServeMux()
-	mux.Handle("/health", healthHandler)
+	mux.Handle("/health", withDeadlines(healthHandler, opts.ReadTimeout, opts.WriteTimeout))
+	mux.Handle("/", withCORS(api, opts.CORS))
 
+	// HTTP/2 is served without TLS (h2c) in the insecure mode, since the gRPC protocol requires HTTP/2
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	if tlsConfig == nil {
+		protocols.SetUnencryptedHTTP2(true)
+	} else {
+		protocols.SetHTTP2(true)
+	}
+
+	// The read and write timeouts are not set for the server, since they would cut off streams
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", opts.Port),
-		Handler: mux,
+		Addr:              fmt.Sprintf(":%d", opts.Port),
+		Handler:           mux,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
+		Protocols:         protocols,
+		TLSConfig:         tlsConfig,
 	}
 
 	return &HTTP{
 		server: server,
+		tls:    tlsConfig != nil,
 	}, nil
 }
 
+// withDeadlines wraps an http handler with setting the read and write deadlines of every request.
+func withDeadlines(handler http.Handler, readTimeout, writeTimeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		rc := http.NewResponseController(w)
+		_ = rc.SetReadDeadline(now.Add(readTimeout))
+		_ = rc.SetWriteDeadline(now.Add(writeTimeout))
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// withCORS wraps an http handler with handling the CORS requests if any origin is allowed.
+func withCORS(handler http.Handler, opts CORSOptions) http.Handler {
+	if len(opts.AllowedOrigins) == 0 {
+		return handler
+	}
+
+	return cors.New(cors.Options{
+		AllowedOrigins: opts.AllowedOrigins,
+		AllowedMethods: []string{http.MethodGet, http.MethodPost},
+		AllowedHeaders: corsAllowedHeaders,
+		ExposedHeaders: corsExposedHeaders,
+		MaxAge:         int(opts.MaxAge.Seconds()),
+	}).Handler(handler)
+}
+
 // String returns the name of the server.
 func (s *HTTP) String() string {
 	return "http-server"
@@ -56,7 +226,14 @@ func (s *HTTP) ListenAndServe() error {
 	// Synchronous/Blocking
 	// ListenAndServe always returns a non-nil error
 	// After Shutdown or Close, the returned error is ErrServerClosed
-	err := s.server.ListenAndServe()
+	var err error
+	if s.tls {
+		// The certificates are provided by the TLS configuration
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+
 	if err != http.ErrServerClosed {
 		return err
 	}
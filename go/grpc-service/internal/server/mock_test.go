@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"io"
 	"net"
 
 	"grpc-service/internal/idl/greetingpb"
@@ -12,6 +13,12 @@ type (
 		OutError error
 	}
 
+	ListenAndServeTLSMock struct {
+		InCertFile string
+		InKeyFile  string
+		OutError   error
+	}
+
 	ShutdownMock struct {
 		InContext context.Context
 		OutError  error
@@ -21,6 +28,9 @@ type (
 		ListenAndServeIndex int
 		ListenAndServeMocks []ListenAndServeMock
 
+		ListenAndServeTLSIndex int
+		ListenAndServeTLSMocks []ListenAndServeTLSMock
+
 		ShutdownIndex int
 		ShutdownMocks []ShutdownMock
 	}
@@ -32,6 +42,14 @@ func (m *MockHTTPServer) ListenAndServe() error {
 	return m.ListenAndServeMocks[i].OutError
 }
 
+func (m *MockHTTPServer) ListenAndServeTLS(certFile, keyFile string) error {
+	i := m.ListenAndServeTLSIndex
+	m.ListenAndServeTLSIndex++
+	m.ListenAndServeTLSMocks[i].InCertFile = certFile
+	m.ListenAndServeTLSMocks[i].InKeyFile = keyFile
+	return m.ListenAndServeTLSMocks[i].OutError
+}
+
 func (m *MockHTTPServer) Shutdown(ctx context.Context) error {
 	i := m.ShutdownIndex
 	m.ShutdownIndex++
@@ -79,6 +97,12 @@ type (
 	MockGreetingService struct {
 		GreetIndex int
 		GreetMocks []GreetMock
+
+		GreetManyIndex int
+		GreetManyMocks []GreetManyMock
+
+		GreetChatIndex int
+		GreetChatMocks []GreetChatMock
 	}
 )
 
@@ -89,3 +113,52 @@ func (m *MockGreetingService) Greet(ctx context.Context, req *greetingpb.GreetRe
 	m.GreetMocks[i].InRequest = req
 	return m.GreetMocks[i].OutResponse, m.GreetMocks[i].OutError
 }
+
+type (
+	GreetManyMock struct {
+		InContext  context.Context
+		InRequest  *greetingpb.GreetManyRequest
+		OutResults []*greetingpb.GreetResult
+		OutError   error
+	}
+
+	GreetChatMock struct {
+		InContext  context.Context
+		InRequests []*greetingpb.GreetRequest
+		OutResults []*greetingpb.GreetResult
+		OutError   error
+	}
+)
+
+func (m *MockGreetingService) GreetMany(req *greetingpb.GreetManyRequest, stream greetingpb.GreetingService_GreetManyServer) error {
+	i := m.GreetManyIndex
+	m.GreetManyIndex++
+	m.GreetManyMocks[i].InContext = stream.Context()
+	m.GreetManyMocks[i].InRequest = req
+	for _, res := range m.GreetManyMocks[i].OutResults {
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+	return m.GreetManyMocks[i].OutError
+}
+
+func (m *MockGreetingService) GreetChat(stream greetingpb.GreetingService_GreetChatServer) error {
+	i := m.GreetChatIndex
+	m.GreetChatIndex++
+	m.GreetChatMocks[i].InContext = stream.Context()
+	for j := 0; ; j++ {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		m.GreetChatMocks[i].InRequests = append(m.GreetChatMocks[i].InRequests, req)
+		if err := stream.Send(m.GreetChatMocks[i].OutResults[j]); err != nil {
+			return err
+		}
+	}
+	return m.GreetChatMocks[i].OutError
+}
@@ -3,14 +3,28 @@ package greeting
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gardenbed/basil/httpx"
+	"github.com/gardenbed/basil/telemetry"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"grpc-service/internal/breaker"
 	"grpc-service/internal/idl/greetingpb"
+	"grpc-service/internal/locale"
+	"grpc-service/internal/problem"
+	"grpc-service/internal/validation"
 )
 
 type (
@@ -24,28 +38,140 @@ type (
 	}
 )
 
+const (
+	// DefaultGithubURL is the base URL of the public GitHub REST API.
+	DefaultGithubURL = "https://api.github.com"
+
+	// DefaultCacheNamespace is the default prefix for the keys of cached GitHub users.
+	DefaultCacheNamespace = "greeting"
+	// DefaultCacheTTL is the default duration for caching a GitHub user.
+	DefaultCacheTTL = time.Hour
+	// DefaultNegativeCacheTTL is the default duration for caching the absence of a GitHub user.
+	DefaultNegativeCacheTTL = time.Minute
+	// DefaultStaleCacheTTL is the default duration for keeping a GitHub user for when the GitHub API is unavailable.
+	DefaultStaleCacheTTL = 24 * time.Hour
+	// DefaultMaxConcurrentLookups is the default maximum number of concurrent GitHub user lookups for a streaming call.
+	DefaultMaxConcurrentLookups = 8
+
+	// StaleHeader is the response header metadata set when a greeting is created for a stale GitHub user.
+	StaleHeader = "x-stale"
+
+	// cacheVersion is bumped whenever the format of cached values changes, so stale entries are never decoded.
+	cacheVersion = "v1"
+	// notFoundValue is the cached value for a GitHub user that does not exist.
+	notFoundValue = "null"
+)
+
+// ErrUserNotFound is returned when a GitHub user does not exist.
+var ErrUserNotFound = errors.New("github user not found")
+
+// Options are optional configurations for creating a new service.
+type Options struct {
+	// GithubURL is the base URL of the GitHub REST API (DefaultGithubURL if empty).
+	GithubURL string
+	// CacheNamespace is the prefix for the keys of cached GitHub users (DefaultCacheNamespace if empty).
+	CacheNamespace string
+	// CacheTTL is the duration for caching a GitHub user (DefaultCacheTTL if zero).
+	CacheTTL time.Duration
+	// NegativeCacheTTL is the duration for caching the absence of a GitHub user (DefaultNegativeCacheTTL if zero).
+	NegativeCacheTTL time.Duration
+	// StaleCacheTTL is the duration for keeping a GitHub user for when the GitHub API is unavailable (DefaultStaleCacheTTL if zero).
+	// While the circuit breaker for the GitHub API is open, users are served from this stale cache.
+	StaleCacheTTL time.Duration
+	// MaxConcurrentLookups is the maximum number of concurrent GitHub user lookups for a streaming call (DefaultMaxConcurrentLookups if zero).
+	MaxConcurrentLookups int
+	// Meter is used for creating the service metrics (the meter of the global telemetry probe if nil).
+	Meter metric.Meter
+}
+
 // service implements the greetingpb.GreetingServiceServer interface.
 type service struct {
-	httpClient  httpClient
-	redisClient redisClient
+	httpClient       httpClient
+	redisClient      redisClient
+	catalog          *locale.Catalog
+	githubURL        string
+	cacheNamespace   string
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+	staleCacheTTL    time.Duration
+	maxLookups       int
+	lookups          singleflight.Group
+	coalesced        metric.Int64Counter
 }
 
 // NewService creates a new service.
-func NewService(httpClient httpClient, redisClient redisClient) (greetingpb.GreetingServiceServer, error) {
+func NewService(httpClient httpClient, redisClient redisClient, catalog *locale.Catalog, opts Options) (greetingpb.GreetingServiceServer, error) {
+	if opts.GithubURL == "" {
+		opts.GithubURL = DefaultGithubURL
+	}
+
+	if opts.CacheNamespace == "" {
+		opts.CacheNamespace = DefaultCacheNamespace
+	}
+
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+
+	if opts.NegativeCacheTTL == 0 {
+		opts.NegativeCacheTTL = DefaultNegativeCacheTTL
+	}
+
+	if opts.StaleCacheTTL == 0 {
+		opts.StaleCacheTTL = DefaultStaleCacheTTL
+	}
+
+	if opts.MaxConcurrentLookups == 0 {
+		opts.MaxConcurrentLookups = DefaultMaxConcurrentLookups
+	}
+
+	if opts.Meter == nil {
+		opts.Meter = telemetry.Get().Meter()
+	}
+
+	coalesced, err := opts.Meter.Int64Counter(
+		"coalesced_github_user_lookups_total",
+		metric.WithDescription("The total number of GitHub user lookups served by an in-flight lookup for the same user"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &service{
-		httpClient:  httpClient,
-		redisClient: redisClient,
+		httpClient:       httpClient,
+		redisClient:      redisClient,
+		catalog:          catalog,
+		githubURL:        strings.TrimSuffix(opts.GithubURL, "/"),
+		cacheNamespace:   opts.CacheNamespace,
+		cacheTTL:         opts.CacheTTL,
+		negativeCacheTTL: opts.NegativeCacheTTL,
+		staleCacheTTL:    opts.StaleCacheTTL,
+		maxLookups:       opts.MaxConcurrentLookups,
+		coalesced:        coalesced,
 	}, nil
 }
 
+// user is the model for a GitHub user.
+type user struct {
+	Login    string `json:"login"`
+	Name     string `json:"name"`
+	Company  string `json:"company"`
+	Location string `json:"location"`
+}
+
 // Greet implements the GreetingService::Greet endpoint.
+// The language of the greeting is negotiated using the accept-language metadata.
+// Failures are responded with gRPC status codes and error details.
 func (s *service) Greet(ctx context.Context, req *greetingpb.GreetRequest) (*greetingpb.GreetResponse, error) {
-	name, err := s.getName(ctx, req.GithubUsername)
+	greeting, stale, err := s.greet(ctx, language(ctx), req.GithubUsername)
 	if err != nil {
 		return nil, err
 	}
 
-	greeting := fmt.Sprintf("Hello, %s!", name)
+	if stale {
+		_ = grpc.SetHeader(ctx, metadata.Pairs(StaleHeader, "true"))
+	}
+
 	resp := &greetingpb.GreetResponse{
 		Greeting: greeting,
 	}
@@ -53,13 +179,226 @@ func (s *service) Greet(ctx context.Context, req *greetingpb.GreetRequest) (*gre
 	return resp, nil
 }
 
-func (s *service) getName(ctx context.Context, username string) (string, error) {
-	name, err := s.redisClient.Get(ctx, username).Result()
-	if err == nil && name != "" {
-		return name, nil
+// GreetMany implements the GreetingService::GreetMany endpoint.
+// Users are looked up concurrently, up to the maximum number of concurrent lookups,
+// and a result is sent as soon as its lookup completes.
+// Results are sent one at a time, so lookups wait while the client is not receiving (flow control).
+// All lookups are cancelled when the call is cancelled or a result cannot be sent.
+func (s *service) GreetMany(req *greetingpb.GreetManyRequest, stream greetingpb.GreetingService_GreetManyServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+
+	lang := language(ctx)
+	results := make(chan *greetingpb.GreetResult)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.maxLookups)
+
+	go func() {
+		defer close(results)
+
+		for _, username := range req.GithubUsernames {
+			if gctx.Err() != nil {
+				break
+			}
+
+			g.Go(func() error {
+				select {
+				case results <- s.greetResult(gctx, lang, username):
+					return nil
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			})
+		}
+
+		_ = g.Wait()
+	}()
+
+	for res := range results {
+		// The pending lookups are cancelled and the results channel is closed once they return
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Context().Err(); err != nil {
+		return problem.Err(err)
+	}
+
+	return nil
+}
+
+// GreetChat implements the GreetingService::GreetChat endpoint.
+// A result is sent for every request received, in the same order.
+// Requests are not received while a result is being created, so clients are paced by the lookups (flow control).
+// Invalid requests are reported as the error status of their results, so they do not end the stream.
+func (s *service) GreetChat(stream greetingpb.GreetingService_GreetChatServer) error {
+	ctx := stream.Context()
+	lang := language(ctx)
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+
+		var invalid *validation.InvalidMessageError
+		if errors.As(err, &invalid) {
+			res := &greetingpb.GreetResult{
+				GithubUsername: invalid.Message.(*greetingpb.GreetRequest).GithubUsername,
+				Error:          invalid.GRPCStatus().Proto(),
+			}
+
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := stream.Send(s.greetResult(ctx, lang, req.GithubUsername)); err != nil {
+			return err
+		}
+	}
+}
+
+// greetResult creates a greeting result for a streaming call.
+// Failures are reported as the result status, so they do not end the stream.
+func (s *service) greetResult(ctx context.Context, lang, username string) *greetingpb.GreetResult {
+	res := &greetingpb.GreetResult{
+		GithubUsername: username,
+	}
+
+	greeting, stale, err := s.greet(ctx, lang, username)
+	if err != nil {
+		res.Error = status.Convert(err).Proto()
+		return res
+	}
+
+	res.Greeting = greeting
+	res.Stale = stale
+
+	return res
+}
+
+// greet creates a greeting for a GitHub user in a language and reports whether the user is stale.
+// Errors are gRPC status errors.
+func (s *service) greet(ctx context.Context, lang, username string) (string, bool, error) {
+	user, stale, err := s.getUser(ctx, username)
+	if errors.Is(err, ErrUserNotFound) {
+		return "", false, problem.Err(problem.Wrap(problem.KindNotFound, err))
+	}
+
+	if err != nil {
+		return "", false, problem.Err(err)
+	}
+
+	greeting, err := s.catalog.Greet(lang, locale.User{
+		Login:    user.Login,
+		Name:     user.Name,
+		Company:  user.Company,
+		Location: user.Location,
+	})
+
+	if err != nil {
+		return "", false, problem.Err(err)
+	}
+
+	return greeting, stale, nil
+}
+
+// language returns the languages accepted by a call from the accept-language metadata.
+func language(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		return strings.Join(md.Get("accept-language"), ",")
+	}
+
+	return ""
+}
+
+// cacheKey returns the namespaced and versioned key for caching a GitHub user.
+// GitHub usernames are case-insensitive, so the keys are lower-cased.
+func (s *service) cacheKey(username string) string {
+	return fmt.Sprintf("%s:%s:user:%s", s.cacheNamespace, cacheVersion, strings.ToLower(username))
+}
+
+// staleCacheKey returns the namespaced and versioned key for keeping a stale GitHub user.
+func (s *service) staleCacheKey(username string) string {
+	return fmt.Sprintf("%s:%s:stale:user:%s", s.cacheNamespace, cacheVersion, strings.ToLower(username))
+}
+
+// getUser retrieves a GitHub user, coalescing concurrent lookups of the same user into a single lookup.
+// The shared lookup is not cancelled when a caller's context is done, so the other callers are not affected,
+// but each caller stops waiting and returns as soon as its own context is done.
+// While the circuit breaker for the GitHub API is open, the user is served from the stale cache if available and reported as stale.
+func (s *service) getUser(ctx context.Context, username string) (*user, bool, error) {
+	key := s.cacheKey(username)
+
+	var leader bool
+	ch := s.lookups.DoChan(key, func() (any, error) {
+		leader = true
+		return s.lookupUser(context.WithoutCancel(ctx), key, username)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared && !leader {
+			s.coalesced.Add(ctx, 1)
+		}
+
+		if errors.Is(res.Err, breaker.ErrOpen) {
+			if u, err := s.lookupStaleUser(ctx, username); err == nil {
+				return u, true, nil
+			}
+		}
+
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+
+		return res.Val.(*user), false, nil
+
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// lookupStaleUser retrieves a GitHub user from the stale cache.
+func (s *service) lookupStaleUser(ctx context.Context, username string) (*user, error) {
+	val, err := s.redisClient.Get(ctx, s.staleCacheKey(username)).Result()
+	if err != nil {
+		return nil, err
 	}
 
-	url := fmt.Sprintf("https://api.github.com/users/%s", username)
+	u := new(user)
+	if err := json.Unmarshal([]byte(val), u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// lookupUser retrieves a GitHub user using a write-through cache.
+// Users are cached for the positive TTL and non-existent users are cached for the negative TTL.
+// Cache failures are not fatal and only result in calling the GitHub API.
+func (s *service) lookupUser(ctx context.Context, key, username string) (*user, error) {
+	if val, err := s.redisClient.Get(ctx, key).Result(); err == nil {
+		if val == notFoundValue {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+		}
+
+		cached := new(user)
+		if err := json.Unmarshal([]byte(val), cached); err == nil && cached.Login != "" {
+			return cached, nil
+		}
+	}
+
+	url := fmt.Sprintf("%s/users/%s", s.githubURL, username)
 	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Set("User-Agent", "command-app")
@@ -68,24 +407,31 @@ func (s *service) getName(ctx context.Context, username string) (string, error)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		_ = s.redisClient.Set(ctx, key, notFoundValue, s.negativeCacheTTL).Err()
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", httpx.NewClientError(resp)
+		return nil, httpx.NewClientError(resp)
 	}
 
-	user := struct {
-		Name string `json:"name"`
-	}{}
+	u := new(user)
+	if err := json.NewDecoder(resp.Body).Decode(u); err != nil {
+		return nil, err
+	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return "", err
+	if val, err := json.Marshal(u); err == nil {
+		_ = s.redisClient.Set(ctx, key, val, s.cacheTTL).Err()
+		_ = s.redisClient.Set(ctx, s.staleCacheKey(username), val, s.staleCacheTTL).Err()
 	}
 
-	return user.Name, nil
+	return u, nil
 }
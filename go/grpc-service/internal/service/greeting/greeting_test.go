@@ -5,13 +5,28 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
+	"grpc-service/internal/breaker"
+	"grpc-service/internal/fakegithub"
 	"grpc-service/internal/idl/greetingpb"
+	"grpc-service/internal/locale"
+	"grpc-service/internal/problem"
+	"grpc-service/internal/validation"
 )
 
 func TestNewService(t *testing.T) {
@@ -19,19 +34,21 @@ func TestNewService(t *testing.T) {
 		name          string
 		httpClient    *MockHTTPClient
 		redisClient   *MockRedisClient
+		catalog       *locale.Catalog
 		expectedError string
 	}{
 		{
 			name:          "OK",
 			httpClient:    &MockHTTPClient{},
 			redisClient:   &MockRedisClient{},
+			catalog:       &locale.Catalog{},
 			expectedError: "",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			s, err := NewService(tc.httpClient, tc.redisClient)
+			s, err := NewService(tc.httpClient, tc.redisClient, tc.catalog, Options{})
 
 			if tc.expectedError == "" {
 				assert.NotNil(t, s)
@@ -47,6 +64,9 @@ func TestNewService(t *testing.T) {
 func TestService_Greet(t *testing.T) {
 	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat", nil)
 
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
 	tests := []struct {
 		name             string
 		httpClient       *MockHTTPClient
@@ -54,13 +74,14 @@ func TestService_Greet(t *testing.T) {
 		ctx              context.Context
 		request          *greetingpb.GreetRequest
 		expectedResponse *greetingpb.GreetResponse
+		expectedCode     codes.Code
 		expectedError    string
 	}{
 		{
 			name: "Success_FromCache",
 			redisClient: &MockRedisClient{
 				GetMocks: []GetMock{
-					{OutStringCmd: redis.NewStringResult("Octocat", nil)},
+					{OutStringCmd: redis.NewStringResult(`{"login":"octocat","name":"Octocat"}`, nil)},
 				},
 			},
 			ctx: context.Background(),
@@ -89,7 +110,28 @@ func TestService_Greet(t *testing.T) {
 				GithubUsername: "octocat",
 			},
 			expectedResponse: nil,
-			expectedError:    "http error",
+			expectedCode:     codes.Internal,
+			expectedError:    "rpc error: code = Internal desc = internal error",
+		},
+		{
+			name: "HTTPCallTimesOut",
+			httpClient: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{OutError: &url.Error{Op: "Get", URL: "https://api.github.com/users/octocat", Err: context.DeadlineExceeded}},
+				},
+			},
+			redisClient: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("", redis.Nil)},
+				},
+			},
+			ctx: context.Background(),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: nil,
+			expectedCode:     codes.DeadlineExceeded,
+			expectedError:    "rpc error: code = DeadlineExceeded desc = deadline exceeded",
 		},
 		{
 			name: "UnexpectedStatusCode",
@@ -116,7 +158,8 @@ func TestService_Greet(t *testing.T) {
 				GithubUsername: "octocat",
 			},
 			expectedResponse: nil,
-			expectedError:    "GET /users/octocat 400: invalid request",
+			expectedCode:     codes.Unavailable,
+			expectedError:    "rpc error: code = Unavailable desc = an upstream service is unavailable",
 		},
 		{
 			name: "InvalidResponseBody",
@@ -143,7 +186,8 @@ func TestService_Greet(t *testing.T) {
 				GithubUsername: "octocat",
 			},
 			expectedResponse: nil,
-			expectedError:    "unexpected EOF",
+			expectedCode:     codes.Internal,
+			expectedError:    "rpc error: code = Internal desc = internal error",
 		},
 		{
 			name: "Success_FromAPI",
@@ -166,6 +210,7 @@ func TestService_Greet(t *testing.T) {
 				},
 				SetMocks: []SetMock{
 					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
 				},
 			},
 			ctx: context.Background(),
@@ -177,6 +222,39 @@ func TestService_Greet(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "Success_Localized",
+			httpClient: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{
+						OutResponse: &http.Response{
+							Request:    req,
+							StatusCode: 200,
+							Body: io.NopCloser(
+								strings.NewReader(`{ "id": 1, "login": "octocat", "email": "octocat@example.com", "name": "" }`),
+							),
+						},
+					},
+				},
+			},
+			redisClient: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("Octocat", nil)},
+				},
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+				},
+			},
+			ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("accept-language", "fr-CA,fr;q=0.9")),
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: &greetingpb.GreetResponse{
+				Greeting: "Bonjour, octocat !",
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
@@ -184,10 +262,85 @@ func TestService_Greet(t *testing.T) {
 			s := &service{
 				httpClient:  tc.httpClient,
 				redisClient: tc.redisClient,
+				catalog:     catalog,
 			}
 
 			response, err := s.Greet(tc.ctx, tc.request)
 
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedResponse, response)
+			} else {
+				assert.Nil(t, response)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestService_Greet_FakeGitHub(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		opts             []fakegithub.Option
+		request          *greetingpb.GreetRequest
+		expectedResponse *greetingpb.GreetResponse
+		expectedCode     codes.Code
+		expectedError    string
+	}{
+		{
+			name: "UserNotFound",
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "ghost",
+			},
+			expectedCode:  codes.NotFound,
+			expectedError: "rpc error: code = NotFound desc = github user not found: ghost",
+		},
+		{
+			name: "ServerError",
+			opts: []fakegithub.Option{fakegithub.WithError("/users/*", 502)},
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedCode:  codes.Unavailable,
+			expectedError: "rpc error: code = Unavailable desc = an upstream service is unavailable",
+		},
+		{
+			name: "Success",
+			request: &greetingpb.GreetRequest{
+				GithubUsername: "octocat",
+			},
+			expectedResponse: &greetingpb.GreetResponse{
+				Greeting: "Hello, The Octocat!",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(tc.opts...)
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			redisClient := &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("", redis.Nil)},
+				},
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+				},
+			}
+
+			s, err := NewService(fake.Client(), redisClient, catalog, Options{GithubURL: fake.URL})
+			assert.NoError(t, err)
+
+			response, err := s.Greet(context.Background(), tc.request)
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
 				assert.Equal(t, tc.expectedResponse, response)
@@ -198,3 +351,490 @@ func TestService_Greet(t *testing.T) {
 		})
 	}
 }
+
+func TestService_getUser_Cache(t *testing.T) {
+	tests := []struct {
+		name             string
+		username         string
+		expectedKey      string
+		expectedTTL      time.Duration
+		expectedError    string
+		expectedRequests int
+	}{
+		{
+			name:             "UserNotFound",
+			username:         "ghost",
+			expectedKey:      "test:v1:user:ghost",
+			expectedTTL:      time.Minute,
+			expectedError:    "github user not found: ghost",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Success",
+			username:         "OctoCat",
+			expectedKey:      "test:v1:user:octocat",
+			expectedTTL:      time.Hour,
+			expectedError:    "",
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer()
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			srv, err := NewService(fake.Client(), redisClient, nil, Options{
+				GithubURL:        fake.URL,
+				CacheNamespace:   "test",
+				CacheTTL:         time.Hour,
+				NegativeCacheTTL: time.Minute,
+			})
+			assert.NoError(t, err)
+			s := srv.(*service)
+
+			// The second call should be served from the cache
+			for range 2 {
+				u, _, err := s.getUser(context.Background(), tc.username)
+
+				if tc.expectedError == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, "octocat", u.Login)
+				} else {
+					assert.ErrorIs(t, err, ErrUserNotFound)
+					assert.EqualError(t, err, tc.expectedError)
+					assert.Nil(t, u)
+				}
+			}
+
+			assert.Equal(t, tc.expectedRequests, fake.Requests())
+			assert.True(t, mr.Exists(tc.expectedKey))
+			assert.Equal(t, tc.expectedTTL, mr.TTL(tc.expectedKey))
+			if tc.expectedError == "" {
+				assert.Equal(t, DefaultStaleCacheTTL, mr.TTL("test:v1:stale:user:octocat"))
+			}
+
+			// Once the entry expires, the user should be fetched again
+			mr.FastForward(tc.expectedTTL)
+			assert.False(t, mr.Exists(tc.expectedKey))
+
+			_, _, _ = s.getUser(context.Background(), tc.username)
+			assert.Equal(t, tc.expectedRequests+1, fake.Requests())
+		})
+	}
+}
+
+func TestService_getUser_Coalescing(t *testing.T) {
+	fake, err := fakegithub.NewServer(fakegithub.WithLatency(200 * time.Millisecond))
+	assert.NoError(t, err)
+	defer fake.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	reader := metricsdk.NewManualReader()
+	meter := metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("test")
+
+	srv, err := NewService(fake.Client(), redisClient, nil, Options{
+		GithubURL: fake.URL,
+		Meter:     meter,
+	})
+	assert.NoError(t, err)
+	s := srv.(*service)
+
+	const callers = 10
+
+	// The last caller joins the in-flight lookup and gives up before the lookup completes
+	cancelledCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	users := make([]*user, callers)
+	errs := make([]error, callers)
+
+	for i := range callers {
+		ctx := context.Background()
+		if i == callers-1 {
+			time.Sleep(10 * time.Millisecond)
+			ctx = cancelledCtx
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users[i], _, errs[i] = s.getUser(ctx, "OctoCat")
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range callers - 1 {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "octocat", users[i].Login)
+	}
+
+	assert.ErrorIs(t, errs[callers-1], context.DeadlineExceeded)
+	assert.Nil(t, users[callers-1])
+
+	assert.Equal(t, 1, fake.Requests())
+	assert.Equal(t, int64(callers-2), coalescedCount(t, reader))
+}
+
+// coalescedCount returns the value of the coalesced lookups counter.
+func coalescedCount(t *testing.T, reader metricsdk.Reader) int64 {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "coalesced_github_user_lookups_total" {
+				return sum.DataPoints[0].Value
+			}
+		}
+	}
+
+	return 0
+}
+
+// serverTransportStream is a grpc.ServerTransportStream for capturing the header metadata set by handlers.
+type serverTransportStream struct {
+	header metadata.MD
+}
+
+func (s *serverTransportStream) Method() string {
+	return "/greeting.GreetingService/Greet"
+}
+
+func (s *serverTransportStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func (s *serverTransportStream) SendHeader(md metadata.MD) error {
+	return s.SetHeader(md)
+}
+
+func (s *serverTransportStream) SetTrailer(md metadata.MD) error {
+	return nil
+}
+
+func TestService_Greet_Stale(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		staleUser        string
+		expectedResponse *greetingpb.GreetResponse
+		expectedStale    []string
+		expectedError    string
+	}{
+		{
+			name:          "NoStaleUser",
+			staleUser:     "",
+			expectedError: "rpc error: code = Unavailable desc = an upstream service is unavailable",
+		},
+		{
+			name:      "StaleUser",
+			staleUser: `{"login":"octocat","name":"The Stale Octocat"}`,
+			expectedResponse: &greetingpb.GreetResponse{
+				Greeting: "Hello, The Stale Octocat!",
+			},
+			expectedStale: []string{"true"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(fakegithub.WithError("/users/*", 502))
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			if tc.staleUser != "" {
+				assert.NoError(t, mr.Set("greeting:v1:stale:user:octocat", tc.staleUser))
+			}
+
+			httpClient := &http.Client{
+				Transport: breaker.NewTransport(http.DefaultTransport, breaker.New(breaker.Options{FailureThreshold: 1})),
+			}
+
+			s, err := NewService(httpClient, redisClient, catalog, Options{GithubURL: fake.URL})
+			assert.NoError(t, err)
+
+			req := &greetingpb.GreetRequest{GithubUsername: "octocat"}
+
+			// The first failure opens the circuit
+			_, err = s.Greet(context.Background(), req)
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+
+			stream := new(serverTransportStream)
+			ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+			response, err := s.Greet(ctx, req)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedResponse, response)
+			} else {
+				assert.Nil(t, response)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			assert.Equal(t, tc.expectedStale, stream.header.Get(StaleHeader))
+			assert.Equal(t, 1, fake.Requests())
+		})
+	}
+}
+
+// greetManyStream is a greetingpb.GreetingService_GreetManyServer for capturing the results sent by handlers.
+type greetManyStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	results []*greetingpb.GreetResult
+	sendErr error
+}
+
+func (s *greetManyStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *greetManyStream) Send(res *greetingpb.GreetResult) error {
+	if s.sendErr != nil {
+		return s.sendErr
+	}
+
+	s.results = append(s.results, res)
+	return nil
+}
+
+func TestService_GreetMany(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name            string
+		ctx             context.Context
+		request         *greetingpb.GreetManyRequest
+		sendErr         error
+		expectedResults map[string]string
+		expectedCodes   map[string]codes.Code
+		expectedError   string
+	}{
+		{
+			name: "Success",
+			ctx:  context.Background(),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "ghost", "hubot"},
+			},
+			expectedResults: map[string]string{
+				"octocat": "Hello, The Octocat!",
+				"ghost":   "",
+				"hubot":   "Hello, hubot!",
+			},
+			expectedCodes: map[string]codes.Code{
+				"octocat": codes.OK,
+				"ghost":   codes.NotFound,
+				"hubot":   codes.OK,
+			},
+		},
+		{
+			name: "NoUsernames",
+			ctx:  context.Background(),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: nil,
+			},
+			expectedResults: map[string]string{},
+			expectedCodes:   map[string]codes.Code{},
+		},
+		{
+			name: "SendFails",
+			ctx:  context.Background(),
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+			},
+			sendErr:         errors.New("stream closed"),
+			expectedResults: map[string]string{},
+			expectedCodes:   map[string]codes.Code{},
+			expectedError:   "stream closed",
+		},
+		{
+			name: "Cancelled",
+			ctx:  cancelledCtx,
+			request: &greetingpb.GreetManyRequest{
+				GithubUsernames: []string{"octocat", "hubot"},
+			},
+			expectedResults: map[string]string{},
+			expectedCodes:   map[string]codes.Code{},
+			expectedError:   "rpc error: code = Canceled desc = call canceled",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(fakegithub.WithLatency(10 * time.Millisecond))
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			s, err := NewService(fake.Client(), redisClient, catalog, Options{
+				GithubURL:            fake.URL,
+				MaxConcurrentLookups: 2,
+			})
+			assert.NoError(t, err)
+
+			stream := &greetManyStream{ctx: tc.ctx, sendErr: tc.sendErr}
+			err = s.GreetMany(tc.request, stream)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			results := map[string]string{}
+			resultCodes := map[string]codes.Code{}
+			for _, res := range stream.results {
+				results[res.GithubUsername] = res.Greeting
+				resultCodes[res.GithubUsername] = codes.Code(res.GetError().GetCode())
+			}
+
+			assert.Equal(t, tc.expectedResults, results)
+			assert.Equal(t, tc.expectedCodes, resultCodes)
+		})
+	}
+}
+
+// greetChatStream is a greetingpb.GreetingService_GreetChatServer receiving requests and capturing the results sent by handlers.
+// Invalid requests are received with an error, as they are by the validation interceptor.
+type greetChatStream struct {
+	grpc.ServerStream
+	requests []*greetingpb.GreetRequest
+	recvErr  error
+	results  []*greetingpb.GreetResult
+}
+
+func (s *greetChatStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *greetChatStream) Recv() (*greetingpb.GreetRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, s.recvErr
+	}
+
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+
+	if err := validation.Validate(req); err != nil {
+		return nil, &validation.InvalidMessageError{Message: req, Err: problem.Err(err)}
+	}
+
+	return req, nil
+}
+
+func (s *greetChatStream) Send(res *greetingpb.GreetResult) error {
+	s.results = append(s.results, res)
+	return nil
+}
+
+func TestService_GreetChat(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name              string
+		requests          []*greetingpb.GreetRequest
+		recvErr           error
+		expectedUsernames []string
+		expectedGreetings []string
+		expectedCodes     []codes.Code
+		expectedError     string
+	}{
+		{
+			name: "Success",
+			requests: []*greetingpb.GreetRequest{
+				{GithubUsername: "hubot"},
+				{GithubUsername: "ghost"},
+				{GithubUsername: "octocat"},
+			},
+			recvErr:           io.EOF,
+			expectedUsernames: []string{"hubot", "ghost", "octocat"},
+			expectedGreetings: []string{"Hello, hubot!", "", "Hello, The Octocat!"},
+			expectedCodes:     []codes.Code{codes.OK, codes.NotFound, codes.OK},
+		},
+		{
+			name: "InvalidRequest",
+			requests: []*greetingpb.GreetRequest{
+				{GithubUsername: "hubot"},
+				{GithubUsername: "-hubot"},
+				{GithubUsername: "octocat"},
+			},
+			recvErr:           io.EOF,
+			expectedUsernames: []string{"hubot", "-hubot", "octocat"},
+			expectedGreetings: []string{"Hello, hubot!", "", "Hello, The Octocat!"},
+			expectedCodes:     []codes.Code{codes.OK, codes.InvalidArgument, codes.OK},
+		},
+		{
+			name: "RecvFails",
+			requests: []*greetingpb.GreetRequest{
+				{GithubUsername: "octocat"},
+			},
+			recvErr:           status.Error(codes.Canceled, "context canceled"),
+			expectedUsernames: []string{"octocat"},
+			expectedGreetings: []string{"Hello, The Octocat!"},
+			expectedCodes:     []codes.Code{codes.OK},
+			expectedError:     "rpc error: code = Canceled desc = context canceled",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer()
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			s, err := NewService(fake.Client(), redisClient, catalog, Options{GithubURL: fake.URL})
+			assert.NoError(t, err)
+
+			stream := &greetChatStream{requests: tc.requests, recvErr: tc.recvErr}
+			err = s.GreetChat(stream)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+
+			var usernames, greetings []string
+			var resultCodes []codes.Code
+			for _, res := range stream.results {
+				usernames = append(usernames, res.GithubUsername)
+				greetings = append(greetings, res.Greeting)
+				resultCodes = append(resultCodes, status.FromProto(res.Error).Code())
+			}
+
+			assert.Equal(t, tc.expectedUsernames, usernames)
+			assert.Equal(t, tc.expectedGreetings, greetings)
+			assert.Equal(t, tc.expectedCodes, resultCodes)
+		})
+	}
+}
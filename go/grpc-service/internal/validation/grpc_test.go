@@ -0,0 +1,205 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"grpc-service/internal/idl/greetingpb"
+)
+
+// fakeStream is a grpc.ServerStream receiving a list of messages.
+type fakeStream struct {
+	grpc.ServerStream
+	messages []*greetingpb.GreetRequest
+}
+
+func (s *fakeStream) RecvMsg(m any) error {
+	if len(s.messages) == 0 {
+		return io.EOF
+	}
+
+	proto.Merge(m.(proto.Message), s.messages[0])
+	s.messages = s.messages[1:]
+	return nil
+}
+
+func TestNewInterceptor(t *testing.T) {
+	tests := []struct {
+		name          string
+		files         []protoreflect.FileDescriptor
+		expectedError string
+	}{
+		{
+			name:  "Supported",
+			files: []protoreflect.FileDescriptor{greetingpb.File_greetingpb_greeting_proto},
+		},
+		{
+			name:          "UnsupportedConstraint",
+			files:         []protoreflect.FileDescriptor{newUnsupportedMessage(t).ProtoReflect().Descriptor().ParentFile()},
+			expectedError: "invalid constraints in test.proto: constraint buf.validate.FieldRules.int32 is not supported",
+		},
+		{
+			name:          "UnsupportedMessageConstraint",
+			files:         []protoreflect.FileDescriptor{newMessageRulesMessage(t).ProtoReflect().Descriptor().ParentFile()},
+			expectedError: "invalid constraints in test.proto: constraint buf.validate.MessageRules.cel is not supported",
+		},
+		{
+			name:          "UnsupportedOneofConstraint",
+			files:         []protoreflect.FileDescriptor{newOneofRulesMessage(t).ProtoReflect().Descriptor().ParentFile()},
+			expectedError: "invalid constraints in test.proto: constraint buf.validate.OneofRules.required is not supported",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i, err := NewInterceptor(tc.files...)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, i)
+			} else {
+				assert.Nil(t, i)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestInterceptor_ServerOptions(t *testing.T) {
+	i, err := NewInterceptor()
+	assert.NoError(t, err)
+	assert.Len(t, i.ServerOptions(), 2)
+}
+
+func TestInterceptor_unaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name               string
+		req                any
+		expectedCode       codes.Code
+		expectedViolations []*errdetails.BadRequest_FieldViolation
+	}{
+		{
+			name:         "NotMessage",
+			req:          "octocat",
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Valid",
+			req:          &greetingpb.GreetRequest{GithubUsername: "octocat"},
+			expectedCode: codes.OK,
+		},
+		{
+			name:         "Invalid",
+			req:          &greetingpb.GreetRequest{GithubUsername: ""},
+			expectedCode: codes.InvalidArgument,
+			expectedViolations: []*errdetails.BadRequest_FieldViolation{
+				{Field: "github_username", Description: "value is required"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i, err := NewInterceptor()
+			assert.NoError(t, err)
+			info := &grpc.UnaryServerInfo{FullMethod: "/greeting.GreetingService/Greet"}
+
+			var called bool
+			_, err = i.unaryInterceptor(context.Background(), tc.req, info, func(ctx context.Context, req any) (any, error) {
+				called = true
+				return nil, nil
+			})
+
+			st := status.Convert(err)
+			assert.Equal(t, tc.expectedCode, st.Code())
+			assert.Equal(t, tc.expectedCode == codes.OK, called)
+
+			for _, d := range st.Details() {
+				if br, ok := d.(*errdetails.BadRequest); ok {
+					assert.Len(t, br.FieldViolations, len(tc.expectedViolations))
+					for j, v := range tc.expectedViolations {
+						assert.True(t, proto.Equal(v, br.FieldViolations[j]))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestInterceptor_streamInterceptor(t *testing.T) {
+	tests := []struct {
+		name             string
+		messages         []*greetingpb.GreetRequest
+		expectedReceived int
+		expectedInvalid  []string
+		expectedCode     codes.Code
+	}{
+		{
+			name: "Valid",
+			messages: []*greetingpb.GreetRequest{
+				{GithubUsername: "octocat"},
+				{GithubUsername: "hubot"},
+			},
+			expectedReceived: 2,
+			expectedCode:     codes.OK,
+		},
+		{
+			name: "Invalid",
+			messages: []*greetingpb.GreetRequest{
+				{GithubUsername: "octocat"},
+				{GithubUsername: "-hubot"},
+				{GithubUsername: "ghost"},
+			},
+			expectedReceived: 2,
+			expectedInvalid:  []string{"-hubot"},
+			expectedCode:     codes.OK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			i, err := NewInterceptor()
+			assert.NoError(t, err)
+			ss := &fakeStream{messages: tc.messages}
+			info := &grpc.StreamServerInfo{FullMethod: "/greeting.GreetingService/GreetChat"}
+
+			// The handler receives messages until the end of the stream or an error, and keeps receiving after invalid messages
+			var received int
+			var invalid []string
+			err = i.streamInterceptor(nil, ss, info, func(srv any, ss grpc.ServerStream) error {
+				for {
+					err := ss.RecvMsg(new(greetingpb.GreetRequest))
+					if err == io.EOF {
+						return nil
+					}
+
+					var e *InvalidMessageError
+					if errors.As(err, &e) {
+						assert.Equal(t, codes.InvalidArgument, status.Code(e))
+						invalid = append(invalid, e.Message.(*greetingpb.GreetRequest).GithubUsername)
+						continue
+					}
+
+					if err != nil {
+						return err
+					}
+					received++
+				}
+			})
+
+			assert.Equal(t, tc.expectedReceived, received)
+			assert.Equal(t, tc.expectedInvalid, invalid)
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+		})
+	}
+}
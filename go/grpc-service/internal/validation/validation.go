@@ -0,0 +1,331 @@
+// Package validation validates gRPC requests against the constraints declared in the IDL using protovalidate annotations.
+// The constraints of the fields of a message are read from its descriptor, so they are declared only once in the .proto files.
+//
+// Only the constraints used by the IDL are supported (required, string min_len, max_len, and pattern,
+// and repeated min_items, max_items, and items), and messages with any other constraints fail validation,
+// including message and oneof constraints, so a constraint is never ignored silently.
+// The constraints of the IDL are checked when the interceptor is created, so unsupported constraints stop the server on startup.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sync"
+	"unicode/utf8"
+
+	"buf.build/gen/go/bufbuild/protovalidate/protocolbuffers/go/buf/validate"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"grpc-service/internal/problem"
+)
+
+var (
+	// patterns are the compiled regular expressions of pattern constraints.
+	patterns sync.Map
+
+	fieldConstraints    = []string{"required", "string", "repeated"}
+	stringConstraints   = []string{"min_len", "max_len", "pattern"}
+	repeatedConstraints = []string{"min_items", "max_items", "items"}
+)
+
+// Validate validates a message against the constraints of its fields, including the fields of its nested messages.
+// It returns a validation error with a violation for every constraint not satisfied by the message.
+func Validate(m proto.Message) error {
+	var violations []problem.Violation
+	if err := validateMessage(m.ProtoReflect(), "", &violations); err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return problem.Validation(fmt.Sprintf("invalid %s", m.ProtoReflect().Descriptor().FullName()), violations...)
+	}
+
+	return nil
+}
+
+// validateMessage validates the fields of a message whose path is prefixed by a prefix.
+func validateMessage(m protoreflect.Message, prefix string, violations *[]problem.Violation) error {
+	if err := unsupportedRules(m.Descriptor()); err != nil {
+		return err
+	}
+
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		path := prefix + string(fd.Name())
+
+		rules, _ := proto.GetExtension(fd.Options(), validate.E_Field).(*validate.FieldRules)
+		if err := validateField(m, fd, rules, path, violations); err != nil {
+			return err
+		}
+
+		if fd.Kind() != protoreflect.MessageKind || fd.IsMap() || !m.Has(fd) {
+			continue
+		}
+
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for j := 0; j < list.Len(); j++ {
+				if err := validateMessage(list.Get(j).Message(), fmt.Sprintf("%s[%d].", path, j), violations); err != nil {
+					return err
+				}
+			}
+		} else if err := validateMessage(m.Get(fd).Message(), path+".", violations); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateField validates the value of a field against the constraints of the field.
+func validateField(m protoreflect.Message, fd protoreflect.FieldDescriptor, rules *validate.FieldRules, path string, violations *[]problem.Violation) error {
+	if rules == nil {
+		return nil
+	}
+
+	if err := supported(rules, fieldConstraints); err != nil {
+		return err
+	}
+
+	if rules.GetRequired() && !m.Has(fd) {
+		*violations = append(*violations, problem.Violation{Field: path, Message: "value is required"})
+		return nil
+	}
+
+	if r := rules.GetRepeated(); r != nil && fd.IsList() {
+		return validateList(m.Get(fd).List(), r, path, violations)
+	}
+
+	if r := rules.GetString(); r != nil && fd.Kind() == protoreflect.StringKind && !fd.IsList() {
+		return validateString(m.Get(fd).String(), r, path, violations)
+	}
+
+	if rules.GetType() != nil {
+		return fmt.Errorf("constraints of %s do not match its type", fd.FullName())
+	}
+
+	return nil
+}
+
+// validateList validates the items of a repeated field against the repeated constraints of the field.
+func validateList(list protoreflect.List, rules *validate.RepeatedRules, path string, violations *[]problem.Violation) error {
+	if err := supported(rules, repeatedConstraints); err != nil {
+		return err
+	}
+
+	n := uint64(list.Len())
+
+	if rules.MinItems != nil && n < *rules.MinItems {
+		*violations = append(*violations, problem.Violation{Field: path, Message: fmt.Sprintf("value must contain at least %d item(s)", *rules.MinItems)})
+	}
+
+	if rules.MaxItems != nil && n > *rules.MaxItems {
+		*violations = append(*violations, problem.Violation{Field: path, Message: fmt.Sprintf("value must contain no more than %d item(s)", *rules.MaxItems)})
+	}
+
+	items := rules.GetItems()
+	if items == nil {
+		return nil
+	}
+
+	if err := supported(items, fieldConstraints); err != nil {
+		return err
+	}
+
+	for i := 0; i < list.Len(); i++ {
+		itemPath := fmt.Sprintf("%s[%d]", path, i)
+
+		// Only strings are supported as items
+		s, ok := list.Get(i).Interface().(string)
+		if !ok || items.GetRepeated() != nil {
+			return fmt.Errorf("constraints of the items of %s are not supported", path)
+		}
+
+		if items.GetRequired() && s == "" {
+			*violations = append(*violations, problem.Violation{Field: itemPath, Message: "value is required"})
+			continue
+		}
+
+		if r := items.GetString(); r != nil {
+			if err := validateString(s, r, itemPath, violations); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateString validates a string against string constraints.
+// Lengths are measured in characters (Unicode code points) rather than bytes.
+func validateString(s string, rules *validate.StringRules, path string, violations *[]problem.Violation) error {
+	if err := supported(rules, stringConstraints); err != nil {
+		return err
+	}
+
+	n := uint64(utf8.RuneCountInString(s))
+
+	if rules.MinLen != nil && n < *rules.MinLen {
+		*violations = append(*violations, problem.Violation{Field: path, Message: fmt.Sprintf("value length must be at least %d characters", *rules.MinLen)})
+	}
+
+	if rules.MaxLen != nil && n > *rules.MaxLen {
+		*violations = append(*violations, problem.Violation{Field: path, Message: fmt.Sprintf("value length must be at most %d characters", *rules.MaxLen)})
+	}
+
+	if rules.Pattern != nil {
+		re, err := pattern(*rules.Pattern)
+		if err != nil {
+			return err
+		}
+
+		if !re.MatchString(s) {
+			*violations = append(*violations, problem.Violation{Field: path, Message: fmt.Sprintf("value does not match regex pattern `%s`", *rules.Pattern)})
+		}
+	}
+
+	return nil
+}
+
+// pattern returns the compiled regular expression of a pattern constraint.
+func pattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern constraint: %w", err)
+	}
+
+	patterns.Store(expr, re)
+	return re, nil
+}
+
+// check returns an error if a message or any of the messages of its fields has constraints not supported by the package.
+// Messages already seen are not checked again, so recursive messages are checked once.
+func check(md protoreflect.MessageDescriptor, seen map[protoreflect.FullName]bool) error {
+	if seen[md.FullName()] {
+		return nil
+	}
+
+	seen[md.FullName()] = true
+
+	if err := unsupportedRules(md); err != nil {
+		return err
+	}
+
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+
+		if rules, _ := proto.GetExtension(fd.Options(), validate.E_Field).(*validate.FieldRules); rules != nil {
+			if err := checkField(fd, rules); err != nil {
+				return err
+			}
+		}
+
+		if fd.Message() != nil {
+			if err := check(fd.Message(), seen); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkField returns an error if the constraints of a field are not supported by the package.
+func checkField(fd protoreflect.FieldDescriptor, rules *validate.FieldRules) error {
+	if err := supported(rules, fieldConstraints); err != nil {
+		return err
+	}
+
+	if r := rules.GetRepeated(); r != nil && fd.IsList() {
+		if err := supported(r, repeatedConstraints); err != nil {
+			return err
+		}
+
+		items := r.GetItems()
+		if items == nil {
+			return nil
+		}
+
+		if err := supported(items, fieldConstraints); err != nil {
+			return err
+		}
+
+		// Only strings are supported as items
+		if fd.Kind() != protoreflect.StringKind || items.GetRepeated() != nil {
+			return fmt.Errorf("constraints of the items of %s are not supported", fd.FullName())
+		}
+
+		if s := items.GetString(); s != nil {
+			return checkString(s)
+		}
+
+		return nil
+	}
+
+	if r := rules.GetString(); r != nil && fd.Kind() == protoreflect.StringKind && !fd.IsList() {
+		return checkString(r)
+	}
+
+	if rules.GetType() != nil {
+		return fmt.Errorf("constraints of %s do not match its type", fd.FullName())
+	}
+
+	return nil
+}
+
+// checkString returns an error if string constraints are not supported by the package or have an invalid pattern.
+func checkString(rules *validate.StringRules) error {
+	if err := supported(rules, stringConstraints); err != nil {
+		return err
+	}
+
+	if rules.Pattern != nil {
+		if _, err := pattern(*rules.Pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unsupportedRules returns an error if a message or any of its oneofs has constraints, as none of them are supported.
+func unsupportedRules(md protoreflect.MessageDescriptor) error {
+	if rules, _ := proto.GetExtension(md.Options(), validate.E_Message).(*validate.MessageRules); rules != nil {
+		if err := supported(rules, nil); err != nil {
+			return err
+		}
+	}
+
+	oneofs := md.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		if rules, _ := proto.GetExtension(oneofs.Get(i).Options(), validate.E_Oneof).(*validate.OneofRules); rules != nil {
+			if err := supported(rules, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// supported returns an error if a set of constraints has any constraints other than the supported ones.
+func supported(rules proto.Message, constraints []string) error {
+	var err error
+	rules.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if !slices.Contains(constraints, string(fd.Name())) {
+			err = fmt.Errorf("constraint %s is not supported", fd.FullName())
+			return false
+		}
+		return true
+	})
+
+	return err
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/gardenbed/basil/config"
 	"github.com/gardenbed/basil/graceful"
@@ -11,9 +14,18 @@ import (
 	"github.com/gardenbed/basil/telemetry"
 	grpctelemetry "github.com/gardenbed/basil/telemetry/grpc"
 
+	"grpc-service/internal/auth"
+	"grpc-service/internal/breaker"
+	"grpc-service/internal/certs"
 	"grpc-service/internal/client"
+	"grpc-service/internal/idl/greetingpb"
+	"grpc-service/internal/locale"
+	"grpc-service/internal/ratelimit"
+	"grpc-service/internal/recovery"
 	"grpc-service/internal/server"
 	"grpc-service/internal/service/greeting"
+	"grpc-service/internal/streaming"
+	"grpc-service/internal/validation"
 	"grpc-service/metadata"
 )
 
@@ -29,6 +41,40 @@ var configs = struct {
 	LogLevel               string
 	OpenTelemetryCollector string
 	RedisAddress           string
+	TemplatesDir           string
+	GithubURL              string
+	CacheNamespace         string
+	CacheTTL               time.Duration
+	NegativeCacheTTL       time.Duration
+	StaleCacheTTL          time.Duration
+	MaxConcurrentLookups   int
+	BreakerFailures        int
+	BreakerOpenTimeout     time.Duration
+	BreakerSuccesses       int
+	RateLimit              int
+	RateLimitPeriod        time.Duration
+	RateLimitBurst         int
+	AuthFailureLimit       int
+	AuthFailurePeriod      time.Duration
+	APIKeys                string
+	JWKSFile               string
+	JWKSURL                string `flag:"jwks.url" env:"JWKS_URL" fileenv:"JWKS_URL_FILE"`
+	JWTIssuer              string
+	JWTAudience            string
+	AuthScope              string
+	GRPCSecurity           string
+	GRPCReflection         bool
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSClientCAFile        string
+	TLSReloadInterval      time.Duration
+	HTTPReadHeaderTimeout  time.Duration
+	HTTPReadTimeout        time.Duration
+	HTTPWriteTimeout       time.Duration
+	HTTPIdleTimeout        time.Duration
+	HTTPMaxHeaderBytes     int
+	CORSAllowedOrigins     []string
+	CORSMaxAge             time.Duration
 }{
 	// Default Values
 	HTTPPort:               8080,
@@ -41,6 +87,40 @@ var configs = struct {
 	LogLevel:               "debug",
 	OpenTelemetryCollector: "localhost:55680",
 	RedisAddress:           "localhost:6379",
+	TemplatesDir:           "",
+	GithubURL:              greeting.DefaultGithubURL,
+	CacheNamespace:         "grpc-service",
+	CacheTTL:               greeting.DefaultCacheTTL,
+	NegativeCacheTTL:       greeting.DefaultNegativeCacheTTL,
+	StaleCacheTTL:          greeting.DefaultStaleCacheTTL,
+	MaxConcurrentLookups:   greeting.DefaultMaxConcurrentLookups,
+	BreakerFailures:        breaker.DefaultFailureThreshold,
+	BreakerOpenTimeout:     breaker.DefaultOpenTimeout,
+	BreakerSuccesses:       breaker.DefaultSuccessThreshold,
+	RateLimit:              ratelimit.DefaultLimit,
+	RateLimitPeriod:        ratelimit.DefaultPeriod,
+	RateLimitBurst:         0,
+	AuthFailureLimit:       ratelimit.DefaultFailureLimit,
+	AuthFailurePeriod:      ratelimit.DefaultPeriod,
+	APIKeys:                "",
+	JWKSFile:               "",
+	JWKSURL:                "",
+	JWTIssuer:              "",
+	JWTAudience:            "",
+	AuthScope:              "",
+	GRPCSecurity:           string(server.SecurityInsecure),
+	GRPCReflection:         false,
+	TLSCertFile:            "",
+	TLSKeyFile:             "",
+	TLSClientCAFile:        "",
+	TLSReloadInterval:      certs.DefaultReloadInterval,
+	HTTPReadHeaderTimeout:  server.DefaultReadHeaderTimeout,
+	HTTPReadTimeout:        server.DefaultReadTimeout,
+	HTTPWriteTimeout:       server.DefaultWriteTimeout,
+	HTTPIdleTimeout:        server.DefaultIdleTimeout,
+	HTTPMaxHeaderBytes:     server.DefaultMaxHeaderBytes,
+	CORSAllowedOrigins:     nil,
+	CORSMaxAge:             server.DefaultCORSMaxAge,
 }
 
 func main() {
@@ -79,12 +159,41 @@ func main() {
 
 	// CREATE CLIENTS
 
-	httpClient := client.NewHTTP()
+	httpClient, err := client.NewHTTP(client.HTTPOptions{
+		Breaker: breaker.Options{
+			FailureThreshold: configs.BreakerFailures,
+			OpenTimeout:      configs.BreakerOpenTimeout,
+			SuccessThreshold: configs.BreakerSuccesses,
+			OnStateChange: func(from, to breaker.State) {
+				probe.Logger().Warn("github circuit breaker state changed", "from", from.String(), "to", to.String())
+			},
+		},
+		Meter: probe.Meter(),
+	})
+	if err != nil {
+		probe.Logger().Error("failed to create http client", "error", err)
+		panic(err)
+	}
+
 	redisClient := client.NewRedis(configs.RedisAddress)
 
 	// CREATE SERVICES
 
-	greetingService, err := greeting.NewService(httpClient, redisClient)
+	catalog, err := locale.NewCatalog(configs.TemplatesDir)
+	if err != nil {
+		probe.Logger().Error("failed to create greeting templates catalog", "error", err)
+		panic(err)
+	}
+
+	greetingService, err := greeting.NewService(httpClient, redisClient, catalog, greeting.Options{
+		GithubURL:            configs.GithubURL,
+		CacheNamespace:       configs.CacheNamespace,
+		CacheTTL:             configs.CacheTTL,
+		NegativeCacheTTL:     configs.NegativeCacheTTL,
+		StaleCacheTTL:        configs.StaleCacheTTL,
+		MaxConcurrentLookups: configs.MaxConcurrentLookups,
+		Meter:                probe.Meter(),
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create greeting service", "error", err)
 		panic(err)
@@ -92,9 +201,71 @@ func main() {
 
 	// CREATE SERVERS
 
+	rateLimiter := ratelimit.New(redisClient, ratelimit.Options{
+		Namespace: configs.Name,
+		Limit:     configs.RateLimit,
+		Period:    configs.RateLimitPeriod,
+		Burst:     configs.RateLimitBurst,
+		Logger:    probe.Logger(),
+	})
+
+	recoveryInterceptor := recovery.NewInterceptor(probe.Logger())
+
+	streamingInterceptor, err := streaming.NewInterceptor(probe.Meter())
+	if err != nil {
+		probe.Logger().Error("failed to create streaming interceptor", "error", err)
+		panic(err)
+	}
+
+	grpcOpts := append(serverInterceptor.ServerOptions(), recoveryInterceptor.ServerOptions()...)
+	grpcOpts = append(grpcOpts, streamingInterceptor.ServerOptions()...)
+
+	authenticator, err := newAuthenticator(ctx)
+	if err != nil {
+		probe.Logger().Error("failed to create authenticator", "error", err)
+		panic(err)
+	}
+
+	if authenticator != nil {
+		// Failed authentications are limited before authentication, so credentials cannot be brute forced
+		failureLimiter := ratelimit.NewFailureLimiter(redisClient, ratelimit.Options{
+			Namespace: configs.Name + "-auth",
+			Limit:     configs.AuthFailureLimit,
+			Period:    configs.AuthFailurePeriod,
+			Logger:    probe.Logger(),
+		})
+
+		grpcOpts = append(grpcOpts, failureLimiter.ServerOptions()...)
+		grpcOpts = append(grpcOpts, auth.NewInterceptor(authenticator, configs.AuthScope).ServerOptions()...)
+	} else {
+		probe.Logger().Warn("authentication is disabled because no api keys or jwks are configured")
+	}
+
+	// Calls are rate limited once they are authenticated, so clients are identified by their principals
+	grpcOpts = append(grpcOpts, rateLimiter.ServerOptions()...)
+
+	validationInterceptor, err := validation.NewInterceptor(greetingpb.File_greetingpb_greeting_proto)
+	if err != nil {
+		probe.Logger().Error("failed to create validation interceptor", "error", err)
+		panic(err)
+	}
+
+	// Requests are validated against the constraints declared in the IDL once they are authenticated and rate limited
+	grpcOpts = append(grpcOpts, validationInterceptor.ServerOptions()...)
+
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		probe.Logger().Error("failed to create tls config", "error", err)
+		panic(err)
+	}
+
 	grpcServer, err := server.NewGRPC(greetingService, server.GRPCOptions{
-		Port:    configs.GRPCPort,
-		Options: serverInterceptor.ServerOptions(),
+		Port:           configs.GRPCPort,
+		Security:       server.Security(configs.GRPCSecurity),
+		TLSConfig:      tlsConfig,
+		HealthCheckers: []health.Checker{httpClient, redisClient},
+		Reflection:     configs.GRPCReflection,
+		Options:        grpcOpts,
 	})
 
 	if err != nil {
@@ -102,13 +273,41 @@ func main() {
 		panic(err)
 	}
 
+	// Create an HTTP gateway for serving the gRPC services as REST/JSON APIs
+	// and an HTTP handler for serving the gRPC services over the Connect and gRPC-Web protocols through the in-process gRPC server
+	grpcConn, err := grpcServer.LocalConn()
+	if err != nil {
+		probe.Logger().Error("failed to create in-process grpc connection", "error", err)
+		panic(err)
+	}
+
+	httpGateway, err := server.NewGateway(ctx, grpcConn)
+	if err != nil {
+		probe.Logger().Error("failed to create http gateway", "error", err)
+		panic(err)
+	}
+
 	// Create an HTTP health handler for health checking the service by external systems
 	health.SetLogger(probe.Logger())
 	health.RegisterChecker(httpClient, redisClient)
 	healthHandler := health.HandlerFunc()
 
+	// The HTTP server serves the same services as the gRPC server, so it is secured the same way
 	httpServer, err := server.NewHTTP(healthHandler, server.HTTPOptions{
-		Port: configs.HTTPPort,
+		Port:              configs.HTTPPort,
+		Security:          server.Security(configs.GRPCSecurity),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: configs.HTTPReadHeaderTimeout,
+		ReadTimeout:       configs.HTTPReadTimeout,
+		WriteTimeout:      configs.HTTPWriteTimeout,
+		IdleTimeout:       configs.HTTPIdleTimeout,
+		MaxHeaderBytes:    configs.HTTPMaxHeaderBytes,
+		Gateway:           httpGateway,
+		Connect:           server.NewConnect(grpcConn),
+		CORS: server.CORSOptions{
+			AllowedOrigins: configs.CORSAllowedOrigins,
+			MaxAge:         configs.CORSMaxAge,
+		},
 	})
 
 	if err != nil {
@@ -126,3 +325,83 @@ func main() {
 
 	os.Exit(code)
 }
+
+// newAuthenticator creates an authenticator for the configured API keys and JWKS.
+// If neither API keys nor a JWKS is configured, authentication is disabled and nil is returned.
+func newAuthenticator(ctx context.Context) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	keys, err := auth.ParseAPIKeys(configs.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > 0 {
+		chain = append(chain, auth.NewAPIKeys(keys))
+	}
+
+	if configs.JWKSFile != "" || configs.JWKSURL != "" {
+		jwt, err := auth.NewJWT(ctx, auth.JWTOptions{
+			JWKSFile: configs.JWKSFile,
+			JWKSURL:  configs.JWKSURL,
+			Issuer:   configs.JWTIssuer,
+			Audience: configs.JWTAudience,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, jwt)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	return chain, nil
+}
+
+// newTLSConfig creates a TLS configuration for the configured security mode and certificate files.
+// The configuration is shared by the gRPC and HTTP servers, so they present the same certificate.
+// The insecure and dev security modes do not use certificate files.
+// A self-signed certificate is generated for the dev security mode, and nil is returned for the insecure security mode.
+func newTLSConfig() (*tls.Config, error) {
+	security := server.Security(configs.GRPCSecurity)
+
+	switch security {
+	case server.SecurityTLS, server.SecurityMTLS:
+	default:
+		if configs.TLSCertFile != "" || configs.TLSKeyFile != "" || configs.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("tls files require the %s or %s security mode, but the security mode is %q", server.SecurityTLS, server.SecurityMTLS, security)
+		}
+
+		if security == server.SecurityDev {
+			return server.NewDevTLSConfig()
+		}
+		return nil, nil
+	}
+
+	if configs.TLSCertFile == "" || configs.TLSKeyFile == "" {
+		return nil, fmt.Errorf("%s security mode requires tls cert file and key file", security)
+	}
+
+	if security == server.SecurityTLS && configs.TLSClientCAFile != "" {
+		return nil, fmt.Errorf("tls client ca file requires the %s security mode", server.SecurityMTLS)
+	}
+
+	if security == server.SecurityMTLS && configs.TLSClientCAFile == "" {
+		return nil, fmt.Errorf("%s security mode requires tls client ca file", security)
+	}
+
+	reloader, err := certs.NewReloader(certs.Options{
+		CertFile:       configs.TLSCertFile,
+		KeyFile:        configs.TLSKeyFile,
+		ClientCAFile:   configs.TLSClientCAFile,
+		ReloadInterval: configs.TLSReloadInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader.TLSConfig(), nil
+}
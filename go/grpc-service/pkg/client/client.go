@@ -0,0 +1,295 @@
+// Package client is a Go client for the gRPC Greeting API.
+//
+// Calls without a deadline are given the default timeout of the client.
+// Calls failing with Unavailable are retried with exponential backoff as configured by the service config of the client,
+// and calls are load balanced across all the addresses resolved for the target (e.g. dns:///greeting.example.com:9090).
+// Failed calls are returned as gRPC status errors, which can be inspected using status.Code and status.Convert.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"grpc-service/internal/idl/greetingpb"
+)
+
+const (
+	// DefaultTimeout is the default deadline for a call without a deadline.
+	DefaultTimeout = 10 * time.Second
+	// DefaultMaxAttempts is the default maximum number of attempts for a call, including the first attempt.
+	DefaultMaxAttempts = 3
+	// DefaultLoadBalancingPolicy is the default policy for balancing calls across the addresses of the target.
+	DefaultLoadBalancingPolicy = "round_robin"
+
+	// staleHeader is the response header metadata set when a greeting is created for a stale GitHub user.
+	staleHeader = "x-stale"
+)
+
+// serviceConfig is the template of the gRPC service config for the client.
+// See https://github.com/grpc/grpc/blob/master/doc/service_config.md
+const serviceConfig = `{
+	"loadBalancingConfig": [{ %q: {} }],
+	"methodConfig": [{
+		"name": [{ "service": "greeting.GreetingService" }]%s
+	}]
+}`
+
+// retryPolicy is the template of the retry policy in the gRPC service config for the client.
+const retryPolicy = `,
+		"retryPolicy": {
+			"maxAttempts": %d,
+			"initialBackoff": "0.1s",
+			"maxBackoff": "1s",
+			"backoffMultiplier": 2,
+			"retryableStatusCodes": ["UNAVAILABLE"]
+		}`
+
+// Options are optional settings for creating a new client.
+type Options struct {
+	// TLSConfig is used for verifying the server identity and presenting the client identity.
+	// If nil, calls are made in plaintext.
+	TLSConfig *tls.Config
+	// Insecure allows sending the API key and the bearer token over plaintext connections (e.g. for local use).
+	Insecure bool
+	// APIKey is sent in the x-api-key metadata of every call if set.
+	APIKey string
+	// BearerToken is sent in the authorization metadata of every call if set.
+	BearerToken string
+	// Timeout is the deadline for a call without a deadline (DefaultTimeout if zero).
+	Timeout time.Duration
+	// MaxAttempts is the maximum number of attempts for a call failing with Unavailable (DefaultMaxAttempts if zero).
+	// gRPC limits the number of attempts to 5, and a value of 1 disables retries.
+	MaxAttempts int
+	// LoadBalancingPolicy is the policy for balancing calls across the addresses of the target (DefaultLoadBalancingPolicy if empty).
+	LoadBalancingPolicy string
+	// DialOptions are additional options for creating the client connection.
+	DialOptions []grpc.DialOption
+}
+
+// Client is a client for the gRPC Greeting API.
+type Client struct {
+	conn    *grpc.ClientConn
+	client  greetingpb.GreetingServiceClient
+	timeout time.Duration
+}
+
+// New creates a new client for the gRPC Greeting API at a target (e.g. localhost:9090 or dns:///greeting.example.com:9090).
+// The connection is established lazily on the first call, and the client must be closed using the Close method.
+func New(target string, opts Options) (*Client, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.MaxAttempts == 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	if opts.LoadBalancingPolicy == "" {
+		opts.LoadBalancingPolicy = DefaultLoadBalancingPolicy
+	}
+
+	if opts.TLSConfig == nil && !opts.Insecure && (opts.APIKey != "" || opts.BearerToken != "") {
+		return nil, errors.New("tls config is required for sending credentials unless insecure")
+	}
+
+	creds := insecure.NewCredentials()
+	if opts.TLSConfig != nil {
+		creds = credentials.NewTLS(opts.TLSConfig)
+	}
+
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithDefaultServiceConfig(newServiceConfig(opts.LoadBalancingPolicy, opts.MaxAttempts)),
+	}
+
+	if opts.APIKey != "" || opts.BearerToken != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(&callCredentials{
+			apiKey:      opts.APIKey,
+			bearerToken: opts.BearerToken,
+			insecure:    opts.Insecure,
+		}))
+	}
+
+	dialOpts = append(dialOpts, opts.DialOptions...)
+
+	conn, err := grpc.NewClient(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		client:  greetingpb.NewGreetingServiceClient(conn),
+		timeout: opts.Timeout,
+	}, nil
+}
+
+// newServiceConfig returns the gRPC service config for a load balancing policy and a maximum number of attempts.
+// A retry policy requires more than one attempt, so calls are not retried for a single attempt.
+func newServiceConfig(lbPolicy string, maxAttempts int) string {
+	var retry string
+	if maxAttempts > 1 {
+		retry = fmt.Sprintf(retryPolicy, maxAttempts)
+	}
+
+	return fmt.Sprintf(serviceConfig, lbPolicy, retry)
+}
+
+// Close closes the connection of the client.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// callContext returns a context with the default timeout of the client if a context has no deadline,
+// and the preferred language of the call as the accept-language metadata.
+func (c *Client) callContext(ctx context.Context, language string) (context.Context, context.CancelFunc) {
+	cancel := func() {}
+	if _, ok := ctx.Deadline(); !ok {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+
+	if language != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "accept-language", language)
+	}
+
+	return ctx, cancel
+}
+
+// GreetRequest is the request for the Greet API.
+type GreetRequest struct {
+	GithubUsername string
+	// Language is the preferred language of the greeting (e.g. fr or fr-CA, en;q=0.8).
+	Language string
+}
+
+// GreetResponse is the response of the Greet API.
+type GreetResponse struct {
+	Greeting string
+	// Stale is true if the greeting is created for a cached GitHub user while GitHub is unavailable.
+	Stale bool
+}
+
+// Greet creates and returns a greeting for a GitHub user.
+func (c *Client) Greet(ctx context.Context, req *GreetRequest) (*GreetResponse, error) {
+	ctx, cancel := c.callContext(ctx, req.Language)
+	defer cancel()
+
+	var header metadata.MD
+	resp, err := c.client.Greet(ctx, &greetingpb.GreetRequest{
+		GithubUsername: req.GithubUsername,
+	}, grpc.Header(&header))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &GreetResponse{
+		Greeting: resp.Greeting,
+		Stale:    isStale(header),
+	}, nil
+}
+
+// GreetManyRequest is the request for the GreetMany API.
+type GreetManyRequest struct {
+	GithubUsernames []string
+	// Language is the preferred language of the greetings (e.g. fr or fr-CA, en;q=0.8).
+	Language string
+}
+
+// GreetResult is a greeting for a GitHub user streamed by the GreetMany API.
+type GreetResult struct {
+	GithubUsername string
+	Greeting       string
+	// Stale is true if the greeting is created for a cached GitHub user while GitHub is unavailable.
+	Stale bool
+	// Err is the status error if the greeting for the GitHub user failed.
+	Err error
+}
+
+// GreetMany streams greetings for a list of GitHub users as their lookups complete.
+// The iteration ends with a non-nil error if the call fails, and stopping the iteration cancels the call.
+// The timeout of the client applies to the whole stream.
+func (c *Client) GreetMany(ctx context.Context, req *GreetManyRequest) iter.Seq2[*GreetResult, error] {
+	return func(yield func(*GreetResult, error) bool) {
+		ctx, cancel := c.callContext(ctx, req.Language)
+		defer cancel()
+
+		stream, err := c.client.GreetMany(ctx, &greetingpb.GreetManyRequest{
+			GithubUsernames: req.GithubUsernames,
+		})
+
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		for {
+			res, err := stream.Recv()
+			if err == io.EOF {
+				return
+			}
+
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			result := &GreetResult{
+				GithubUsername: res.GithubUsername,
+				Greeting:       res.Greeting,
+				Stale:          res.Stale,
+			}
+
+			if res.Error != nil {
+				result.Err = status.ErrorProto(res.Error)
+			}
+
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}
+}
+
+// isStale determines whether the header metadata of a response marks it as stale.
+func isStale(header metadata.MD) bool {
+	vals := header.Get(staleHeader)
+	return len(vals) > 0 && vals[0] == "true"
+}
+
+// callCredentials is a credentials.PerRPCCredentials sending an API key and a bearer token with every call.
+type callCredentials struct {
+	apiKey      string
+	bearerToken string
+	insecure    bool
+}
+
+func (c *callCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	md := map[string]string{}
+
+	if c.apiKey != "" {
+		md["x-api-key"] = c.apiKey
+	}
+
+	if c.bearerToken != "" {
+		md["authorization"] = "Bearer " + c.bearerToken
+	}
+
+	return md, nil
+}
+
+// RequireTransportSecurity allows the credentials over plaintext connections only if insecure.
+func (c *callCredentials) RequireTransportSecurity() bool {
+	return !c.insecure
+}
@@ -0,0 +1,455 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/resolver/manual"
+	"google.golang.org/grpc/status"
+
+	"grpc-service/internal/auth"
+	internalclient "grpc-service/internal/client"
+	"grpc-service/internal/fakegithub"
+	"grpc-service/internal/idl/greetingpb"
+	"grpc-service/internal/locale"
+	"grpc-service/internal/ratelimit"
+	"grpc-service/internal/service/greeting"
+	"grpc-service/internal/validation"
+)
+
+const testAPIKey = "secret"
+
+// testServer is the greeting service served in-process, backed by a fake GitHub API and an in-memory Redis,
+// with authentication by API key, rate limiting, and validation.
+type testServer struct {
+	addr string
+	// calls is the number of calls received, including the failed ones.
+	calls atomic.Int64
+	// failures is the number of first calls failed with Unavailable.
+	failures int64
+}
+
+func newServer(t *testing.T, rateLimit int, failures int64, githubOpts ...fakegithub.Option) *testServer {
+	fake, err := fakegithub.NewServer(githubOpts...)
+	assert.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	mr := miniredis.RunT(t)
+	redisClient := internalclient.NewRedis(mr.Addr())
+
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	service, err := greeting.NewService(fake.Client(), redisClient, catalog, greeting.Options{GithubURL: fake.URL})
+	assert.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	ts := &testServer{
+		addr:     lis.Addr().String(),
+		failures: failures,
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(ts.unaryInterceptor),
+	}
+	opts = append(opts, auth.NewInterceptor(auth.NewAPIKeys(map[string]string{"test": testAPIKey}), "").ServerOptions()...)
+	opts = append(opts, ratelimit.New(redisClient, ratelimit.Options{Limit: rateLimit}).ServerOptions()...)
+	validationInterceptor, err := validation.NewInterceptor(greetingpb.File_greetingpb_greeting_proto)
+	assert.NoError(t, err)
+	opts = append(opts, validationInterceptor.ServerOptions()...)
+
+	server := grpc.NewServer(opts...)
+	greetingpb.RegisterGreetingServiceServer(server, service)
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+
+	t.Cleanup(server.Stop)
+
+	return ts
+}
+
+func (s *testServer) unaryInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.calls.Add(1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "server unavailable")
+	}
+
+	return handler(ctx, req)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name            string
+		target          string
+		opts            Options
+		expectedTimeout time.Duration
+		expectedError   string
+	}{
+		{
+			name:          "InvalidLoadBalancingPolicy",
+			target:        "localhost:9090",
+			opts:          Options{LoadBalancingPolicy: "unknown"},
+			expectedError: "grpc: the provided default service config is invalid",
+		},
+		{
+			name:          "CredentialsWithoutTLS",
+			target:        "localhost:9090",
+			opts:          Options{APIKey: "key"},
+			expectedError: "tls config is required for sending credentials unless insecure",
+		},
+		{
+			name:            "Defaults",
+			target:          "localhost:9090",
+			opts:            Options{},
+			expectedTimeout: DefaultTimeout,
+		},
+		{
+			name:   "Custom",
+			target: "dns:///localhost:9090",
+			opts: Options{
+				Insecure:            true,
+				APIKey:              "key",
+				BearerToken:         "token",
+				Timeout:             time.Second,
+				MaxAttempts:         1,
+				LoadBalancingPolicy: "pick_first",
+			},
+			expectedTimeout: time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(tc.target, tc.opts)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedTimeout, c.timeout)
+				assert.NoError(t, c.Close())
+			} else {
+				assert.Nil(t, c)
+				assert.ErrorContains(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestCallCredentials_RequireTransportSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		creds    *callCredentials
+		expected bool
+	}{
+		{
+			name:     "Secure",
+			creds:    &callCredentials{apiKey: "key"},
+			expected: true,
+		},
+		{
+			name:     "Insecure",
+			creds:    &callCredentials{apiKey: "key", insecure: true},
+			expected: false,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.creds.RequireTransportSecurity())
+		})
+	}
+}
+
+func TestNewServiceConfig(t *testing.T) {
+	tests := []struct {
+		name          string
+		lbPolicy      string
+		maxAttempts   int
+		expectedRetry bool
+	}{
+		{
+			name:          "NoRetries",
+			lbPolicy:      "pick_first",
+			maxAttempts:   1,
+			expectedRetry: false,
+		},
+		{
+			name:          "Retries",
+			lbPolicy:      "round_robin",
+			maxAttempts:   3,
+			expectedRetry: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sc := newServiceConfig(tc.lbPolicy, tc.maxAttempts)
+
+			var config struct {
+				LoadBalancingConfig []map[string]any `json:"loadBalancingConfig"`
+				MethodConfig        []struct {
+					RetryPolicy *struct {
+						MaxAttempts int `json:"maxAttempts"`
+					} `json:"retryPolicy"`
+				} `json:"methodConfig"`
+			}
+
+			assert.NoError(t, json.Unmarshal([]byte(sc), &config))
+			assert.Contains(t, config.LoadBalancingConfig[0], tc.lbPolicy)
+
+			if tc.expectedRetry {
+				assert.Equal(t, tc.maxAttempts, config.MethodConfig[0].RetryPolicy.MaxAttempts)
+			} else {
+				assert.Nil(t, config.MethodConfig[0].RetryPolicy)
+			}
+		})
+	}
+}
+
+func TestClient_Greet(t *testing.T) {
+	tests := []struct {
+		name             string
+		rateLimit        int
+		failures         int64
+		githubOpts       []fakegithub.Option
+		opts             Options
+		calls            int
+		req              *GreetRequest
+		expectedResponse *GreetResponse
+		expectedCode     codes.Code
+		expectedAttempts int64
+	}{
+		{
+			name:      "Success",
+			rateLimit: 10,
+			opts:      Options{Insecure: true, APIKey: testAPIKey},
+			calls:     1,
+			req:       &GreetRequest{GithubUsername: "octocat"},
+			expectedResponse: &GreetResponse{
+				Greeting: "Hello, The Octocat!",
+			},
+			expectedCode:     codes.OK,
+			expectedAttempts: 1,
+		},
+		{
+			name:      "Language",
+			rateLimit: 10,
+			opts:      Options{Insecure: true, APIKey: testAPIKey},
+			calls:     1,
+			req:       &GreetRequest{GithubUsername: "octocat", Language: "fr-CA, en;q=0.8"},
+			expectedResponse: &GreetResponse{
+				Greeting: "Bonjour, The Octocat !",
+			},
+			expectedCode:     codes.OK,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "Unauthenticated",
+			rateLimit:        10,
+			opts:             Options{Insecure: true, APIKey: "invalid"},
+			calls:            1,
+			req:              &GreetRequest{GithubUsername: "octocat"},
+			expectedCode:     codes.Unauthenticated,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "InvalidArgument",
+			rateLimit:        10,
+			opts:             Options{Insecure: true, APIKey: testAPIKey},
+			calls:            1,
+			req:              &GreetRequest{GithubUsername: "-octocat"},
+			expectedCode:     codes.InvalidArgument,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "NotFound",
+			rateLimit:        10,
+			opts:             Options{Insecure: true, APIKey: testAPIKey},
+			calls:            1,
+			req:              &GreetRequest{GithubUsername: "ghost"},
+			expectedCode:     codes.NotFound,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "RateLimited",
+			rateLimit:        1,
+			opts:             Options{Insecure: true, APIKey: testAPIKey},
+			calls:            2,
+			req:              &GreetRequest{GithubUsername: "octocat"},
+			expectedCode:     codes.ResourceExhausted,
+			expectedAttempts: 2,
+		},
+		{
+			name:             "DeadlineExceeded",
+			rateLimit:        10,
+			githubOpts:       []fakegithub.Option{fakegithub.WithLatency(time.Second)},
+			opts:             Options{Insecure: true, APIKey: testAPIKey, Timeout: 50 * time.Millisecond},
+			calls:            1,
+			req:              &GreetRequest{GithubUsername: "octocat"},
+			expectedCode:     codes.DeadlineExceeded,
+			expectedAttempts: 1,
+		},
+		{
+			name:      "Retried",
+			rateLimit: 10,
+			failures:  2,
+			opts:      Options{Insecure: true, APIKey: testAPIKey},
+			calls:     1,
+			req:       &GreetRequest{GithubUsername: "octocat"},
+			expectedResponse: &GreetResponse{
+				Greeting: "Hello, The Octocat!",
+			},
+			expectedCode:     codes.OK,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "RetriesExhausted",
+			rateLimit:        10,
+			failures:         3,
+			opts:             Options{Insecure: true, APIKey: testAPIKey, MaxAttempts: 2},
+			calls:            1,
+			req:              &GreetRequest{GithubUsername: "octocat"},
+			expectedCode:     codes.Unavailable,
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newServer(t, tc.rateLimit, tc.failures, tc.githubOpts...)
+
+			c, err := New(server.addr, tc.opts)
+			assert.NoError(t, err)
+			defer c.Close()
+
+			var res *GreetResponse
+			for range tc.calls {
+				res, err = c.Greet(context.Background(), tc.req)
+			}
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedResponse, res)
+			assert.Equal(t, tc.expectedAttempts, server.calls.Load())
+		})
+	}
+}
+
+func TestClient_GreetMany(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            Options
+		req             *GreetManyRequest
+		expectedResults map[string]string
+		expectedErrors  map[string]codes.Code
+		expectedCode    codes.Code
+	}{
+		{
+			name: "Success",
+			opts: Options{Insecure: true, APIKey: testAPIKey},
+			req: &GreetManyRequest{
+				GithubUsernames: []string{"octocat", "ghost", "hubot"},
+				Language:        "en",
+			},
+			expectedResults: map[string]string{
+				"octocat": "Hello, The Octocat!",
+				"hubot":   "Hello, hubot!",
+			},
+			expectedErrors: map[string]codes.Code{
+				"ghost": codes.NotFound,
+			},
+			expectedCode: codes.OK,
+		},
+		{
+			name: "Unauthenticated",
+			opts: Options{},
+			req: &GreetManyRequest{
+				GithubUsernames: []string{"octocat"},
+			},
+			expectedResults: map[string]string{},
+			expectedErrors:  map[string]codes.Code{},
+			expectedCode:    codes.Unauthenticated,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newServer(t, 10, 0)
+
+			c, err := New(server.addr, tc.opts)
+			assert.NoError(t, err)
+			defer c.Close()
+
+			results := map[string]string{}
+			errs := map[string]codes.Code{}
+
+			err = nil
+			for res, e := range c.GreetMany(context.Background(), tc.req) {
+				if e != nil {
+					err = e
+					break
+				}
+
+				if res.Err != nil {
+					errs[res.GithubUsername] = status.Code(res.Err)
+				} else {
+					results[res.GithubUsername] = res.Greeting
+				}
+			}
+
+			assert.Equal(t, tc.expectedCode, status.Code(err))
+			assert.Equal(t, tc.expectedResults, results)
+			assert.Equal(t, tc.expectedErrors, errs)
+		})
+	}
+}
+
+func TestClient_LoadBalancing(t *testing.T) {
+	servers := []*testServer{
+		newServer(t, 10, 0),
+		newServer(t, 10, 0),
+	}
+
+	// The manual resolver resolves the target to the addresses of both servers, as a DNS name with two records
+	r := manual.NewBuilderWithScheme("test")
+	r.InitialState(resolver.State{
+		Addresses: []resolver.Address{
+			{Addr: servers[0].addr},
+			{Addr: servers[1].addr},
+		},
+	})
+
+	c, err := New("test:///greeting", Options{
+		Insecure:    true,
+		APIKey:      testAPIKey,
+		DialOptions: []grpc.DialOption{grpc.WithResolvers(r)},
+	})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// Wait for both servers to be connected, so calls are not balanced across the connected servers only
+	for servers[0].calls.Load() == 0 || servers[1].calls.Load() == 0 {
+		_, err := c.Greet(context.Background(), &GreetRequest{GithubUsername: "octocat"})
+		assert.NoError(t, err)
+	}
+
+	before := []int64{servers[0].calls.Load(), servers[1].calls.Load()}
+
+	for range 4 {
+		_, err := c.Greet(context.Background(), &GreetRequest{GithubUsername: "octocat"})
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, int64(2), servers[0].calls.Load()-before[0])
+	assert.Equal(t, int64(2), servers[1].calls.Load()-before[1])
+}
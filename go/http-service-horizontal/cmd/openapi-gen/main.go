@@ -0,0 +1,49 @@
+// openapi-gen generates the Go code for HTTP endpoints from an OpenAPI specification.
+// The generated file is named after the specification (i.e. idl/greeting.yaml is generated as greeting.go).
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"http-service-horizontal/internal/openapigen"
+)
+
+func main() {
+	var (
+		spec string
+		out  string
+	)
+
+	flag.StringVar(&spec, "spec", "", "the path to the OpenAPI specification (i.e. idl/greeting.yaml)")
+	flag.StringVar(&out, "out", "", "the directory of the Go package for the generated code (i.e. internal/idl)")
+	flag.Parse()
+
+	if spec == "" || out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, err := filepath.Abs(out)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := openapigen.Generate(data, filepath.Base(dir), filepath.ToSlash(spec))
+	if err != nil {
+		log.Fatalf("%s: %s", spec, err)
+	}
+
+	name := strings.TrimSuffix(filepath.Base(spec), filepath.Ext(spec)) + ".go"
+	if err := os.WriteFile(filepath.Join(out, name), code, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
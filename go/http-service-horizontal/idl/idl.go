@@ -0,0 +1,9 @@
+// Package idl embeds the OpenAPI specifications of the service, so requests can be validated against them at runtime.
+package idl
+
+import _ "embed"
+
+// Greeting is the OpenAPI specification of the Greeting API.
+//
+//go:embed greeting.yaml
+var Greeting []byte
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware_Wrap(t *testing.T) {
+	tests := []struct {
+		name               string
+		authenticator      Authenticator
+		scope              string
+		headers            map[string]string
+		expectedStatusCode int
+		expectedBody       string
+		expectedCreds      Credentials
+	}{
+		{
+			name: "NoCredentials",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return nil, ErrNoCredentials
+			}),
+			expectedStatusCode: 401,
+			expectedBody:       `{"type":"urn:problem-type:unauthenticated","title":"Unauthenticated","status":401,"code":"UNAUTHENTICATED","detail":"unauthenticated: no credentials","instance":"/v1/greet"}` + "\n",
+			expectedCreds:      Credentials{},
+		},
+		{
+			name: "Unauthenticated",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return nil, errors.New("unauthenticated: invalid api key")
+			}),
+			headers:            map[string]string{"X-API-Key": "invalid"},
+			expectedStatusCode: 401,
+			expectedBody:       `{"type":"urn:problem-type:unauthenticated","title":"Unauthenticated","status":401,"code":"UNAUTHENTICATED","detail":"unauthenticated: invalid api key","instance":"/v1/greet"}` + "\n",
+			expectedCreds:      Credentials{APIKey: "invalid"},
+		},
+		{
+			name: "PermissionDenied",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return &Principal{Subject: "octocat", Scopes: []string{"read"}}, nil
+			}),
+			scope:              "greet",
+			headers:            map[string]string{"Authorization": "Bearer token"},
+			expectedStatusCode: 403,
+			expectedBody:       `{"type":"urn:problem-type:permission-denied","title":"Permission Denied","status":403,"code":"PERMISSION_DENIED","detail":"permission denied: missing scope greet","instance":"/v1/greet"}` + "\n",
+			expectedCreds:      Credentials{BearerToken: "token"},
+		},
+		{
+			name: "Authorized",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return &Principal{Subject: "octocat", Scopes: []string{"greet"}}, nil
+			}),
+			scope:              "greet",
+			headers:            map[string]string{"Authorization": "bearer token"},
+			expectedStatusCode: 200,
+			expectedBody:       "octocat",
+			expectedCreds:      Credentials{BearerToken: "token"},
+		},
+		{
+			name: "Authorized_NoScope",
+			authenticator: authenticatorFunc(func(context.Context, Credentials) (*Principal, error) {
+				return &Principal{Subject: "alice"}, nil
+			}),
+			scope:              "",
+			headers:            map[string]string{"X-API-Key": "secret", "Authorization": "Basic dXNlcjpwYXNz"},
+			expectedStatusCode: 200,
+			expectedBody:       "alice",
+			expectedCreds:      Credentials{APIKey: "secret"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var creds Credentials
+			authenticator := authenticatorFunc(func(ctx context.Context, c Credentials) (*Principal, error) {
+				creds = c
+				return tc.authenticator.Authenticate(ctx, c)
+			})
+
+			m := NewMiddleware(authenticator, tc.scope)
+			handler := m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				p, _ := FromContext(r.Context())
+				_, _ = io.WriteString(w, p.Subject)
+			})
+
+			req := httptest.NewRequest("POST", "/v1/greet", nil)
+			for key, val := range tc.headers {
+				req.Header.Set(key, val)
+			}
+
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			res := rec.Result()
+			body, _ := io.ReadAll(res.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedBody, string(body))
+			assert.Equal(t, tc.expectedCreds, creds)
+			if tc.expectedStatusCode == 401 {
+				assert.Equal(t, "Bearer", res.Header.Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package certs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testCert is a throwaway certificate for testing.
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newCert generates a certificate signed by a CA, or a self-signed CA certificate if the CA is nil.
+func newCert(t *testing.T, cn string, ca *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+
+	parent, signer := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{cn}
+		template.KeyUsage = x509.KeyUsageDigitalSignature
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		parent, signer = ca.cert, ca.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) tlsCertificate() tls.Certificate {
+	return tls.Certificate{
+		Certificate: [][]byte{c.cert.Raw},
+		PrivateKey:  c.key,
+	}
+}
+
+// writeFile writes a file and moves its modification time forward, so a change is always detected.
+func writeFile(t *testing.T, path string, data []byte) {
+	assert.NoError(t, os.WriteFile(path, data, 0o600))
+	mtime := time.Now().Add(time.Duration(len(data)) * time.Millisecond)
+	assert.NoError(t, os.Chtimes(path, mtime, mtime))
+}
+
+// handshake performs a TLS handshake between a server and a client over a loopback connection.
+// It returns the connection states of both sides and the error of the server side.
+func handshake(t *testing.T, serverConfig, clientConfig *tls.Config) (tls.ConnectionState, tls.ConnectionState, error) {
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	assert.NoError(t, err)
+	defer lis.Close()
+
+	type result struct {
+		state tls.ConnectionState
+		err   error
+	}
+
+	results := make(chan result, 1)
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			results <- result{err: err}
+			return
+		}
+		defer conn.Close()
+
+		tlsConn := conn.(*tls.Conn)
+		err = tlsConn.Handshake()
+		results <- result{state: tlsConn.ConnectionState(), err: err}
+	}()
+
+	conn, err := net.Dial("tcp", lis.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	client := tls.Client(conn, clientConfig)
+	_ = client.Handshake()
+
+	res := <-results
+	return res.state, client.ConnectionState(), res.err
+}
+
+func TestNewReloader(t *testing.T) {
+	ca := newCert(t, "Test CA", nil)
+	server := newCert(t, "localhost", ca)
+	other := newCert(t, "other", ca)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	otherKeyFile := filepath.Join(dir, "other.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	invalidFile := filepath.Join(dir, "invalid.crt")
+	missingFile := filepath.Join(dir, "missing.crt")
+	writeFile(t, certFile, server.certPEM)
+	writeFile(t, keyFile, server.keyPEM)
+	writeFile(t, otherKeyFile, other.keyPEM)
+	writeFile(t, caFile, ca.certPEM)
+	writeFile(t, invalidFile, []byte("invalid"))
+
+	tests := []struct {
+		name          string
+		opts          Options
+		expectedError string
+	}{
+		{
+			name:          "NoCertFile",
+			opts:          Options{KeyFile: keyFile},
+			expectedError: "tls cert file and key file are required",
+		},
+		{
+			name:          "MissingFile",
+			opts:          Options{CertFile: missingFile, KeyFile: keyFile},
+			expectedError: "stat " + missingFile + ": no such file or directory",
+		},
+		{
+			name:          "KeyMismatch",
+			opts:          Options{CertFile: certFile, KeyFile: otherKeyFile},
+			expectedError: "failed to load tls certificate: tls: private key does not match public key",
+		},
+		{
+			name:          "InvalidClientCA",
+			opts:          Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: invalidFile},
+			expectedError: "invalid client ca file: no certificates",
+		},
+		{
+			name:          "TLS",
+			opts:          Options{CertFile: certFile, KeyFile: keyFile},
+			expectedError: "",
+		},
+		{
+			name:          "MTLS",
+			opts:          Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile},
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReloader(tc.opts)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, r)
+				assert.Equal(t, DefaultReloadInterval, r.opts.ReloadInterval)
+				assert.NotNil(t, r.cert)
+				assert.Equal(t, tc.opts.ClientCAFile != "", r.pool != nil)
+			} else {
+				assert.Nil(t, r)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestReloader_TLSConfig(t *testing.T) {
+	ca := newCert(t, "Test CA", nil)
+	server := newCert(t, "localhost", ca)
+	client := newCert(t, "client", ca)
+	untrusted := newCert(t, "untrusted", newCert(t, "Untrusted CA", nil))
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	writeFile(t, certFile, server.certPEM)
+	writeFile(t, keyFile, server.keyPEM)
+	writeFile(t, caFile, ca.certPEM)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	tests := []struct {
+		name              string
+		opts              Options
+		clientCert        *testCert
+		expectedError     string
+		expectedPeerNames []string
+	}{
+		{
+			name:              "TLS",
+			opts:              Options{CertFile: certFile, KeyFile: keyFile},
+			clientCert:        nil,
+			expectedError:     "",
+			expectedPeerNames: nil,
+		},
+		{
+			name:              "MTLS_NoClientCert",
+			opts:              Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile},
+			clientCert:        nil,
+			expectedError:     "tls: client didn't provide a certificate",
+			expectedPeerNames: nil,
+		},
+		{
+			name:              "MTLS_UntrustedClientCert",
+			opts:              Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile},
+			clientCert:        untrusted,
+			expectedError:     "tls: failed to verify certificate: x509: certificate signed by unknown authority",
+			expectedPeerNames: nil,
+		},
+		{
+			name:              "MTLS",
+			opts:              Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: caFile},
+			clientCert:        client,
+			expectedError:     "",
+			expectedPeerNames: []string{"client"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewReloader(tc.opts)
+			assert.NoError(t, err)
+
+			clientConfig := &tls.Config{
+				ServerName: "localhost",
+				RootCAs:    roots,
+				NextProtos: []string{"h2"},
+			}
+
+			// The client certificate is always sent, even if it is not signed by a CA accepted by the server
+			if tc.clientCert != nil {
+				cert := tc.clientCert.tlsCertificate()
+				clientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+					return &cert, nil
+				}
+			}
+
+			state, _, err := handshake(t, r.TLSConfig(), clientConfig)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "h2", state.NegotiatedProtocol)
+
+				var names []string
+				for _, chain := range state.VerifiedChains {
+					names = append(names, chain[0].Subject.CommonName)
+				}
+				assert.Equal(t, tc.expectedPeerNames, names)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestReloader_Reload(t *testing.T) {
+	ca := newCert(t, "Test CA", nil)
+	first := newCert(t, "localhost", ca)
+	second := newCert(t, "localhost", ca)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	writeFile(t, certFile, first.certPEM)
+	writeFile(t, keyFile, first.keyPEM)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientConfig := &tls.Config{ServerName: "localhost", RootCAs: roots}
+
+	r, err := NewReloader(Options{
+		CertFile:       certFile,
+		KeyFile:        keyFile,
+		ReloadInterval: time.Minute,
+	})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	r.now = func() time.Time { return now }
+	r.checked = now
+
+	serial := func() *big.Int {
+		_, state, err := handshake(t, r.TLSConfig(), clientConfig)
+		assert.NoError(t, err)
+		return state.PeerCertificates[0].SerialNumber
+	}
+
+	assert.Equal(t, first.cert.SerialNumber, serial())
+
+	// The certificate is rotated
+	writeFile(t, certFile, second.certPEM)
+	writeFile(t, keyFile, second.keyPEM)
+
+	// Files are not checked more than once per reload interval
+	assert.Equal(t, first.cert.SerialNumber, serial())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, second.cert.SerialNumber, serial())
+
+	// The certificate is rotated before its key, so the previous certificate is kept
+	writeFile(t, certFile, first.certPEM)
+	now = now.Add(time.Minute)
+	assert.Equal(t, second.cert.SerialNumber, serial())
+
+	// The key is rotated too, so the new certificate is loaded
+	writeFile(t, keyFile, first.keyPEM)
+	now = now.Add(time.Minute)
+	assert.Equal(t, first.cert.SerialNumber, serial())
+}
@@ -2,10 +2,18 @@ package greeting
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"strings"
 
+	"github.com/gardenbed/basil/telemetry"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+
+	"http-service-horizontal/internal/breaker"
 	"http-service-horizontal/internal/entity"
+	githubentity "http-service-horizontal/internal/entity/github"
 	"http-service-horizontal/internal/gateway/github"
+	"http-service-horizontal/internal/locale"
 	"http-service-horizontal/internal/repository/usercache"
 )
 
@@ -14,47 +22,130 @@ type Controller interface {
 	Greet(context.Context, *entity.GreetRequest) (*entity.GreetResponse, error)
 }
 
+// Options are optional configurations for creating a new controller.
+type Options struct {
+	// Meter is used for creating the controller metrics (the meter of the global telemetry probe if nil).
+	Meter metric.Meter
+}
+
 // controller implements the Controller interface.
 type controller struct {
 	githubGateway       github.Gateway
 	usercacheRepository usercache.Repository
+	catalog             *locale.Catalog
+	lookups             singleflight.Group
+	coalesced           metric.Int64Counter
 }
 
 // NewController creates a new controller.
-func NewController(githubGateway github.Gateway, usercacheRepository usercache.Repository) (Controller, error) {
+func NewController(githubGateway github.Gateway, usercacheRepository usercache.Repository, catalog *locale.Catalog, opts Options) (Controller, error) {
+	if opts.Meter == nil {
+		opts.Meter = telemetry.Get().Meter()
+	}
+
+	coalesced, err := opts.Meter.Int64Counter(
+		"coalesced_github_user_lookups_total",
+		metric.WithDescription("The total number of GitHub user lookups served by an in-flight lookup for the same user"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &controller{
 		githubGateway:       githubGateway,
 		usercacheRepository: usercacheRepository,
+		catalog:             catalog,
+		coalesced:           coalesced,
 	}, nil
 }
 
-// Greet creates a greeting for a given GitHub user!
+// Greet creates a greeting for a given GitHub user in the requested language!
 func (c *controller) Greet(ctx context.Context, req *entity.GreetRequest) (*entity.GreetResponse, error) {
-	name, err := c.getName(ctx, req.GithubUsername)
+	user, stale, err := c.getUser(ctx, req.GithubUsername)
+	if err != nil {
+		return nil, err
+	}
+
+	greeting, err := c.catalog.Greet(req.Language, locale.User{
+		Login:    user.Login,
+		Name:     user.Name,
+		Company:  user.Company,
+		Location: user.Location,
+	})
+
 	if err != nil {
 		return nil, err
 	}
 
-	greeting := fmt.Sprintf("Hello, %s!", name)
 	resp := &entity.GreetResponse{
 		Greeting: greeting,
+		Language: c.catalog.Match(req.Language),
+		Stale:    stale,
 	}
 
 	return resp, nil
 }
 
-func (c *controller) getName(ctx context.Context, username string) (string, error) {
-	name, err := c.usercacheRepository.Lookup(ctx, username)
-	if err == nil && name != "" {
-		return name, nil
+// getUser retrieves a GitHub user, coalescing concurrent lookups of the same user into a single lookup.
+// The shared lookup is not cancelled when a caller's context is done, so the other callers are not affected,
+// but each caller stops waiting and returns as soon as its own context is done.
+// While the circuit breaker for the GitHub API is open, the user is served from the stale cache if available and reported as stale.
+func (c *controller) getUser(ctx context.Context, username string) (*githubentity.User, bool, error) {
+	// GitHub usernames are case-insensitive
+	key := strings.ToLower(username)
+
+	var leader bool
+	ch := c.lookups.DoChan(key, func() (any, error) {
+		leader = true
+		return c.lookupUser(context.WithoutCancel(ctx), username)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared && !leader {
+			c.coalesced.Add(ctx, 1)
+		}
+
+		if errors.Is(res.Err, breaker.ErrOpen) {
+			if user, err := c.usercacheRepository.LookupStale(ctx, username); err == nil {
+				return user, true, nil
+			}
+		}
+
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+
+		return res.Val.(*githubentity.User), false, nil
+
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+}
+
+// lookupUser retrieves a GitHub user using a write-through cache.
+// Both users and the absence of users are cached; cache failures only result in calling the GitHub API.
+func (c *controller) lookupUser(ctx context.Context, username string) (*githubentity.User, error) {
+	user, err := c.usercacheRepository.Lookup(ctx, username)
+	if err == nil && user != nil {
+		return user, nil
+	}
+
+	if errors.Is(err, githubentity.ErrUserNotFound) {
+		return nil, err
+	}
+
+	user, err = c.githubGateway.GetUser(ctx, username)
+	if errors.Is(err, githubentity.ErrUserNotFound) {
+		_ = c.usercacheRepository.StoreNotFound(ctx, username)
+		return nil, err
 	}
 
-	user, err := c.githubGateway.GetUser(ctx, username)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	_ = c.usercacheRepository.Store(ctx, username, user.Name)
+	_ = c.usercacheRepository.Store(ctx, username, user)
 
-	return user.Name, nil
+	return user, nil
 }
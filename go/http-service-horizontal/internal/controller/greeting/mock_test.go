@@ -104,14 +104,27 @@ type (
 	StoreMock struct {
 		InContext  context.Context
 		InUsername string
-		InName     string
+		InUser     *githubentity.User
+		OutError   error
+	}
+
+	StoreNotFoundMock struct {
+		InContext  context.Context
+		InUsername string
 		OutError   error
 	}
 
 	LookupMock struct {
 		InContext  context.Context
 		InUsername string
-		OutName    string
+		OutUser    *githubentity.User
+		OutError   error
+	}
+
+	LookupStaleMock struct {
+		InContext  context.Context
+		InUsername string
+		OutUser    *githubentity.User
 		OutError   error
 	}
 
@@ -124,8 +137,14 @@ type (
 		StoreIndex int
 		StoreMocks []StoreMock
 
+		StoreNotFoundIndex int
+		StoreNotFoundMocks []StoreNotFoundMock
+
 		LookupIndex int
 		LookupMocks []LookupMock
+
+		LookupStaleIndex int
+		LookupStaleMocks []LookupStaleMock
 	}
 )
 
@@ -133,19 +152,35 @@ func (m *MockUserCacheRepository) String() string {
 	return m.StringOut
 }
 
-func (m *MockUserCacheRepository) Store(ctx context.Context, username, name string) error {
+func (m *MockUserCacheRepository) Store(ctx context.Context, username string, user *githubentity.User) error {
 	i := m.StoreIndex
 	m.StoreIndex++
 	m.StoreMocks[i].InContext = ctx
 	m.StoreMocks[i].InUsername = username
-	m.StoreMocks[i].InName = name
+	m.StoreMocks[i].InUser = user
 	return m.StoreMocks[i].OutError
 }
 
-func (m *MockUserCacheRepository) Lookup(ctx context.Context, username string) (string, error) {
+func (m *MockUserCacheRepository) StoreNotFound(ctx context.Context, username string) error {
+	i := m.StoreNotFoundIndex
+	m.StoreNotFoundIndex++
+	m.StoreNotFoundMocks[i].InContext = ctx
+	m.StoreNotFoundMocks[i].InUsername = username
+	return m.StoreNotFoundMocks[i].OutError
+}
+
+func (m *MockUserCacheRepository) Lookup(ctx context.Context, username string) (*githubentity.User, error) {
 	i := m.LookupIndex
 	m.LookupIndex++
 	m.LookupMocks[i].InContext = ctx
 	m.LookupMocks[i].InUsername = username
-	return m.LookupMocks[i].OutName, m.LookupMocks[i].OutError
+	return m.LookupMocks[i].OutUser, m.LookupMocks[i].OutError
+}
+
+func (m *MockUserCacheRepository) LookupStale(ctx context.Context, username string) (*githubentity.User, error) {
+	i := m.LookupStaleIndex
+	m.LookupStaleIndex++
+	m.LookupStaleMocks[i].InContext = ctx
+	m.LookupStaleMocks[i].InUsername = username
+	return m.LookupStaleMocks[i].OutUser, m.LookupStaleMocks[i].OutError
 }
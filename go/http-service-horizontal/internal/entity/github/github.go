@@ -1,16 +1,42 @@
 package github
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrUserNotFound is returned when a GitHub user does not exist.
+var ErrUserNotFound = errors.New("github user not found")
+
+// RateLimitError is returned when GitHub rejects a request for exceeding its rate limit.
+type RateLimitError struct {
+	// RetryAfter is the duration until the rate limit resets (unknown if zero).
+	RetryAfter time.Duration
+	Err        error
+}
+
+// Error implements the error interface.
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("github rate limit exceeded: %s", e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RateLimitError) Unwrap() error {
+	return e.Err
+}
 
 // User is the entity for a GitHub user.
 type User struct {
-	ID    int    `json:"id"`
-	Login string `json:"login"`
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	ID       int    `json:"id"`
+	Login    string `json:"login"`
+	Email    string `json:"email"`
+	Name     string `json:"name"`
+	Company  string `json:"company"`
+	Location string `json:"location"`
 }
 
 // String implements the fmt.Stringer interface.
 func (u *User) String() string {
-	return fmt.Sprintf("User{id=%d login=%s email=%s name=%s}", u.ID, u.Login, u.Email, u.Name)
+	return fmt.Sprintf("User{id=%d login=%s email=%s name=%s company=%s location=%s}", u.ID, u.Login, u.Email, u.Name, u.Company, u.Location)
 }
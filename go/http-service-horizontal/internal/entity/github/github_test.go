@@ -1,7 +1,9 @@
 package github
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -15,12 +17,14 @@ func TestUser(t *testing.T) {
 		{
 			name: "OK",
 			entity: User{
-				ID:    1,
-				Login: "octocat",
-				Email: "octocat@example.com",
-				Name:  "Octocat",
+				ID:       1,
+				Login:    "octocat",
+				Email:    "octocat@example.com",
+				Name:     "Octocat",
+				Company:  "GitHub",
+				Location: "San Francisco",
 			},
-			expectedString: "User{id=1 login=octocat email=octocat@example.com name=Octocat}",
+			expectedString: "User{id=1 login=octocat email=octocat@example.com name=Octocat company=GitHub location=San Francisco}",
 		},
 	}
 
@@ -30,3 +34,11 @@ func TestUser(t *testing.T) {
 		})
 	}
 }
+
+func TestRateLimitError(t *testing.T) {
+	cause := errors.New("GET /users/octocat 403: API rate limit exceeded.")
+	err := &RateLimitError{RetryAfter: time.Minute, Err: cause}
+
+	assert.EqualError(t, err, "github rate limit exceeded: GET /users/octocat 403: API rate limit exceeded.")
+	assert.ErrorIs(t, err, cause)
+}
@@ -5,19 +5,25 @@ import "fmt"
 // GreetRequest is the domain model for a Greet request.
 type GreetRequest struct {
 	GithubUsername string
+	// Language is the preferred language of the greeting (i.e. a language tag or an Accept-Language value).
+	Language string
 }
 
 // String implements the fmt.Stringer interface.
 func (r *GreetRequest) String() string {
-	return fmt.Sprintf("GreetRequest{github_username=%s}", r.GithubUsername)
+	return fmt.Sprintf("GreetRequest{github_username=%s language=%s}", r.GithubUsername, r.Language)
 }
 
 // GreetResponse is the domain model for a Greet response.
 type GreetResponse struct {
 	Greeting string
+	// Language is the language of the greeting matched from the preferred language.
+	Language string
+	// Stale is true if the greeting is created for a stale GitHub user because the GitHub API is unavailable.
+	Stale bool
 }
 
 // String implements the fmt.Stringer interface.
 func (r *GreetResponse) String() string {
-	return fmt.Sprintf("GreetResponse{greeting=%s}", r.Greeting)
+	return fmt.Sprintf("GreetResponse{greeting=%s language=%s stale=%t}", r.Greeting, r.Language, r.Stale)
 }
@@ -16,8 +16,9 @@ func TestGreetRequest(t *testing.T) {
 			name: "OK",
 			entity: GreetRequest{
 				GithubUsername: "octocat",
+				Language:       "fr",
 			},
-			expectedString: "GreetRequest{github_username=octocat}",
+			expectedString: "GreetRequest{github_username=octocat language=fr}",
 		},
 	}
 
@@ -38,8 +39,9 @@ func TestGreetResponse(t *testing.T) {
 			name: "OK",
 			entity: GreetResponse{
 				Greeting: "Hello, Jane!",
+				Language: "en",
 			},
-			expectedString: "GreetResponse{greeting=Hello, Jane!}",
+			expectedString: "GreetResponse{greeting=Hello, Jane! language=en stale=false}",
 		},
 	}
 
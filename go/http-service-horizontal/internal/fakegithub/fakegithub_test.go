@@ -0,0 +1,171 @@
+package fakegithub
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandler(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(dir, "users"), 0o755))
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		expectedError string
+	}{
+		{
+			name:          "InvalidFixtures",
+			opts:          []Option{WithFixtures(filepath.Join(dir, "missing"))},
+			expectedError: "invalid fixtures: stat users: no such file or directory",
+		},
+		{
+			name:          "EmbeddedFixtures",
+			opts:          nil,
+			expectedError: "",
+		},
+		{
+			name:          "FixturesDir",
+			opts:          []Option{WithFixtures(dir), WithLatency(time.Millisecond), WithRateLimit(10), WithError("/users/*", 500)},
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := NewHandler(tc.opts...)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, h)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+				assert.Nil(t, h)
+			}
+		})
+	}
+}
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name               string
+		opts               []Option
+		requests           int
+		path               string
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+		expectedBody       string
+	}{
+		{
+			name:               "UserNotFound",
+			path:               "/users/ghost",
+			expectedStatusCode: 404,
+			expectedBody:       `{"documentation_url":"https://docs.github.com/rest","message":"Not Found"}`,
+		},
+		{
+			name:               "User",
+			path:               "/users/OctoCat",
+			expectedStatusCode: 200,
+			expectedBody:       `"login": "octocat"`,
+		},
+		{
+			name:               "ListNotFound",
+			path:               "/users/octocat/gists",
+			expectedStatusCode: 404,
+		},
+		{
+			name:               "EmptyList",
+			path:               "/users/hubot/repos",
+			expectedStatusCode: 200,
+			expectedBody:       `[]`,
+		},
+		{
+			name:               "FirstPage",
+			path:               "/users/octocat/repos?per_page=3",
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Link": `<http://{host}/users/octocat/repos?page=2&per_page=3>; rel="next", <http://{host}/users/octocat/repos?page=3&per_page=3>; rel="last"`,
+			},
+			expectedBody: `"full_name":"octocat/boysenberry-repo-1"`,
+		},
+		{
+			name:               "LastPage",
+			path:               "/users/octocat/repos?page=3&per_page=3",
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"Link": `<http://{host}/users/octocat/repos?page=2&per_page=3>; rel="prev", <http://{host}/users/octocat/repos?page=1&per_page=3>; rel="first"`,
+			},
+			expectedBody: `"full_name":"octocat/test-repo1"`,
+		},
+		{
+			name:               "RateLimited",
+			opts:               []Option{WithRateLimit(2)},
+			requests:           2,
+			path:               "/users/octocat",
+			expectedStatusCode: 403,
+			expectedHeaders: map[string]string{
+				"X-RateLimit-Limit":     "2",
+				"X-RateLimit-Remaining": "0",
+			},
+			expectedBody: `"message":"API rate limit exceeded."`,
+		},
+		{
+			name:               "InjectedError",
+			opts:               []Option{WithError("/users/*/repos", 502)},
+			path:               "/users/octocat/repos",
+			expectedStatusCode: 502,
+			expectedBody:       `"message":"Bad Gateway"`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewServer(tc.opts...)
+			assert.NoError(t, err)
+			defer s.Close()
+
+			for range tc.requests {
+				resp, err := http.Get(s.URL + tc.path)
+				assert.NoError(t, err)
+				_ = resp.Body.Close()
+			}
+
+			resp, err := http.Get(s.URL + tc.path)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatusCode, resp.StatusCode)
+			assert.Contains(t, string(body), tc.expectedBody)
+			for key, val := range tc.expectedHeaders {
+				host := s.Listener.Addr().String()
+				assert.Equal(t, strings.ReplaceAll(val, "{host}", host), resp.Header.Get(key))
+			}
+			assert.Equal(t, tc.requests+1, s.Requests())
+		})
+	}
+}
+
+func TestServer_Latency(t *testing.T) {
+	s, err := NewServer(WithLatency(time.Second))
+	assert.NoError(t, err)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, "GET", s.URL+"/users/octocat", nil)
+	_, err = http.DefaultClient.Do(req)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -2,15 +2,20 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
-	"github.com/gardenbed/basil/httpx"
-
 	"http-service-horizontal/internal/controller/greeting"
+	githubentity "http-service-horizontal/internal/entity/github"
 	"http-service-horizontal/internal/idl"
 	"http-service-horizontal/internal/mapper"
+	"http-service-horizontal/internal/problem"
 )
 
+// StaleHeader is the response header set when a greeting is created for a stale GitHub user.
+const StaleHeader = "X-Stale"
+
 // GreetingHandler is an alias for the HTTP service interface.
 type GreetingHandler = idl.GreetingHandler
 
@@ -27,31 +32,52 @@ func NewGreetingHandler(greetingController greeting.Controller) (GreetingHandler
 }
 
 // Greet is the handler for GreetingService::Greet endpoint.
+// The language of the greeting is negotiated using the Accept-Language header and responded in the Content-Language header.
+// Failures are responded with problem details (RFC 7807).
 func (h *greetingHandler) Greet(w http.ResponseWriter, r *http.Request) {
 	req := new(idl.GreetRequest)
 	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		problem.Write(w, r, problem.Wrap(problem.KindValidation, fmt.Errorf("invalid request body: %w", err)))
 		return
 	}
 
 	domainReq, err := mapper.GreetRequestIDLToDomain(req)
 	if err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		problem.Write(w, r, err)
 		return
 	}
 
+	domainReq.Language = r.Header.Get("Accept-Language")
+
 	domainResp, err := h.greetingController.Greet(r.Context(), domainReq)
+	if errors.Is(err, githubentity.ErrUserNotFound) {
+		problem.Write(w, r, problem.Wrap(problem.KindNotFound, err))
+		return
+	}
+
+	var rateLimitErr *githubentity.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		problem.Write(w, r, problem.UpstreamRateLimited(err, rateLimitErr.RetryAfter))
+		return
+	}
+
 	if err != nil {
-		httpx.Error(w, err, http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
 	resp, err := mapper.GreetResponseDomainToIDL(domainResp)
 	if err != nil {
-		httpx.Error(w, err, http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Language", domainResp.Language)
+
+	if domainResp.Stale {
+		w.Header().Set(StaleHeader, "true")
+	}
+
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
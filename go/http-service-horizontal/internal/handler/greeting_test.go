@@ -2,16 +2,19 @@ package handler
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
-	"github.com/gardenbed/basil/httpx"
 	"github.com/stretchr/testify/assert"
 
+	"http-service-horizontal/internal/breaker"
 	"http-service-horizontal/internal/entity"
+	githubentity "http-service-horizontal/internal/entity/github"
 )
 
 func TestNewGreetingHandler(t *testing.T) {
@@ -43,35 +46,77 @@ func TestNewGreetingHandler(t *testing.T) {
 }
 
 func TestGreetingHandler_Greet(t *testing.T) {
+	frenchReq := httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`))
+	frenchReq.Header.Set("Accept-Language", "fr")
+
+	tooLargeReq := httptest.NewRequest("POST", "/greet", nil)
+	tooLargeReq.Body = http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(`{ "githubUsername": "octocat" }`)), 8)
+
 	tests := []struct {
 		name               string
 		greetingController *MockGreetingController
 		req                *http.Request
 		expectedStatusCode int
 		expectedBody       string
+		expectedLanguage   string
+		expectedContent    string
+		expectedStale      string
 	}{
 		{
 			name:               "RequestDecodingFails",
 			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{`)),
 			expectedStatusCode: 400,
-			expectedBody:       "unexpected EOF\n",
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid request body: unexpected EOF","instance":"/greet"}` + "\n",
 		},
 		{
-			name:               "RequestMappingFails",
-			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "" }`)),
-			expectedStatusCode: 400,
-			expectedBody:       "github username cannot be empty\n",
+			name:               "RequestTooLarge",
+			req:                tooLargeReq,
+			expectedStatusCode: 413,
+			expectedBody:       `{"type":"urn:problem-type:request-too-large","title":"Request Too Large","status":413,"code":"REQUEST_TOO_LARGE","detail":"invalid request body: http: request body too large","instance":"/greet"}` + "\n",
+		},
+		{
+			name: "UserNotFound",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutError: fmt.Errorf("%w: ghost", githubentity.ErrUserNotFound)},
+				},
+			},
+			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "ghost" }`)),
+			expectedStatusCode: 404,
+			expectedBody:       `{"type":"urn:problem-type:not-found","title":"Not Found","status":404,"code":"NOT_FOUND","detail":"github user not found: ghost","instance":"/greet"}` + "\n",
+		},
+		{
+			name: "UpstreamUnavailable",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutError: fmt.Errorf("degraded: %w", breaker.ErrOpen)},
+				},
+			},
+			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
+			expectedStatusCode: 503,
+			expectedBody:       `{"type":"urn:problem-type:upstream-unavailable","title":"Upstream Unavailable","status":503,"code":"UPSTREAM_UNAVAILABLE","detail":"an upstream service is unavailable","instance":"/greet"}` + "\n",
+		},
+		{
+			name: "UpstreamRateLimited",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{OutError: &githubentity.RateLimitError{RetryAfter: time.Minute, Err: errors.New("GET /users/octocat 403")}},
+				},
+			},
+			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
+			expectedStatusCode: 429,
+			expectedBody:       `{"type":"urn:problem-type:rate-limited","title":"Rate Limited","status":429,"code":"RATE_LIMITED","detail":"an upstream service is rate limited","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "ControllerFails",
 			greetingController: &MockGreetingController{
 				GreetMocks: []GreetMock{
-					{OutError: httpx.NewServerError(errors.New("controller failed"), 500)},
+					{OutError: errors.New("controller failed")},
 				},
 			},
 			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 500,
-			expectedBody:       "controller failed\n",
+			expectedBody:       `{"type":"urn:problem-type:internal","title":"Internal Server Error","status":500,"code":"INTERNAL","detail":"internal server error","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "ResponseMappingFails",
@@ -82,7 +127,7 @@ func TestGreetingHandler_Greet(t *testing.T) {
 			},
 			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 500,
-			expectedBody:       "greeting cannot be empty\n",
+			expectedBody:       `{"type":"urn:problem-type:internal","title":"Internal Server Error","status":500,"code":"INTERNAL","detail":"internal server error","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "Success",
@@ -91,6 +136,43 @@ func TestGreetingHandler_Greet(t *testing.T) {
 					{
 						OutResponse: &entity.GreetResponse{
 							Greeting: "Hello, Jane!",
+							Language: "en",
+						},
+					},
+				},
+			},
+			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
+			expectedStatusCode: 200,
+			expectedBody:       "{\"greeting\":\"Hello, Jane!\"}\n",
+			expectedContent:    "en",
+		},
+		{
+			name: "Success_Localized",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{
+						OutResponse: &entity.GreetResponse{
+							Greeting: "Bonjour, Jane !",
+							Language: "fr",
+						},
+					},
+				},
+			},
+			req:                frenchReq,
+			expectedStatusCode: 200,
+			expectedBody:       "{\"greeting\":\"Bonjour, Jane !\"}\n",
+			expectedContent:    "fr",
+			expectedLanguage:   "fr",
+		},
+		{
+			name: "Success_Stale",
+			greetingController: &MockGreetingController{
+				GreetMocks: []GreetMock{
+					{
+						OutResponse: &entity.GreetResponse{
+							Greeting: "Hello, Jane!",
+							Language: "en",
+							Stale:    true,
 						},
 					},
 				},
@@ -98,6 +180,8 @@ func TestGreetingHandler_Greet(t *testing.T) {
 			req:                httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 200,
 			expectedBody:       "{\"greeting\":\"Hello, Jane!\"}\n",
+			expectedContent:    "en",
+			expectedStale:      "true",
 		},
 	}
 
@@ -117,6 +201,12 @@ func TestGreetingHandler_Greet(t *testing.T) {
 
 			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
 			assert.Equal(t, tc.expectedBody, body)
+			assert.Equal(t, tc.expectedContent, res.Header.Get("Content-Language"))
+			assert.Equal(t, tc.expectedStale, res.Header.Get(StaleHeader))
+
+			if tc.expectedStatusCode == http.StatusOK {
+				assert.Equal(t, tc.expectedLanguage, tc.greetingController.GreetMocks[0].InRequest.Language)
+			}
 		})
 	}
 }
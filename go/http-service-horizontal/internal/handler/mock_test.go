@@ -26,3 +26,62 @@ func (m *MockGreetingController) Greet(ctx context.Context, request *entity.Gree
 	m.GreetMocks[i].InRequest = request
 	return m.GreetMocks[i].OutResponse, m.GreetMocks[i].OutError
 }
+
+type (
+	TakeMock struct {
+		InContext    context.Context
+		InClient     *entity.RateLimitClient
+		OutRateLimit *entity.RateLimit
+		OutError     error
+	}
+
+	PeekMock struct {
+		InContext    context.Context
+		InClient     *entity.RateLimitClient
+		OutRateLimit *entity.RateLimit
+		OutError     error
+	}
+
+	// MockRateLimitRepository is a mock implementation for ratelimit.Repository.
+	MockRateLimitRepository struct {
+		StringOut string
+
+		TakeIndex int
+		TakeMocks []TakeMock
+
+		PeekIndex int
+		PeekMocks []PeekMock
+	}
+)
+
+func (m *MockRateLimitRepository) String() string {
+	return m.StringOut
+}
+
+func (m *MockRateLimitRepository) Connect() error {
+	return nil
+}
+
+func (m *MockRateLimitRepository) Disconnect(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockRateLimitRepository) HealthCheck(ctx context.Context) error {
+	return nil
+}
+
+func (m *MockRateLimitRepository) Take(ctx context.Context, client *entity.RateLimitClient) (*entity.RateLimit, error) {
+	i := m.TakeIndex
+	m.TakeIndex++
+	m.TakeMocks[i].InContext = ctx
+	m.TakeMocks[i].InClient = client
+	return m.TakeMocks[i].OutRateLimit, m.TakeMocks[i].OutError
+}
+
+func (m *MockRateLimitRepository) Peek(ctx context.Context, client *entity.RateLimitClient) (*entity.RateLimit, error) {
+	i := m.PeekIndex
+	m.PeekIndex++
+	m.PeekMocks[i].InContext = ctx
+	m.PeekMocks[i].InClient = client
+	return m.PeekMocks[i].OutRateLimit, m.PeekMocks[i].OutError
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gardenbed/basil/httpx"
+	"github.com/gardenbed/basil/telemetry"
+
+	"http-service-horizontal/internal/auth"
+	"http-service-horizontal/internal/entity"
+	"http-service-horizontal/internal/problem"
+	"http-service-horizontal/internal/repository/ratelimit"
+)
+
+// rateLimitMiddleware implements the httpx.Middleware interface.
+type rateLimitMiddleware struct {
+	ratelimitRepository ratelimit.Repository
+	logger              telemetry.Logger
+}
+
+// NewRateLimitMiddleware creates a new middleware for rate limiting requests per client.
+// If the logger is nil, the logger of the global telemetry probe is used.
+func NewRateLimitMiddleware(ratelimitRepository ratelimit.Repository, logger telemetry.Logger) httpx.Middleware {
+	if logger == nil {
+		logger = telemetry.Get().Logger()
+	}
+
+	return &rateLimitMiddleware{
+		ratelimitRepository: ratelimitRepository,
+		logger:              logger,
+	}
+}
+
+// Wrap rejects requests exceeding the limit of their clients with 429 Too Many Requests.
+// Responses carry RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, and RateLimit-Policy headers,
+// and rejected responses carry a Retry-After header too.
+// If the rate limit repository is unavailable, requests are allowed.
+func (m *rateLimitMiddleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rateLimit, err := m.ratelimitRepository.Take(r.Context(), rateLimitClient(r))
+		if err != nil {
+			m.logger.Warn("rate limiting failed, allowing request", "error", err)
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(rateLimit.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(rateLimit.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(rateLimit.Reset)))
+		h.Set("RateLimit-Policy", strconv.Itoa(rateLimit.Limit)+";w="+strconv.Itoa(seconds(rateLimit.Period)))
+
+		if !rateLimit.Allowed {
+			h.Set("Retry-After", strconv.Itoa(seconds(rateLimit.RetryAfter)))
+			problem.Write(w, r, problem.New(problem.KindRateLimited, "rate limit exceeded"))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// authFailureMiddleware implements the httpx.Middleware interface.
+type authFailureMiddleware struct {
+	ratelimitRepository ratelimit.Repository
+	logger              telemetry.Logger
+}
+
+// NewAuthFailureMiddleware creates a new middleware for limiting the failed authentications of clients, so credentials cannot be brute forced.
+// It is used before authentication, so clients are identified by the subjects of their verified TLS certificates or their IP addresses.
+// If the logger is nil, the logger of the global telemetry probe is used.
+func NewAuthFailureMiddleware(ratelimitRepository ratelimit.Repository, logger telemetry.Logger) httpx.Middleware {
+	if logger == nil {
+		logger = telemetry.Get().Logger()
+	}
+
+	return &authFailureMiddleware{
+		ratelimitRepository: ratelimitRepository,
+		logger:              logger,
+	}
+}
+
+// Wrap rejects requests of clients exceeding their limit of failed authentications with 429 Too Many Requests and a Retry-After header.
+// Responses with 401 Unauthorized are counted as failed authentications.
+// If the rate limit repository is unavailable, requests are allowed.
+func (m *authFailureMiddleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := rateLimitClient(r)
+
+		rateLimit, err := m.ratelimitRepository.Peek(r.Context(), client)
+		if err != nil {
+			m.logger.Warn("rate limiting failed authentications failed, allowing request", "error", err)
+			next(w, r)
+			return
+		}
+
+		if !rateLimit.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(rateLimit.RetryAfter)))
+			problem.Write(w, r, problem.New(problem.KindRateLimited, "too many failed authentications"))
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w}
+		next(sw, r)
+
+		if sw.status == http.StatusUnauthorized {
+			if _, err := m.ratelimitRepository.Take(r.Context(), client); err != nil {
+				m.logger.Warn("rate limiting failed authentications failed", "error", err)
+			}
+		}
+	}
+}
+
+// statusWriter is an http.ResponseWriter recording the status code of its response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// rateLimitClient identifies the client of a request by its authenticated principal, the subject of its verified TLS certificate, or its IP address.
+// Requests are authenticated before being rate limited, so the principal is in the request context if authentication is enabled.
+// Clients are never identified by their credentials, since a client presenting a new credential for every request would get a new bucket every time.
+func rateLimitClient(r *http.Request) *entity.RateLimitClient {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return &entity.RateLimitClient{Kind: "principal", ID: p.Method + ":" + p.Subject}
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return &entity.RateLimitClient{Kind: "subject", ID: r.TLS.VerifiedChains[0][0].Subject.String()}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return &entity.RateLimitClient{Kind: "ip", ID: host}
+}
+
+// seconds rounds a duration up to whole seconds for headers.
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,318 @@
+package handler
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+
+	"http-service-horizontal/internal/auth"
+	"http-service-horizontal/internal/entity"
+	"http-service-horizontal/internal/repository/ratelimit"
+)
+
+func TestNewRateLimitMiddleware(t *testing.T) {
+	m := NewRateLimitMiddleware(&MockRateLimitRepository{}, nil)
+	assert.NotNil(t, m)
+}
+
+func TestRateLimitMiddleware_Wrap(t *testing.T) {
+	tests := []struct {
+		name                string
+		ratelimitRepository *MockRateLimitRepository
+		expectedStatusCode  int
+		expectedHeaders     map[string]string
+	}{
+		{
+			name: "TakeFails",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{OutError: errors.New("redis error")},
+				},
+			},
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+		{
+			name: "Allowed",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{
+						OutRateLimit: &entity.RateLimit{
+							Allowed:   true,
+							Limit:     60,
+							Period:    time.Minute,
+							Remaining: 59,
+							Reset:     time.Second,
+						},
+					},
+				},
+			},
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "60",
+				"RateLimit-Remaining": "59",
+				"RateLimit-Reset":     "1",
+				"RateLimit-Policy":    "60;w=60",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name: "Rejected",
+			ratelimitRepository: &MockRateLimitRepository{
+				TakeMocks: []TakeMock{
+					{
+						OutRateLimit: &entity.RateLimit{
+							Allowed:    false,
+							Limit:      60,
+							Period:     time.Minute,
+							Remaining:  0,
+							RetryAfter: 500 * time.Millisecond,
+							Reset:      time.Minute,
+						},
+					},
+				},
+			},
+			expectedStatusCode: 429,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "60",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"RateLimit-Policy":    "60;w=60",
+				"Retry-After":         "1",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewRateLimitMiddleware(tc.ratelimitRepository, nil)
+			handler := m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/v1/greet", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			res := rec.Result()
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"}, tc.ratelimitRepository.TakeMocks[0].InClient)
+			for key, val := range tc.expectedHeaders {
+				assert.Equal(t, val, res.Header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestNewAuthFailureMiddleware(t *testing.T) {
+	m := NewAuthFailureMiddleware(&MockRateLimitRepository{}, nil)
+	assert.NotNil(t, m)
+}
+
+func TestAuthFailureMiddleware_Wrap(t *testing.T) {
+	tests := []struct {
+		name                string
+		ratelimitRepository *MockRateLimitRepository
+		status              int
+		expectedStatusCode  int
+		expectedRetryAfter  string
+		expectedTakes       int
+	}{
+		{
+			name: "PeekFails",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutError: errors.New("redis error")},
+				},
+			},
+			status:             401,
+			expectedStatusCode: 401,
+			expectedRetryAfter: "",
+			expectedTakes:      0,
+		},
+		{
+			name: "Authenticated",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+			},
+			status:             200,
+			expectedStatusCode: 200,
+			expectedRetryAfter: "",
+			expectedTakes:      0,
+		},
+		{
+			name: "Unauthenticated",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+				TakeMocks: []TakeMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+			},
+			status:             401,
+			expectedStatusCode: 401,
+			expectedRetryAfter: "",
+			expectedTakes:      1,
+		},
+		{
+			name: "TakeFails",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{OutRateLimit: &entity.RateLimit{Allowed: true}},
+				},
+				TakeMocks: []TakeMock{
+					{OutError: errors.New("redis error")},
+				},
+			},
+			status:             401,
+			expectedStatusCode: 401,
+			expectedRetryAfter: "",
+			expectedTakes:      1,
+		},
+		{
+			name: "Rejected",
+			ratelimitRepository: &MockRateLimitRepository{
+				PeekMocks: []PeekMock{
+					{
+						OutRateLimit: &entity.RateLimit{
+							Allowed:    false,
+							RetryAfter: 500 * time.Millisecond,
+						},
+					},
+				},
+			},
+			status:             401,
+			expectedStatusCode: 429,
+			expectedRetryAfter: "1",
+			expectedTakes:      0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewAuthFailureMiddleware(tc.ratelimitRepository, nil)
+			handler := m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			})
+
+			req := httptest.NewRequest("POST", "/v1/greet", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+			res := rec.Result()
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedRetryAfter, res.Header.Get("Retry-After"))
+			assert.Equal(t, &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"}, tc.ratelimitRepository.PeekMocks[0].InClient)
+			assert.Equal(t, tc.expectedTakes, tc.ratelimitRepository.TakeIndex)
+		})
+	}
+}
+
+func TestAuthFailureMiddleware_Wrap_RepeatedFailures(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Now())
+
+	ratelimitRepository, err := ratelimit.NewRepository(mr.Addr(), ratelimit.Options{Limit: 2, Period: time.Minute})
+	assert.NoError(t, err)
+	defer ratelimitRepository.Disconnect(context.Background())
+
+	authenticator := auth.NewAPIKeys(map[string]string{"client": "key"})
+	m := NewAuthFailureMiddleware(ratelimitRepository, nil)
+	handler := m.Wrap(auth.NewMiddleware(authenticator, "").Wrap(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	greet := func(key string) *http.Response {
+		req := httptest.NewRequest("POST", "/v1/greet", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec.Result()
+	}
+
+	// Authenticated requests are not counted
+	for range 3 {
+		assert.Equal(t, 200, greet("key").StatusCode)
+	}
+
+	for range 2 {
+		assert.Equal(t, 401, greet("guess").StatusCode)
+	}
+
+	// Once the limit is exceeded, even valid credentials are rejected before authentication
+	res := greet("key")
+	assert.Equal(t, 429, res.StatusCode)
+	assert.Equal(t, "30", res.Header.Get("Retry-After"))
+}
+
+func TestRateLimitClient(t *testing.T) {
+	withPrincipal := httptest.NewRequest("GET", "/", nil)
+	withPrincipal.RemoteAddr = "192.0.2.1:1234"
+	withPrincipal = withPrincipal.WithContext(auth.NewContext(withPrincipal.Context(), &auth.Principal{Subject: "client", Method: auth.MethodJWT}))
+
+	// Credentials are not verified by the middleware, so they never identify a client
+	withAPIKey := httptest.NewRequest("GET", "/", nil)
+	withAPIKey.RemoteAddr = "192.0.2.1:1234"
+	withAPIKey.Header.Set("X-API-Key", "secret")
+
+	withCert := httptest.NewRequest("GET", "/", nil)
+	withCert.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{
+			{{Subject: pkix.Name{CommonName: "client"}}},
+		},
+	}
+
+	withIP := httptest.NewRequest("GET", "/", nil)
+	withIP.RemoteAddr = "192.0.2.1:1234"
+
+	tests := []struct {
+		name           string
+		req            *http.Request
+		expectedClient *entity.RateLimitClient
+	}{
+		{
+			name:           "Principal",
+			req:            withPrincipal,
+			expectedClient: &entity.RateLimitClient{Kind: "principal", ID: "jwt:client"},
+		},
+		{
+			name:           "UnverifiedAPIKey",
+			req:            withAPIKey,
+			expectedClient: &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+		},
+		{
+			name:           "Subject",
+			req:            withCert,
+			expectedClient: &entity.RateLimitClient{Kind: "subject", ID: "CN=client"},
+		},
+		{
+			name:           "IP",
+			req:            withIP,
+			expectedClient: &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedClient, rateLimitClient(tc.req))
+		})
+	}
+}
@@ -0,0 +1,4 @@
+// Package idl includes type and route definitions for HTTP endpoints.
+// The other files in this package are generated from the OpenAPI specifications
+// in the top-level `idl` directory using `make openapi`, and must not be edited.
+package idl
@@ -0,0 +1,36 @@
+package idl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"http-service-horizontal/internal/openapigen"
+)
+
+// TestGenerated fails if the generated files are stale (run make openapi to regenerate them).
+func TestGenerated(t *testing.T) {
+	specs, err := filepath.Glob("../../idl/*.yaml")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, specs)
+
+	for _, spec := range specs {
+		name := strings.TrimSuffix(filepath.Base(spec), ".yaml") + ".go"
+
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(spec)
+			assert.NoError(t, err)
+
+			expected, err := openapigen.Generate(data, "idl", "idl/"+filepath.Base(spec))
+			assert.NoError(t, err)
+
+			actual, err := os.ReadFile(name)
+			assert.NoError(t, err)
+
+			assert.Equal(t, string(expected), string(actual), "%s is stale, run make openapi to regenerate it", name)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package locale
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCatalog(t *testing.T) {
+	validDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(validDir, "it.tmpl"), []byte(`Ciao, {{.Name}}!`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(validDir, "en.tmpl"), []byte(`Hi, {{.Name}}!`), 0644))
+
+	invalidDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(invalidDir, "en.tmpl"), []byte(`Hi, {{.Name}!`), 0644))
+
+	tests := []struct {
+		name              string
+		dir               string
+		expectedLanguages []string
+		expectedError     string
+	}{
+		{
+			name:              "Embedded",
+			dir:               "",
+			expectedLanguages: []string{"de", "en", "es", "fr"},
+		},
+		{
+			name:              "WithDirectory",
+			dir:               validDir,
+			expectedLanguages: []string{"de", "en", "es", "fr", "it"},
+		},
+		{
+			name:          "InvalidTemplate",
+			dir:           invalidDir,
+			expectedError: `template: en:1: bad character U+007D '}'`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewCatalog(tc.dir)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedLanguages, c.Languages())
+			} else {
+				assert.Nil(t, c)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestCatalog_Match(t *testing.T) {
+	c, err := NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		preference       string
+		expectedLanguage string
+	}{
+		{"Empty", "", "en"},
+		{"Unavailable", "ja", "en"},
+		{"Exact", "fr", "fr"},
+		{"Region", "de-AT", "de"},
+		{"Locale", "es_ES.UTF-8", "es"},
+		{"AcceptLanguage", "ja, fr-CA;q=0.8, en;q=0.9", "en"},
+		{"Wildcard", "*, es;q=0.5", "es"},
+		{"ZeroQuality", "fr;q=0, de;q=0.1", "de"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedLanguage, c.Match(tc.preference))
+		})
+	}
+}
+
+func TestCatalog_Greet(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "en.tmpl"), []byte(`Hello, {{.Name}} ({{.Login}}) from {{.Company}} in {{.Location}}!`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "xx.tmpl"), []byte(`{{.Undefined}}`), 0644))
+
+	custom, err := NewCatalog(dir)
+	assert.NoError(t, err)
+
+	embedded, err := NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		c                *Catalog
+		preference       string
+		user             User
+		expectedGreeting string
+		expectedError    string
+	}{
+		{
+			name:             "Default",
+			c:                embedded,
+			preference:       "",
+			user:             User{Login: "octocat", Name: "Octocat"},
+			expectedGreeting: "Hello, Octocat!",
+		},
+		{
+			name:             "French",
+			c:                embedded,
+			preference:       "fr-FR,fr;q=0.9",
+			user:             User{Login: "octocat", Name: "Octocat"},
+			expectedGreeting: "Bonjour, Octocat !",
+		},
+		{
+			name:             "NoName",
+			c:                embedded,
+			preference:       "es",
+			user:             User{Login: "octocat"},
+			expectedGreeting: "¡Hola, octocat!",
+		},
+		{
+			name:             "FullUser",
+			c:                custom,
+			preference:       "en",
+			user:             User{Login: "octocat", Name: "Octocat", Company: "GitHub", Location: "San Francisco"},
+			expectedGreeting: "Hello, Octocat (octocat) from GitHub in San Francisco!",
+		},
+		{
+			name:          "ExecuteFails",
+			c:             custom,
+			preference:    "xx",
+			user:          User{Login: "octocat"},
+			expectedError: `template: xx:1:2: executing "xx" at <.Undefined>: can't evaluate field Undefined in type locale.User`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			greeting, err := tc.c.Greet(tc.preference, tc.user)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedGreeting, greeting)
+			} else {
+				assert.Empty(t, greeting)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
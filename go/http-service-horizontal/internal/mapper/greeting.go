@@ -8,15 +8,12 @@ import (
 )
 
 // GreetRequestIDLToDomain transforms the IDL-specific (wire or transport protocol) representation of GreetRequest to its domain-specific representation.
+// Requests are validated against the constraints declared in the OpenAPI specification before they are handled (see internal/validation).
 func GreetRequestIDLToDomain(req *idl.GreetRequest) (*entity.GreetRequest, error) {
 	if req == nil {
 		return nil, errors.New("greet request cannot be nil")
 	}
 
-	if req.GithubUsername == "" {
-		return nil, errors.New("github username cannot be empty")
-	}
-
 	return &entity.GreetRequest{
 		GithubUsername: req.GithubUsername,
 	}, nil
@@ -23,12 +23,6 @@ func TestGreetRequestIDLToDomain(t *testing.T) {
 			expectedReq:   nil,
 			expectedError: errors.New("greet request cannot be nil"),
 		},
-		{
-			name:          "EmptyGithubUsername",
-			req:           &idl.GreetRequest{},
-			expectedReq:   nil,
-			expectedError: errors.New("github username cannot be empty"),
-		},
 		{
 			name: "OK",
 			req: &idl.GreetRequest{
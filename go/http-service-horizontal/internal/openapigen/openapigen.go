@@ -0,0 +1,368 @@
+// Package openapigen generates Go code for HTTP endpoints from OpenAPI specifications.
+// It generates a type for every schema in the components of a specification,
+// and a handler interface with a function for registering its gorilla/mux routes for every tag of the operations.
+//
+// Operations are grouped into handlers by their first tag (e.g. greeting for GreetingHandler),
+// and the methods of the handlers and the names of the routes are the operationIds of the operations.
+// The paths of the routes are prefixed by the path of the first server of the specification (e.g. /v1).
+package openapigen
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go/format"
+	"go/token"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Generate generates the Go source code of a package for an OpenAPI specification.
+// The source is the path of the specification mentioned in the generated code (e.g. idl/greeting.yaml).
+func Generate(spec []byte, pkg, source string) ([]byte, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %w", err)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %w", err)
+	}
+
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name: %s", pkg)
+	}
+
+	f := &file{
+		Source:  source,
+		Package: pkg,
+	}
+
+	if f.Types, err = types(doc); err != nil {
+		return nil, err
+	}
+
+	if f.Handlers, err = handlers(doc); err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := fileTemplate.Execute(buf, f); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+type (
+	// file is the model of a generated file.
+	file struct {
+		Source   string
+		Package  string
+		Types    []*goType
+		Handlers []*handler
+	}
+
+	// goType is the model of a type generated for a schema.
+	goType struct {
+		Name   string
+		Doc    string
+		Fields []*field
+	}
+
+	// field is the model of a struct field generated for a property of a schema.
+	field struct {
+		Name string
+		Doc  string
+		Type string
+		Tag  string
+	}
+
+	// handler is the model of a handler interface generated for a tag.
+	handler struct {
+		Name       string
+		Tag        string
+		Operations []*operation
+	}
+
+	// operation is the model of a handler method and its route generated for an operation.
+	operation struct {
+		Name   string
+		Doc    string
+		Method string
+		Path   string
+	}
+)
+
+// HandlerVar returns the name of the variable for the handler function of the operation.
+func (o *operation) HandlerVar() string {
+	return unexported(o.Name) + "Handler"
+}
+
+// types returns the types for the schemas in the components of a specification sorted by name.
+func types(doc *openapi3.T) ([]*goType, error) {
+	if doc.Components == nil {
+		return nil, nil
+	}
+
+	var names []string
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var types []*goType
+	for _, name := range names {
+		schema := doc.Components.Schemas[name].Value
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return nil, fmt.Errorf("schema %s: name is not an exported identifier", name)
+		}
+
+		if !schema.Type.Is(openapi3.TypeObject) {
+			return nil, fmt.Errorf("schema %s: only object schemas are supported", name)
+		}
+
+		t := &goType{
+			Name: name,
+			Doc:  comment(name+" is", schema.Description, name+" is the "+name+" schema."),
+		}
+
+		var props []string
+		for prop := range schema.Properties {
+			props = append(props, prop)
+		}
+		sort.Strings(props)
+
+		for _, prop := range props {
+			ref := schema.Properties[prop]
+
+			typ, err := goTypeOf(ref)
+			if err != nil {
+				return nil, fmt.Errorf("schema %s: property %s: %w", name, prop, err)
+			}
+
+			tag := prop
+			if !slices.Contains(schema.Required, prop) {
+				tag += ",omitempty"
+			}
+
+			fieldName := exported(prop)
+			t.Fields = append(t.Fields, &field{
+				Name: fieldName,
+				Doc:  comment(fieldName+" is", ref.Value.Description, ""),
+				Type: typ,
+				Tag:  fmt.Sprintf("`json:%q`", tag),
+			})
+		}
+
+		types = append(types, t)
+	}
+
+	return types, nil
+}
+
+// goTypeOf returns the Go type for a schema.
+// Schemas in the components are referenced by the names of their types.
+func goTypeOf(ref *openapi3.SchemaRef) (string, error) {
+	if ref.Ref != "" {
+		name, ok := strings.CutPrefix(ref.Ref, "#/components/schemas/")
+		if !ok {
+			return "", fmt.Errorf("reference %s is not supported", ref.Ref)
+		}
+		return name, nil
+	}
+
+	schema := ref.Value
+	switch {
+	case schema.Type.Is(openapi3.TypeString):
+		return "string", nil
+	case schema.Type.Is(openapi3.TypeBoolean):
+		return "bool", nil
+	case schema.Type.Is(openapi3.TypeInteger):
+		if schema.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+	case schema.Type.Is(openapi3.TypeNumber):
+		if schema.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+	case schema.Type.Is(openapi3.TypeArray):
+		item, err := goTypeOf(schema.Items)
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+	default:
+		return "", fmt.Errorf("inline schemas of type %s are not supported", strings.Join(schema.Type.Slice(), ", "))
+	}
+}
+
+// handlers returns the handlers for the operations of a specification grouped by their first tags.
+// Handlers are sorted by tag, and operations are sorted by path and method.
+func handlers(doc *openapi3.T) ([]*handler, error) {
+	var basePath string
+	if len(doc.Servers) > 0 {
+		u, err := url.Parse(doc.Servers[0].URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server url: %w", err)
+		}
+		basePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	byTag := map[string]*handler{}
+	for _, path := range doc.Paths.InMatchingOrder() {
+		item := doc.Paths.Value(path)
+
+		var methods []string
+		for method := range item.Operations() {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+
+		for _, method := range methods {
+			op := item.GetOperation(method)
+
+			if !token.IsIdentifier(op.OperationID) || !token.IsExported(op.OperationID) {
+				return nil, fmt.Errorf("operation %s %s: operationId %q is not an exported identifier", method, path, op.OperationID)
+			}
+
+			if len(op.Tags) == 0 {
+				return nil, fmt.Errorf("operation %s: no tag", op.OperationID)
+			}
+
+			tag := op.Tags[0]
+			h, ok := byTag[tag]
+			if !ok {
+				h = &handler{
+					Name: exported(tag) + "Handler",
+					Tag:  tag,
+				}
+				byTag[tag] = h
+			}
+
+			h.Operations = append(h.Operations, &operation{
+				Name:   op.OperationID,
+				Doc:    comment(op.OperationID, op.Description, op.OperationID+" is the handler for the "+op.OperationID+" operation."),
+				Method: method,
+				Path:   basePath + path,
+			})
+		}
+	}
+
+	if len(byTag) == 0 {
+		return nil, fmt.Errorf("no operations")
+	}
+
+	var tags []string
+	for tag := range byTag {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	var handlers []*handler
+	for _, tag := range tags {
+		h := byTag[tag]
+		sort.Slice(h.Operations, func(i, j int) bool {
+			if h.Operations[i].Path != h.Operations[j].Path {
+				return h.Operations[i].Path < h.Operations[j].Path
+			}
+			return h.Operations[i].Method < h.Operations[j].Method
+		})
+		handlers = append(handlers, h)
+	}
+
+	return handlers, nil
+}
+
+// comment returns the doc comment for a subject from a description (e.g. "Greet creates a greeting." for "Creates a greeting.").
+// If the description is empty, the fallback comment is returned.
+func comment(subject, description, fallback string) string {
+	description = strings.Join(strings.Fields(description), " ")
+	if description == "" {
+		return fallback
+	}
+
+	return subject + " " + unexported(description)
+}
+
+// exported returns the exported Go identifier for a name (e.g. GithubUsername for githubUsername or github_username).
+func exported(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	return b.String()
+}
+
+// unexported lower-cases the first letter of a name unless the name starts with an acronym (e.g. HTTP).
+func unexported(name string) string {
+	r := []rune(name)
+	if len(r) > 1 && unicode.IsUpper(r[1]) {
+		return name
+	}
+
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by openapi-gen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"net/http"
+
+	"github.com/gardenbed/basil/httpx"
+	"github.com/gorilla/mux"
+)
+{{range .Types}}
+// {{.Doc}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{- if .Doc}}
+	// {{.Doc}}
+	{{- end}}
+	{{.Name}} {{.Type}} {{.Tag}}
+{{- end}}
+}
+{{end}}
+{{- range .Handlers}}
+// {{.Name}} is the interface for {{.Tag}} handler functions.
+type {{.Name}} interface {
+{{- range .Operations}}
+	// {{.Doc}}
+	{{.Name}}(http.ResponseWriter, *http.Request)
+{{- end}}
+}
+
+// Register{{.Name}} registers the HTTP routes for {{.Tag}} handler.
+// Middleware are applied from left to right (the first middleware is the most inner and the last middleware is the most outter).
+func Register{{.Name}}(router *mux.Router, handler {{.Name}}, middleware ...httpx.Middleware) {
+{{- range .Operations}}
+	{{.HandlerVar}} := handler.{{.Name}}
+{{- end}}
+
+	for _, mid := range middleware {
+	{{- range .Operations}}
+		{{.HandlerVar}} = mid.Wrap({{.HandlerVar}})
+	{{- end}}
+	}
+{{range .Operations}}
+	router.Name({{printf "%q" .Name}}).Methods({{printf "%q" .Method}}).Path({{printf "%q" .Path}}).HandlerFunc({{.HandlerVar}})
+{{- end}}
+}
+{{end}}`))
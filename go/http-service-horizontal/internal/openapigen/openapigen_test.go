@@ -0,0 +1,328 @@
+package openapigen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSpec = `
+openapi: 3.0.3
+info:
+  version: 1.0.0
+  title: Test API
+servers:
+  - url: https://api.example.com/v1/
+paths:
+  /users/{id}:
+    parameters:
+      - name: id
+        in: path
+        required: true
+        schema:
+          type: string
+    get:
+      tags:
+        - user
+      operationId: GetUser
+      description: Returns a user.
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+    delete:
+      tags:
+        - user
+      operationId: DeleteUser
+      responses:
+        '204':
+          description: No Content
+  /greet:
+    post:
+      tags:
+        - greeting
+      operationId: Greet
+      responses:
+        '200':
+          description: OK
+components:
+  schemas:
+    User:
+      description: A user.
+      type: object
+      required:
+        - id
+      properties:
+        id:
+          type: string
+          description: The unique identifier of the user.
+        age:
+          type: integer
+          format: int32
+        score:
+          type: number
+        verified:
+          type: boolean
+        tags:
+          type: array
+          items:
+            type: string
+        friends:
+          type: array
+          items:
+            $ref: '#/components/schemas/User'
+        created_at:
+          type: integer
+    Error:
+      type: object
+      properties:
+        message:
+          type: string
+`
+
+const testCode = `// Code generated by openapi-gen from idl/test.yaml. DO NOT EDIT.
+
+package idl
+
+import (
+	"net/http"
+
+	"github.com/gardenbed/basil/httpx"
+	"github.com/gorilla/mux"
+)
+
+// Error is the Error schema.
+type Error struct {
+	Message string ` + "`json:\"message,omitempty\"`" + `
+}
+
+// User is a user.
+type User struct {
+	Age       int32  ` + "`json:\"age,omitempty\"`" + `
+	CreatedAt int64  ` + "`json:\"created_at,omitempty\"`" + `
+	Friends   []User ` + "`json:\"friends,omitempty\"`" + `
+	// Id is the unique identifier of the user.
+	Id       string   ` + "`json:\"id\"`" + `
+	Score    float64  ` + "`json:\"score,omitempty\"`" + `
+	Tags     []string ` + "`json:\"tags,omitempty\"`" + `
+	Verified bool     ` + "`json:\"verified,omitempty\"`" + `
+}
+
+// GreetingHandler is the interface for greeting handler functions.
+type GreetingHandler interface {
+	// Greet is the handler for the Greet operation.
+	Greet(http.ResponseWriter, *http.Request)
+}
+
+// RegisterGreetingHandler registers the HTTP routes for greeting handler.
+// Middleware are applied from left to right (the first middleware is the most inner and the last middleware is the most outter).
+func RegisterGreetingHandler(router *mux.Router, handler GreetingHandler, middleware ...httpx.Middleware) {
+	greetHandler := handler.Greet
+
+	for _, mid := range middleware {
+		greetHandler = mid.Wrap(greetHandler)
+	}
+
+	router.Name("Greet").Methods("POST").Path("/v1/greet").HandlerFunc(greetHandler)
+}
+
+// UserHandler is the interface for user handler functions.
+type UserHandler interface {
+	// DeleteUser is the handler for the DeleteUser operation.
+	DeleteUser(http.ResponseWriter, *http.Request)
+	// GetUser returns a user.
+	GetUser(http.ResponseWriter, *http.Request)
+}
+
+// RegisterUserHandler registers the HTTP routes for user handler.
+// Middleware are applied from left to right (the first middleware is the most inner and the last middleware is the most outter).
+func RegisterUserHandler(router *mux.Router, handler UserHandler, middleware ...httpx.Middleware) {
+	deleteUserHandler := handler.DeleteUser
+	getUserHandler := handler.GetUser
+
+	for _, mid := range middleware {
+		deleteUserHandler = mid.Wrap(deleteUserHandler)
+		getUserHandler = mid.Wrap(getUserHandler)
+	}
+
+	router.Name("DeleteUser").Methods("DELETE").Path("/v1/users/{id}").HandlerFunc(deleteUserHandler)
+	router.Name("GetUser").Methods("GET").Path("/v1/users/{id}").HandlerFunc(getUserHandler)
+}
+`
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string
+		pkg           string
+		expectedCode  string
+		expectedError string
+	}{
+		{
+			name:          "InvalidYAML",
+			spec:          "openapi: [",
+			pkg:           "idl",
+			expectedError: "invalid openapi specification",
+		},
+		{
+			name: "InvalidSpec",
+			spec: `
+openapi: 3.0.3
+paths: {}
+`,
+			pkg:           "idl",
+			expectedError: "invalid openapi specification: invalid info: must be an object",
+		},
+		{
+			name:          "InvalidPackage",
+			spec:          testSpec,
+			pkg:           "my-idl",
+			expectedError: "invalid package name: my-idl",
+		},
+		{
+			name: "NoOperations",
+			spec: `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths: {}
+`,
+			pkg:           "idl",
+			expectedError: "no operations",
+		},
+		{
+			name: "NoOperationID",
+			spec: `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /test:
+    get:
+      tags:
+        - test
+      responses:
+        '200':
+          description: OK
+`,
+			pkg:           "idl",
+			expectedError: `operation GET /test: operationId "" is not an exported identifier`,
+		},
+		{
+			name: "NoTag",
+			spec: `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /test:
+    get:
+      operationId: Test
+      responses:
+        '200':
+          description: OK
+`,
+			pkg:           "idl",
+			expectedError: "operation Test: no tag",
+		},
+		{
+			name: "UnsupportedSchema",
+			spec: `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Names:
+      type: array
+      items:
+        type: string
+`,
+			pkg:           "idl",
+			expectedError: "schema Names: only object schemas are supported",
+		},
+		{
+			name: "UnsupportedProperty",
+			spec: `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        address:
+          type: object
+`,
+			pkg:           "idl",
+			expectedError: `schema User: property address: inline schemas of type object are not supported`,
+		},
+		{
+			name:         "Success",
+			spec:         testSpec,
+			pkg:          "idl",
+			expectedCode: testCode,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			code, err := Generate([]byte(tc.spec), tc.pkg, "idl/test.yaml")
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedCode, string(code))
+			} else {
+				assert.ErrorContains(t, err, tc.expectedError)
+				assert.Nil(t, code)
+			}
+		})
+	}
+}
+
+func TestExported(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"githubUsername", "GithubUsername"},
+		{"github_username", "GithubUsername"},
+		{"github-username", "GithubUsername"},
+		{"greeting", "Greeting"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, exported(tc.name))
+		})
+	}
+}
+
+func TestUnexported(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Greet", "greet"},
+		{"GetUser", "getUser"},
+		{"HTTP model", "HTTP model"},
+		{"A user.", "a user."},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, unexported(tc.name))
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package problem implements Problem Details for HTTP APIs (RFC 7807).
+// Errors are classified into a small set of kinds, each with a stable error code,
+// and written as application/problem+json responses carrying the trace ID of the request.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/gardenbed/basil/httpx"
+	"go.opentelemetry.io/otel/trace"
+
+	"http-service-horizontal/internal/breaker"
+)
+
+const (
+	// ContentType is the media type of problem details.
+	ContentType = "application/problem+json"
+
+	// TypeURIPrefix is the prefix of the URIs identifying the types of problems.
+	TypeURIPrefix = "urn:problem-type:"
+)
+
+// Kind is the kind of an error.
+type Kind string
+
+const (
+	// KindValidation is the kind of errors for invalid requests.
+	KindValidation Kind = "validation"
+	// KindRequestTooLarge is the kind of errors for requests with too large bodies.
+	KindRequestTooLarge Kind = "request-too-large"
+	// KindUnauthenticated is the kind of errors for requests without valid credentials.
+	KindUnauthenticated Kind = "unauthenticated"
+	// KindPermissionDenied is the kind of errors for requests of principals not granted the required scope.
+	KindPermissionDenied Kind = "permission-denied"
+	// KindNotFound is the kind of errors for resources that do not exist.
+	KindNotFound Kind = "not-found"
+	// KindRateLimited is the kind of errors for requests exceeding the rate limit of their clients or of an upstream service.
+	KindRateLimited Kind = "rate-limited"
+	// KindUpstreamUnavailable is the kind of errors for upstream services failing or being unavailable.
+	KindUpstreamUnavailable Kind = "upstream-unavailable"
+	// KindInternal is the kind of unexpected errors.
+	KindInternal Kind = "internal"
+)
+
+// kindInfo is the static information of a kind of errors.
+type kindInfo struct {
+	status int
+	code   string
+	title  string
+	// detail replaces the error message, so the internals of the service are not exposed to clients.
+	detail string
+}
+
+var kinds = map[Kind]kindInfo{
+	KindValidation:          {http.StatusBadRequest, "VALIDATION_FAILED", "Validation Failed", ""},
+	KindRequestTooLarge:     {http.StatusRequestEntityTooLarge, "REQUEST_TOO_LARGE", "Request Too Large", ""},
+	KindUnauthenticated:     {http.StatusUnauthorized, "UNAUTHENTICATED", "Unauthenticated", ""},
+	KindPermissionDenied:    {http.StatusForbidden, "PERMISSION_DENIED", "Permission Denied", ""},
+	KindNotFound:            {http.StatusNotFound, "NOT_FOUND", "Not Found", ""},
+	KindRateLimited:         {http.StatusTooManyRequests, "RATE_LIMITED", "Rate Limited", ""},
+	KindUpstreamUnavailable: {http.StatusServiceUnavailable, "UPSTREAM_UNAVAILABLE", "Upstream Unavailable", "an upstream service is unavailable"},
+	KindInternal:            {http.StatusInternalServerError, "INTERNAL", "Internal Server Error", "internal server error"},
+}
+
+// Status returns the HTTP status code for the kind.
+func (k Kind) Status() int {
+	return kinds[k].status
+}
+
+// Code returns the stable error code for the kind.
+func (k Kind) Code() string {
+	return kinds[k].code
+}
+
+// Violation is a field-level validation error.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error of a known kind.
+type Error struct {
+	Kind       Kind
+	Violations []Violation
+	// RetryAfter is the duration after which a rate limited request can be retried (unknown if zero).
+	RetryAfter time.Duration
+	// detail replaces the error message in problems, so the internals of upstream services are not exposed to clients.
+	detail string
+	err    error
+}
+
+// New creates a new error of a given kind.
+func New(kind Kind, message string) *Error {
+	return &Error{
+		Kind: kind,
+		err:  errors.New(message),
+	}
+}
+
+// Wrap marks an error as a given kind.
+func Wrap(kind Kind, err error) *Error {
+	return &Error{
+		Kind: kind,
+		err:  err,
+	}
+}
+
+// Validation creates a new validation error with field-level violations.
+func Validation(message string, violations ...Violation) *Error {
+	return &Error{
+		Kind:       KindValidation,
+		Violations: violations,
+		err:        errors.New(message),
+	}
+}
+
+// UpstreamRateLimited marks an error as an upstream service rejecting requests for exceeding its rate limit.
+// Requests can be retried once the rate limit resets after a duration (unknown if zero).
+func UpstreamRateLimited(err error, retryAfter time.Duration) *Error {
+	return &Error{
+		Kind:       KindRateLimited,
+		RetryAfter: retryAfter,
+		detail:     "an upstream service is rate limited",
+		err:        err,
+	}
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *Error) Unwrap() error {
+	return e.err
+}
+
+// Problem is the Problem Details representation of an error.
+type Problem struct {
+	Type       string      `json:"type"`
+	Title      string      `json:"title"`
+	Status     int         `json:"status"`
+	Code       string      `json:"code"`
+	Detail     string      `json:"detail,omitempty"`
+	Instance   string      `json:"instance,omitempty"`
+	TraceID    string      `json:"traceId,omitempty"`
+	Violations []Violation `json:"violations,omitempty"`
+	// RetryAfter is responded in the Retry-After header rather than the problem.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// FromError maps an error to a problem.
+// Errors of unknown kinds are classified by their types; upstream rate limits (429) are reported as rate limited,
+// other upstream failures are reported as unavailable upstreams, and other errors are reported as internal errors.
+func FromError(err error) *Problem {
+	e := classify(err)
+	info := kinds[e.Kind]
+
+	detail := info.detail
+	if e.detail != "" {
+		detail = e.detail
+	} else if detail == "" {
+		detail = err.Error()
+	}
+
+	return &Problem{
+		Type:       TypeURIPrefix + string(e.Kind),
+		Title:      info.title,
+		Status:     info.status,
+		Code:       info.code,
+		Detail:     detail,
+		Violations: e.Violations,
+		RetryAfter: e.RetryAfter,
+	}
+}
+
+// classify determines the kind of an error.
+func classify(err error) *Error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return Wrap(KindRequestTooLarge, err)
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		if _, ok := kinds[e.Kind]; ok {
+			return e
+		}
+	}
+
+	var clientErr *httpx.ClientError
+	if errors.As(err, &clientErr) && clientErr.StatusCode() == http.StatusTooManyRequests {
+		return UpstreamRateLimited(err, 0)
+	}
+
+	var urlErr *url.Error
+	if errors.Is(err, breaker.ErrOpen) || errors.As(err, &clientErr) || errors.As(err, &urlErr) {
+		return Wrap(KindUpstreamUnavailable, err)
+	}
+
+	return Wrap(KindInternal, err)
+}
+
+// Write maps an error to a problem and writes it as the response to a request.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	p := FromError(err)
+	p.Instance = r.URL.Path
+
+	if sc := trace.SpanContextFromContext(r.Context()); sc.HasTraceID() {
+		p.TraceID = sc.TraceID().String()
+	}
+
+	if p.RetryAfter > 0 {
+		// The duration is rounded up, so requests are not retried before the rate limit resets
+		w.Header().Set("Retry-After", strconv.Itoa(int((p.RetryAfter+time.Second-1)/time.Second)))
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,313 @@
+package problem
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gardenbed/basil/httpx"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+
+	"http-service-horizontal/internal/breaker"
+)
+
+func TestKind(t *testing.T) {
+	tests := []struct {
+		kind           Kind
+		expectedStatus int
+		expectedCode   string
+	}{
+		{KindValidation, 400, "VALIDATION_FAILED"},
+		{KindRequestTooLarge, 413, "REQUEST_TOO_LARGE"},
+		{KindUnauthenticated, 401, "UNAUTHENTICATED"},
+		{KindPermissionDenied, 403, "PERMISSION_DENIED"},
+		{KindNotFound, 404, "NOT_FOUND"},
+		{KindRateLimited, 429, "RATE_LIMITED"},
+		{KindUpstreamUnavailable, 503, "UPSTREAM_UNAVAILABLE"},
+		{KindInternal, 500, "INTERNAL"},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.kind), func(t *testing.T) {
+			assert.Equal(t, tc.expectedStatus, tc.kind.Status())
+			assert.Equal(t, tc.expectedCode, tc.kind.Code())
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	cause := errors.New("github user not found")
+
+	tests := []struct {
+		name               string
+		err                *Error
+		expectedKind       Kind
+		expectedViolations []Violation
+		expectedError      string
+	}{
+		{
+			name:          "New",
+			err:           New(KindRateLimited, "rate limit exceeded"),
+			expectedKind:  KindRateLimited,
+			expectedError: "rate limit exceeded",
+		},
+		{
+			name:          "Wrap",
+			err:           Wrap(KindNotFound, cause),
+			expectedKind:  KindNotFound,
+			expectedError: "github user not found",
+		},
+		{
+			name:               "Validation",
+			err:                Validation("invalid greet request", Violation{"githubUsername", "cannot be empty"}),
+			expectedKind:       KindValidation,
+			expectedViolations: []Violation{{"githubUsername", "cannot be empty"}},
+			expectedError:      "invalid greet request",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedKind, tc.err.Kind)
+			assert.Equal(t, tc.expectedViolations, tc.err.Violations)
+			assert.EqualError(t, tc.err, tc.expectedError)
+		})
+	}
+
+	assert.True(t, errors.Is(Wrap(KindNotFound, cause), cause))
+}
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedProblem *Problem
+	}{
+		{
+			name: "Validation",
+			err:  Validation("invalid greet request", Violation{"githubUsername", "cannot be empty"}),
+			expectedProblem: &Problem{
+				Type:       "urn:problem-type:validation",
+				Title:      "Validation Failed",
+				Status:     400,
+				Code:       "VALIDATION_FAILED",
+				Detail:     "invalid greet request",
+				Violations: []Violation{{"githubUsername", "cannot be empty"}},
+			},
+		},
+		{
+			name: "RequestTooLarge",
+			err:  fmt.Errorf("invalid request body: %w", &http.MaxBytesError{Limit: 8}),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:request-too-large",
+				Title:  "Request Too Large",
+				Status: 413,
+				Code:   "REQUEST_TOO_LARGE",
+				Detail: "invalid request body: http: request body too large",
+			},
+		},
+		{
+			name: "NotFound",
+			err:  fmt.Errorf("%w: ghost", Wrap(KindNotFound, errors.New("github user not found"))),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:not-found",
+				Title:  "Not Found",
+				Status: 404,
+				Code:   "NOT_FOUND",
+				Detail: "github user not found: ghost",
+			},
+		},
+		{
+			name: "RateLimited",
+			err:  New(KindRateLimited, "rate limit exceeded"),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:rate-limited",
+				Title:  "Rate Limited",
+				Status: 429,
+				Code:   "RATE_LIMITED",
+				Detail: "rate limit exceeded",
+			},
+		},
+		{
+			name: "Unauthenticated",
+			err:  Wrap(KindUnauthenticated, errors.New("unauthenticated: invalid api key")),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:unauthenticated",
+				Title:  "Unauthenticated",
+				Status: 401,
+				Code:   "UNAUTHENTICATED",
+				Detail: "unauthenticated: invalid api key",
+			},
+		},
+		{
+			name: "PermissionDenied",
+			err:  Wrap(KindPermissionDenied, errors.New("permission denied: missing scope greet")),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:permission-denied",
+				Title:  "Permission Denied",
+				Status: 403,
+				Code:   "PERMISSION_DENIED",
+				Detail: "permission denied: missing scope greet",
+			},
+		},
+		{
+			name: "UpstreamRateLimited",
+			err: UpstreamRateLimited(httpx.NewClientError(&http.Response{
+				StatusCode: 403,
+				Request:    httptest.NewRequest("GET", "/users/octocat", nil),
+			}), time.Minute),
+			expectedProblem: &Problem{
+				Type:       "urn:problem-type:rate-limited",
+				Title:      "Rate Limited",
+				Status:     429,
+				Code:       "RATE_LIMITED",
+				Detail:     "an upstream service is rate limited",
+				RetryAfter: time.Minute,
+			},
+		},
+		{
+			name: "UpstreamTooManyRequests",
+			err: httpx.NewClientError(&http.Response{
+				StatusCode: 429,
+				Request:    httptest.NewRequest("GET", "/users/octocat", nil),
+			}),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:rate-limited",
+				Title:  "Rate Limited",
+				Status: 429,
+				Code:   "RATE_LIMITED",
+				Detail: "an upstream service is rate limited",
+			},
+		},
+		{
+			name: "CircuitBreakerOpen",
+			err:  fmt.Errorf("degraded: %w", breaker.ErrOpen),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:upstream-unavailable",
+				Title:  "Upstream Unavailable",
+				Status: 503,
+				Code:   "UPSTREAM_UNAVAILABLE",
+				Detail: "an upstream service is unavailable",
+			},
+		},
+		{
+			name: "UpstreamClientError",
+			err: httpx.NewClientError(&http.Response{
+				StatusCode: 502,
+				Request:    httptest.NewRequest("GET", "/users/octocat", nil),
+			}),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:upstream-unavailable",
+				Title:  "Upstream Unavailable",
+				Status: 503,
+				Code:   "UPSTREAM_UNAVAILABLE",
+				Detail: "an upstream service is unavailable",
+			},
+		},
+		{
+			name: "UpstreamConnectionError",
+			err:  &url.Error{Op: "Get", URL: "https://api.github.com/users/octocat", Err: errors.New("connection refused")},
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:upstream-unavailable",
+				Title:  "Upstream Unavailable",
+				Status: 503,
+				Code:   "UPSTREAM_UNAVAILABLE",
+				Detail: "an upstream service is unavailable",
+			},
+		},
+		{
+			name: "UnknownKind",
+			err:  Wrap(Kind("unknown"), errors.New("something went wrong")),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:internal",
+				Title:  "Internal Server Error",
+				Status: 500,
+				Code:   "INTERNAL",
+				Detail: "internal server error",
+			},
+		},
+		{
+			name: "Internal",
+			err:  errors.New("something went wrong"),
+			expectedProblem: &Problem{
+				Type:   "urn:problem-type:internal",
+				Title:  "Internal Server Error",
+				Status: 500,
+				Code:   "INTERNAL",
+				Detail: "internal server error",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := FromError(tc.err)
+			assert.Equal(t, tc.expectedProblem, p)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	})
+
+	tests := []struct {
+		name               string
+		ctx                context.Context
+		err                error
+		expectedStatusCode int
+		expectedRetryAfter string
+		expectedBody       string
+	}{
+		{
+			name:               "WithoutTrace",
+			ctx:                context.Background(),
+			err:                Validation("invalid greet request", Violation{"githubUsername", "cannot be empty"}),
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid greet request","instance":"/v1/greet","violations":[{"field":"githubUsername","message":"cannot be empty"}]}`,
+		},
+		{
+			name:               "WithTrace",
+			ctx:                trace.ContextWithSpanContext(context.Background(), spanCtx),
+			err:                errors.New("something went wrong"),
+			expectedStatusCode: 500,
+			expectedBody:       `{"type":"urn:problem-type:internal","title":"Internal Server Error","status":500,"code":"INTERNAL","detail":"internal server error","instance":"/v1/greet","traceId":"4bf92f3577b34da6a3ce929d0e0e4736"}`,
+		},
+		{
+			name:               "RetryAfter",
+			ctx:                context.Background(),
+			err:                UpstreamRateLimited(errors.New("github rate limit exceeded"), 1500*time.Millisecond),
+			expectedStatusCode: 429,
+			expectedRetryAfter: "2",
+			expectedBody:       `{"type":"urn:problem-type:rate-limited","title":"Rate Limited","status":429,"code":"RATE_LIMITED","detail":"an upstream service is rate limited","instance":"/v1/greet"}`,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", "/v1/greet", nil).WithContext(tc.ctx)
+			rec := httptest.NewRecorder()
+			Write(rec, r, tc.err)
+
+			res := rec.Result()
+			body, _ := io.ReadAll(res.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, ContentType, res.Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedRetryAfter, res.Header.Get("Retry-After"))
+			assert.Equal(t, tc.expectedBody, strings.TrimSpace(string(body)))
+		})
+	}
+}
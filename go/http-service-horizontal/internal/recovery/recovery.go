@@ -0,0 +1,58 @@
+// Package recovery recovers from panics in handlers.
+// A recovered panic is logged with its stack trace, and the request fails with an internal error instead of a dropped connection.
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gardenbed/basil/telemetry"
+
+	"http-service-horizontal/internal/problem"
+)
+
+// ErrInternal is the error responded to requests when a panic is recovered.
+var ErrInternal = errors.New("internal server error")
+
+// Middleware is an HTTP middleware for recovering from panics in handlers.
+type Middleware struct {
+	logger telemetry.Logger
+}
+
+// NewMiddleware creates a new middleware logging recovered panics using a logger.
+func NewMiddleware(logger telemetry.Logger) *Middleware {
+	return &Middleware{
+		logger: logger,
+	}
+}
+
+// Wrap implements the httpx.Middleware interface.
+// It responds with a 500 status code when a handler panics.
+func (m *Middleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			val := recover()
+			if val == nil {
+				return
+			}
+
+			// http.ErrAbortHandler aborts a response deliberately and is not logged by the http server
+			if val == http.ErrAbortHandler {
+				panic(val)
+			}
+
+			m.logger.Error("recovered from panic",
+				"panic", fmt.Sprint(val),
+				"method", r.Method,
+				"path", r.URL.Path,
+				"stack", string(debug.Stack()),
+			)
+
+			problem.Write(w, r, ErrInternal)
+		}()
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,89 @@
+package recovery
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gardenbed/basil/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+// logger is a telemetry.Logger recording error logs for testing.
+type logger struct {
+	telemetry.Logger
+	messages []string
+	kvs      [][]any
+}
+
+func (l *logger) Error(message string, kv ...any) {
+	l.messages = append(l.messages, message)
+	l.kvs = append(l.kvs, kv)
+}
+
+func TestMiddleware_Wrap(t *testing.T) {
+	tests := []struct {
+		name               string
+		handler            http.HandlerFunc
+		expectedStatusCode int
+		expectedBody       string
+		expectedPanic      string
+	}{
+		{
+			name: "NoPanic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.WriteString(w, "ok")
+			},
+			expectedStatusCode: 200,
+			expectedBody:       "ok",
+			expectedPanic:      "",
+		},
+		{
+			name: "Panic",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				panic("something went wrong")
+			},
+			expectedStatusCode: 500,
+			expectedBody:       `{"type":"urn:problem-type:internal","title":"Internal Server Error","status":500,"code":"INTERNAL","detail":"internal server error","instance":"/v1/greet"}` + "\n",
+			expectedPanic:      "something went wrong",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			l := new(logger)
+			handler := NewMiddleware(l).Wrap(tc.handler)
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest("POST", "/v1/greet", nil))
+			res := rec.Result()
+			body, _ := io.ReadAll(res.Body)
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedBody, string(body))
+
+			if tc.expectedPanic == "" {
+				assert.Empty(t, l.messages)
+			} else {
+				assert.Equal(t, []string{"recovered from panic"}, l.messages)
+				kv := l.kvs[0]
+				assert.Equal(t, []any{"panic", tc.expectedPanic, "method", "POST", "path", "/v1/greet", "stack"}, kv[:len(kv)-1])
+				assert.True(t, strings.Contains(kv[len(kv)-1].(string), "recovery.TestMiddleware_Wrap"))
+			}
+		})
+	}
+}
+
+func TestMiddleware_Wrap_ErrAbortHandler(t *testing.T) {
+	l := new(logger)
+	handler := NewMiddleware(l).Wrap(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler(httptest.NewRecorder(), httptest.NewRequest("POST", "/v1/greet", nil))
+	})
+	assert.Empty(t, l.messages)
+}
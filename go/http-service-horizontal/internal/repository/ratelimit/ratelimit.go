@@ -0,0 +1,199 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gardenbed/basil/graceful"
+	"github.com/gardenbed/basil/health"
+	"github.com/redis/go-redis/v9"
+
+	"http-service-horizontal/internal/entity"
+)
+
+// Repository is the interface for interacting with the data store.
+type Repository interface {
+	graceful.Client
+	health.Checker
+	Take(ctx context.Context, client *entity.RateLimitClient) (*entity.RateLimit, error)
+	Peek(ctx context.Context, client *entity.RateLimitClient) (*entity.RateLimit, error)
+}
+
+type redisClient interface {
+	redis.Scripter
+	Close() error
+	Ping(context.Context) *redis.StatusCmd
+}
+
+const (
+	// DefaultNamespace is the default prefix for the keys of buckets.
+	DefaultNamespace = "ratelimit"
+	// DefaultLimit is the default number of requests allowed per period.
+	DefaultLimit = 60
+	// DefaultPeriod is the default period for refilling the limit of requests.
+	DefaultPeriod = time.Minute
+	// DefaultFailureLimit is the default number of failed authentications allowed per period.
+	DefaultFailureLimit = 10
+
+	// version is bumped whenever the format of buckets changes.
+	version = "v1"
+)
+
+// script takes a number of tokens from a token bucket using the clock of Redis, so limits hold across replicas.
+// It returns whether the request is allowed, the remaining tokens,
+// the milliseconds until a token is available, and the milliseconds until the bucket is full.
+// Taking no tokens checks whether a token is available without taking it.
+var script = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local period = tonumber(ARGV[3])
+local cost = tonumber(ARGV[4])
+
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+
+tokens = math.min(burst, tokens + math.max(0, now - ts) * limit / period)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	allowed = 1
+	tokens = tokens - cost
+else
+	retry = math.ceil((1 - tokens) * period / limit)
+end
+
+local reset = math.ceil((burst - tokens) * period / limit)
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+
+return { allowed, math.floor(tokens), retry, reset }
+`)
+
+// Options are optional configurations for creating a new repository.
+type Options struct {
+	// Namespace is the prefix for the keys of buckets (DefaultNamespace if empty).
+	Namespace string
+	// Limit is the number of requests allowed per period (DefaultLimit if zero).
+	Limit int
+	// Period is the period for refilling the limit of requests (DefaultPeriod if zero).
+	Period time.Duration
+	// Burst is the maximum number of requests allowed at once (Limit if zero).
+	Burst int
+}
+
+// repository implements the Repository interface.
+// Each client has a bucket holding up to a burst of tokens, refilled at a rate of limit tokens per period.
+type repository struct {
+	client    redisClient
+	namespace string
+	limit     int
+	period    time.Duration
+	burst     int
+}
+
+// NewRepository creates a new repository.
+func NewRepository(redisAddress string, opts Options) (Repository, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultLimit
+	}
+
+	if opts.Period <= 0 {
+		opts.Period = DefaultPeriod
+	}
+
+	if opts.Burst <= 0 {
+		opts.Burst = opts.Limit
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:     redisAddress,
+		Password: "", // no password set
+		DB:       0,  // use default DB
+	})
+
+	return &repository{
+		client:    client,
+		namespace: opts.Namespace,
+		limit:     opts.Limit,
+		period:    opts.Period,
+		burst:     opts.Burst,
+	}, nil
+}
+
+// String returns a name for the repository.
+func (r *repository) String() string {
+	return "ratelimit-repository"
+}
+
+// Connect opens a long-lived connection to the repository backend.
+func (r *repository) Connect() error {
+	ctx := context.Background()
+	return r.client.Ping(ctx).Err()
+}
+
+// Disconnect closes the long-lived connection to the repository backend.
+func (r *repository) Disconnect(ctx context.Context) error {
+	return r.client.Close()
+}
+
+// HealthCheck checks the health of connection to the repository backend.
+func (r *repository) HealthCheck(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+// key returns the namespaced and versioned key for the bucket of a client.
+func (r *repository) key(client *entity.RateLimitClient) string {
+	return fmt.Sprintf("%s:%s:bucket:%s:%s", r.namespace, version, client.Kind, client.ID)
+}
+
+// Take takes a token from the bucket of a client for a request.
+func (r *repository) Take(ctx context.Context, client *entity.RateLimitClient) (*entity.RateLimit, error) {
+	return r.take(ctx, client, 1)
+}
+
+// Peek checks whether a token is available in the bucket of a client without taking it.
+func (r *repository) Peek(ctx context.Context, client *entity.RateLimitClient) (*entity.RateLimit, error) {
+	return r.take(ctx, client, 0)
+}
+
+func (r *repository) take(ctx context.Context, client *entity.RateLimitClient, n int) (*entity.RateLimit, error) {
+	if client == nil || client.Kind == "" || client.ID == "" {
+		return nil, errors.New("no client")
+	}
+
+	period := max(r.period.Milliseconds(), 1)
+
+	vals, err := script.Run(ctx, r.client, []string{r.key(client)}, r.burst, r.limit, period, n).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vals) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", vals)
+	}
+
+	return &entity.RateLimit{
+		Allowed:    vals[0] == 1,
+		Limit:      r.limit,
+		Period:     r.period,
+		Remaining:  int(vals[1]),
+		RetryAfter: time.Duration(vals[2]) * time.Millisecond,
+		Reset:      time.Duration(vals[3]) * time.Millisecond,
+	}, nil
+}
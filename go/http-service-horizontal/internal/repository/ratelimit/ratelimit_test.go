@@ -0,0 +1,259 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+
+	"http-service-horizontal/internal/entity"
+)
+
+func TestNewRepository(t *testing.T) {
+	tests := []struct {
+		name          string
+		redisAddress  string
+		opts          Options
+		expectedError string
+	}{
+		{
+			name:          "OK",
+			redisAddress:  "redis:6379",
+			opts:          Options{},
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewRepository(tc.redisAddress, tc.opts)
+
+			if tc.expectedError == "" {
+				assert.NotNil(t, r)
+				assert.NoError(t, err)
+
+				repo := r.(*repository)
+				assert.Equal(t, DefaultNamespace, repo.namespace)
+				assert.Equal(t, DefaultLimit, repo.limit)
+				assert.Equal(t, DefaultPeriod, repo.period)
+				assert.Equal(t, DefaultLimit, repo.burst)
+			} else {
+				assert.Nil(t, r)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRepository_String(t *testing.T) {
+	r := new(repository)
+	assert.Equal(t, "ratelimit-repository", r.String())
+}
+
+func TestRepository_Connection(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	r, err := NewRepository(mr.Addr(), Options{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, r.Connect())
+	assert.NoError(t, r.HealthCheck(context.Background()))
+	assert.NoError(t, r.Disconnect(context.Background()))
+	assert.Error(t, r.HealthCheck(context.Background()))
+}
+
+func TestRepository_Take(t *testing.T) {
+	tests := []struct {
+		name              string
+		opts              Options
+		client            *entity.RateLimitClient
+		requests          int
+		elapsed           time.Duration
+		expectedRateLimit *entity.RateLimit
+		expectedTTL       time.Duration
+		expectedError     string
+	}{
+		{
+			name:              "NoClient",
+			opts:              Options{},
+			client:            nil,
+			expectedRateLimit: nil,
+			expectedError:     "no client",
+		},
+		{
+			name:     "FirstRequest",
+			opts:     Options{Limit: 10, Period: 10 * time.Second},
+			client:   &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+			requests: 0,
+			expectedRateLimit: &entity.RateLimit{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  9,
+				RetryAfter: 0,
+				Reset:      time.Second,
+			},
+			expectedTTL: time.Second,
+		},
+		{
+			name:     "Exhausted",
+			opts:     Options{Limit: 10, Period: 10 * time.Second, Burst: 2},
+			client:   &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+			requests: 2,
+			expectedRateLimit: &entity.RateLimit{
+				Allowed:    false,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  0,
+				RetryAfter: time.Second,
+				Reset:      2 * time.Second,
+			},
+			expectedTTL: 2 * time.Second,
+		},
+		{
+			name:     "Refilled",
+			opts:     Options{Limit: 10, Period: 10 * time.Second, Burst: 2},
+			client:   &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+			requests: 2,
+			elapsed:  1500 * time.Millisecond,
+			expectedRateLimit: &entity.RateLimit{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  0,
+				RetryAfter: 0,
+				Reset:      1500 * time.Millisecond,
+			},
+			expectedTTL: 1500 * time.Millisecond,
+		},
+		{
+			name:     "FullyRefilled",
+			opts:     Options{Limit: 10, Period: 10 * time.Second, Burst: 2},
+			client:   &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+			requests: 2,
+			elapsed:  time.Hour,
+			expectedRateLimit: &entity.RateLimit{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  1,
+				RetryAfter: 0,
+				Reset:      time.Second,
+			},
+			expectedTTL: time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			now := time.Now()
+			mr.SetTime(now)
+
+			tc.opts.Namespace = "test"
+			r, err := NewRepository(mr.Addr(), tc.opts)
+			assert.NoError(t, err)
+			defer r.Disconnect(context.Background())
+
+			ctx := context.Background()
+
+			for range tc.requests {
+				_, err := r.Take(ctx, tc.client)
+				assert.NoError(t, err)
+			}
+
+			mr.SetTime(now.Add(tc.elapsed))
+			rateLimit, err := r.Take(ctx, tc.client)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedRateLimit, rateLimit)
+				assert.Equal(t, tc.expectedTTL, mr.TTL("test:v1:bucket:ip:192.0.2.1"))
+
+				// Other clients have their own buckets
+				rateLimit, err = r.Take(ctx, &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.2"})
+				assert.NoError(t, err)
+				assert.True(t, rateLimit.Allowed)
+			} else {
+				assert.Nil(t, rateLimit)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRepository_Peek(t *testing.T) {
+	tests := []struct {
+		name              string
+		client            *entity.RateLimitClient
+		requests          int
+		expectedRateLimit *entity.RateLimit
+		expectedError     string
+	}{
+		{
+			name:              "NoClient",
+			client:            nil,
+			expectedRateLimit: nil,
+			expectedError:     "no client",
+		},
+		{
+			name:     "Available",
+			client:   &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+			requests: 1,
+			expectedRateLimit: &entity.RateLimit{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  1,
+				RetryAfter: 0,
+				Reset:      time.Second,
+			},
+		},
+		{
+			name:     "Exhausted",
+			client:   &entity.RateLimitClient{Kind: "ip", ID: "192.0.2.1"},
+			requests: 2,
+			expectedRateLimit: &entity.RateLimit{
+				Allowed:    false,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  0,
+				RetryAfter: time.Second,
+				Reset:      2 * time.Second,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			r, err := NewRepository(mr.Addr(), Options{Limit: 10, Period: 10 * time.Second, Burst: 2})
+			assert.NoError(t, err)
+			defer r.Disconnect(context.Background())
+
+			ctx := context.Background()
+
+			for range tc.requests {
+				_, err := r.Take(ctx, tc.client)
+				assert.NoError(t, err)
+			}
+
+			// Peeking does not take a token
+			for range 2 {
+				rateLimit, err := r.Peek(ctx, tc.client)
+
+				if tc.expectedError == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, tc.expectedRateLimit, rateLimit)
+				} else {
+					assert.Nil(t, rateLimit)
+					assert.EqualError(t, err, tc.expectedError)
+				}
+			}
+		})
+	}
+}
@@ -2,20 +2,27 @@ package usercache
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gardenbed/basil/graceful"
 	"github.com/gardenbed/basil/health"
 	"github.com/redis/go-redis/v9"
+
+	githubentity "http-service-horizontal/internal/entity/github"
 )
 
 // Repository is the interface for interacting with the data store.
 type Repository interface {
 	graceful.Client
 	health.Checker
-	Store(ctx context.Context, username, name string) error
-	Lookup(ctx context.Context, username string) (string, error)
+	Store(ctx context.Context, username string, user *githubentity.User) error
+	StoreNotFound(ctx context.Context, username string) error
+	Lookup(ctx context.Context, username string) (*githubentity.User, error)
+	LookupStale(ctx context.Context, username string) (*githubentity.User, error)
 }
 
 type redisClient interface {
@@ -25,13 +32,61 @@ type redisClient interface {
 	Set(context.Context, string, interface{}, time.Duration) *redis.StatusCmd
 }
 
+const (
+	// DefaultNamespace is the default prefix for the keys of cached users.
+	DefaultNamespace = "usercache"
+	// DefaultTTL is the default duration for caching a user.
+	DefaultTTL = time.Hour
+	// DefaultNegativeTTL is the default duration for caching the absence of a user.
+	DefaultNegativeTTL = time.Minute
+	// DefaultStaleTTL is the default duration for keeping a stale user.
+	DefaultStaleTTL = 24 * time.Hour
+
+	// version is bumped whenever the format of cached values changes, so stale entries are never decoded.
+	version = "v1"
+	// notFoundValue is the cached value for a user that does not exist.
+	notFoundValue = "null"
+)
+
+// Options are optional configurations for creating a new repository.
+type Options struct {
+	// Namespace is the prefix for the keys of cached users (DefaultNamespace if empty).
+	Namespace string
+	// TTL is the duration for caching a user (DefaultTTL if zero).
+	TTL time.Duration
+	// NegativeTTL is the duration for caching the absence of a user (DefaultNegativeTTL if zero).
+	NegativeTTL time.Duration
+	// StaleTTL is the duration for keeping a stale user for when the source of users is unavailable (DefaultStaleTTL if zero).
+	StaleTTL time.Duration
+}
+
 // repository implements the Repository interface.
 type repository struct {
-	client redisClient
+	client      redisClient
+	namespace   string
+	ttl         time.Duration
+	negativeTTL time.Duration
+	staleTTL    time.Duration
 }
 
 // NewRepository creates a new repository.
-func NewRepository(redisAddress string) (Repository, error) {
+func NewRepository(redisAddress string, opts Options) (Repository, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+
+	if opts.TTL == 0 {
+		opts.TTL = DefaultTTL
+	}
+
+	if opts.NegativeTTL == 0 {
+		opts.NegativeTTL = DefaultNegativeTTL
+	}
+
+	if opts.StaleTTL == 0 {
+		opts.StaleTTL = DefaultStaleTTL
+	}
+
 	client := redis.NewClient(&redis.Options{
 		Addr:     redisAddress,
 		Password: "", // no password set
@@ -39,7 +94,11 @@ func NewRepository(redisAddress string) (Repository, error) {
 	})
 
 	return &repository{
-		client: client,
+		client:      client,
+		namespace:   opts.Namespace,
+		ttl:         opts.TTL,
+		negativeTTL: opts.NegativeTTL,
+		staleTTL:    opts.StaleTTL,
 	}, nil
 }
 
@@ -64,24 +123,89 @@ func (r *repository) HealthCheck(ctx context.Context) error {
 	return r.client.Ping(ctx).Err()
 }
 
-// Store saves a key-value pair in cache.
-func (r *repository) Store(ctx context.Context, username, name string) error {
+// key returns the namespaced and versioned key for a user.
+// GitHub usernames are case-insensitive, so the keys are lower-cased.
+func (r *repository) key(username string) string {
+	return fmt.Sprintf("%s:%s:user:%s", r.namespace, version, strings.ToLower(username))
+}
+
+// staleKey returns the namespaced and versioned key for a stale user.
+func (r *repository) staleKey(username string) string {
+	return fmt.Sprintf("%s:%s:stale:user:%s", r.namespace, version, strings.ToLower(username))
+}
+
+// Store saves a user in cache for the positive TTL.
+// The user is also kept as a stale user for the stale TTL.
+func (r *repository) Store(ctx context.Context, username string, user *githubentity.User) error {
+	if username == "" {
+		return errors.New("no username")
+	}
+
+	if user == nil {
+		return errors.New("no user")
+	}
+
+	val, err := json.Marshal(user)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.Set(ctx, r.key(username), val, r.ttl).Err(); err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, r.staleKey(username), val, r.staleTTL).Err()
+}
+
+// StoreNotFound saves the absence of a user in cache for the negative TTL.
+func (r *repository) StoreNotFound(ctx context.Context, username string) error {
 	if username == "" {
 		return errors.New("no username")
 	}
 
-	if name == "" {
-		return errors.New("no name")
+	return r.client.Set(ctx, r.key(username), notFoundValue, r.negativeTTL).Err()
+}
+
+// Lookup loads a user from cache.
+// If the absence of the user is cached, an error wrapping githubentity.ErrUserNotFound is returned.
+func (r *repository) Lookup(ctx context.Context, username string) (*githubentity.User, error) {
+	if username == "" {
+		return nil, errors.New("no username")
 	}
 
-	return r.client.Set(ctx, username, name, 0).Err()
+	val, err := r.client.Get(ctx, r.key(username)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	if val == notFoundValue {
+		return nil, fmt.Errorf("%w: %s", githubentity.ErrUserNotFound, username)
+	}
+
+	user := new(githubentity.User)
+	if err := json.Unmarshal([]byte(val), user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
 }
 
-// Lookup loads a key-value from cache.
-func (r *repository) Lookup(ctx context.Context, username string) (string, error) {
+// LookupStale loads a stale user from cache.
+// A stale user is kept for longer than a cached user for when the source of users is unavailable.
+func (r *repository) LookupStale(ctx context.Context, username string) (*githubentity.User, error) {
 	if username == "" {
-		return "", errors.New("no username")
+		return nil, errors.New("no username")
+	}
+
+	val, err := r.client.Get(ctx, r.staleKey(username)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	user := new(githubentity.User)
+	if err := json.Unmarshal([]byte(val), user); err != nil {
+		return nil, err
 	}
 
-	return r.client.Get(ctx, username).Result()
+	return user, nil
 }
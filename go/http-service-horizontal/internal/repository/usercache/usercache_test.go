@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+
+	githubentity "http-service-horizontal/internal/entity/github"
 )
 
 func TestNewRepository(t *testing.T) {
@@ -24,7 +28,7 @@ func TestNewRepository(t *testing.T) {
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			r, err := NewRepository(tc.redisAddress)
+			r, err := NewRepository(tc.redisAddress, Options{})
 
 			if tc.expectedError == "" {
 				assert.NotNil(t, r)
@@ -183,7 +187,7 @@ func TestRepository_Store(t *testing.T) {
 		client        *MockRedisClient
 		ctx           context.Context
 		username      string
-		name          string
+		user          *githubentity.User
 		expectedError string
 	}{
 		{
@@ -191,16 +195,16 @@ func TestRepository_Store(t *testing.T) {
 			client:        &MockRedisClient{},
 			ctx:           context.Background(),
 			username:      "",
-			name:          "",
+			user:          nil,
 			expectedError: "no username",
 		},
 		{
-			testname:      "NoName",
+			testname:      "NoUser",
 			client:        &MockRedisClient{},
 			ctx:           context.Background(),
 			username:      "octocat",
-			name:          "",
-			expectedError: "no name",
+			user:          nil,
+			expectedError: "no user",
 		},
 		{
 			testname: "SetFails",
@@ -211,7 +215,20 @@ func TestRepository_Store(t *testing.T) {
 			},
 			ctx:           context.Background(),
 			username:      "octocat",
-			name:          "Octocat",
+			user:          &githubentity.User{Login: "octocat", Name: "Octocat"},
+			expectedError: "redis error",
+		},
+		{
+			testname: "StaleSetFails",
+			client: &MockRedisClient{
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", errors.New("redis error"))},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "octocat",
+			user:          &githubentity.User{Login: "octocat", Name: "Octocat"},
 			expectedError: "redis error",
 		},
 		{
@@ -219,11 +236,12 @@ func TestRepository_Store(t *testing.T) {
 			client: &MockRedisClient{
 				SetMocks: []SetMock{
 					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
 				},
 			},
 			ctx:           context.Background(),
 			username:      "octocat",
-			name:          "Octocat",
+			user:          &githubentity.User{Login: "octocat", Name: "Octocat"},
 			expectedError: "",
 		},
 	}
@@ -234,7 +252,63 @@ func TestRepository_Store(t *testing.T) {
 				client: tc.client,
 			}
 
-			err := r.Store(tc.ctx, tc.username, tc.name)
+			err := r.Store(tc.ctx, tc.username, tc.user)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRepository_StoreNotFound(t *testing.T) {
+	tests := []struct {
+		testname      string
+		client        *MockRedisClient
+		ctx           context.Context
+		username      string
+		expectedError string
+	}{
+		{
+			testname:      "NoUsername",
+			client:        &MockRedisClient{},
+			ctx:           context.Background(),
+			username:      "",
+			expectedError: "no username",
+		},
+		{
+			testname: "SetFails",
+			client: &MockRedisClient{
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", errors.New("redis error"))},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "ghost",
+			expectedError: "redis error",
+		},
+		{
+			testname: "Success",
+			client: &MockRedisClient{
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "ghost",
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testname, func(t *testing.T) {
+			r := &repository{
+				client: tc.client,
+			}
+
+			err := r.StoreNotFound(tc.ctx, tc.username)
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
@@ -251,7 +325,7 @@ func TestRepository_Lookup(t *testing.T) {
 		client        *MockRedisClient
 		ctx           context.Context
 		username      string
-		expectedName  string
+		expectedUser  *githubentity.User
 		expectedError string
 	}{
 		{
@@ -259,7 +333,7 @@ func TestRepository_Lookup(t *testing.T) {
 			client:        &MockRedisClient{},
 			ctx:           context.Background(),
 			username:      "",
-			expectedName:  "",
+			expectedUser:  nil,
 			expectedError: "no username",
 		},
 		{
@@ -271,11 +345,97 @@ func TestRepository_Lookup(t *testing.T) {
 			},
 			ctx:           context.Background(),
 			username:      "octocat",
-			expectedName:  "",
+			expectedUser:  nil,
 			expectedError: "redis error",
 		},
+		{
+			testname: "NotFound",
+			client: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("null", nil)},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "ghost",
+			expectedUser:  nil,
+			expectedError: "github user not found: ghost",
+		},
+		{
+			testname: "InvalidValue",
+			client: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("Octocat", nil)},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "octocat",
+			expectedUser:  nil,
+			expectedError: "invalid character 'O' looking for beginning of value",
+		},
 		{
 			testname: "Success",
+			client: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult(`{"id":1,"login":"octocat","name":"Octocat"}`, nil)},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "octocat",
+			expectedUser:  &githubentity.User{ID: 1, Login: "octocat", Name: "Octocat"},
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.testname, func(t *testing.T) {
+			r := &repository{
+				client: tc.client,
+			}
+
+			user, err := r.Lookup(tc.ctx, tc.username)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedUser, user)
+			} else {
+				assert.Nil(t, user)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestRepository_LookupStale(t *testing.T) {
+	tests := []struct {
+		testname      string
+		client        *MockRedisClient
+		ctx           context.Context
+		username      string
+		expectedUser  *githubentity.User
+		expectedError string
+	}{
+		{
+			testname:      "NoUsername",
+			client:        &MockRedisClient{},
+			ctx:           context.Background(),
+			username:      "",
+			expectedUser:  nil,
+			expectedError: "no username",
+		},
+		{
+			testname: "GetFails",
+			client: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("", errors.New("redis error"))},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "octocat",
+			expectedUser:  nil,
+			expectedError: "redis error",
+		},
+		{
+			testname: "InvalidValue",
 			client: &MockRedisClient{
 				GetMocks: []GetMock{
 					{OutStringCmd: redis.NewStringResult("Octocat", nil)},
@@ -283,7 +443,19 @@ func TestRepository_Lookup(t *testing.T) {
 			},
 			ctx:           context.Background(),
 			username:      "octocat",
-			expectedName:  "Octocat",
+			expectedUser:  nil,
+			expectedError: "invalid character 'O' looking for beginning of value",
+		},
+		{
+			testname: "Success",
+			client: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult(`{"id":1,"login":"octocat","name":"Octocat"}`, nil)},
+				},
+			},
+			ctx:           context.Background(),
+			username:      "octocat",
+			expectedUser:  &githubentity.User{ID: 1, Login: "octocat", Name: "Octocat"},
 			expectedError: "",
 		},
 	}
@@ -294,15 +466,75 @@ func TestRepository_Lookup(t *testing.T) {
 				client: tc.client,
 			}
 
-			name, err := r.Lookup(tc.ctx, tc.username)
+			user, err := r.LookupStale(tc.ctx, tc.username)
 
 			if tc.expectedError == "" {
 				assert.NoError(t, err)
-				assert.Equal(t, tc.expectedName, name)
+				assert.Equal(t, tc.expectedUser, user)
 			} else {
-				assert.Empty(t, name)
+				assert.Nil(t, user)
 				assert.EqualError(t, err, tc.expectedError)
 			}
 		})
 	}
 }
+
+func TestRepository_Redis(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	r, err := NewRepository(mr.Addr(), Options{
+		Namespace:   "test",
+		TTL:         time.Hour,
+		NegativeTTL: time.Minute,
+		StaleTTL:    24 * time.Hour,
+	})
+	assert.NoError(t, err)
+	defer r.Disconnect(context.Background())
+
+	ctx := context.Background()
+	user := &githubentity.User{ID: 1, Login: "octocat", Name: "Octocat"}
+
+	t.Run("Miss", func(t *testing.T) {
+		u, err := r.Lookup(ctx, "octocat")
+		assert.ErrorIs(t, err, redis.Nil)
+		assert.Nil(t, u)
+	})
+
+	t.Run("Hit", func(t *testing.T) {
+		assert.NoError(t, r.Store(ctx, "OctoCat", user))
+		assert.Equal(t, time.Hour, mr.TTL("test:v1:user:octocat"))
+		assert.Equal(t, 24*time.Hour, mr.TTL("test:v1:stale:user:octocat"))
+
+		u, err := r.Lookup(ctx, "octocat")
+		assert.NoError(t, err)
+		assert.Equal(t, user, u)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		assert.NoError(t, r.StoreNotFound(ctx, "ghost"))
+		assert.Equal(t, time.Minute, mr.TTL("test:v1:user:ghost"))
+
+		u, err := r.Lookup(ctx, "ghost")
+		assert.ErrorIs(t, err, githubentity.ErrUserNotFound)
+		assert.Nil(t, u)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		mr.FastForward(time.Hour)
+
+		_, err := r.Lookup(ctx, "octocat")
+		assert.ErrorIs(t, err, redis.Nil)
+
+		_, err = r.Lookup(ctx, "ghost")
+		assert.ErrorIs(t, err, redis.Nil)
+	})
+
+	t.Run("Stale", func(t *testing.T) {
+		u, err := r.LookupStale(ctx, "octocat")
+		assert.NoError(t, err)
+		assert.Equal(t, user, u)
+
+		_, err = r.LookupStale(ctx, "ghost")
+		assert.ErrorIs(t, err, redis.Nil)
+	})
+}
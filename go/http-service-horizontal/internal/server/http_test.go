@@ -2,9 +2,14 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -28,6 +33,18 @@ func TestNewHTTP(t *testing.T) {
 			opts:            HTTPOptions{},
 			expectedError:   "",
 		},
+		{
+			name: "TLS",
+			healthHandler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}),
+			greetingHandler: &MockGreetingHandler{},
+			opts: HTTPOptions{
+				Port:      8443,
+				TLSConfig: &tls.Config{},
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
@@ -37,6 +54,7 @@ func TestNewHTTP(t *testing.T) {
 			if tc.expectedError == "" {
 				assert.NotNil(t, s)
 				assert.NoError(t, err)
+				assert.Equal(t, tc.opts.TLSConfig != nil, s.tls)
 			} else {
 				assert.Nil(t, s)
 				assert.EqualError(t, err, tc.expectedError)
@@ -45,6 +63,80 @@ func TestNewHTTP(t *testing.T) {
 	}
 }
 
+func TestNewHTTP_Limits(t *testing.T) {
+	tests := []struct {
+		name                      string
+		opts                      HTTPOptions
+		expectedReadHeaderTimeout time.Duration
+		expectedReadTimeout       time.Duration
+		expectedWriteTimeout      time.Duration
+		expectedIdleTimeout       time.Duration
+		expectedMaxHeaderBytes    int
+		expectedMaxBodyBytes      int64
+	}{
+		{
+			name:                      "Defaults",
+			opts:                      HTTPOptions{},
+			expectedReadHeaderTimeout: DefaultReadHeaderTimeout,
+			expectedReadTimeout:       DefaultReadTimeout,
+			expectedWriteTimeout:      DefaultWriteTimeout,
+			expectedIdleTimeout:       DefaultIdleTimeout,
+			expectedMaxHeaderBytes:    DefaultMaxHeaderBytes,
+			expectedMaxBodyBytes:      DefaultMaxBodyBytes,
+		},
+		{
+			name: "Custom",
+			opts: HTTPOptions{
+				ReadHeaderTimeout: time.Second,
+				ReadTimeout:       2 * time.Second,
+				WriteTimeout:      3 * time.Second,
+				IdleTimeout:       4 * time.Second,
+				MaxHeaderBytes:    1024,
+				MaxBodyBytes:      16,
+			},
+			expectedReadHeaderTimeout: time.Second,
+			expectedReadTimeout:       2 * time.Second,
+			expectedWriteTimeout:      3 * time.Second,
+			expectedIdleTimeout:       4 * time.Second,
+			expectedMaxHeaderBytes:    1024,
+			expectedMaxBodyBytes:      16,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// The health handler reads the body of requests
+			healthHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if _, err := io.ReadAll(r.Body); err != nil {
+					w.WriteHeader(http.StatusRequestEntityTooLarge)
+				}
+			})
+
+			s, err := NewHTTP(healthHandler, &MockGreetingHandler{}, tc.opts)
+			assert.NoError(t, err)
+
+			server := s.server.(*http.Server)
+			assert.Equal(t, tc.expectedReadHeaderTimeout, server.ReadHeaderTimeout)
+			assert.Equal(t, tc.expectedReadTimeout, server.ReadTimeout)
+			assert.Equal(t, tc.expectedWriteTimeout, server.WriteTimeout)
+			assert.Equal(t, tc.expectedIdleTimeout, server.IdleTimeout)
+			assert.Equal(t, tc.expectedMaxHeaderBytes, server.MaxHeaderBytes)
+
+			for _, size := range []int64{tc.expectedMaxBodyBytes, tc.expectedMaxBodyBytes + 1} {
+				req := httptest.NewRequest("GET", "/health", strings.NewReader(strings.Repeat("x", int(size))))
+				rec := httptest.NewRecorder()
+				server.Handler.ServeHTTP(rec, req)
+
+				if size <= tc.expectedMaxBodyBytes {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				} else {
+					assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+				}
+			}
+		})
+	}
+}
+
 func TestHTTP_String(t *testing.T) {
 	s := new(HTTP)
 	assert.Equal(t, "http-server", s.String())
@@ -78,6 +170,30 @@ func TestHTTP_ListenAndServe(t *testing.T) {
 			},
 			expectedError: "",
 		},
+		{
+			name: "TLS_ListenFails",
+			s: &HTTP{
+				server: &MockHTTPServer{
+					ListenAndServeTLSMocks: []ListenAndServeTLSMock{
+						{OutError: errors.New("error on listening")},
+					},
+				},
+				tls: true,
+			},
+			expectedError: "error on listening",
+		},
+		{
+			name: "TLS_ServerClosed",
+			s: &HTTP{
+				server: &MockHTTPServer{
+					ListenAndServeTLSMocks: []ListenAndServeTLSMock{
+						{OutError: http.ErrServerClosed},
+					},
+				},
+				tls: true,
+			},
+			expectedError: "",
+		},
 	}
 
 	for _, tc := range tests {
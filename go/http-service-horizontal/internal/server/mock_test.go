@@ -10,6 +10,12 @@ type (
 		OutError error
 	}
 
+	ListenAndServeTLSMock struct {
+		InCertFile string
+		InKeyFile  string
+		OutError   error
+	}
+
 	ShutdownMock struct {
 		InContext context.Context
 		OutError  error
@@ -19,6 +25,9 @@ type (
 		ListenAndServeIndex int
 		ListenAndServeMocks []ListenAndServeMock
 
+		ListenAndServeTLSIndex int
+		ListenAndServeTLSMocks []ListenAndServeTLSMock
+
 		ShutdownIndex int
 		ShutdownMocks []ShutdownMock
 	}
@@ -30,6 +39,14 @@ func (m *MockHTTPServer) ListenAndServe() error {
 	return m.ListenAndServeMocks[i].OutError
 }
 
+func (m *MockHTTPServer) ListenAndServeTLS(certFile, keyFile string) error {
+	i := m.ListenAndServeTLSIndex
+	m.ListenAndServeTLSIndex++
+	m.ListenAndServeTLSMocks[i].InCertFile = certFile
+	m.ListenAndServeTLSMocks[i].InKeyFile = keyFile
+	return m.ListenAndServeTLSMocks[i].OutError
+}
+
 func (m *MockHTTPServer) Shutdown(ctx context.Context) error {
 	i := m.ShutdownIndex
 	m.ShutdownIndex++
@@ -0,0 +1,224 @@
+// Package validation validates HTTP requests against the constraints declared in the OpenAPI specifications of the service.
+// The operation of a request is found by the name of its gorilla/mux route, which is the operationId of the operation,
+// so the constraints are declared only once in the specifications.
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/gorilla/mux"
+
+	"http-service-horizontal/internal/problem"
+)
+
+// Middleware is an HTTP middleware for validating requests.
+type Middleware struct {
+	routes  map[string]*routers.Route
+	options *openapi3filter.Options
+}
+
+// NewMiddleware creates a new middleware validating requests against an OpenAPI specification.
+// Every operation in the specification must have an operationId matching the name of its route.
+func NewMiddleware(spec []byte) (*Middleware, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %w", err)
+	}
+
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid openapi specification: %w", err)
+	}
+
+	routes := map[string]*routers.Route{}
+	for path, item := range doc.Paths.Map() {
+		for method, op := range item.Operations() {
+			if op.OperationID == "" {
+				return nil, fmt.Errorf("operation %s %s has no operationId", method, path)
+			}
+
+			routes[op.OperationID] = &routers.Route{
+				Spec:      doc,
+				Path:      path,
+				PathItem:  item,
+				Method:    method,
+				Operation: op,
+			}
+		}
+	}
+
+	return &Middleware{
+		routes: routes,
+		options: &openapi3filter.Options{
+			MultiError: true,
+			// Requests are authenticated by the auth middleware
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}, nil
+}
+
+// Wrap implements the httpx.Middleware interface.
+// It rejects invalid requests with 400 Bad Request and a violation for every invalid field.
+// Request bodies without a Content-Type header are validated as JSON.
+// Requests for routes not declared in the specification are not validated.
+func (m *Middleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next(w, r)
+			return
+		}
+
+		op, ok := m.routes[route.GetName()]
+		if !ok {
+			next(w, r)
+			return
+		}
+
+		// Bodies without a content type are decoded as JSON by the handlers, so they are validated as JSON too
+		if op.Operation.RequestBody != nil && r.Header.Get("Content-Type") == "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		if err := m.validate(r.Context(), r, op); err != nil {
+			problem.Write(w, r, err)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// validate validates a request against an operation.
+// The body of the request is restored after validation, so it can be read again by the handler.
+func (m *Middleware) validate(ctx context.Context, r *http.Request, op *routers.Route) error {
+	err := openapi3filter.ValidateRequest(ctx, &openapi3filter.RequestValidationInput{
+		Request:    r,
+		PathParams: mux.Vars(r),
+		Route:      op,
+		Options:    m.options,
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	// Failures in reading the body (e.g. too large bodies) are not validation failures
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("invalid request body: %w", maxBytesErr)
+	}
+
+	// Failures of the request as a whole (e.g. malformed bodies) are reported in the detail rather than as violations
+	var violations []problem.Violation
+	var messages []string
+	for _, e := range unwrapErrors(err) {
+		if v := violation(e); v.Field != "" {
+			violations = append(violations, v)
+		} else {
+			messages = append(messages, v.Message)
+		}
+	}
+
+	message := fmt.Sprintf("invalid %s request", op.Operation.OperationID)
+	if len(messages) > 0 {
+		message += ": " + strings.Join(messages, "; ")
+	}
+
+	return problem.Validation(message, violations...)
+}
+
+// unwrapErrors flattens the multiple errors of a failed validation.
+func unwrapErrors(err error) []error {
+	var me openapi3.MultiError
+	if errors.As(err, &me) {
+		var errs []error
+		for _, e := range me {
+			errs = append(errs, unwrapErrors(e)...)
+		}
+		return errs
+	}
+
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Err != nil {
+		if errors.As(reqErr.Err, &me) {
+			var errs []error
+			for _, e := range me {
+				errs = append(errs, unwrapErrors(&openapi3filter.RequestError{
+					Input:       reqErr.Input,
+					Parameter:   reqErr.Parameter,
+					RequestBody: reqErr.RequestBody,
+					Reason:      reqErr.Reason,
+					Err:         e,
+				})...)
+			}
+			return errs
+		}
+	}
+
+	return []error{err}
+}
+
+// violation maps an error of a failed validation to a field-level violation.
+// Fields in the body are identified by their paths (e.g. githubUsername or items[1].name) and parameters by their names.
+func violation(err error) problem.Violation {
+	var field string
+	var reqErr *openapi3filter.RequestError
+	if errors.As(err, &reqErr) && reqErr.Parameter != nil {
+		field = reqErr.Parameter.Name
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		if path := fieldPath(schemaErr.JSONPointer()); path != "" {
+			field = path
+		}
+
+		return problem.Violation{Field: field, Message: schemaErr.Reason}
+	}
+
+	if reqErr != nil {
+		return problem.Violation{Field: field, Message: requestErrorMessage(reqErr)}
+	}
+
+	return problem.Violation{Field: field, Message: err.Error()}
+}
+
+// fieldPath converts a JSON pointer to a field path.
+func fieldPath(pointer []string) string {
+	var b strings.Builder
+	for _, seg := range pointer {
+		if _, err := strconv.Atoi(seg); err == nil {
+			b.WriteString("[" + seg + "]")
+			continue
+		}
+
+		if b.Len() > 0 {
+			b.WriteString(".")
+		}
+		b.WriteString(seg)
+	}
+
+	return b.String()
+}
+
+// requestErrorMessage returns the message of a request error without the details of the request.
+func requestErrorMessage(err *openapi3filter.RequestError) string {
+	if err.Err == nil {
+		return err.Reason
+	}
+
+	if err.Reason == "" {
+		return err.Err.Error()
+	}
+
+	return err.Reason + ": " + err.Err.Error()
+}
@@ -0,0 +1,201 @@
+package validation
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"http-service-horizontal/idl"
+)
+
+func TestNewMiddleware(t *testing.T) {
+	tests := []struct {
+		name          string
+		spec          string
+		expectedError string
+	}{
+		{
+			name:          "InvalidYAML",
+			spec:          "openapi: [",
+			expectedError: "invalid openapi specification",
+		},
+		{
+			name: "InvalidSpec",
+			spec: `
+openapi: 3.0.3
+paths: {}
+`,
+			expectedError: "invalid openapi specification: invalid info: must be an object",
+		},
+		{
+			name: "NoOperationID",
+			spec: `
+openapi: 3.0.3
+info:
+  title: Test
+  version: 1.0.0
+paths:
+  /test:
+    get:
+      responses:
+        '200':
+          description: OK
+`,
+			expectedError: "operation GET /test has no operationId",
+		},
+		{
+			name:          "Success",
+			spec:          string(idl.Greeting),
+			expectedError: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := NewMiddleware([]byte(tc.spec))
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.NotNil(t, m)
+				assert.Contains(t, m.routes, "Greet")
+			} else {
+				assert.ErrorContains(t, err, tc.expectedError)
+				assert.Nil(t, m)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Wrap(t *testing.T) {
+	tests := []struct {
+		name               string
+		routeName          string
+		contentType        string
+		body               string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "Valid",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{"githubUsername":"octo-cat"}`,
+			expectedStatusCode: 200,
+			expectedBody:       `{"githubUsername":"octo-cat"}`,
+		},
+		{
+			name:               "NoContentType",
+			routeName:          "Greet",
+			contentType:        "",
+			body:               `{"githubUsername":"octo-cat"}`,
+			expectedStatusCode: 200,
+			expectedBody:       `{"githubUsername":"octo-cat"}`,
+		},
+		{
+			name:               "NoContentType_Invalid",
+			routeName:          "Greet",
+			contentType:        "",
+			body:               `{"githubUsername":1}`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request","instance":"/v1/greet","violations":[{"field":"githubUsername","message":"value must be a string"}]}` + "\n",
+		},
+		{
+			name:               "NotDeclared",
+			routeName:          "Other",
+			contentType:        "application/json",
+			body:               `{"githubUsername":""}`,
+			expectedStatusCode: 200,
+			expectedBody:       `{"githubUsername":""}`,
+		},
+		{
+			name:               "Required",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{}`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request","instance":"/v1/greet","violations":[{"field":"githubUsername","message":"property \"githubUsername\" is missing"}]}` + "\n",
+		},
+		{
+			name:               "Empty",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{"githubUsername":""}`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request","instance":"/v1/greet","violations":[{"field":"githubUsername","message":"minimum string length is 1"},{"field":"githubUsername","message":"string doesn't match the regular expression \"^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$\""}]}` + "\n",
+		},
+		{
+			name:               "TooLongAndInvalidPattern",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{"githubUsername":"` + strings.Repeat("a", 39) + `-"}`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request","instance":"/v1/greet","violations":[{"field":"githubUsername","message":"maximum string length is 39"},{"field":"githubUsername","message":"string doesn't match the regular expression \"^[A-Za-z0-9]+(-[A-Za-z0-9]+)*$\""}]}` + "\n",
+		},
+		{
+			name:               "InvalidType",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{"githubUsername":1}`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request","instance":"/v1/greet","violations":[{"field":"githubUsername","message":"value must be a string"}]}` + "\n",
+		},
+		{
+			name:               "InvalidJSON",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request: failed to decode request body: unexpected EOF","instance":"/v1/greet"}` + "\n",
+		},
+		{
+			name:               "NoBody",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               ``,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request: value is required but missing","instance":"/v1/greet"}` + "\n",
+		},
+		{
+			name:               "UnsupportedContentType",
+			routeName:          "Greet",
+			contentType:        "text/plain",
+			body:               `octocat`,
+			expectedStatusCode: 400,
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid Greet request: header Content-Type has unexpected value \"text/plain\"","instance":"/v1/greet"}` + "\n",
+		},
+		{
+			name:               "TooLarge",
+			routeName:          "Greet",
+			contentType:        "application/json",
+			body:               `{"githubUsername":"` + strings.Repeat("a", 100) + `"}`,
+			expectedStatusCode: 413,
+			expectedBody:       `{"type":"urn:problem-type:request-too-large","title":"Request Too Large","status":413,"code":"REQUEST_TOO_LARGE","detail":"invalid request body: http: request body too large","instance":"/v1/greet"}` + "\n",
+		},
+	}
+
+	m, err := NewMiddleware(idl.Greeting)
+	assert.NoError(t, err)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Name(tc.routeName).Methods("POST").Path("/v1/greet").HandlerFunc(m.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				_, _ = w.Write(b)
+			}))
+
+			req := httptest.NewRequest("POST", "/v1/greet", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+			http.MaxBytesHandler(router, 64).ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatusCode, rec.Code)
+			assert.Equal(t, tc.expectedBody, rec.Body.String())
+		})
+	}
+}
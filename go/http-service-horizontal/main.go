@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/gardenbed/basil/config"
 	"github.com/gardenbed/basil/graceful"
@@ -12,11 +15,19 @@ import (
 	"github.com/gardenbed/basil/telemetry"
 	httptelemetry "github.com/gardenbed/basil/telemetry/http"
 
+	"http-service-horizontal/idl"
+	"http-service-horizontal/internal/auth"
+	"http-service-horizontal/internal/breaker"
+	"http-service-horizontal/internal/certs"
 	"http-service-horizontal/internal/controller/greeting"
 	"http-service-horizontal/internal/gateway/github"
 	"http-service-horizontal/internal/handler"
+	"http-service-horizontal/internal/locale"
+	"http-service-horizontal/internal/recovery"
+	"http-service-horizontal/internal/repository/ratelimit"
 	"http-service-horizontal/internal/repository/usercache"
 	"http-service-horizontal/internal/server"
+	"http-service-horizontal/internal/validation"
 	"http-service-horizontal/metadata"
 )
 
@@ -31,6 +42,36 @@ var configs = struct {
 	LogLevel               string
 	OpenTelemetryCollector string
 	RedisAddress           string
+	TemplatesDir           string
+	GithubURL              string
+	CacheNamespace         string
+	CacheTTL               time.Duration
+	NegativeCacheTTL       time.Duration
+	StaleCacheTTL          time.Duration
+	BreakerFailures        int
+	BreakerOpenTimeout     time.Duration
+	BreakerSuccesses       int
+	RateLimit              int
+	RateLimitPeriod        time.Duration
+	RateLimitBurst         int
+	AuthFailureLimit       int
+	AuthFailurePeriod      time.Duration
+	APIKeys                string
+	JWKSFile               string
+	JWKSURL                string `flag:"jwks.url" env:"JWKS_URL" fileenv:"JWKS_URL_FILE"`
+	JWTIssuer              string
+	JWTAudience            string
+	AuthScope              string
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSClientCAFile        string
+	TLSReloadInterval      time.Duration
+	HTTPReadHeaderTimeout  time.Duration
+	HTTPReadTimeout        time.Duration
+	HTTPWriteTimeout       time.Duration
+	HTTPIdleTimeout        time.Duration
+	HTTPMaxHeaderBytes     int
+	HTTPMaxBodyBytes       int64
 }{
 	// Default Values
 	HTTPPort:               8080,
@@ -42,6 +83,36 @@ var configs = struct {
 	LogLevel:               "debug",
 	OpenTelemetryCollector: "localhost:55680",
 	RedisAddress:           "localhost:6379",
+	TemplatesDir:           "",
+	GithubURL:              github.DefaultBaseURL,
+	CacheNamespace:         "http-service-horizontal",
+	CacheTTL:               usercache.DefaultTTL,
+	NegativeCacheTTL:       usercache.DefaultNegativeTTL,
+	StaleCacheTTL:          usercache.DefaultStaleTTL,
+	BreakerFailures:        breaker.DefaultFailureThreshold,
+	BreakerOpenTimeout:     breaker.DefaultOpenTimeout,
+	BreakerSuccesses:       breaker.DefaultSuccessThreshold,
+	RateLimit:              ratelimit.DefaultLimit,
+	RateLimitPeriod:        ratelimit.DefaultPeriod,
+	RateLimitBurst:         0,
+	AuthFailureLimit:       ratelimit.DefaultFailureLimit,
+	AuthFailurePeriod:      ratelimit.DefaultPeriod,
+	APIKeys:                "",
+	JWKSFile:               "",
+	JWKSURL:                "",
+	JWTIssuer:              "",
+	JWTAudience:            "",
+	AuthScope:              "",
+	TLSCertFile:            "",
+	TLSKeyFile:             "",
+	TLSClientCAFile:        "",
+	TLSReloadInterval:      certs.DefaultReloadInterval,
+	HTTPReadHeaderTimeout:  server.DefaultReadHeaderTimeout,
+	HTTPReadTimeout:        server.DefaultReadTimeout,
+	HTTPWriteTimeout:       server.DefaultWriteTimeout,
+	HTTPIdleTimeout:        server.DefaultIdleTimeout,
+	HTTPMaxHeaderBytes:     server.DefaultMaxHeaderBytes,
+	HTTPMaxBodyBytes:       server.DefaultMaxBodyBytes,
 }
 
 func main() {
@@ -80,7 +151,18 @@ func main() {
 
 	// CREATE GATEWAYS
 
-	githubGateway, err := github.NewGateway()
+	githubGateway, err := github.NewGateway(github.Options{
+		BaseURL: configs.GithubURL,
+		Breaker: breaker.Options{
+			FailureThreshold: configs.BreakerFailures,
+			OpenTimeout:      configs.BreakerOpenTimeout,
+			SuccessThreshold: configs.BreakerSuccesses,
+			OnStateChange: func(from, to breaker.State) {
+				probe.Logger().Warn("github circuit breaker state changed", "from", from.String(), "to", to.String())
+			},
+		},
+		Meter: probe.Meter(),
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create github gateway", "error", err)
 		panic(err)
@@ -88,15 +170,49 @@ func main() {
 
 	// CREATE REPOSITORIES
 
-	usercacheRepository, err := usercache.NewRepository(configs.RedisAddress)
+	usercacheRepository, err := usercache.NewRepository(configs.RedisAddress, usercache.Options{
+		Namespace:   configs.CacheNamespace,
+		TTL:         configs.CacheTTL,
+		NegativeTTL: configs.NegativeCacheTTL,
+		StaleTTL:    configs.StaleCacheTTL,
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create greeting cache repository", "error", err)
 		panic(err)
 	}
 
+	ratelimitRepository, err := ratelimit.NewRepository(configs.RedisAddress, ratelimit.Options{
+		Namespace: configs.Name,
+		Limit:     configs.RateLimit,
+		Period:    configs.RateLimitPeriod,
+		Burst:     configs.RateLimitBurst,
+	})
+	if err != nil {
+		probe.Logger().Error("failed to create rate limit repository", "error", err)
+		panic(err)
+	}
+
+	authFailureRepository, err := ratelimit.NewRepository(configs.RedisAddress, ratelimit.Options{
+		Namespace: configs.Name + "-auth",
+		Limit:     configs.AuthFailureLimit,
+		Period:    configs.AuthFailurePeriod,
+	})
+	if err != nil {
+		probe.Logger().Error("failed to create auth failure repository", "error", err)
+		panic(err)
+	}
+
 	// CREATE CONTROLLERS
 
-	greetingController, err := greeting.NewController(githubGateway, usercacheRepository)
+	catalog, err := locale.NewCatalog(configs.TemplatesDir)
+	if err != nil {
+		probe.Logger().Error("failed to create greeting templates catalog", "error", err)
+		panic(err)
+	}
+
+	greetingController, err := greeting.NewController(githubGateway, usercacheRepository, catalog, greeting.Options{
+		Meter: probe.Meter(),
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create greeting controller", "error", err)
 		panic(err)
@@ -110,18 +226,56 @@ func main() {
 		panic(err)
 	}
 
+	rateLimitMiddleware := handler.NewRateLimitMiddleware(ratelimitRepository, probe.Logger())
+
+	validationMiddleware, err := validation.NewMiddleware(idl.Greeting)
+	if err != nil {
+		probe.Logger().Error("failed to create validation middleware", "error", err)
+		panic(err)
+	}
+
+	// Requests are rate limited once they are authenticated, so clients are identified by their principals
+	middleware := []httpx.Middleware{validationMiddleware, rateLimitMiddleware}
+
+	authenticator, err := newAuthenticator(ctx)
+	if err != nil {
+		probe.Logger().Error("failed to create authenticator", "error", err)
+		panic(err)
+	}
+
+	if authenticator != nil {
+		// Failed authentications are limited before authentication, so credentials cannot be brute forced
+		authFailureMiddleware := handler.NewAuthFailureMiddleware(authFailureRepository, probe.Logger())
+		middleware = append(middleware, auth.NewMiddleware(authenticator, configs.AuthScope), authFailureMiddleware)
+	} else {
+		probe.Logger().Warn("authentication is disabled because no api keys or jwks are configured")
+	}
+
+	recoveryMiddleware := recovery.NewMiddleware(probe.Logger())
+
 	// CREATE SERVERS
 
 	// Create an HTTP health handler for health checking the service by external systems
 	health.SetLogger(probe.Logger())
-	health.RegisterChecker(githubGateway, usercacheRepository)
+	health.RegisterChecker(githubGateway, usercacheRepository, ratelimitRepository, authFailureRepository)
 	healthHandler := health.HandlerFunc()
 
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		probe.Logger().Error("failed to create tls config", "error", err)
+		panic(err)
+	}
+
 	httpServer, err := server.NewHTTP(healthHandler, greetingHandler, server.HTTPOptions{
-		Port: configs.HTTPPort,
-		Middleware: []httpx.Middleware{
-			telemetryMiddleware,
-		},
+		Port:              configs.HTTPPort,
+		Middleware:        append(middleware, recoveryMiddleware, telemetryMiddleware),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: configs.HTTPReadHeaderTimeout,
+		ReadTimeout:       configs.HTTPReadTimeout,
+		WriteTimeout:      configs.HTTPWriteTimeout,
+		IdleTimeout:       configs.HTTPIdleTimeout,
+		MaxHeaderBytes:    configs.HTTPMaxHeaderBytes,
+		MaxBodyBytes:      configs.HTTPMaxBodyBytes,
 	})
 
 	if err != nil {
@@ -133,9 +287,67 @@ func main() {
 	// Gracefully, retry the lost connections
 	// Gracefully, disconnect the clients and shutdown the servers on termination signals
 	graceful.SetLogger(probe.Logger())
-	graceful.RegisterClient(githubGateway, usercacheRepository)
+	graceful.RegisterClient(githubGateway, usercacheRepository, ratelimitRepository, authFailureRepository)
 	graceful.RegisterServer(httpServer)
 	code := graceful.StartAndWait()
 
 	os.Exit(code)
 }
+
+// newAuthenticator creates an authenticator for the configured API keys and JWKS.
+// If neither API keys nor a JWKS is configured, authentication is disabled and nil is returned.
+func newAuthenticator(ctx context.Context) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	keys, err := auth.ParseAPIKeys(configs.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > 0 {
+		chain = append(chain, auth.NewAPIKeys(keys))
+	}
+
+	if configs.JWKSFile != "" || configs.JWKSURL != "" {
+		jwt, err := auth.NewJWT(ctx, auth.JWTOptions{
+			JWKSFile: configs.JWKSFile,
+			JWKSURL:  configs.JWKSURL,
+			Issuer:   configs.JWTIssuer,
+			Audience: configs.JWTAudience,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, jwt)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	return chain, nil
+}
+
+// newTLSConfig creates a TLS configuration for the configured certificate files.
+// If no certificate is configured, TLS is disabled and nil is returned.
+func newTLSConfig() (*tls.Config, error) {
+	if configs.TLSCertFile == "" && configs.TLSKeyFile == "" {
+		if configs.TLSClientCAFile != "" {
+			return nil, errors.New("tls client ca file requires tls cert file and key file")
+		}
+		return nil, nil
+	}
+
+	reloader, err := certs.NewReloader(certs.Options{
+		CertFile:       configs.TLSCertFile,
+		KeyFile:        configs.TLSKeyFile,
+		ClientCAFile:   configs.TLSClientCAFile,
+		ReloadInterval: configs.TLSReloadInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader.TLSConfig(), nil
+}
@@ -0,0 +1,92 @@
+// fake-github serves a fake GitHub REST API, so the application can be run and tested offline.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"http-service/internal/fakegithub"
+)
+
+// errorFlags is a repeatable flag for injecting errors in the form of <path-pattern>=<status-code>.
+type errorFlags []fakegithub.Option
+
+func (f *errorFlags) String() string {
+	return ""
+}
+
+func (f *errorFlags) Set(val string) error {
+	pattern, code, ok := strings.Cut(val, "=")
+	if !ok {
+		return fmt.Errorf("invalid error %q: expected <path-pattern>=<status-code>", val)
+	}
+
+	status, err := strconv.Atoi(code)
+	if err != nil {
+		return fmt.Errorf("invalid status code %q: %s", code, err)
+	}
+
+	*f = append(*f, fakegithub.WithError(pattern, status))
+
+	return nil
+}
+
+func main() {
+	var (
+		port      uint
+		fixtures  string
+		latency   time.Duration
+		rateLimit int
+		errs      errorFlags
+	)
+
+	flag.UintVar(&port, "port", 8080, "the port for serving the fake GitHub API")
+	flag.StringVar(&fixtures, "fixtures", "", "a directory of fixtures replacing the embedded fixtures")
+	flag.DurationVar(&latency, "latency", 0, "the delay added to every response")
+	flag.IntVar(&rateLimit, "rate-limit", 0, "the number of requests allowed before being rate limited (0 means unlimited)")
+	flag.Var(&errs, "error", "fail requests matching a path pattern with a status code (i.e. /users/*/repos=502)")
+	flag.Parse()
+
+	opts := append([]fakegithub.Option{
+		fakegithub.WithLatency(latency),
+		fakegithub.WithRateLimit(rateLimit),
+	}, errs...)
+
+	if fixtures != "" {
+		opts = append(opts, fakegithub.WithFixtures(fixtures))
+	}
+
+	handler, err := fakegithub.NewHandler(opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	log.Printf("fake GitHub API listening on %s", server.Addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"http-service/internal/problem"
+)
+
+// APIKeyHeader is the request header carrying a static API key.
+const APIKeyHeader = "X-API-Key"
+
+// Middleware is an httpx.Middleware for authenticating and authorizing requests.
+type Middleware struct {
+	authenticator Authenticator
+	scope         string
+}
+
+// NewMiddleware creates a new middleware authenticating requests using an authenticator.
+// Authenticated principals must be granted a scope to be authorized (all principals are authorized if empty).
+func NewMiddleware(authenticator Authenticator, scope string) *Middleware {
+	return &Middleware{
+		authenticator: authenticator,
+		scope:         scope,
+	}
+}
+
+// Wrap implements the httpx.Middleware interface.
+// Unauthenticated requests are rejected with 401 Unauthorized and unauthorized requests with 403 Forbidden as problem details.
+// The principal of an authorized request is stored in the request context.
+func (m *Middleware) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, err := m.authenticator.Authenticate(r.Context(), httpCredentials(r))
+		if errors.Is(err, ErrNoCredentials) {
+			err = fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+		}
+
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			problem.Write(w, r, problem.Wrap(problem.KindUnauthenticated, err))
+			return
+		}
+
+		if !p.HasScope(m.scope) {
+			problem.Write(w, r, problem.Wrap(problem.KindPermissionDenied, fmt.Errorf("%w: missing scope %s", ErrPermissionDenied, m.scope)))
+			return
+		}
+
+		next(w, r.WithContext(NewContext(r.Context(), p)))
+	}
+}
+
+// httpCredentials returns the credentials of a request from the X-API-Key and Authorization headers.
+func httpCredentials(r *http.Request) Credentials {
+	return Credentials{
+		APIKey:      r.Header.Get(APIKeyHeader),
+		BearerToken: bearerToken(r.Header.Get("Authorization")),
+	}
+}
+
+// bearerToken returns the token from an Authorization header value using the Bearer scheme.
+func bearerToken(authorization string) string {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/sync/singleflight"
+)
+
+// DefaultJWKSRefreshInterval is the default minimum interval between refreshing a JWKS from a URL.
+const DefaultJWKSRefreshInterval = time.Minute
+
+// DefaultJWKSTimeout is the default timeout for fetching a JWKS from a URL.
+const DefaultJWKSTimeout = 10 * time.Second
+
+// JWTOptions are configurations for creating a new JWT authenticator.
+type JWTOptions struct {
+	// JWKSFile is the path to a local file containing a JSON Web Key Set (JWKS).
+	JWKSFile string
+	// JWKSURL is the URL of a JSON Web Key Set (JWKS).
+	// Keys are refreshed from the URL when a token is signed by an unknown key.
+	JWKSURL string
+	// JWKSRefreshInterval is the minimum interval between refreshing the keys from JWKSURL (DefaultJWKSRefreshInterval if zero).
+	JWKSRefreshInterval time.Duration
+	// Issuer is the expected issuer of tokens (not verified if empty).
+	Issuer string
+	// Audience is the expected audience of tokens (not verified if empty).
+	Audience string
+	// Client is used for fetching the keys from JWKSURL (a client with DefaultJWKSTimeout if nil).
+	Client *http.Client
+}
+
+// JWT is an authenticator for JWT bearer tokens verified against a JSON Web Key Set (JWKS).
+// The subject of a principal is the sub claim, and the scopes of a principal are the scope or scp claim.
+type JWT struct {
+	opts   JWTOptions
+	parser *jwt.Parser
+	now    func() time.Time
+	group  singleflight.Group
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	attemptedAt time.Time
+}
+
+// NewJWT creates a new JWT authenticator.
+// The keys are loaded immediately, so misconfigurations are reported on startup.
+func NewJWT(ctx context.Context, opts JWTOptions) (*JWT, error) {
+	if (opts.JWKSFile == "") == (opts.JWKSURL == "") {
+		return nil, errors.New("exactly one of jwks file or jwks url is required")
+	}
+
+	if opts.JWKSRefreshInterval <= 0 {
+		opts.JWKSRefreshInterval = DefaultJWKSRefreshInterval
+	}
+
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: DefaultJWKSTimeout}
+	}
+
+	parserOpts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	}
+
+	if opts.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(opts.Issuer))
+	}
+
+	if opts.Audience != "" {
+		parserOpts = append(parserOpts, jwt.WithAudience(opts.Audience))
+	}
+
+	j := &JWT{
+		opts:   opts,
+		parser: jwt.NewParser(parserOpts...),
+		now:    time.Now,
+	}
+
+	if err := j.load(ctx); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// load loads the keys from the JWKS file or URL.
+// The lock is only held for swapping the keys, so keys known before are still served while loading.
+// The attempt is recorded before loading, so failed loads are also backed off by the refresh interval.
+func (j *JWT) load(ctx context.Context) error {
+	var data []byte
+	var err error
+
+	j.mu.Lock()
+	j.attemptedAt = j.now()
+	j.mu.Unlock()
+
+	if j.opts.JWKSFile != "" {
+		if data, err = os.ReadFile(j.opts.JWKSFile); err != nil {
+			return fmt.Errorf("failed to read jwks file: %w", err)
+		}
+	} else {
+		if data, err = j.fetch(ctx); err != nil {
+			return fmt.Errorf("failed to fetch jwks: %w", err)
+		}
+	}
+
+	keys, err := parseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("invalid jwks: %w", err)
+	}
+
+	j.mu.Lock()
+	j.keys = keys
+	j.mu.Unlock()
+
+	return nil
+}
+
+func (j *JWT) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", j.opts.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := j.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// key returns the public key for a key id.
+// If the key is unknown and the keys are loaded from a URL, the keys are refreshed at most once per refresh interval.
+// Concurrent refreshes share a single fetch, which is not canceled when one of the callers is.
+func (j *JWT) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := j.lookup(kid); ok {
+		return key, nil
+	}
+
+	if j.opts.JWKSURL != "" {
+		_, err, _ := j.group.Do(j.opts.JWKSURL, func() (any, error) {
+			if !j.stale() {
+				return nil, nil
+			}
+
+			return nil, j.load(context.WithoutCancel(ctx))
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if key, ok := j.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// lookup returns the loaded public key for a key id.
+func (j *JWT) lookup(kid string) (crypto.PublicKey, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key, ok := j.keys[kid]
+	return key, ok
+}
+
+// stale reports whether the refresh interval has passed since the keys were last attempted to be loaded.
+func (j *JWT) stale() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.now().Sub(j.attemptedAt) >= j.opts.JWKSRefreshInterval
+}
+
+// Authenticate implements the Authenticator interface.
+func (j *JWT) Authenticate(ctx context.Context, creds Credentials) (*Principal, error) {
+	if creds.BearerToken == "" {
+		return nil, ErrNoCredentials
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := j.parser.ParseWithClaims(creds.BearerToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return j.key(ctx, kid)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnauthenticated, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	return &Principal{
+		Subject: subject,
+		Method:  MethodJWT,
+		Scopes:  scopes(claims),
+	}, nil
+}
+
+// scopes returns the scopes from either a space-separated scope claim or a scp claim list.
+func scopes(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+
+	var scopes []string
+	if scp, ok := claims["scp"].([]any); ok {
+		for _, s := range scp {
+			if s, ok := s.(string); ok {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+
+	return scopes
+}
+
+// jwk is a JSON Web Key (RFC 7517).
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the public keys for signing from a JSON Web Key Set (JWKS) keyed by their key ids.
+// Keys for other uses and of unsupported types are skipped.
+func parseJWKS(data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k.Kid, err)
+		}
+
+		if key != nil {
+			keys[k.Kid] = key
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys")
+	}
+
+	return keys, nil
+}
+
+// publicKey returns the public key for a JWK, or nil if the key type is not supported.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid ed25519 key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+
+	default:
+		return nil, nil
+	}
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, errors.New("missing key parameter")
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
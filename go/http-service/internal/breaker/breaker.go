@@ -0,0 +1,282 @@
+// Package breaker implements the circuit breaker pattern for calls to unreliable dependencies.
+//
+// A circuit breaker starts closed and lets all calls through.
+// After a number of consecutive failures, it opens and fails all calls immediately without calling the dependency.
+// Once the open timeout elapses, it becomes half-open and lets one trial call through at a time.
+// After a number of consecutive successful trial calls it closes again, and on a failed trial call it opens again.
+// A circuit opened by a dependency rejecting calls for a duration (i.e. until its rate limit resets) stays open at least until then.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFailureThreshold is the default number of consecutive failures that opens a circuit.
+	DefaultFailureThreshold = 5
+	// DefaultOpenTimeout is the default duration a circuit stays open before allowing trial calls.
+	DefaultOpenTimeout = 30 * time.Second
+	// DefaultSuccessThreshold is the default number of consecutive successful trial calls that closes a half-open circuit.
+	DefaultSuccessThreshold = 1
+)
+
+// ErrOpen is returned when a call is not allowed because the circuit is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets all calls through.
+	Closed State = iota
+	// Open fails all calls immediately.
+	Open
+	// HalfOpen lets one trial call through at a time.
+	HalfOpen
+)
+
+// String implements the fmt.Stringer interface.
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// Options are optional configurations for creating a new circuit breaker.
+type Options struct {
+	// FailureThreshold is the number of consecutive failures that opens the circuit (DefaultFailureThreshold if zero).
+	FailureThreshold int
+	// OpenTimeout is the duration the circuit stays open before allowing trial calls (DefaultOpenTimeout if zero).
+	OpenTimeout time.Duration
+	// SuccessThreshold is the number of consecutive successful trial calls that closes the circuit (DefaultSuccessThreshold if zero).
+	SuccessThreshold int
+	// OnStateChange is called whenever the state of the circuit changes.
+	// It is called while the breaker is locked, so it must not call the breaker.
+	OnStateChange func(from, to State)
+}
+
+// Breaker is a circuit breaker safe for concurrent use.
+type Breaker struct {
+	mu        sync.Mutex
+	opts      Options
+	now       func() time.Time
+	state     State
+	failures  int
+	successes int
+	trial     bool
+	openUntil time.Time
+	// retryAt is the time the dependency accepts calls again, so an opening circuit stays open at least until then.
+	retryAt time.Time
+	// generation is incremented on every state change, so outcomes of calls allowed in a previous state are ignored.
+	generation uint64
+}
+
+// New creates a new circuit breaker.
+func New(opts Options) *Breaker {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = DefaultFailureThreshold
+	}
+
+	if opts.OpenTimeout <= 0 {
+		opts.OpenTimeout = DefaultOpenTimeout
+	}
+
+	if opts.SuccessThreshold <= 0 {
+		opts.SuccessThreshold = DefaultSuccessThreshold
+	}
+
+	return &Breaker{
+		opts:  opts,
+		now:   time.Now,
+		state: Closed,
+	}
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	return b.state
+}
+
+// Allow checks whether a call is allowed.
+// If the call is allowed, the returned function must be called exactly once with the outcome of the call.
+// Otherwise, ErrOpen is returned.
+func (b *Breaker) Allow() (func(failed bool), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+
+	switch b.state {
+	case Open:
+		return nil, ErrOpen
+	case HalfOpen:
+		if b.trial {
+			return nil, ErrOpen
+		}
+		b.trial = true
+	}
+
+	var once sync.Once
+	generation := b.generation
+
+	return func(failed bool) {
+		once.Do(func() {
+			b.done(generation, failed)
+		})
+	}, nil
+}
+
+// done records the outcome of an allowed call.
+func (b *Breaker) done(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case Closed:
+		if !failed {
+			b.failures = 0
+		} else if b.failures++; b.failures >= b.opts.FailureThreshold {
+			b.setState(Open)
+		}
+
+	case HalfOpen:
+		b.trial = false
+		if failed {
+			b.setState(Open)
+		} else if b.successes++; b.successes >= b.opts.SuccessThreshold {
+			b.setState(Closed)
+		}
+	}
+}
+
+// retryAfter records that the dependency rejects calls for a duration.
+func (b *Breaker) retryAfter(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if at := b.now().Add(d); at.After(b.retryAt) {
+		b.retryAt = at
+	}
+}
+
+// refresh moves an open circuit to half-open once the open timeout elapses.
+func (b *Breaker) refresh() {
+	if b.state == Open && !b.now().Before(b.openUntil) {
+		b.setState(HalfOpen)
+	}
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.trial = false
+
+	if state == Open {
+		b.openUntil = b.now().Add(b.opts.OpenTimeout)
+		if b.retryAt.After(b.openUntil) {
+			b.openUntil = b.retryAt
+		}
+		b.retryAt = time.Time{}
+	}
+
+	if b.opts.OnStateChange != nil && from != state {
+		b.opts.OnStateChange(from, state)
+	}
+}
+
+// Transport is an http.RoundTripper that sends requests through a circuit breaker.
+// Transport errors, 5xx responses, and rate-limited responses are counted as failures.
+// Requests cancelled by the caller are not counted as failures.
+// If a rate-limited response opens the circuit, it stays open at least until the rate limit resets.
+type Transport struct {
+	base    http.RoundTripper
+	breaker *Breaker
+}
+
+// NewTransport creates a new transport sending requests using a base transport through a circuit breaker.
+func NewTransport(base http.RoundTripper, breaker *Breaker) *Transport {
+	return &Transport{
+		base:    base,
+		breaker: breaker,
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	done, err := t.breaker.Allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+
+	var retryAfter time.Duration
+	var limited bool
+	if err == nil {
+		retryAfter, limited = RateLimited(resp, t.breaker.now())
+	}
+
+	switch {
+	case err != nil:
+		done(!errors.Is(err, context.Canceled))
+	case limited:
+		t.breaker.retryAfter(retryAfter)
+		done(true)
+	case resp.StatusCode >= 500:
+		done(true)
+	default:
+		done(false)
+	}
+
+	return resp, err
+}
+
+// RateLimited determines whether a response rejects a request for exceeding a rate limit.
+// Rate-limited responses are 429 Too Many Requests responses, and 403 Forbidden responses as sent by GitHub
+// with either no remaining requests (X-RateLimit-Remaining: 0) or a Retry-After header.
+// It returns the duration until requests are accepted again from the Retry-After or X-RateLimit-Reset header (zero if unknown).
+func RateLimited(resp *http.Response, now time.Time) (time.Duration, bool) {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden && (resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""):
+	default:
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		if d := time.Unix(reset, 0).Sub(now); d > 0 {
+			return d, true
+		}
+	}
+
+	return 0, true
+}
@@ -0,0 +1,378 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clock is a fake clock for moving the time of a breaker forward.
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func TestState_String(t *testing.T) {
+	tests := []struct {
+		state          State
+		expectedString string
+	}{
+		{Closed, "closed"},
+		{Open, "open"},
+		{HalfOpen, "half-open"},
+		{State(-1), "unknown"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.expectedString, func(t *testing.T) {
+			assert.Equal(t, tc.expectedString, tc.state.String())
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	b := New(Options{})
+
+	assert.NotNil(t, b)
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, DefaultFailureThreshold, b.opts.FailureThreshold)
+	assert.Equal(t, DefaultOpenTimeout, b.opts.OpenTimeout)
+	assert.Equal(t, DefaultSuccessThreshold, b.opts.SuccessThreshold)
+}
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          Options
+		outcomes      []bool
+		elapsed       time.Duration
+		expectedState State
+		expectedError error
+	}{
+		{
+			name:          "Closed_NoFailure",
+			opts:          Options{FailureThreshold: 3},
+			outcomes:      []bool{false, false, false},
+			expectedState: Closed,
+			expectedError: nil,
+		},
+		{
+			name:          "Closed_NonConsecutiveFailures",
+			opts:          Options{FailureThreshold: 3},
+			outcomes:      []bool{true, true, false, true, true},
+			expectedState: Closed,
+			expectedError: nil,
+		},
+		{
+			name:          "Open",
+			opts:          Options{FailureThreshold: 3, OpenTimeout: time.Minute},
+			outcomes:      []bool{true, true, true},
+			elapsed:       30 * time.Second,
+			expectedState: Open,
+			expectedError: ErrOpen,
+		},
+		{
+			name:          "HalfOpen",
+			opts:          Options{FailureThreshold: 3, OpenTimeout: time.Minute},
+			outcomes:      []bool{true, true, true},
+			elapsed:       time.Minute,
+			expectedState: HalfOpen,
+			expectedError: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &clock{t: time.Now()}
+			b := New(tc.opts)
+			b.now = c.now
+
+			for _, failed := range tc.outcomes {
+				done, err := b.Allow()
+				assert.NoError(t, err)
+				done(failed)
+			}
+
+			c.t = c.t.Add(tc.elapsed)
+
+			assert.Equal(t, tc.expectedState, b.State())
+
+			_, err := b.Allow()
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	var transitions []string
+
+	c := &clock{t: time.Now()}
+	b := New(Options{
+		FailureThreshold: 1,
+		OpenTimeout:      time.Minute,
+		SuccessThreshold: 2,
+		OnStateChange: func(from, to State) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	})
+	b.now = c.now
+
+	open := func() {
+		done, err := b.Allow()
+		assert.NoError(t, err)
+		done(true)
+		c.t = c.t.Add(time.Minute)
+	}
+
+	// A failed trial call opens the circuit again
+	open()
+	done, err := b.Allow()
+	assert.NoError(t, err)
+
+	// Only one trial call is allowed at a time
+	_, err = b.Allow()
+	assert.Equal(t, ErrOpen, err)
+
+	done(true)
+	done(false) // ignored
+	assert.Equal(t, Open, b.State())
+
+	// Consecutive successful trial calls close the circuit
+	c.t = c.t.Add(time.Minute)
+	for range 2 {
+		done, err := b.Allow()
+		assert.NoError(t, err)
+		done(false)
+	}
+
+	assert.Equal(t, Closed, b.State())
+	assert.Equal(t, []string{
+		"closed->open", "open->half-open", "half-open->open", "open->half-open", "half-open->closed",
+	}, transitions)
+}
+
+func TestBreaker_StaleOutcome(t *testing.T) {
+	b := New(Options{FailureThreshold: 1})
+
+	stale, err := b.Allow()
+	assert.NoError(t, err)
+
+	done, err := b.Allow()
+	assert.NoError(t, err)
+	done(true)
+	assert.Equal(t, Open, b.State())
+
+	// The outcome of a call allowed before the circuit opened is ignored
+	stale(false)
+	assert.Equal(t, Open, b.State())
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransport_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		base          roundTripperFunc
+		expectedState State
+	}{
+		{
+			name: "Success",
+			base: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 404}, nil
+			},
+			expectedState: Closed,
+		},
+		{
+			name: "Cancelled",
+			base: func(*http.Request) (*http.Response, error) {
+				return nil, context.Canceled
+			},
+			expectedState: Closed,
+		},
+		{
+			name: "TransportError",
+			base: func(*http.Request) (*http.Response, error) {
+				return nil, errors.New("connection refused")
+			},
+			expectedState: Open,
+		},
+		{
+			name: "ServerError",
+			base: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 502}, nil
+			},
+			expectedState: Open,
+		},
+		{
+			name: "TooManyRequests",
+			base: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 429}, nil
+			},
+			expectedState: Open,
+		},
+		{
+			name: "Forbidden",
+			base: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 403, Header: http.Header{"X-Ratelimit-Remaining": {"10"}}}, nil
+			},
+			expectedState: Closed,
+		},
+		{
+			name: "RateLimitExceeded",
+			base: func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 403, Header: http.Header{"X-Ratelimit-Remaining": {"0"}}}, nil
+			},
+			expectedState: Open,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			b := New(Options{FailureThreshold: 1})
+			transport := NewTransport(tc.base, b)
+
+			_, _ = transport.RoundTrip(httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, tc.expectedState, b.State())
+
+			if tc.expectedState == Open {
+				resp, err := transport.RoundTrip(httptest.NewRequest("GET", "/", nil))
+				assert.Nil(t, resp)
+				assert.Equal(t, ErrOpen, err)
+			}
+		})
+	}
+}
+
+func TestTransport_RoundTrip_RateLimitReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  func(now time.Time) http.Header
+		elapsed time.Duration
+	}{
+		{
+			name: "RetryAfter",
+			header: func(time.Time) http.Header {
+				return http.Header{"Retry-After": {"120"}}
+			},
+			elapsed: 2 * time.Minute,
+		},
+		{
+			name: "RateLimitReset",
+			header: func(now time.Time) http.Header {
+				return http.Header{
+					"X-Ratelimit-Remaining": {"0"},
+					"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(3*time.Minute).Unix(), 10)},
+				}
+			},
+			elapsed: 3 * time.Minute,
+		},
+		{
+			name: "Unknown",
+			header: func(time.Time) http.Header {
+				return nil
+			},
+			elapsed: 30 * time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &clock{t: time.Unix(1700000000, 0)}
+			b := New(Options{FailureThreshold: 1, OpenTimeout: 30 * time.Second})
+			b.now = c.now
+
+			transport := NewTransport(roundTripperFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: 429, Header: tc.header(c.t)}, nil
+			}), b)
+
+			_, _ = transport.RoundTrip(httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, Open, b.State())
+
+			// The circuit stays open until the rate limit resets, and at least for the open timeout
+			c.t = c.t.Add(tc.elapsed - time.Second)
+			assert.Equal(t, Open, b.State())
+
+			c.t = c.t.Add(time.Second)
+			assert.Equal(t, HalfOpen, b.State())
+		})
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name               string
+		resp               *http.Response
+		expectedRetryAfter time.Duration
+		expectedLimited    bool
+	}{
+		{
+			name:            "OK",
+			resp:            &http.Response{StatusCode: 200},
+			expectedLimited: false,
+		},
+		{
+			name:            "Forbidden",
+			resp:            &http.Response{StatusCode: 403, Header: http.Header{"X-Ratelimit-Remaining": {"10"}}},
+			expectedLimited: false,
+		},
+		{
+			name:            "TooManyRequests",
+			resp:            &http.Response{StatusCode: 429},
+			expectedLimited: true,
+		},
+		{
+			name:               "TooManyRequests_RetryAfter",
+			resp:               &http.Response{StatusCode: 429, Header: http.Header{"Retry-After": {"60"}}},
+			expectedRetryAfter: time.Minute,
+			expectedLimited:    true,
+		},
+		{
+			name:               "SecondaryRateLimit",
+			resp:               &http.Response{StatusCode: 403, Header: http.Header{"Retry-After": {"30"}}},
+			expectedRetryAfter: 30 * time.Second,
+			expectedLimited:    true,
+		},
+		{
+			name: "PrimaryRateLimit",
+			resp: &http.Response{StatusCode: 403, Header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(5*time.Minute).Unix(), 10)},
+			}},
+			expectedRetryAfter: 5 * time.Minute,
+			expectedLimited:    true,
+		},
+		{
+			name: "PrimaryRateLimit_Reset",
+			resp: &http.Response{StatusCode: 403, Header: http.Header{
+				"X-Ratelimit-Remaining": {"0"},
+				"X-Ratelimit-Reset":     {strconv.FormatInt(now.Add(-time.Minute).Unix(), 10)},
+			}},
+			expectedRetryAfter: 0,
+			expectedLimited:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			retryAfter, limited := RateLimited(tc.resp, now)
+
+			assert.Equal(t, tc.expectedRetryAfter, retryAfter)
+			assert.Equal(t, tc.expectedLimited, limited)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+// Package fakegithub provides a fake GitHub REST API for running tests and the application offline.
+// The fake serves users and their repositories, followers, and organizations from JSON fixture files.
+// It can also simulate latency, rate limiting, and failures.
+package fakegithub
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+	docsURL        = "https://docs.github.com/rest"
+)
+
+// Fixtures are JSON files laid out as follows:
+//
+//	users/<username>.json            the response for GET /users/<username>
+//	users/<username>/repos.json      the response for GET /users/<username>/repos
+//	users/<username>/followers.json  the response for GET /users/<username>/followers
+//	users/<username>/orgs.json       the response for GET /users/<username>/orgs
+//
+//go:embed fixtures
+var embedded embed.FS
+
+// lists are the paginated resources available for a user.
+var lists = map[string]bool{
+	"repos":     true,
+	"followers": true,
+	"orgs":      true,
+}
+
+type failure struct {
+	pattern string
+	status  int
+}
+
+// Option configures a fake GitHub API.
+type Option func(*Handler)
+
+// WithFixtures loads fixtures from a directory instead of the embedded fixtures.
+func WithFixtures(dir string) Option {
+	return func(h *Handler) {
+		h.fixtures = os.DirFS(dir)
+	}
+}
+
+// WithLatency delays every response by the given duration.
+func WithLatency(d time.Duration) Option {
+	return func(h *Handler) {
+		h.latency = d
+	}
+}
+
+// WithRateLimit allows only the given number of requests.
+// Once the limit is exceeded, requests fail the same way GitHub fails them when the primary rate limit is exceeded.
+func WithRateLimit(limit int) Option {
+	return func(h *Handler) {
+		h.rateLimit = limit
+	}
+}
+
+// WithError fails the requests with paths matching the given pattern (see path.Match) with the given status code.
+func WithError(pattern string, status int) Option {
+	return func(h *Handler) {
+		h.failures = append(h.failures, failure{pattern, status})
+	}
+}
+
+// Handler is an http.Handler implementing a fake GitHub REST API.
+type Handler struct {
+	fixtures  fs.FS
+	latency   time.Duration
+	rateLimit int
+	failures  []failure
+	requests  atomic.Int64
+	mux       *http.ServeMux
+}
+
+// NewHandler creates a new fake GitHub API handler.
+func NewHandler(opts ...Option) (*Handler, error) {
+	sub, _ := fs.Sub(embedded, "fixtures")
+
+	h := &Handler{
+		fixtures: sub,
+		mux:      http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if _, err := fs.Stat(h.fixtures, "users"); err != nil {
+		return nil, fmt.Errorf("invalid fixtures: %s", err)
+	}
+
+	h.mux.HandleFunc("GET /users/{username}", h.getUser)
+	h.mux.HandleFunc("GET /users/{username}/{list}", h.listUser)
+
+	return h, nil
+}
+
+// Requests returns the number of requests received so far.
+func (h *Handler) Requests() int {
+	return int(h.requests.Load())
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := int(h.requests.Add(1))
+
+	if h.latency > 0 {
+		select {
+		case <-time.After(h.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if h.rateLimit > 0 {
+		remaining := max(h.rateLimit-n, 0)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(h.rateLimit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		w.Header().Set("X-RateLimit-Used", strconv.Itoa(min(n, h.rateLimit)))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+
+		if n > h.rateLimit {
+			writeError(w, http.StatusForbidden, "API rate limit exceeded.")
+			return
+		}
+	}
+
+	for _, f := range h.failures {
+		if ok, _ := path.Match(f.pattern, r.URL.Path); ok {
+			writeError(w, f.status, http.StatusText(f.status))
+			return
+		}
+	}
+
+	h.mux.ServeHTTP(w, r)
+}
+
+func (h *Handler) getUser(w http.ResponseWriter, r *http.Request) {
+	b, err := h.readFixture(r.PathValue("username") + ".json")
+	if err != nil {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(b)
+}
+
+func (h *Handler) listUser(w http.ResponseWriter, r *http.Request) {
+	username, list := r.PathValue("username"), r.PathValue("list")
+
+	if _, err := h.readFixture(username + ".json"); err != nil || !lists[list] {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+
+	items := []json.RawMessage{}
+	if b, err := h.readFixture(username + "/" + list + ".json"); err == nil {
+		if err := json.Unmarshal(b, &items); err != nil {
+			writeError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	page, perPage := queryInt(r, "page", 1), min(queryInt(r, "per_page", defaultPerPage), maxPerPage)
+	last := max((len(items)+perPage-1)/perPage, 1)
+
+	start := min((page-1)*perPage, len(items))
+	end := min(start+perPage, len(items))
+
+	if link := linkHeader(r, page, perPage, last); link != "" {
+		w.Header().Set("Link", link)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(items[start:end])
+}
+
+// readFixture reads a fixture file relative to the users directory.
+// GitHub usernames are case-insensitive, so fixture file names are expected to be lower-case.
+func (h *Handler) readFixture(name string) ([]byte, error) {
+	name = "users/" + strings.ToLower(name)
+	if !fs.ValidPath(name) {
+		return nil, errors.New("invalid fixture path")
+	}
+
+	return fs.ReadFile(h.fixtures, name)
+}
+
+// queryInt returns a positive integer query parameter or the default value.
+func queryInt(r *http.Request, key string, def int) int {
+	if v, err := strconv.Atoi(r.URL.Query().Get(key)); err == nil && v > 0 {
+		return v
+	}
+	return def
+}
+
+// linkHeader creates a Link header for a paginated response.
+// See https://docs.github.com/en/rest/using-the-rest-api/using-pagination-in-the-rest-api
+func linkHeader(r *http.Request, page, perPage, last int) string {
+	pageURL := func(p int) string {
+		return fmt.Sprintf("http://%s%s?page=%d&per_page=%d", r.Host, r.URL.Path, p, perPage)
+	}
+
+	var links []string
+	if page > 1 {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(page-1)), fmt.Sprintf(`<%s>; rel="first"`, pageURL(1)))
+	}
+	if page < last {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(page+1)), fmt.Sprintf(`<%s>; rel="last"`, pageURL(last)))
+	}
+
+	return strings.Join(links, ", ")
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"message":           message,
+		"documentation_url": docsURL,
+	})
+}
+
+// Server is an in-process fake GitHub API listening on a random local port.
+type Server struct {
+	*httptest.Server
+	handler *Handler
+}
+
+// NewServer creates and starts a new fake GitHub API server.
+// The base URL of the API is available via the URL field and the server must be closed using the Close method.
+func NewServer(opts ...Option) (*Server, error) {
+	h, err := NewHandler(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		Server:  httptest.NewServer(h),
+		handler: h,
+	}, nil
+}
+
+// Requests returns the number of requests received by the server so far.
+func (s *Server) Requests() int {
+	return s.handler.Requests()
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+
+	"http-service/internal/problem"
+)
+
+// FailureLimiter limits the failed authentications of clients, so credentials cannot be brute forced.
+// It is used before authentication, so clients are identified by the subjects of their verified TLS certificates or their IP addresses.
+type FailureLimiter struct {
+	limiter *Limiter
+}
+
+// NewFailureLimiter creates a new limiter of failed authentications storing buckets using a Redis client.
+// Every failed authentication takes a token from the bucket of its client,
+// and requests are rejected before authentication when the bucket is empty.
+func NewFailureLimiter(client redis.Scripter, opts Options) *FailureLimiter {
+	if opts.Limit <= 0 {
+		opts.Limit = DefaultFailureLimit
+	}
+
+	return &FailureLimiter{
+		limiter: New(client, opts),
+	}
+}
+
+// Wrap implements the httpx.Middleware interface.
+// It rejects requests of clients exceeding their limit of failed authentications with 429 Too Many Requests and a Retry-After header.
+// Responses with 401 Unauthorized are counted as failed authentications.
+func (f *FailureLimiter) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		c := httpClient(r)
+
+		res, err := f.limiter.Peek(r.Context(), c)
+		if err != nil {
+			f.limiter.logger.Warn("rate limiting failed authentications failed, allowing request", "error", err)
+			next(w, r)
+			return
+		}
+
+		if !res.Allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+			problem.Write(w, r, problem.New(problem.KindRateLimited, "too many failed authentications"))
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w}
+		next(sw, r)
+
+		if sw.status == http.StatusUnauthorized {
+			if _, err := f.limiter.Allow(r.Context(), c); err != nil {
+				f.limiter.logger.Warn("rate limiting failed authentications failed", "error", err)
+			}
+		}
+	}
+}
+
+// statusWriter is an http.ResponseWriter recording the status code of its response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements the http.ResponseWriter interface.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write implements the http.ResponseWriter interface.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
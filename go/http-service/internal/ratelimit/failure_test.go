@@ -0,0 +1,98 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewFailureLimiter(t *testing.T) {
+	f := NewFailureLimiter(nil, Options{})
+
+	assert.NotNil(t, f)
+	assert.Equal(t, DefaultFailureLimit, f.limiter.limit)
+	assert.Equal(t, DefaultFailureLimit, f.limiter.burst)
+}
+
+func TestFailureLimiter_Wrap(t *testing.T) {
+	tests := []struct {
+		name               string
+		redisDown          bool
+		status             int
+		requests           int
+		expectedStatusCode int
+		expectedRetryAfter string
+	}{
+		{
+			name:               "Authenticated",
+			status:             200,
+			requests:           5,
+			expectedStatusCode: 200,
+			expectedRetryAfter: "",
+		},
+		{
+			name:               "Forbidden",
+			status:             403,
+			requests:           5,
+			expectedStatusCode: 403,
+			expectedRetryAfter: "",
+		},
+		{
+			name:               "Unauthenticated",
+			status:             401,
+			requests:           1,
+			expectedStatusCode: 401,
+			expectedRetryAfter: "",
+		},
+		{
+			name:               "RepeatedlyUnauthenticated",
+			status:             401,
+			requests:           2,
+			expectedStatusCode: 429,
+			expectedRetryAfter: "30",
+		},
+		{
+			name:               "RedisFails",
+			redisDown:          true,
+			status:             401,
+			requests:           5,
+			expectedStatusCode: 401,
+			expectedRetryAfter: "",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			defer redisClient.Close()
+
+			if tc.redisDown {
+				mr.Close()
+			}
+
+			f := NewFailureLimiter(redisClient, Options{Limit: 2, Period: time.Minute})
+			handler := f.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+			})
+
+			for range tc.requests {
+				handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			}
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest("GET", "/", nil))
+			res := rec.Result()
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedRetryAfter, res.Header.Get("Retry-After"))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"http-service/internal/auth"
+	"http-service/internal/problem"
+)
+
+// Wrap implements the httpx.Middleware interface.
+// It rejects requests exceeding the limit of their clients with 429 Too Many Requests.
+// Responses carry RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, and RateLimit-Policy headers,
+// and rejected responses carry a Retry-After header too.
+func (l *Limiter) Wrap(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, err := l.Allow(r.Context(), httpClient(r))
+		if err != nil {
+			l.logger.Warn("rate limiting failed, allowing request", "error", err)
+			next(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(seconds(res.Reset)))
+		h.Set("RateLimit-Policy", strconv.Itoa(res.Limit)+";w="+strconv.Itoa(seconds(res.Period)))
+
+		if !res.Allowed {
+			h.Set("Retry-After", strconv.Itoa(seconds(res.RetryAfter)))
+			problem.Write(w, r, problem.New(problem.KindRateLimited, "rate limit exceeded"))
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// httpClient identifies the client of a request by its authenticated principal, the subject of its verified TLS certificate, or its IP address.
+// Requests are authenticated before being rate limited, so the principal is in the request context if authentication is enabled.
+func httpClient(r *http.Request) Client {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return Principal(p)
+	}
+
+	if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+		return Subject(r.TLS.VerifiedChains[0][0].Subject.String())
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	return IP(host)
+}
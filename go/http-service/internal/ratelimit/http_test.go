@@ -0,0 +1,150 @@
+package ratelimit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"http-service/internal/auth"
+)
+
+func TestLimiter_Wrap(t *testing.T) {
+	tests := []struct {
+		name               string
+		redisDown          bool
+		requests           int
+		expectedStatusCode int
+		expectedHeaders    map[string]string
+	}{
+		{
+			name:               "Allowed",
+			requests:           0,
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "1",
+				"RateLimit-Reset":     "30",
+				"RateLimit-Policy":    "2;w=60",
+				"Retry-After":         "",
+			},
+		},
+		{
+			name:               "Rejected",
+			requests:           2,
+			expectedStatusCode: 429,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit":     "2",
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     "60",
+				"RateLimit-Policy":    "2;w=60",
+				"Retry-After":         "30",
+			},
+		},
+		{
+			name:               "RedisFails",
+			redisDown:          true,
+			requests:           0,
+			expectedStatusCode: 200,
+			expectedHeaders: map[string]string{
+				"RateLimit-Limit": "",
+				"Retry-After":     "",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+			defer redisClient.Close()
+
+			if tc.redisDown {
+				mr.Close()
+			}
+
+			l := New(redisClient, Options{Limit: 2, Period: time.Minute})
+			handler := l.Wrap(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			for range tc.requests {
+				handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			}
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest("GET", "/", nil))
+			res := rec.Result()
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			for key, val := range tc.expectedHeaders {
+				assert.Equal(t, val, res.Header.Get(key), key)
+			}
+		})
+	}
+}
+
+func TestHTTPClient(t *testing.T) {
+	principal := &auth.Principal{Subject: "client", Method: auth.MethodJWT}
+
+	withPrincipal := httptest.NewRequest("GET", "/", nil)
+	withPrincipal.RemoteAddr = "192.0.2.1:1234"
+	withPrincipal = withPrincipal.WithContext(auth.NewContext(withPrincipal.Context(), principal))
+
+	// Credentials are not verified by the limiter, so they never identify a client
+	withAPIKey := httptest.NewRequest("GET", "/", nil)
+	withAPIKey.RemoteAddr = "192.0.2.1:1234"
+	withAPIKey.Header.Set("X-API-Key", "secret")
+
+	withCert := httptest.NewRequest("GET", "/", nil)
+	withCert.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{
+			{{Subject: pkix.Name{CommonName: "client"}}},
+		},
+	}
+
+	withIP := httptest.NewRequest("GET", "/", nil)
+	withIP.RemoteAddr = "192.0.2.1:1234"
+
+	tests := []struct {
+		name           string
+		req            *http.Request
+		expectedClient Client
+	}{
+		{
+			name:           "Principal",
+			req:            withPrincipal,
+			expectedClient: Principal(principal),
+		},
+		{
+			name:           "UnverifiedAPIKey",
+			req:            withAPIKey,
+			expectedClient: IP("192.0.2.1"),
+		},
+		{
+			name:           "Subject",
+			req:            withCert,
+			expectedClient: Subject("CN=client"),
+		},
+		{
+			name:           "IP",
+			req:            withIP,
+			expectedClient: IP("192.0.2.1"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedClient, httpClient(tc.req))
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+
+	"http-service/internal/auth"
+)
+
+func TestNew(t *testing.T) {
+	l := New(nil, Options{})
+
+	assert.NotNil(t, l)
+	assert.Equal(t, DefaultNamespace, l.namespace)
+	assert.Equal(t, DefaultLimit, l.limit)
+	assert.Equal(t, DefaultPeriod, l.period)
+	assert.Equal(t, DefaultLimit, l.burst)
+	assert.NotNil(t, l.logger)
+}
+
+func TestClient(t *testing.T) {
+	tests := []struct {
+		name        string
+		client      Client
+		expectedKey string
+	}{
+		{
+			name:        "Principal",
+			client:      Principal(&auth.Principal{Subject: "client", Method: auth.MethodAPIKey}),
+			expectedKey: "test:v1:bucket:principal:apikey:client",
+		},
+		{
+			name:        "Subject",
+			client:      Subject("CN=client"),
+			expectedKey: "test:v1:bucket:subject:CN=client",
+		},
+		{
+			name:        "IP",
+			client:      IP("192.0.2.1"),
+			expectedKey: "test:v1:bucket:ip:192.0.2.1",
+		},
+	}
+
+	l := New(nil, Options{Namespace: "test"})
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedKey, l.key(tc.client))
+		})
+	}
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	tests := []struct {
+		name           string
+		opts           Options
+		requests       int
+		elapsed        time.Duration
+		expectedResult *Result
+		expectedTTL    time.Duration
+	}{
+		{
+			name:     "FirstRequest",
+			opts:     Options{Limit: 10, Period: 10 * time.Second},
+			requests: 0,
+			expectedResult: &Result{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  9,
+				RetryAfter: 0,
+				Reset:      time.Second,
+			},
+			expectedTTL: time.Second,
+		},
+		{
+			name:     "Exhausted",
+			opts:     Options{Limit: 10, Period: 10 * time.Second, Burst: 2},
+			requests: 2,
+			expectedResult: &Result{
+				Allowed:    false,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  0,
+				RetryAfter: time.Second,
+				Reset:      2 * time.Second,
+			},
+			expectedTTL: 2 * time.Second,
+		},
+		{
+			name:     "Refilled",
+			opts:     Options{Limit: 10, Period: 10 * time.Second, Burst: 2},
+			requests: 2,
+			elapsed:  1500 * time.Millisecond,
+			expectedResult: &Result{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  0,
+				RetryAfter: 0,
+				Reset:      1500 * time.Millisecond,
+			},
+			expectedTTL: 1500 * time.Millisecond,
+		},
+		{
+			name:     "FullyRefilled",
+			opts:     Options{Limit: 10, Period: 10 * time.Second, Burst: 2},
+			requests: 2,
+			elapsed:  time.Hour,
+			expectedResult: &Result{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  1,
+				RetryAfter: 0,
+				Reset:      time.Second,
+			},
+			expectedTTL: time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			now := time.Now()
+			mr.SetTime(now)
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			tc.opts.Namespace = "test"
+			l := New(redisClient, tc.opts)
+			ctx := context.Background()
+
+			for range tc.requests {
+				_, err := l.Allow(ctx, IP("192.0.2.1"))
+				assert.NoError(t, err)
+			}
+
+			mr.SetTime(now.Add(tc.elapsed))
+			res, err := l.Allow(ctx, IP("192.0.2.1"))
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedResult, res)
+			assert.Equal(t, tc.expectedTTL, mr.TTL("test:v1:bucket:ip:192.0.2.1"))
+
+			// Other clients have their own buckets
+			res, err = l.Allow(ctx, IP("192.0.2.2"))
+			assert.NoError(t, err)
+			assert.True(t, res.Allowed)
+		})
+	}
+}
+
+func TestLimiter_Peek(t *testing.T) {
+	tests := []struct {
+		name           string
+		requests       int
+		expectedResult *Result
+	}{
+		{
+			name:     "Available",
+			requests: 1,
+			expectedResult: &Result{
+				Allowed:    true,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  1,
+				RetryAfter: 0,
+				Reset:      time.Second,
+			},
+		},
+		{
+			name:     "Exhausted",
+			requests: 2,
+			expectedResult: &Result{
+				Allowed:    false,
+				Limit:      10,
+				Period:     10 * time.Second,
+				Remaining:  0,
+				RetryAfter: time.Second,
+				Reset:      2 * time.Second,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			mr := miniredis.RunT(t)
+			mr.SetTime(time.Now())
+
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			l := New(redisClient, Options{Limit: 10, Period: 10 * time.Second, Burst: 2})
+			ctx := context.Background()
+
+			for range tc.requests {
+				_, err := l.Allow(ctx, IP("192.0.2.1"))
+				assert.NoError(t, err)
+			}
+
+			// Peeking does not take a token
+			for range 2 {
+				res, err := l.Peek(ctx, IP("192.0.2.1"))
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedResult, res)
+			}
+		})
+	}
+}
+
+func TestLimiter_Allow_RedisFails(t *testing.T) {
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	defer redisClient.Close()
+
+	mr.Close()
+
+	l := New(redisClient, Options{})
+	res, err := l.Allow(context.Background(), IP("192.0.2.1"))
+
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
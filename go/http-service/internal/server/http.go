@@ -2,8 +2,10 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gardenbed/basil/httpx"
 	"github.com/gorilla/mux"
@@ -11,17 +13,34 @@ import (
 	"http-service/internal/service/greeting"
 )
 
-const defaultHTTPPort = 8080
+const (
+	defaultHTTPPort = 8080
+
+	// DefaultReadHeaderTimeout is the default maximum duration for reading the headers of a request.
+	DefaultReadHeaderTimeout = 5 * time.Second
+	// DefaultReadTimeout is the default maximum duration for reading an entire request.
+	DefaultReadTimeout = 15 * time.Second
+	// DefaultWriteTimeout is the default maximum duration for handling a request and writing its response.
+	DefaultWriteTimeout = 30 * time.Second
+	// DefaultIdleTimeout is the default maximum duration for waiting for the next request on a keep-alive connection.
+	DefaultIdleTimeout = 2 * time.Minute
+	// DefaultMaxHeaderBytes is the default maximum size of the headers of a request.
+	DefaultMaxHeaderBytes = 64 << 10
+	// DefaultMaxBodyBytes is the default maximum size of the body of a request.
+	DefaultMaxBodyBytes = 1 << 20
+)
 
 // httpServer is an interface for http.Server struct.
 type httpServer interface {
 	ListenAndServe() error
+	ListenAndServeTLS(certFile, keyFile string) error
 	Shutdown(ctx context.Context) error
 }
 
 // HTTP is an http server implementing the graceful.Server interface.
 type HTTP struct {
 	server httpServer
+	tls    bool
 }
 
 // HTTPOptions are optional settings for creating an http server.
@@ -29,8 +48,30 @@ type HTTPOptions struct {
 	// The port number for the HTTP server.
 	// The default port number is 8080.
 	Port uint16
+	// The maximum duration for reading the headers of a request.
+	// The default timeout is DefaultReadHeaderTimeout.
+	ReadHeaderTimeout time.Duration
+	// The maximum duration for reading an entire request, including the body.
+	// The default timeout is DefaultReadTimeout.
+	ReadTimeout time.Duration
+	// The maximum duration for handling a request and writing its response.
+	// The default timeout is DefaultWriteTimeout.
+	WriteTimeout time.Duration
+	// The maximum duration for waiting for the next request on a keep-alive connection.
+	// The default timeout is DefaultIdleTimeout.
+	IdleTimeout time.Duration
+	// The maximum size of the headers of a request.
+	// The default size is DefaultMaxHeaderBytes.
+	MaxHeaderBytes int
+	// The maximum size of the body of a request.
+	// Reading a larger body fails with an *http.MaxBytesError.
+	// The default size is DefaultMaxBodyBytes.
+	MaxBodyBytes int64
 	// HTTP middleware for handlers.
 	Middleware []httpx.Middleware
+	// A TLS configuration for the server identity and verifying client identities.
+	// If nil, the server serves plaintext HTTP.
+	TLSConfig *tls.Config
 }
 
 // NewHTTP creates a new http Server.
@@ -39,17 +80,48 @@ func NewHTTP(healthHandler http.Handler, greetingService *greeting.Service, opts
 		opts.Port = defaultHTTPPort
 	}
 
+	if opts.ReadHeaderTimeout == 0 {
+		opts.ReadHeaderTimeout = DefaultReadHeaderTimeout
+	}
+
+	if opts.ReadTimeout == 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+
+	if opts.WriteTimeout == 0 {
+		opts.WriteTimeout = DefaultWriteTimeout
+	}
+
+	if opts.IdleTimeout == 0 {
+		opts.IdleTimeout = DefaultIdleTimeout
+	}
+
+	if opts.MaxHeaderBytes == 0 {
+		opts.MaxHeaderBytes = DefaultMaxHeaderBytes
+	}
+
+	if opts.MaxBodyBytes == 0 {
+		opts.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+
 	router := mux.NewRouter()
 	router.Path("/health").Handler(healthHandler)
 	greetingService.RegisterRoutes(router, opts.Middleware...)
 
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", opts.Port),
-		Handler: router,
+		Addr:              fmt.Sprintf(":%d", opts.Port),
+		Handler:           http.MaxBytesHandler(router, opts.MaxBodyBytes),
+		TLSConfig:         opts.TLSConfig,
+		ReadHeaderTimeout: opts.ReadHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		MaxHeaderBytes:    opts.MaxHeaderBytes,
 	}
 
 	return &HTTP{
 		server: server,
+		tls:    opts.TLSConfig != nil,
 	}, nil
 }
 
@@ -64,7 +136,14 @@ func (s *HTTP) ListenAndServe() error {
 	// Synchronous/Blocking
 	// ListenAndServe always returns a non-nil error
 	// After Shutdown or Close, the returned error is ErrServerClosed
-	err := s.server.ListenAndServe()
+	var err error
+	if s.tls {
+		// The certificates are provided by the TLS configuration
+		err = s.server.ListenAndServeTLS("", "")
+	} else {
+		err = s.server.ListenAndServe()
+	}
+
 	if err != http.ErrServerClosed {
 		return err
 	}
@@ -9,6 +9,12 @@ type (
 		OutError error
 	}
 
+	ListenAndServeTLSMock struct {
+		InCertFile string
+		InKeyFile  string
+		OutError   error
+	}
+
 	ShutdownMock struct {
 		InContext context.Context
 		OutError  error
@@ -18,6 +24,9 @@ type (
 		ListenAndServeIndex int
 		ListenAndServeMocks []ListenAndServeMock
 
+		ListenAndServeTLSIndex int
+		ListenAndServeTLSMocks []ListenAndServeTLSMock
+
 		ShutdownIndex int
 		ShutdownMocks []ShutdownMock
 	}
@@ -29,6 +38,14 @@ func (m *MockHTTPServer) ListenAndServe() error {
 	return m.ListenAndServeMocks[i].OutError
 }
 
+func (m *MockHTTPServer) ListenAndServeTLS(certFile, keyFile string) error {
+	i := m.ListenAndServeTLSIndex
+	m.ListenAndServeTLSIndex++
+	m.ListenAndServeTLSMocks[i].InCertFile = certFile
+	m.ListenAndServeTLSMocks[i].InKeyFile = keyFile
+	return m.ListenAndServeTLSMocks[i].OutError
+}
+
 func (m *MockHTTPServer) Shutdown(ctx context.Context) error {
 	i := m.ShutdownIndex
 	m.ShutdownIndex++
@@ -3,13 +3,22 @@ package greeting
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gardenbed/basil/httpx"
+	"github.com/gardenbed/basil/telemetry"
 	"github.com/gorilla/mux"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/sync/singleflight"
+
+	"http-service/internal/breaker"
+	"http-service/internal/locale"
+	"http-service/internal/problem"
 )
 
 type (
@@ -23,17 +32,106 @@ type (
 	}
 )
 
+const (
+	// DefaultGithubURL is the base URL of the public GitHub REST API.
+	DefaultGithubURL = "https://api.github.com"
+
+	// DefaultCacheNamespace is the default prefix for the keys of cached GitHub users.
+	DefaultCacheNamespace = "greeting"
+	// DefaultCacheTTL is the default duration for caching a GitHub user.
+	DefaultCacheTTL = time.Hour
+	// DefaultNegativeCacheTTL is the default duration for caching the absence of a GitHub user.
+	DefaultNegativeCacheTTL = time.Minute
+	// DefaultStaleCacheTTL is the default duration for keeping a GitHub user for when the GitHub API is unavailable.
+	DefaultStaleCacheTTL = 24 * time.Hour
+
+	// StaleHeader is the response header set when a greeting is created for a stale GitHub user.
+	StaleHeader = "X-Stale"
+
+	// cacheVersion is bumped whenever the format of cached values changes, so stale entries are never decoded.
+	cacheVersion = "v1"
+	// notFoundValue is the cached value for a GitHub user that does not exist.
+	notFoundValue = "null"
+)
+
+// ErrUserNotFound is returned when a GitHub user does not exist.
+var ErrUserNotFound = errors.New("github user not found")
+
+// Options are optional configurations for creating a new service.
+type Options struct {
+	// GithubURL is the base URL of the GitHub REST API (DefaultGithubURL if empty).
+	GithubURL string
+	// CacheNamespace is the prefix for the keys of cached GitHub users (DefaultCacheNamespace if empty).
+	CacheNamespace string
+	// CacheTTL is the duration for caching a GitHub user (DefaultCacheTTL if zero).
+	CacheTTL time.Duration
+	// NegativeCacheTTL is the duration for caching the absence of a GitHub user (DefaultNegativeCacheTTL if zero).
+	NegativeCacheTTL time.Duration
+	// StaleCacheTTL is the duration for keeping a GitHub user for when the GitHub API is unavailable (DefaultStaleCacheTTL if zero).
+	// While the circuit breaker for the GitHub API is open, users are served from this stale cache.
+	StaleCacheTTL time.Duration
+	// Meter is used for creating the service metrics (the meter of the global telemetry probe if nil).
+	Meter metric.Meter
+}
+
 // Service implements the HTTP handlers for Greeting APIs.
 type Service struct {
-	httpClient  httpClient
-	redisClient redisClient
+	httpClient       httpClient
+	redisClient      redisClient
+	catalog          *locale.Catalog
+	githubURL        string
+	cacheNamespace   string
+	cacheTTL         time.Duration
+	negativeCacheTTL time.Duration
+	staleCacheTTL    time.Duration
+	lookups          singleflight.Group
+	coalesced        metric.Int64Counter
 }
 
 // NewService creates a new service.
-func NewService(httpClient httpClient, redisClient redisClient) (*Service, error) {
+func NewService(httpClient httpClient, redisClient redisClient, catalog *locale.Catalog, opts Options) (*Service, error) {
+	if opts.GithubURL == "" {
+		opts.GithubURL = DefaultGithubURL
+	}
+
+	if opts.CacheNamespace == "" {
+		opts.CacheNamespace = DefaultCacheNamespace
+	}
+
+	if opts.CacheTTL == 0 {
+		opts.CacheTTL = DefaultCacheTTL
+	}
+
+	if opts.NegativeCacheTTL == 0 {
+		opts.NegativeCacheTTL = DefaultNegativeCacheTTL
+	}
+
+	if opts.StaleCacheTTL == 0 {
+		opts.StaleCacheTTL = DefaultStaleCacheTTL
+	}
+
+	if opts.Meter == nil {
+		opts.Meter = telemetry.Get().Meter()
+	}
+
+	coalesced, err := opts.Meter.Int64Counter(
+		"coalesced_github_user_lookups_total",
+		metric.WithDescription("The total number of GitHub user lookups served by an in-flight lookup for the same user"),
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Service{
-		httpClient:  httpClient,
-		redisClient: redisClient,
+		httpClient:       httpClient,
+		redisClient:      redisClient,
+		catalog:          catalog,
+		githubURL:        strings.TrimSuffix(opts.GithubURL, "/"),
+		cacheNamespace:   opts.CacheNamespace,
+		cacheTTL:         opts.CacheTTL,
+		negativeCacheTTL: opts.NegativeCacheTTL,
+		staleCacheTTL:    opts.StaleCacheTTL,
+		coalesced:        coalesced,
 	}, nil
 }
 
@@ -59,36 +157,139 @@ type GreetResponse struct {
 	Greeting string `json:"greeting"`
 }
 
+// user is the model for a GitHub user.
+type user struct {
+	Login    string `json:"login"`
+	Name     string `json:"name"`
+	Company  string `json:"company"`
+	Location string `json:"location"`
+}
+
 // Greet is the handler for the GreetingService::Greet endpoint.
+// The language of the greeting is negotiated using the Accept-Language header.
+// Failures are responded with problem details (RFC 7807).
 func (s *Service) Greet(w http.ResponseWriter, r *http.Request) {
 	req := new(GreetRequest)
 	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
-		httpx.Error(w, err, http.StatusBadRequest)
+		problem.Write(w, r, problem.Wrap(problem.KindValidation, fmt.Errorf("invalid request body: %w", err)))
+		return
+	}
+
+	user, stale, err := s.getUser(r.Context(), req.GithubUsername)
+	if errors.Is(err, ErrUserNotFound) {
+		problem.Write(w, r, problem.Wrap(problem.KindNotFound, err))
+		return
+	}
+
+	if err != nil {
+		problem.Write(w, r, err)
 		return
 	}
 
-	name, err := s.getName(r.Context(), req.GithubUsername)
+	lang := r.Header.Get("Accept-Language")
+	greeting, err := s.catalog.Greet(lang, locale.User{
+		Login:    user.Login,
+		Name:     user.Name,
+		Company:  user.Company,
+		Location: user.Location,
+	})
+
 	if err != nil {
-		httpx.Error(w, err, http.StatusInternalServerError)
+		problem.Write(w, r, err)
 		return
 	}
 
-	greeting := fmt.Sprintf("Hello, %s!", name)
 	resp := &GreetResponse{
 		Greeting: greeting,
 	}
 
+	if stale {
+		w.Header().Set(StaleHeader, "true")
+	}
+
+	w.Header().Set("Content-Language", s.catalog.Match(lang))
 	w.WriteHeader(http.StatusOK)
 	_ = json.NewEncoder(w).Encode(resp)
 }
 
-func (s *Service) getName(ctx context.Context, username string) (string, error) {
-	name, err := s.redisClient.Get(ctx, username).Result()
-	if err == nil && name != "" {
-		return name, nil
+// cacheKey returns the namespaced and versioned key for caching a GitHub user.
+// GitHub usernames are case-insensitive, so the keys are lower-cased.
+func (s *Service) cacheKey(username string) string {
+	return fmt.Sprintf("%s:%s:user:%s", s.cacheNamespace, cacheVersion, strings.ToLower(username))
+}
+
+// staleCacheKey returns the namespaced and versioned key for keeping a stale GitHub user.
+func (s *Service) staleCacheKey(username string) string {
+	return fmt.Sprintf("%s:%s:stale:user:%s", s.cacheNamespace, cacheVersion, strings.ToLower(username))
+}
+
+// getUser retrieves a GitHub user, coalescing concurrent lookups of the same user into a single lookup.
+// The shared lookup is not cancelled when a caller's context is done, so the other callers are not affected,
+// but each caller stops waiting and returns as soon as its own context is done.
+// While the circuit breaker for the GitHub API is open, the user is served from the stale cache if available and reported as stale.
+func (s *Service) getUser(ctx context.Context, username string) (*user, bool, error) {
+	key := s.cacheKey(username)
+
+	var leader bool
+	ch := s.lookups.DoChan(key, func() (any, error) {
+		leader = true
+		return s.lookupUser(context.WithoutCancel(ctx), key, username)
+	})
+
+	select {
+	case res := <-ch:
+		if res.Shared && !leader {
+			s.coalesced.Add(ctx, 1)
+		}
+
+		if errors.Is(res.Err, breaker.ErrOpen) {
+			if u, err := s.lookupStaleUser(ctx, username); err == nil {
+				return u, true, nil
+			}
+		}
+
+		if res.Err != nil {
+			return nil, false, res.Err
+		}
+
+		return res.Val.(*user), false, nil
+
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
 	}
+}
 
-	url := fmt.Sprintf("https://api.github.com/users/%s", username)
+// lookupStaleUser retrieves a GitHub user from the stale cache.
+func (s *Service) lookupStaleUser(ctx context.Context, username string) (*user, error) {
+	val, err := s.redisClient.Get(ctx, s.staleCacheKey(username)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	u := new(user)
+	if err := json.Unmarshal([]byte(val), u); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// lookupUser retrieves a GitHub user using a write-through cache.
+// Users are cached for the positive TTL and non-existent users are cached for the negative TTL.
+// Cache failures are not fatal and only result in calling the GitHub API.
+func (s *Service) lookupUser(ctx context.Context, key, username string) (*user, error) {
+	if val, err := s.redisClient.Get(ctx, key).Result(); err == nil {
+		if val == notFoundValue {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+		}
+
+		cached := new(user)
+		if err := json.Unmarshal([]byte(val), cached); err == nil && cached.Login != "" {
+			return cached, nil
+		}
+	}
+
+	url := fmt.Sprintf("%s/users/%s", s.githubURL, username)
 	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
 
 	req.Header.Set("User-Agent", "command-app")
@@ -97,24 +298,35 @@ func (s *Service) getName(ctx context.Context, username string) (string, error)
 
 	resp, err := s.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
+	if resp.StatusCode == http.StatusNotFound {
+		_ = s.redisClient.Set(ctx, key, notFoundValue, s.negativeCacheTTL).Err()
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, username)
+	}
+
+	if retryAfter, limited := breaker.RateLimited(resp, time.Now()); limited {
+		return nil, problem.UpstreamRateLimited(httpx.NewClientError(resp), retryAfter)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return "", httpx.NewClientError(resp)
+		return nil, httpx.NewClientError(resp)
 	}
 
-	user := struct {
-		Name string `json:"name"`
-	}{}
+	u := new(user)
+	if err := json.NewDecoder(resp.Body).Decode(u); err != nil {
+		return nil, err
+	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&user); err != nil {
-		return "", err
+	if val, err := json.Marshal(u); err == nil {
+		_ = s.redisClient.Set(ctx, key, val, s.cacheTTL).Err()
+		_ = s.redisClient.Set(ctx, s.staleCacheKey(username), val, s.staleCacheTTL).Err()
 	}
 
-	return user.Name, nil
+	return u, nil
 }
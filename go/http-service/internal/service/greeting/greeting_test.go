@@ -7,12 +7,21 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
 	"github.com/gardenbed/basil/httpx"
-	"github.com/redis/go-redis/v9"
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
+	metricsdk "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+
+	"http-service/internal/breaker"
+	"http-service/internal/fakegithub"
+	"http-service/internal/locale"
 )
 
 func TestNewService(t *testing.T) {
@@ -20,19 +29,21 @@ func TestNewService(t *testing.T) {
 		name          string
 		httpClient    *MockHTTPClient
 		redisClient   *MockRedisClient
+		catalog       *locale.Catalog
 		expectedError string
 	}{
 		{
 			name:          "OK",
 			httpClient:    &MockHTTPClient{},
 			redisClient:   &MockRedisClient{},
+			catalog:       &locale.Catalog{},
 			expectedError: "",
 		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			s, err := NewService(tc.httpClient, tc.redisClient)
+			s, err := NewService(tc.httpClient, tc.redisClient, tc.catalog, Options{})
 
 			if tc.expectedError == "" {
 				assert.NotNil(t, s)
@@ -74,6 +85,15 @@ func TestService_RegisterRoutes(t *testing.T) {
 func TestService_Greet(t *testing.T) {
 	req, _ := http.NewRequest("GET", "https://api.github.com/users/octocat", nil)
 
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	frenchReq := httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`))
+	frenchReq.Header.Set("Accept-Language", "fr-CA,fr;q=0.9,en;q=0.8")
+
+	tooLargeReq := httptest.NewRequest("POST", "/greet", nil)
+	tooLargeReq.Body = http.MaxBytesReader(nil, io.NopCloser(strings.NewReader(`{ "githubUsername": "octocat" }`)), 8)
+
 	tests := []struct {
 		name               string
 		httpClient         *MockHTTPClient
@@ -81,6 +101,7 @@ func TestService_Greet(t *testing.T) {
 		ctx                context.Context
 		r                  *http.Request
 		expectedStatusCode int
+		expectedLanguage   string
 		expectedBody       string
 	}{
 		{
@@ -88,18 +109,26 @@ func TestService_Greet(t *testing.T) {
 			ctx:                context.Background(),
 			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{`)),
 			expectedStatusCode: 400,
-			expectedBody:       "unexpected EOF\n",
+			expectedBody:       `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid request body: unexpected EOF","instance":"/greet"}` + "\n",
+		},
+		{
+			name:               "RequestTooLarge",
+			ctx:                context.Background(),
+			r:                  tooLargeReq,
+			expectedStatusCode: 413,
+			expectedBody:       `{"type":"urn:problem-type:request-too-large","title":"Request Too Large","status":413,"code":"REQUEST_TOO_LARGE","detail":"invalid request body: http: request body too large","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "Success_FromCache",
 			redisClient: &MockRedisClient{
 				GetMocks: []GetMock{
-					{OutStringCmd: redis.NewStringResult("Octocat", nil)},
+					{OutStringCmd: redis.NewStringResult(`{"login":"octocat","name":"Octocat"}`, nil)},
 				},
 			},
 			ctx:                context.Background(),
 			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 200,
+			expectedLanguage:   "en",
 			expectedBody:       "{\"greeting\":\"Hello, Octocat!\"}\n",
 		},
 		{
@@ -117,7 +146,7 @@ func TestService_Greet(t *testing.T) {
 			ctx:                context.Background(),
 			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 500,
-			expectedBody:       "http error\n",
+			expectedBody:       `{"type":"urn:problem-type:internal","title":"Internal Server Error","status":500,"code":"INTERNAL","detail":"internal server error","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "UnexpectedStatusCode",
@@ -141,8 +170,34 @@ func TestService_Greet(t *testing.T) {
 			},
 			ctx:                context.Background(),
 			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
-			expectedStatusCode: 500,
-			expectedBody:       "GET /users/octocat 400: invalid request\n",
+			expectedStatusCode: 503,
+			expectedBody:       `{"type":"urn:problem-type:upstream-unavailable","title":"Upstream Unavailable","status":503,"code":"UPSTREAM_UNAVAILABLE","detail":"an upstream service is unavailable","instance":"/greet"}` + "\n",
+		},
+		{
+			name: "UpstreamRateLimited",
+			httpClient: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{
+						OutResponse: &http.Response{
+							Request:    req,
+							StatusCode: 403,
+							Header:     http.Header{"X-Ratelimit-Remaining": {"0"}},
+							Body: io.NopCloser(
+								strings.NewReader(`{ "message": "API rate limit exceeded." }`),
+							),
+						},
+					},
+				},
+			},
+			redisClient: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("", errors.New("redis error"))},
+				},
+			},
+			ctx:                context.Background(),
+			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
+			expectedStatusCode: 429,
+			expectedBody:       `{"type":"urn:problem-type:rate-limited","title":"Rate Limited","status":429,"code":"RATE_LIMITED","detail":"an upstream service is rate limited","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "InvalidResponseBody",
@@ -167,7 +222,7 @@ func TestService_Greet(t *testing.T) {
 			ctx:                context.Background(),
 			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 500,
-			expectedBody:       "unexpected EOF\n",
+			expectedBody:       `{"type":"urn:problem-type:internal","title":"Internal Server Error","status":500,"code":"INTERNAL","detail":"internal server error","instance":"/greet"}` + "\n",
 		},
 		{
 			name: "Success_FromAPI",
@@ -190,13 +245,45 @@ func TestService_Greet(t *testing.T) {
 				},
 				SetMocks: []SetMock{
 					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
 				},
 			},
 			ctx:                context.Background(),
 			r:                  httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)),
 			expectedStatusCode: 200,
+			expectedLanguage:   "en",
 			expectedBody:       "{\"greeting\":\"Hello, Octocat!\"}\n",
 		},
+		{
+			name: "Success_Localized",
+			httpClient: &MockHTTPClient{
+				DoMocks: []DoMock{
+					{
+						OutResponse: &http.Response{
+							Request:    req,
+							StatusCode: 200,
+							Body: io.NopCloser(
+								strings.NewReader(`{ "id": 1, "login": "octocat", "email": "octocat@example.com", "name": "" }`),
+							),
+						},
+					},
+				},
+			},
+			redisClient: &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("Octocat", nil)},
+				},
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+				},
+			},
+			ctx:                context.Background(),
+			r:                  frenchReq,
+			expectedStatusCode: 200,
+			expectedLanguage:   "fr",
+			expectedBody:       "{\"greeting\":\"Bonjour, octocat !\"}\n",
+		},
 	}
 
 	for _, tc := range tests {
@@ -204,6 +291,7 @@ func TestService_Greet(t *testing.T) {
 			s := &Service{
 				httpClient:  tc.httpClient,
 				redisClient: tc.redisClient,
+				catalog:     catalog,
 			}
 
 			rec := httptest.NewRecorder()
@@ -215,7 +303,292 @@ func TestService_Greet(t *testing.T) {
 			body := string(b)
 
 			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedLanguage, res.Header.Get("Content-Language"))
 			assert.Equal(t, tc.expectedBody, body)
 		})
 	}
 }
+
+func TestService_Greet_FakeGitHub(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		opts               []fakegithub.Option
+		githubUsername     string
+		expectedStatusCode int
+		expectedBody       string
+	}{
+		{
+			name:               "UserNotFound",
+			githubUsername:     "ghost",
+			expectedStatusCode: 404,
+			expectedBody:       `{"type":"urn:problem-type:not-found","title":"Not Found","status":404,"code":"NOT_FOUND","detail":"github user not found: ghost","instance":"/greet"}` + "\n",
+		},
+		{
+			name:               "ServerError",
+			opts:               []fakegithub.Option{fakegithub.WithError("/users/*", 502)},
+			githubUsername:     "octocat",
+			expectedStatusCode: 503,
+			expectedBody:       `{"type":"urn:problem-type:upstream-unavailable","title":"Upstream Unavailable","status":503,"code":"UPSTREAM_UNAVAILABLE","detail":"an upstream service is unavailable","instance":"/greet"}` + "\n",
+		},
+		{
+			name:               "Success",
+			githubUsername:     "octocat",
+			expectedStatusCode: 200,
+			expectedBody:       "{\"greeting\":\"Hello, The Octocat!\"}\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(tc.opts...)
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			redisClient := &MockRedisClient{
+				GetMocks: []GetMock{
+					{OutStringCmd: redis.NewStringResult("", redis.Nil)},
+				},
+				SetMocks: []SetMock{
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+					{OutStatusCmd: redis.NewStatusResult("", nil)},
+				},
+			}
+
+			s, err := NewService(fake.Client(), redisClient, catalog, Options{GithubURL: fake.URL})
+			assert.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			r := httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "`+tc.githubUsername+`" }`))
+			s.Greet(rec, r)
+
+			res := rec.Result()
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedBody, string(b))
+		})
+	}
+}
+
+func TestService_getUser_Cache(t *testing.T) {
+	tests := []struct {
+		name             string
+		username         string
+		expectedKey      string
+		expectedTTL      time.Duration
+		expectedError    string
+		expectedRequests int
+	}{
+		{
+			name:             "UserNotFound",
+			username:         "ghost",
+			expectedKey:      "test:v1:user:ghost",
+			expectedTTL:      time.Minute,
+			expectedError:    "github user not found: ghost",
+			expectedRequests: 1,
+		},
+		{
+			name:             "Success",
+			username:         "OctoCat",
+			expectedKey:      "test:v1:user:octocat",
+			expectedTTL:      time.Hour,
+			expectedError:    "",
+			expectedRequests: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer()
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			s, err := NewService(fake.Client(), redisClient, nil, Options{
+				GithubURL:        fake.URL,
+				CacheNamespace:   "test",
+				CacheTTL:         time.Hour,
+				NegativeCacheTTL: time.Minute,
+			})
+			assert.NoError(t, err)
+
+			// The second call should be served from the cache
+			for range 2 {
+				u, _, err := s.getUser(context.Background(), tc.username)
+
+				if tc.expectedError == "" {
+					assert.NoError(t, err)
+					assert.Equal(t, "octocat", u.Login)
+				} else {
+					assert.ErrorIs(t, err, ErrUserNotFound)
+					assert.EqualError(t, err, tc.expectedError)
+					assert.Nil(t, u)
+				}
+			}
+
+			assert.Equal(t, tc.expectedRequests, fake.Requests())
+			assert.True(t, mr.Exists(tc.expectedKey))
+			assert.Equal(t, tc.expectedTTL, mr.TTL(tc.expectedKey))
+			if tc.expectedError == "" {
+				assert.Equal(t, DefaultStaleCacheTTL, mr.TTL("test:v1:stale:user:octocat"))
+			}
+
+			// Once the entry expires, the user should be fetched again
+			mr.FastForward(tc.expectedTTL)
+			assert.False(t, mr.Exists(tc.expectedKey))
+
+			_, _, _ = s.getUser(context.Background(), tc.username)
+			assert.Equal(t, tc.expectedRequests+1, fake.Requests())
+		})
+	}
+}
+
+func TestService_getUser_Coalescing(t *testing.T) {
+	fake, err := fakegithub.NewServer(fakegithub.WithLatency(200 * time.Millisecond))
+	assert.NoError(t, err)
+	defer fake.Close()
+
+	mr := miniredis.RunT(t)
+	redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer redisClient.Close()
+
+	reader := metricsdk.NewManualReader()
+	meter := metricsdk.NewMeterProvider(metricsdk.WithReader(reader)).Meter("test")
+
+	s, err := NewService(fake.Client(), redisClient, nil, Options{
+		GithubURL: fake.URL,
+		Meter:     meter,
+	})
+	assert.NoError(t, err)
+
+	const callers = 10
+
+	// The last caller joins the in-flight lookup and gives up before the lookup completes
+	cancelledCtx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	users := make([]*user, callers)
+	errs := make([]error, callers)
+
+	for i := range callers {
+		ctx := context.Background()
+		if i == callers-1 {
+			time.Sleep(10 * time.Millisecond)
+			ctx = cancelledCtx
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users[i], _, errs[i] = s.getUser(ctx, "OctoCat")
+		}()
+	}
+
+	wg.Wait()
+
+	for i := range callers - 1 {
+		assert.NoError(t, errs[i])
+		assert.Equal(t, "octocat", users[i].Login)
+	}
+
+	assert.ErrorIs(t, errs[callers-1], context.DeadlineExceeded)
+	assert.Nil(t, users[callers-1])
+
+	assert.Equal(t, 1, fake.Requests())
+	assert.Equal(t, int64(callers-2), coalescedCount(t, reader))
+}
+
+// coalescedCount returns the value of the coalesced lookups counter.
+func coalescedCount(t *testing.T, reader metricsdk.Reader) int64 {
+	var rm metricdata.ResourceMetrics
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if sum, ok := m.Data.(metricdata.Sum[int64]); ok && m.Name == "coalesced_github_user_lookups_total" {
+				return sum.DataPoints[0].Value
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestService_Greet_Stale(t *testing.T) {
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		staleUser          string
+		expectedStatusCode int
+		expectedStale      string
+		expectedBody       string
+	}{
+		{
+			name:               "NoStaleUser",
+			staleUser:          "",
+			expectedStatusCode: 503,
+			expectedStale:      "",
+			expectedBody:       `"code":"UPSTREAM_UNAVAILABLE"`,
+		},
+		{
+			name:               "StaleUser",
+			staleUser:          `{"login":"octocat","name":"The Stale Octocat"}`,
+			expectedStatusCode: 200,
+			expectedStale:      "true",
+			expectedBody:       "{\"greeting\":\"Hello, The Stale Octocat!\"}\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fake, err := fakegithub.NewServer(fakegithub.WithError("/users/*", 502))
+			assert.NoError(t, err)
+			defer fake.Close()
+
+			mr := miniredis.RunT(t)
+			redisClient := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+			defer redisClient.Close()
+
+			if tc.staleUser != "" {
+				assert.NoError(t, mr.Set("greeting:v1:stale:user:octocat", tc.staleUser))
+			}
+
+			httpClient := &http.Client{
+				Transport: breaker.NewTransport(http.DefaultTransport, breaker.New(breaker.Options{FailureThreshold: 1})),
+			}
+
+			s, err := NewService(httpClient, redisClient, catalog, Options{GithubURL: fake.URL})
+			assert.NoError(t, err)
+
+			greet := func() *http.Response {
+				rec := httptest.NewRecorder()
+				s.Greet(rec, httptest.NewRequest("POST", "/greet", strings.NewReader(`{ "githubUsername": "octocat" }`)))
+				return rec.Result()
+			}
+
+			// The first failure opens the circuit
+			res := greet()
+			assert.Equal(t, 503, res.StatusCode)
+			assert.Empty(t, res.Header.Get(StaleHeader))
+
+			res = greet()
+			b, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.expectedStatusCode, res.StatusCode)
+			assert.Equal(t, tc.expectedStale, res.Header.Get(StaleHeader))
+			assert.Contains(t, string(b), tc.expectedBody)
+			assert.Equal(t, 1, fake.Requests())
+		})
+	}
+}
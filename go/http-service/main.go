@@ -2,8 +2,11 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"flag"
 	"os"
+	"time"
 
 	"github.com/gardenbed/basil/config"
 	"github.com/gardenbed/basil/graceful"
@@ -12,9 +15,17 @@ import (
 	"github.com/gardenbed/basil/telemetry"
 	httptelemetry "github.com/gardenbed/basil/telemetry/http"
 
+	"http-service/idl"
+	"http-service/internal/auth"
+	"http-service/internal/breaker"
+	"http-service/internal/certs"
 	"http-service/internal/client"
+	"http-service/internal/locale"
+	"http-service/internal/ratelimit"
+	"http-service/internal/recovery"
 	"http-service/internal/server"
 	"http-service/internal/service/greeting"
+	"http-service/internal/validation"
 	"http-service/metadata"
 )
 
@@ -29,6 +40,36 @@ var configs = struct {
 	LogLevel               string
 	OpenTelemetryCollector string
 	RedisAddress           string
+	TemplatesDir           string
+	GithubURL              string
+	CacheNamespace         string
+	CacheTTL               time.Duration
+	NegativeCacheTTL       time.Duration
+	StaleCacheTTL          time.Duration
+	BreakerFailures        int
+	BreakerOpenTimeout     time.Duration
+	BreakerSuccesses       int
+	RateLimit              int
+	RateLimitPeriod        time.Duration
+	RateLimitBurst         int
+	AuthFailureLimit       int
+	AuthFailurePeriod      time.Duration
+	APIKeys                string
+	JWKSFile               string
+	JWKSURL                string `flag:"jwks.url" env:"JWKS_URL" fileenv:"JWKS_URL_FILE"`
+	JWTIssuer              string
+	JWTAudience            string
+	AuthScope              string
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSClientCAFile        string
+	TLSReloadInterval      time.Duration
+	HTTPReadHeaderTimeout  time.Duration
+	HTTPReadTimeout        time.Duration
+	HTTPWriteTimeout       time.Duration
+	HTTPIdleTimeout        time.Duration
+	HTTPMaxHeaderBytes     int
+	HTTPMaxBodyBytes       int64
 }{
 	// Default Values
 	HTTPPort:               8080,
@@ -40,6 +81,36 @@ var configs = struct {
 	LogLevel:               "debug",
 	OpenTelemetryCollector: "localhost:55680",
 	RedisAddress:           "localhost:6379",
+	TemplatesDir:           "",
+	GithubURL:              greeting.DefaultGithubURL,
+	CacheNamespace:         "http-service",
+	CacheTTL:               greeting.DefaultCacheTTL,
+	NegativeCacheTTL:       greeting.DefaultNegativeCacheTTL,
+	StaleCacheTTL:          greeting.DefaultStaleCacheTTL,
+	BreakerFailures:        breaker.DefaultFailureThreshold,
+	BreakerOpenTimeout:     breaker.DefaultOpenTimeout,
+	BreakerSuccesses:       breaker.DefaultSuccessThreshold,
+	RateLimit:              ratelimit.DefaultLimit,
+	RateLimitPeriod:        ratelimit.DefaultPeriod,
+	RateLimitBurst:         0,
+	AuthFailureLimit:       ratelimit.DefaultFailureLimit,
+	AuthFailurePeriod:      ratelimit.DefaultPeriod,
+	APIKeys:                "",
+	JWKSFile:               "",
+	JWKSURL:                "",
+	JWTIssuer:              "",
+	JWTAudience:            "",
+	AuthScope:              "",
+	TLSCertFile:            "",
+	TLSKeyFile:             "",
+	TLSClientCAFile:        "",
+	TLSReloadInterval:      certs.DefaultReloadInterval,
+	HTTPReadHeaderTimeout:  server.DefaultReadHeaderTimeout,
+	HTTPReadTimeout:        server.DefaultReadTimeout,
+	HTTPWriteTimeout:       server.DefaultWriteTimeout,
+	HTTPIdleTimeout:        server.DefaultIdleTimeout,
+	HTTPMaxHeaderBytes:     server.DefaultMaxHeaderBytes,
+	HTTPMaxBodyBytes:       server.DefaultMaxBodyBytes,
 }
 
 func main() {
@@ -78,12 +149,40 @@ func main() {
 
 	// CREATE CLIENTS
 
-	httpClient := client.NewHTTP()
+	httpClient, err := client.NewHTTP(client.HTTPOptions{
+		Breaker: breaker.Options{
+			FailureThreshold: configs.BreakerFailures,
+			OpenTimeout:      configs.BreakerOpenTimeout,
+			SuccessThreshold: configs.BreakerSuccesses,
+			OnStateChange: func(from, to breaker.State) {
+				probe.Logger().Warn("github circuit breaker state changed", "from", from.String(), "to", to.String())
+			},
+		},
+		Meter: probe.Meter(),
+	})
+	if err != nil {
+		probe.Logger().Error("failed to create http client", "error", err)
+		panic(err)
+	}
+
 	redisClient := client.NewRedis(configs.RedisAddress)
 
 	// CREATE SERVICES
 
-	greetingService, err := greeting.NewService(httpClient, redisClient)
+	catalog, err := locale.NewCatalog(configs.TemplatesDir)
+	if err != nil {
+		probe.Logger().Error("failed to create greeting templates catalog", "error", err)
+		panic(err)
+	}
+
+	greetingService, err := greeting.NewService(httpClient, redisClient, catalog, greeting.Options{
+		GithubURL:        configs.GithubURL,
+		CacheNamespace:   configs.CacheNamespace,
+		CacheTTL:         configs.CacheTTL,
+		NegativeCacheTTL: configs.NegativeCacheTTL,
+		StaleCacheTTL:    configs.StaleCacheTTL,
+		Meter:            probe.Meter(),
+	})
 	if err != nil {
 		probe.Logger().Error("failed to create greeting service", "error", err)
 		panic(err)
@@ -91,16 +190,67 @@ func main() {
 
 	// CREATE SERVERS
 
+	recoveryMiddleware := recovery.NewMiddleware(probe.Logger())
+
+	rateLimiter := ratelimit.New(redisClient, ratelimit.Options{
+		Namespace: configs.Name,
+		Limit:     configs.RateLimit,
+		Period:    configs.RateLimitPeriod,
+		Burst:     configs.RateLimitBurst,
+		Logger:    probe.Logger(),
+	})
+
+	// Requests are validated against the constraints declared in the IDL once they are authenticated and rate limited
+	validationMiddleware, err := validation.NewMiddleware(idl.Greeting)
+	if err != nil {
+		probe.Logger().Error("failed to create validation middleware", "error", err)
+		panic(err)
+	}
+
+	// Requests are rate limited once they are authenticated, so clients are identified by their principals
+	middleware := []httpx.Middleware{validationMiddleware, rateLimiter}
+
+	authenticator, err := newAuthenticator(ctx)
+	if err != nil {
+		probe.Logger().Error("failed to create authenticator", "error", err)
+		panic(err)
+	}
+
+	if authenticator != nil {
+		// Failed authentications are limited before authentication, so credentials cannot be brute forced
+		failureLimiter := ratelimit.NewFailureLimiter(redisClient, ratelimit.Options{
+			Namespace: configs.Name + "-auth",
+			Limit:     configs.AuthFailureLimit,
+			Period:    configs.AuthFailurePeriod,
+			Logger:    probe.Logger(),
+		})
+
+		middleware = append(middleware, auth.NewMiddleware(authenticator, configs.AuthScope), failureLimiter)
+	} else {
+		probe.Logger().Warn("authentication is disabled because no api keys or jwks are configured")
+	}
+
 	// Create an HTTP health handler for health checking the service by external systems
 	health.SetLogger(probe.Logger())
 	health.RegisterChecker(httpClient, redisClient)
 	healthHandler := health.HandlerFunc()
 
+	tlsConfig, err := newTLSConfig()
+	if err != nil {
+		probe.Logger().Error("failed to create tls config", "error", err)
+		panic(err)
+	}
+
 	httpServer, err := server.NewHTTP(healthHandler, greetingService, server.HTTPOptions{
-		Port: configs.HTTPPort,
-		Middleware: []httpx.Middleware{
-			telemetryMiddleware,
-		},
+		Port:              configs.HTTPPort,
+		Middleware:        append(middleware, recoveryMiddleware, telemetryMiddleware),
+		TLSConfig:         tlsConfig,
+		ReadHeaderTimeout: configs.HTTPReadHeaderTimeout,
+		ReadTimeout:       configs.HTTPReadTimeout,
+		WriteTimeout:      configs.HTTPWriteTimeout,
+		IdleTimeout:       configs.HTTPIdleTimeout,
+		MaxHeaderBytes:    configs.HTTPMaxHeaderBytes,
+		MaxBodyBytes:      configs.HTTPMaxBodyBytes,
 	})
 
 	if err != nil {
@@ -118,3 +268,61 @@ func main() {
 
 	os.Exit(code)
 }
+
+// newAuthenticator creates an authenticator for the configured API keys and JWKS.
+// If neither API keys nor a JWKS is configured, authentication is disabled and nil is returned.
+func newAuthenticator(ctx context.Context) (auth.Authenticator, error) {
+	var chain auth.Chain
+
+	keys, err := auth.ParseAPIKeys(configs.APIKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keys) > 0 {
+		chain = append(chain, auth.NewAPIKeys(keys))
+	}
+
+	if configs.JWKSFile != "" || configs.JWKSURL != "" {
+		jwt, err := auth.NewJWT(ctx, auth.JWTOptions{
+			JWKSFile: configs.JWKSFile,
+			JWKSURL:  configs.JWKSURL,
+			Issuer:   configs.JWTIssuer,
+			Audience: configs.JWTAudience,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		chain = append(chain, jwt)
+	}
+
+	if len(chain) == 0 {
+		return nil, nil
+	}
+
+	return chain, nil
+}
+
+// newTLSConfig creates a TLS configuration for the configured certificate files.
+// If no certificate is configured, TLS is disabled and nil is returned.
+func newTLSConfig() (*tls.Config, error) {
+	if configs.TLSCertFile == "" && configs.TLSKeyFile == "" {
+		if configs.TLSClientCAFile != "" {
+			return nil, errors.New("tls client ca file requires tls cert file and key file")
+		}
+		return nil, nil
+	}
+
+	reloader, err := certs.NewReloader(certs.Options{
+		CertFile:       configs.TLSCertFile,
+		KeyFile:        configs.TLSKeyFile,
+		ClientCAFile:   configs.TLSClientCAFile,
+		ReloadInterval: configs.TLSReloadInterval,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reloader.TLSConfig(), nil
+}
@@ -0,0 +1,270 @@
+// Package client is a Go client for the Greeting HTTP API.
+// Failed calls are returned as *Error values, which can be matched against the sentinel errors using errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTimeout is the default maximum duration for a call, including reading its response.
+	DefaultTimeout = 10 * time.Second
+
+	// maxErrorBytes is the maximum size of the body of a failed response read for its error.
+	maxErrorBytes = 64 << 10
+)
+
+// Options are optional settings for creating a new client.
+type Options struct {
+	// HTTPClient is used for sending requests (a new http.Client with Timeout if nil).
+	HTTPClient *http.Client
+	// Timeout is the maximum duration for a call (DefaultTimeout if zero).
+	// It is ignored if HTTPClient is set, and a shorter deadline can be set per call using the context.
+	Timeout time.Duration
+	// APIKey is sent in the X-API-Key header of every request if set.
+	APIKey string
+	// BearerToken is sent in the Authorization header of every request if set.
+	BearerToken string
+	// Insecure allows sending the API key and the bearer token to an http base URL (e.g. for local use).
+	Insecure bool
+	// UserAgent is sent in the User-Agent header of every request if set.
+	UserAgent string
+}
+
+// Client is a client for the Greeting HTTP API.
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	apiKey      string
+	bearerToken string
+	userAgent   string
+}
+
+// New creates a new client for the Greeting HTTP API at a base URL (e.g. http://localhost:8080).
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base url: unsupported scheme %q", u.Scheme)
+	}
+
+	if u.Scheme != "https" && !opts.Insecure && (opts.APIKey != "" || opts.BearerToken != "") {
+		return nil, errors.New("https base url is required for sending credentials unless insecure")
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultTimeout
+	}
+
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{
+			Timeout: opts.Timeout,
+		}
+	}
+
+	return &Client{
+		baseURL:     strings.TrimSuffix(u.String(), "/"),
+		httpClient:  opts.HTTPClient,
+		apiKey:      opts.APIKey,
+		bearerToken: opts.BearerToken,
+		userAgent:   opts.UserAgent,
+	}, nil
+}
+
+// GreetRequest is the request for the Greet API.
+type GreetRequest struct {
+	GithubUsername string `json:"githubUsername"`
+	// Language is the preferred language of the greeting (e.g. fr or fr-CA, en;q=0.8), sent in the Accept-Language header.
+	Language string `json:"-"`
+}
+
+// GreetResponse is the response of the Greet API.
+type GreetResponse struct {
+	Greeting string `json:"greeting"`
+	// Language is the language of the greeting, received in the Content-Language header.
+	Language string `json:"-"`
+	// Stale is true if the greeting is created for a cached GitHub user while GitHub is unavailable.
+	Stale bool `json:"-"`
+}
+
+// Greet creates and returns a greeting for a GitHub user.
+func (c *Client) Greet(ctx context.Context, req *GreetRequest) (*GreetResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := c.newRequest(ctx, "POST", "/v1/greet", body)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Language != "" {
+		httpReq.Header.Set("Accept-Language", req.Language)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(resp)
+	}
+
+	res := new(GreetResponse)
+	if err := json.NewDecoder(resp.Body).Decode(res); err != nil {
+		return nil, fmt.Errorf("invalid response body: %w", err)
+	}
+
+	res.Language = resp.Header.Get("Content-Language")
+	res.Stale = resp.Header.Get("X-Stale") == "true"
+
+	return res, nil
+}
+
+// newRequest creates a new request with a JSON body and the headers of the client.
+func (c *Client) newRequest(ctx context.Context, method, path string, body []byte) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	if c.bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearerToken)
+	}
+
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	return req, nil
+}
+
+// Stable error codes of failed calls.
+const (
+	CodeValidationFailed    = "VALIDATION_FAILED"
+	CodeUnauthenticated     = "UNAUTHENTICATED"
+	CodePermissionDenied    = "PERMISSION_DENIED"
+	CodeNotFound            = "NOT_FOUND"
+	CodeRequestTooLarge     = "REQUEST_TOO_LARGE"
+	CodeRateLimited         = "RATE_LIMITED"
+	CodeUpstreamUnavailable = "UPSTREAM_UNAVAILABLE"
+	CodeInternal            = "INTERNAL"
+)
+
+// Sentinel errors for matching failed calls by their codes using errors.Is.
+var (
+	ErrValidationFailed    = &Error{Code: CodeValidationFailed}
+	ErrUnauthenticated     = &Error{Code: CodeUnauthenticated}
+	ErrPermissionDenied    = &Error{Code: CodePermissionDenied}
+	ErrNotFound            = &Error{Code: CodeNotFound}
+	ErrRequestTooLarge     = &Error{Code: CodeRequestTooLarge}
+	ErrRateLimited         = &Error{Code: CodeRateLimited}
+	ErrUpstreamUnavailable = &Error{Code: CodeUpstreamUnavailable}
+	ErrInternal            = &Error{Code: CodeInternal}
+)
+
+// statusCodes are the codes of failed responses without problem details.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            CodeValidationFailed,
+	http.StatusUnauthorized:          CodeUnauthenticated,
+	http.StatusForbidden:             CodePermissionDenied,
+	http.StatusNotFound:              CodeNotFound,
+	http.StatusRequestEntityTooLarge: CodeRequestTooLarge,
+	http.StatusTooManyRequests:       CodeRateLimited,
+	http.StatusServiceUnavailable:    CodeUpstreamUnavailable,
+	http.StatusInternalServerError:   CodeInternal,
+}
+
+// Violation is a field-level validation error.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a failed call.
+// Failed calls are responded with problem details (RFC 7807), and the code of other failed responses is determined by their status codes.
+type Error struct {
+	StatusCode int         `json:"status"`
+	Code       string      `json:"code"`
+	Title      string      `json:"title"`
+	Detail     string      `json:"detail"`
+	TraceID    string      `json:"traceId"`
+	Violations []Violation `json:"violations"`
+	// RetryAfter is the duration to wait before retrying a rate limited call.
+	RetryAfter time.Duration `json:"-"`
+}
+
+// newError creates an error for a failed response.
+func newError(resp *http.Response) *Error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBytes))
+
+	e := new(Error)
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "application/problem+json" {
+		_ = json.Unmarshal(b, e)
+	} else {
+		e.Detail = strings.TrimSpace(string(b))
+	}
+
+	e.StatusCode = resp.StatusCode
+
+	if e.Code == "" {
+		e.Code = statusCodes[resp.StatusCode]
+	}
+
+	if e.Title == "" {
+		e.Title = http.StatusText(resp.StatusCode)
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		e.RetryAfter = time.Duration(seconds) * time.Second
+	}
+
+	return e
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, e.Title)
+	if e.Code != "" {
+		msg += " (" + e.Code + ")"
+	}
+
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+
+	return msg
+}
+
+// Is determines whether the error has the same code as a target error.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code != "" && t.Code == e.Code
+}
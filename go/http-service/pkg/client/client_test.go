@@ -0,0 +1,382 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gardenbed/basil/httpx"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"http-service/idl"
+	"http-service/internal/auth"
+	internalclient "http-service/internal/client"
+	"http-service/internal/fakegithub"
+	"http-service/internal/locale"
+	"http-service/internal/ratelimit"
+	"http-service/internal/service/greeting"
+	"http-service/internal/validation"
+)
+
+const testAPIKey = "secret"
+
+// newServer starts the greeting service in-process, backed by a fake GitHub API and an in-memory Redis,
+// with authentication by API key, rate limiting, and validation.
+func newServer(t *testing.T, rateLimit int, opts ...fakegithub.Option) *httptest.Server {
+	fake, err := fakegithub.NewServer(opts...)
+	assert.NoError(t, err)
+	t.Cleanup(fake.Close)
+
+	mr := miniredis.RunT(t)
+	redisClient := internalclient.NewRedis(mr.Addr())
+
+	catalog, err := locale.NewCatalog("")
+	assert.NoError(t, err)
+
+	service, err := greeting.NewService(fake.Client(), redisClient, catalog, greeting.Options{GithubURL: fake.URL})
+	assert.NoError(t, err)
+
+	validator, err := validation.NewMiddleware(idl.Greeting)
+	assert.NoError(t, err)
+
+	router := mux.NewRouter()
+	service.RegisterRoutes(router, []httpx.Middleware{
+		validator,
+		ratelimit.New(redisClient, ratelimit.Options{Limit: rateLimit}),
+		auth.NewMiddleware(auth.NewAPIKeys(map[string]string{"test": testAPIKey}), ""),
+	}...)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name            string
+		baseURL         string
+		opts            Options
+		expectedBaseURL string
+		expectedTimeout time.Duration
+		expectedError   string
+	}{
+		{
+			name:          "InvalidURL",
+			baseURL:       ":localhost",
+			opts:          Options{},
+			expectedError: `invalid base url: parse ":localhost": missing protocol scheme`,
+		},
+		{
+			name:          "UnsupportedScheme",
+			baseURL:       "ftp://localhost",
+			opts:          Options{},
+			expectedError: `invalid base url: unsupported scheme "ftp"`,
+		},
+		{
+			name:          "CredentialsWithoutTLS",
+			baseURL:       "http://localhost:8080",
+			opts:          Options{APIKey: "key"},
+			expectedError: "https base url is required for sending credentials unless insecure",
+		},
+		{
+			name:            "InsecureCredentials",
+			baseURL:         "http://localhost:8080",
+			opts:            Options{Insecure: true, BearerToken: "token"},
+			expectedBaseURL: "http://localhost:8080",
+			expectedTimeout: DefaultTimeout,
+		},
+		{
+			name:            "Defaults",
+			baseURL:         "http://localhost:8080/",
+			opts:            Options{},
+			expectedBaseURL: "http://localhost:8080",
+			expectedTimeout: DefaultTimeout,
+		},
+		{
+			name:    "Custom",
+			baseURL: "https://api.example.com",
+			opts: Options{
+				APIKey:  "key",
+				Timeout: time.Second,
+			},
+			expectedBaseURL: "https://api.example.com",
+			expectedTimeout: time.Second,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := New(tc.baseURL, tc.opts)
+
+			if tc.expectedError == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedBaseURL, c.baseURL)
+				assert.Equal(t, tc.expectedTimeout, c.httpClient.Timeout)
+			} else {
+				assert.Nil(t, c)
+				assert.EqualError(t, err, tc.expectedError)
+			}
+		})
+	}
+}
+
+func TestClient_Greet(t *testing.T) {
+	tests := []struct {
+		name             string
+		rateLimit        int
+		githubOpts       []fakegithub.Option
+		apiKey           string
+		timeout          time.Duration
+		calls            int
+		req              *GreetRequest
+		expectedResponse *GreetResponse
+		expectedError    error
+		expectedCode     string
+	}{
+		{
+			name:      "Success",
+			rateLimit: 10,
+			apiKey:    testAPIKey,
+			calls:     1,
+			req:       &GreetRequest{GithubUsername: "octocat"},
+			expectedResponse: &GreetResponse{
+				Greeting: "Hello, The Octocat!",
+				Language: "en",
+			},
+		},
+		{
+			name:      "Language",
+			rateLimit: 10,
+			apiKey:    testAPIKey,
+			calls:     1,
+			req:       &GreetRequest{GithubUsername: "octocat", Language: "fr-CA, en;q=0.8"},
+			expectedResponse: &GreetResponse{
+				Greeting: "Bonjour, The Octocat !",
+				Language: "fr",
+			},
+		},
+		{
+			name:          "Unauthenticated",
+			rateLimit:     10,
+			apiKey:        "invalid",
+			calls:         1,
+			req:           &GreetRequest{GithubUsername: "octocat"},
+			expectedError: ErrUnauthenticated,
+			expectedCode:  CodeUnauthenticated,
+		},
+		{
+			name:          "InvalidArgument",
+			rateLimit:     10,
+			apiKey:        testAPIKey,
+			calls:         1,
+			req:           &GreetRequest{GithubUsername: "-octocat"},
+			expectedError: ErrValidationFailed,
+			expectedCode:  CodeValidationFailed,
+		},
+		{
+			name:          "NotFound",
+			rateLimit:     10,
+			apiKey:        testAPIKey,
+			calls:         1,
+			req:           &GreetRequest{GithubUsername: "ghost"},
+			expectedError: ErrNotFound,
+			expectedCode:  CodeNotFound,
+		},
+		{
+			name:          "UpstreamUnavailable",
+			rateLimit:     10,
+			githubOpts:    []fakegithub.Option{fakegithub.WithError("/users/*", 502)},
+			apiKey:        testAPIKey,
+			calls:         1,
+			req:           &GreetRequest{GithubUsername: "octocat"},
+			expectedError: ErrUpstreamUnavailable,
+			expectedCode:  CodeUpstreamUnavailable,
+		},
+		{
+			name:          "RateLimited",
+			rateLimit:     1,
+			apiKey:        testAPIKey,
+			calls:         2,
+			req:           &GreetRequest{GithubUsername: "octocat"},
+			expectedError: ErrRateLimited,
+			expectedCode:  CodeRateLimited,
+		},
+		{
+			name:          "DeadlineExceeded",
+			rateLimit:     10,
+			githubOpts:    []fakegithub.Option{fakegithub.WithLatency(time.Second)},
+			apiKey:        testAPIKey,
+			timeout:       50 * time.Millisecond,
+			calls:         1,
+			req:           &GreetRequest{GithubUsername: "octocat"},
+			expectedError: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := newServer(t, tc.rateLimit, tc.githubOpts...)
+
+			c, err := New(server.URL, Options{Insecure: true, APIKey: tc.apiKey, UserAgent: "test"})
+			assert.NoError(t, err)
+
+			ctx := context.Background()
+			if tc.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tc.timeout)
+				defer cancel()
+			}
+
+			var res *GreetResponse
+			for range tc.calls {
+				res, err = c.Greet(ctx, tc.req)
+			}
+
+			if tc.expectedError == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expectedResponse, res)
+			} else {
+				assert.Nil(t, res)
+				assert.ErrorIs(t, err, tc.expectedError)
+			}
+
+			var e *Error
+			if tc.expectedCode != "" && assert.ErrorAs(t, err, &e) {
+				assert.Equal(t, tc.expectedCode, e.Code)
+				assert.NotEmpty(t, e.Detail)
+			}
+
+			if tc.expectedCode == CodeValidationFailed {
+				assert.NotEmpty(t, e.Violations)
+			}
+
+			if tc.expectedCode == CodeRateLimited {
+				assert.Positive(t, e.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestClient_Greet_InvalidResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("<html>"))
+	}))
+	defer server.Close()
+
+	c, err := New(server.URL, Options{})
+	assert.NoError(t, err)
+
+	res, err := c.Greet(context.Background(), &GreetRequest{GithubUsername: "octocat"})
+
+	assert.Nil(t, res)
+	assert.ErrorContains(t, err, "invalid response body")
+}
+
+func TestError(t *testing.T) {
+	tests := []struct {
+		name          string
+		statusCode    int
+		contentType   string
+		header        http.Header
+		body          string
+		expectedError *Error
+		expectedMsg   string
+		expectedIs    error
+	}{
+		{
+			name:        "Problem",
+			statusCode:  400,
+			contentType: "application/problem+json",
+			body:        `{"type":"urn:problem-type:validation","title":"Validation Failed","status":400,"code":"VALIDATION_FAILED","detail":"invalid request","traceId":"abcd","violations":[{"field":"githubUsername","message":"is required"}]}`,
+			expectedError: &Error{
+				StatusCode: 400,
+				Code:       CodeValidationFailed,
+				Title:      "Validation Failed",
+				Detail:     "invalid request",
+				TraceID:    "abcd",
+				Violations: []Violation{
+					{Field: "githubUsername", Message: "is required"},
+				},
+			},
+			expectedMsg: "400 Validation Failed (VALIDATION_FAILED): invalid request",
+			expectedIs:  ErrValidationFailed,
+		},
+		{
+			name:        "RetryAfter",
+			statusCode:  429,
+			contentType: "application/problem+json; charset=utf-8",
+			header:      http.Header{"Retry-After": []string{"30"}},
+			body:        `{"title":"Rate Limited","status":429,"code":"RATE_LIMITED","detail":"rate limit exceeded"}`,
+			expectedError: &Error{
+				StatusCode: 429,
+				Code:       CodeRateLimited,
+				Title:      "Rate Limited",
+				Detail:     "rate limit exceeded",
+				RetryAfter: 30 * time.Second,
+			},
+			expectedMsg: "429 Rate Limited (RATE_LIMITED): rate limit exceeded",
+			expectedIs:  ErrRateLimited,
+		},
+		{
+			name:        "PlainText",
+			statusCode:  403,
+			contentType: "text/plain; charset=utf-8",
+			body:        "permission denied: missing scope greet\n",
+			expectedError: &Error{
+				StatusCode: 403,
+				Code:       CodePermissionDenied,
+				Title:      "Forbidden",
+				Detail:     "permission denied: missing scope greet",
+			},
+			expectedMsg: "403 Forbidden (PERMISSION_DENIED): permission denied: missing scope greet",
+			expectedIs:  ErrPermissionDenied,
+		},
+		{
+			name:       "UnknownStatus",
+			statusCode: 502,
+			body:       "",
+			expectedError: &Error{
+				StatusCode: 502,
+				Title:      "Bad Gateway",
+			},
+			expectedMsg: "502 Bad Gateway",
+			expectedIs:  nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			header := http.Header{}
+			for k, v := range tc.header {
+				header[k] = v
+			}
+
+			header.Set("Content-Type", tc.contentType)
+
+			resp := &http.Response{
+				StatusCode: tc.statusCode,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(tc.body)),
+			}
+
+			e := newError(resp)
+
+			assert.Equal(t, tc.expectedError, e)
+			assert.EqualError(t, e, tc.expectedMsg)
+
+			if tc.expectedIs != nil {
+				assert.ErrorIs(t, e, tc.expectedIs)
+			}
+
+			assert.False(t, errors.Is(e, ErrInternal))
+		})
+	}
+}
@@ -49,14 +49,37 @@ protoc: check-tools
 	unzip -o protoc.zip -d /usr/local include/*
 	rm -f protoc.zip
 
+.PHONY: googleapis
+googleapis: check-tools
+	mkdir -p /usr/local/include/google/api
+	curl -fsSL https://raw.githubusercontent.com/googleapis/googleapis/master/google/api/annotations.proto -o /usr/local/include/google/api/annotations.proto
+	curl -fsSL https://raw.githubusercontent.com/googleapis/googleapis/master/google/api/http.proto -o /usr/local/include/google/api/http.proto
+	mkdir -p /usr/local/include/google/rpc
+	curl -fsSL https://raw.githubusercontent.com/googleapis/googleapis/master/google/rpc/status.proto -o /usr/local/include/google/rpc/status.proto
+
+.PHONY: protovalidate
+protovalidate: check-tools
+	mkdir -p /usr/local/include/buf/validate
+	curl -fsSL https://raw.githubusercontent.com/bufbuild/protovalidate/main/proto/protovalidate/buf/validate/validate.proto -o /usr/local/include/buf/validate/validate.proto
+
 .PHONY: protoc-gen-go
 protoc-gen-go:
 	go install github.com/golang/protobuf/protoc-gen-go@latest
 
+.PHONY: protoc-gen-grpc-gateway
+protoc-gen-grpc-gateway:
+	go install github.com/grpc-ecosystem/grpc-gateway/v2/protoc-gen-grpc-gateway@latest
+
+.PHONY: protoc-gen-connect-go
+protoc-gen-connect-go:
+	go install connectrpc.com/connect/cmd/protoc-gen-connect-go@latest
+
 .PHONY: protobuf
 protobuf:
 	@ mkdir -p $(go_out_path)
 	protoc \
 	  --proto_path=$(proto_path) \
 	  --go_out=paths=source_relative,plugins=grpc:$(go_out_path) \
+	  --grpc-gateway_out=paths=source_relative:$(go_out_path) \
+	  --connect-go_out=paths=source_relative:$(go_out_path) \
 	  $(foreach proto_file, $(shell find $(proto_path) -name '*.proto'), $(proto_file))
@@ -0,0 +1,18 @@
+## PREREQUISITES:
+##
+## The following variables need to be defined where this file is included:
+##   - openapi_path
+##   - openapi_gen_pkg
+##   - go_out_path
+##
+## Every OpenAPI specification (*.yaml) is generated as a Go file with the same name in the go_out_path package.
+##
+
+
+## RULES
+##
+
+.PHONY: openapi
+openapi:
+	@ mkdir -p $(go_out_path)
+	$(foreach spec_file, $(shell find $(openapi_path) -name '*.yaml'), go run $(openapi_gen_pkg) -spec $(spec_file) -out $(go_out_path);)